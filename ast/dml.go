@@ -28,6 +28,7 @@ var (
 
 	_ Node = &Assignment{}
 	_ Node = &ByItem{}
+	_ Node = &CommonTableExpression{}
 	_ Node = &FieldList{}
 	_ Node = &GroupByClause{}
 	_ Node = &HavingClause{}
@@ -41,6 +42,7 @@ var (
 	_ Node = &TableSource{}
 	_ Node = &UnionSelectList{}
 	_ Node = &WildCardField{}
+	_ Node = &WithClause{}
 )
 
 // JoinType is join type, including cross/left/right/full.
@@ -109,6 +111,9 @@ type TableName struct {
 
 	DBInfo    *model.DBInfo
 	TableInfo *model.TableInfo
+	// CTE is the common table expression that the name refers to, it is set by the resolver
+	// and DBInfo and TableInfo are nil in this case.
+	CTE *CommonTableExpression
 
 	IndexHints []*IndexHint
 }
@@ -141,6 +146,16 @@ type IndexHint struct {
 	HintScope  IndexHintScope
 }
 
+// TableOptimizerHint is a table level optimizer hint, like HASH_JOIN(t1, t2).
+type TableOptimizerHint struct {
+	// HintName is the name of the hint, like HASH_JOIN, MERGE_JOIN and INL_JOIN.
+	HintName model.CIStr
+	// Tables are the table names or aliases that the hint applies to.
+	Tables []model.CIStr
+	// MaxExecutionTime is the time limit in milliseconds of the MAX_EXECUTION_TIME(N) hint.
+	MaxExecutionTime uint64
+}
+
 // Accept implements Node Accept interface.
 func (n *TableName) Accept(v Visitor) (Node, bool) {
 	newNode, skipChildren := v.Enter(n)
@@ -437,12 +452,68 @@ func (n *OrderByClause) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// WithClause represents the WITH clause of a query.
+// See https://dev.mysql.com/doc/refman/8.0/en/with.html
+type WithClause struct {
+	node
+
+	IsRecursive bool
+	CTEs        []*CommonTableExpression
+}
+
+// Accept implements Node Accept interface.
+func (n *WithClause) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*WithClause)
+	for i, cte := range n.CTEs {
+		node, ok := cte.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.CTEs[i] = node.(*CommonTableExpression)
+	}
+	return v.Leave(n)
+}
+
+// CommonTableExpression represents a named temporary result set defined in the WITH clause.
+type CommonTableExpression struct {
+	node
+
+	Name model.CIStr
+	// ColNameList is the optional column names of the result set.
+	ColNameList []model.CIStr
+	// Query is a SelectStmt or a UnionStmt.
+	Query ResultSetNode
+	// IsRecursive is set by the resolver if the query refers to the expression itself.
+	IsRecursive bool
+}
+
+// Accept implements Node Accept interface.
+func (n *CommonTableExpression) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CommonTableExpression)
+	node, ok := n.Query.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Query = node.(ResultSetNode)
+	return v.Leave(n)
+}
+
 // SelectStmt represents the select query node.
 // See https://dev.mysql.com/doc/refman/5.7/en/select.html
 type SelectStmt struct {
 	dmlNode
 	resultSetNode
 
+	// With is the WITH clause of the query.
+	With *WithClause
 	// Distinct represents if the select has distinct option.
 	Distinct bool
 	// From is the from clause of the query.
@@ -461,6 +532,38 @@ type SelectStmt struct {
 	Limit *Limit
 	// Lock is the lock type
 	LockTp SelectLockType
+	// TableHints are the optimizer hints of the statement, like /*+ HASH_JOIN(t1) */.
+	TableHints []*TableOptimizerHint
+	// HintWarnings are the warnings of the optimizer hints that are ignored, they are reported when the statement is planned.
+	HintWarnings []error
+	// SelectIntoOpt is the INTO OUTFILE or INTO DUMPFILE clause, the result is written to a file on the server.
+	SelectIntoOpt *SelectIntoOption
+}
+
+// SelectIntoType is the type of SelectIntoOption.
+type SelectIntoType int
+
+// SelectIntoOption types.
+const (
+	SelectIntoOutfile SelectIntoType = iota + 1
+	SelectIntoDumpfile
+)
+
+// SelectIntoOption represents the INTO OUTFILE or INTO DUMPFILE clause of a select statement.
+// See https://dev.mysql.com/doc/refman/5.7/en/select-into.html
+type SelectIntoOption struct {
+	Tp       SelectIntoType
+	FileName string
+	// FieldsInfo and LinesInfo are the format of INTO OUTFILE, they are nil for INTO DUMPFILE.
+	FieldsInfo *FieldsClause
+	LinesInfo  *LinesClause
+}
+
+// SelectStmtOpts wraps around select hints and switches.
+type SelectStmtOpts struct {
+	Distinct     bool
+	TableHints   []*TableOptimizerHint
+	HintWarnings []error
 }
 
 // Accept implements Node Accept interface.
@@ -471,6 +574,14 @@ func (n *SelectStmt) Accept(v Visitor) (Node, bool) {
 	}
 
 	n = newNode.(*SelectStmt)
+	if n.With != nil {
+		node, ok := n.With.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.With = node.(*WithClause)
+	}
+
 	if n.From != nil {
 		node, ok := n.From.Accept(v)
 		if !ok {
@@ -560,6 +671,7 @@ type UnionStmt struct {
 	dmlNode
 	resultSetNode
 
+	With       *WithClause
 	Distinct   bool
 	SelectList *UnionSelectList
 	OrderBy    *OrderByClause
@@ -573,6 +685,13 @@ func (n *UnionStmt) Accept(v Visitor) (Node, bool) {
 		return v.Leave(newNode)
 	}
 	n = newNode.(*UnionStmt)
+	if n.With != nil {
+		node, ok := n.With.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.With = node.(*WithClause)
+	}
 	if n.SelectList != nil {
 		node, ok := n.SelectList.Accept(v)
 		if !ok {
@@ -640,13 +759,28 @@ const (
 type LoadDataStmt struct {
 	dmlNode
 
-	IsLocal    bool
-	Path       string
-	Table      *TableName
-	FieldsInfo *FieldsClause
-	LinesInfo  *LinesClause
+	IsLocal     bool
+	Path        string
+	OnDuplicate OnDuplicateKeyHandlingType
+	Table       *TableName
+	FieldsInfo  *FieldsClause
+	LinesInfo   *LinesClause
 }
 
+// OnDuplicateKeyHandlingType is the way to handle the rows that duplicate the existing rows on a unique key,
+// it is the IGNORE or REPLACE keyword in LOAD DATA statement.
+type OnDuplicateKeyHandlingType int
+
+// OnDuplicateKeyHandling types.
+const (
+	// OnDuplicateKeyHandlingError returns an error on the duplicate rows.
+	OnDuplicateKeyHandlingError OnDuplicateKeyHandlingType = iota
+	// OnDuplicateKeyHandlingIgnore skips the duplicate rows.
+	OnDuplicateKeyHandlingIgnore
+	// OnDuplicateKeyHandlingReplace replaces the existing rows with the duplicate rows.
+	OnDuplicateKeyHandlingReplace
+)
+
 // Accept implements Node Accept interface.
 func (n *LoadDataStmt) Accept(v Visitor) (Node, bool) {
 	newNode, skipChildren := v.Enter(n)
@@ -669,6 +803,8 @@ type FieldsClause struct {
 	Terminated string
 	Enclosed   byte
 	Escaped    byte
+	// OptEnclosed is true if the fields are OPTIONALLY ENCLOSED, only the string fields are enclosed then.
+	OptEnclosed bool
 }
 
 // LinesClause represents lines references clause in load data statement.
@@ -908,6 +1044,7 @@ const (
 	ShowTriggers
 	ShowProcedureStatus
 	ShowIndex
+	ShowErrors
 )
 
 // ShowStmt is a statement to provide information about databases, tables, columns and so on.
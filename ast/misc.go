@@ -24,6 +24,7 @@ import (
 
 var (
 	_ StmtNode = &AdminStmt{}
+	_ StmtNode = &AlterUserStmt{}
 	_ StmtNode = &BeginStmt{}
 	_ StmtNode = &BinlogStmt{}
 	_ StmtNode = &CommitStmt{}
@@ -33,9 +34,13 @@ var (
 	_ StmtNode = &ExecuteStmt{}
 	_ StmtNode = &ExplainStmt{}
 	_ StmtNode = &GrantStmt{}
+	_ StmtNode = &GrantRoleStmt{}
 	_ StmtNode = &PrepareStmt{}
+	_ StmtNode = &RenameUserStmt{}
 	_ StmtNode = &RollbackStmt{}
+	_ StmtNode = &SetDefaultRoleStmt{}
 	_ StmtNode = &SetPwdStmt{}
+	_ StmtNode = &SetRoleStmt{}
 	_ StmtNode = &SetStmt{}
 	_ StmtNode = &UseStmt{}
 	_ StmtNode = &AnalyzeTableStmt{}
@@ -64,7 +69,8 @@ type AuthOption struct {
 	ByAuthString bool
 	AuthString   string
 	HashString   string
-	// TODO: support auth_plugin
+	// AuthPlugin is the authentication plugin, it is empty if the plugin is not specified.
+	AuthPlugin string
 }
 
 // ExplainStmt is a statement to provide information about how is SQL statement executed
@@ -74,8 +80,23 @@ type ExplainStmt struct {
 	stmtNode
 
 	Stmt StmtNode
+	// Analyze indicates EXPLAIN ANALYZE, which executes the statement and reports the runtime statistics.
+	Analyze bool
+	// Format is the output format, one of ExplainFormatRow, ExplainFormatJSON and ExplainFormatDOT.
+	// An empty format means ExplainFormatRow.
+	Format string
 }
 
+// Output formats of EXPLAIN.
+const (
+	// ExplainFormatRow outputs a row for each operator.
+	ExplainFormatRow = "row"
+	// ExplainFormatJSON outputs the plan as a JSON document.
+	ExplainFormatJSON = "json"
+	// ExplainFormatDOT outputs the plan as a DOT graph.
+	ExplainFormatDOT = "dot"
+)
+
 // Accept implements Node Accept interface.
 func (n *ExplainStmt) Accept(v Visitor) (Node, bool) {
 	newNode, skipChildren := v.Enter(n)
@@ -366,19 +387,113 @@ func (n *SetPwdStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// SetRoleStmtType is the type of the roles in SET ROLE and SET DEFAULT ROLE statements.
+type SetRoleStmtType int
+
+// SetRoleStmtType types.
+const (
+	SetRoleRegular SetRoleStmtType = iota
+	SetRoleDefault
+	SetRoleNone
+	SetRoleAll
+	SetRoleAllExcept
+)
+
+// SetRoleStmt is a statement to set the active roles of the current session.
+// See https://dev.mysql.com/doc/refman/8.0/en/set-role.html
+type SetRoleStmt struct {
+	stmtNode
+
+	SetRoleOpt SetRoleStmtType
+	RoleList   []string
+}
+
+// Accept implements Node Accept interface.
+func (n *SetRoleStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*SetRoleStmt)
+	return v.Leave(n)
+}
+
+// SetDefaultRoleStmt is a statement to set the roles activated when the users log in.
+// See https://dev.mysql.com/doc/refman/8.0/en/set-default-role.html
+type SetDefaultRoleStmt struct {
+	stmtNode
+
+	SetRoleOpt SetRoleStmtType
+	RoleList   []string
+	UserList   []string
+}
+
+// Accept implements Node Accept interface.
+func (n *SetDefaultRoleStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*SetDefaultRoleStmt)
+	return v.Leave(n)
+}
+
 // UserSpec is used for parsing create user statement.
 type UserSpec struct {
 	User    string
 	AuthOpt *AuthOption
 }
 
+// ResourceOptionType is the type of ResourceOption.
+type ResourceOptionType int
+
+// ResourceOption types.
+const (
+	ResourceOptionMaxQueriesPerHour ResourceOptionType = iota + 1
+	ResourceOptionMaxUpdatesPerHour
+	ResourceOptionMaxConnectionsPerHour
+	ResourceOptionMaxUserConnections
+)
+
+// ResourceOption is used for parsing the resource limits of an account, zero means no limit.
+// See https://dev.mysql.com/doc/refman/5.7/en/user-resources.html
+type ResourceOption struct {
+	Tp    ResourceOptionType
+	Count uint64
+}
+
+// PasswordOrLockOptionType is the type of PasswordOrLockOption.
+type PasswordOrLockOptionType int
+
+// PasswordOrLockOption types.
+const (
+	PasswordExpire PasswordOrLockOptionType = iota + 1
+	PasswordExpireDefault
+	PasswordExpireNever
+	PasswordExpireInterval
+	AccountLock
+	AccountUnlock
+)
+
+// PasswordOrLockOption is used for parsing the password expiration and the account locking options of an account.
+// See https://dev.mysql.com/doc/refman/5.7/en/alter-user.html
+type PasswordOrLockOption struct {
+	Tp PasswordOrLockOptionType
+	// Count is the number of days for PasswordExpireInterval.
+	Count uint64
+}
+
 // CreateUserStmt creates user account.
 // See https://dev.mysql.com/doc/refman/5.7/en/create-user.html
 type CreateUserStmt struct {
 	stmtNode
 
-	IfNotExists bool
-	Specs       []*UserSpec
+	// IsCreateRole is true for CREATE ROLE, a role is a locked account.
+	IsCreateRole          bool
+	IfNotExists           bool
+	Specs                 []*UserSpec
+	ResourceOptions       []*ResourceOption
+	PasswordOrLockOptions []*PasswordOrLockOption
 }
 
 // Accept implements Node Accept interface.
@@ -391,13 +506,59 @@ func (n *CreateUserStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// AlterUserStmt modifies user account.
+// See https://dev.mysql.com/doc/refman/5.7/en/alter-user.html
+type AlterUserStmt struct {
+	stmtNode
+
+	IfExists              bool
+	Specs                 []*UserSpec
+	ResourceOptions       []*ResourceOption
+	PasswordOrLockOptions []*PasswordOrLockOption
+}
+
+// Accept implements Node Accept interface.
+func (n *AlterUserStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*AlterUserStmt)
+	return v.Leave(n)
+}
+
+// UserToUser is the old user and the new user of RENAME USER.
+type UserToUser struct {
+	OldUser string
+	NewUser string
+}
+
+// RenameUserStmt renames user accounts.
+// See https://dev.mysql.com/doc/refman/5.7/en/rename-user.html
+type RenameUserStmt struct {
+	stmtNode
+
+	UserToUsers []*UserToUser
+}
+
+// Accept implements Node Accept interface.
+func (n *RenameUserStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*RenameUserStmt)
+	return v.Leave(n)
+}
+
 // DropUserStmt creates user account.
 // See http://dev.mysql.com/doc/refman/5.7/en/drop-user.html
 type DropUserStmt struct {
 	stmtNode
 
-	IfExists bool
-	UserList []string
+	IsDropRole bool
+	IfExists   bool
+	UserList   []string
 }
 
 // Accept implements Node Accept interface.
@@ -441,14 +602,28 @@ type AdminStmtType int
 const (
 	AdminShowDDL = iota + 1
 	AdminCheckTable
+	AdminShowDDLJobs
+	AdminCancelDDLJobs
+	AdminCheckIndex
+	AdminRecoverIndex
+	AdminCleanupIndex
 )
 
+// HandleRange represents a range of row handles where Begin and End are both included.
+type HandleRange struct {
+	Begin int64
+	End   int64
+}
+
 // AdminStmt is the struct for Admin statement.
 type AdminStmt struct {
 	stmtNode
 
-	Tp     AdminStmtType
-	Tables []*TableName
+	Tp           AdminStmtType
+	Index        string
+	Tables       []*TableName
+	JobIDs       []int64
+	HandleRanges []HandleRange
 }
 
 // Accept implements Node Accpet interface.
@@ -553,6 +728,25 @@ func (n *GrantStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// GrantRoleStmt is the struct for GRANT role statement.
+// See https://dev.mysql.com/doc/refman/8.0/en/grant.html#grant-roles
+type GrantRoleStmt struct {
+	stmtNode
+
+	Roles []string
+	Users []string
+}
+
+// Accept implements Node Accept interface.
+func (n *GrantRoleStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*GrantRoleStmt)
+	return v.Leave(n)
+}
+
 // Ident is the table identifier composed of schema name and table name.
 type Ident struct {
 	Schema model.CIStr
@@ -45,6 +45,7 @@ func (visitor1) Enter(in Node) (Node, bool) {
 func (ts *testMiscSuite) TestMiscVisitorCover(c *C) {
 	stmts := []Node{
 		(&AdminStmt{}),
+		(&AlterUserStmt{}),
 		(&BeginStmt{}),
 		(&BinlogStmt{}),
 		(&CommitStmt{}),
@@ -54,9 +55,13 @@ func (ts *testMiscSuite) TestMiscVisitorCover(c *C) {
 		(&ExecuteStmt{UsingVars: []ExprNode{&ValueExpr{}}}),
 		(&ExplainStmt{Stmt: &ShowStmt{}}),
 		(&GrantStmt{}),
+		(&GrantRoleStmt{}),
 		(&PrepareStmt{SQLVar: &VariableExpr{Value: &ValueExpr{}}}),
+		(&RenameUserStmt{}),
 		(&RollbackStmt{}),
+		(&SetDefaultRoleStmt{}),
 		(&SetPwdStmt{}),
+		(&SetRoleStmt{}),
 		(&SetStmt{Variables: []*VariableAssignment{
 			{
 				Value: &ValueExpr{},
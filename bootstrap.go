@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -50,6 +51,18 @@ const (
 		Execute_priv		ENUM('N','Y') NOT NULL  DEFAULT 'N',
 		Index_priv		ENUM('N','Y') NOT NULL  DEFAULT 'N',
 		Create_user_priv	ENUM('N','Y') NOT NULL  DEFAULT 'N',
+		Process_priv		ENUM('N','Y') NOT NULL  DEFAULT 'N',
+		File_priv		ENUM('N','Y') NOT NULL  DEFAULT 'N',
+		plugin			CHAR(64) NOT NULL DEFAULT 'mysql_native_password',
+		authentication_string	TEXT,
+		max_questions		INT UNSIGNED NOT NULL DEFAULT 0,
+		max_updates		INT UNSIGNED NOT NULL DEFAULT 0,
+		max_connections		INT UNSIGNED NOT NULL DEFAULT 0,
+		max_user_connections	INT UNSIGNED NOT NULL DEFAULT 0,
+		password_expired	ENUM('N','Y') NOT NULL DEFAULT 'N',
+		password_last_changed	TIMESTAMP NULL DEFAULT NULL,
+		password_lifetime	SMALLINT UNSIGNED NULL DEFAULT NULL,
+		account_locked		ENUM('N','Y') NOT NULL DEFAULT 'N',
 		PRIMARY KEY (Host, User));`
 	// CreateDBPrivTable is the SQL statement creates DB scope privilege table in system db.
 	CreateDBPrivTable = `CREATE TABLE if not exists mysql.db (
@@ -88,6 +101,22 @@ const (
 		Timestamp	Timestamp DEFAULT CURRENT_TIMESTAMP,
 		Column_priv	SET('Select','Insert','Update'),
 		PRIMARY KEY (Host, DB, User, Table_name, Column_name));`
+	// CreateRoleEdgesTable is the SQL statement creates the table of the roles granted to the users in system db.
+	// The role FROM_USER@FROM_HOST is granted to the user TO_USER@TO_HOST.
+	CreateRoleEdgesTable = `CREATE TABLE if not exists mysql.role_edges(
+		FROM_HOST		CHAR(60) COLLATE utf8_bin NOT NULL DEFAULT '',
+		FROM_USER		CHAR(32) COLLATE utf8_bin NOT NULL DEFAULT '',
+		TO_HOST			CHAR(60) COLLATE utf8_bin NOT NULL DEFAULT '',
+		TO_USER			CHAR(32) COLLATE utf8_bin NOT NULL DEFAULT '',
+		WITH_ADMIN_OPTION	ENUM('N','Y') CHARACTER SET utf8 COLLATE utf8_general_ci NOT NULL DEFAULT 'N',
+		PRIMARY KEY (FROM_HOST, FROM_USER, TO_HOST, TO_USER));`
+	// CreateDefaultRolesTable is the SQL statement creates the table of the default roles of the users in system db.
+	CreateDefaultRolesTable = `CREATE TABLE if not exists mysql.default_roles(
+		HOST			CHAR(60) COLLATE utf8_bin NOT NULL DEFAULT '',
+		USER			CHAR(32) COLLATE utf8_bin NOT NULL DEFAULT '',
+		DEFAULT_ROLE_HOST	CHAR(60) COLLATE utf8_bin NOT NULL DEFAULT '%',
+		DEFAULT_ROLE_USER	CHAR(32) COLLATE utf8_bin NOT NULL DEFAULT '',
+		PRIMARY KEY (HOST, USER, DEFAULT_ROLE_HOST, DEFAULT_ROLE_USER));`
 	// CreateGloablVariablesTable is the SQL statement creates global variable table in system db.
 	// TODO: MySQL puts GLOBAL_VARIABLES table in INFORMATION_SCHEMA db.
 	// INFORMATION_SCHEMA is a virtual db in TiDB. So we put this table in system db.
@@ -146,6 +175,30 @@ const (
 	tidbServerVersionVar = "tidb_server_version" //
 	// Const for TiDB server version 2.
 	version2 = 2
+	// Const for TiDB server version 3.
+	version3 = 3
+	// Const for TiDB server version 4.
+	version4 = 4
+	// Const for TiDB server version 5.
+	version5 = 5
+	// Const for TiDB server version 6.
+	version6 = 6
+	// Const for TiDB server version 7.
+	version7 = 7
+	// Const for TiDB server version 8.
+	version8 = 8
+	// Const for TiDB server version 9.
+	version9 = 9
+	// Const for TiDB server version 10.
+	version10 = 10
+	// Const for TiDB server version 11.
+	version11 = 11
+	// Const for TiDB server version 12.
+	version12 = 12
+	// Const for TiDB server version 13.
+	version13 = 13
+	// Const for TiDB server version 14.
+	version14 = 14
 )
 
 func checkBootstrapped(s Session) (bool, error) {
@@ -175,7 +228,7 @@ func checkBootstrapped(s Session) (bool, error) {
 // Get variable value from mysql.tidb table.
 // Those variables are used by TiDB server.
 func getTiDBVar(s Session, name string) (types.Datum, error) {
-	sql := fmt.Sprintf(`SELECT VARIABLE_VALUE FROM %s.%s WHERE VARIABLE_NAME="%s"`,
+	sql := fmt.Sprintf(`SELECT VARIABLE_VALUE FROM %s.%s WHERE VARIABLE_NAME='%s'`,
 		mysql.SystemDB, mysql.TiDBTable, name)
 	rs, err := s.Execute(sql)
 	if err != nil {
@@ -211,6 +264,42 @@ func upgrade(s Session) error {
 	if ver < version2 {
 		upgradeToVer2(s)
 	}
+	if ver < version3 {
+		upgradeToVer3(s)
+	}
+	if ver < version4 {
+		upgradeToVer4(s)
+	}
+	if ver < version5 {
+		upgradeToVer5(s)
+	}
+	if ver < version6 {
+		upgradeToVer6(s)
+	}
+	if ver < version7 {
+		upgradeToVer7(s)
+	}
+	if ver < version8 {
+		upgradeToVer8(s)
+	}
+	if ver < version9 {
+		upgradeToVer9(s)
+	}
+	if ver < version10 {
+		upgradeToVer10(s)
+	}
+	if ver < version11 {
+		upgradeToVer11(s)
+	}
+	if ver < version12 {
+		upgradeToVer12(s)
+	}
+	if ver < version13 {
+		upgradeToVer13(s)
+	}
+	if ver < version14 {
+		upgradeToVer14(s)
+	}
 	updateBootstrapVer(s)
 	_, err = s.Execute("COMMIT")
 
@@ -242,7 +331,7 @@ func upgradeToVer2(s Session) {
 	distSQLVars := []string{variable.DistSQLScanConcurrencyVar, variable.DistSQLJoinConcurrencyVar}
 	values := make([]string, 0, len(distSQLVars))
 	for _, v := range distSQLVars {
-		value := fmt.Sprintf(`("%s", "%s")`, v, variable.SysVars[v].Value)
+		value := fmt.Sprintf(`('%s', '%s')`, v, variable.SysVars[v].Value)
 		values = append(values, value)
 	}
 	sql := fmt.Sprintf("INSERT IGNORE INTO %s.%s VALUES %s;", mysql.SystemDB, mysql.GlobalVariablesTable,
@@ -250,10 +339,142 @@ func upgradeToVer2(s Session) {
 	mustExecute(s, sql)
 }
 
+// Update to version 3.
+func upgradeToVer3(s Session) {
+	// Version 3 add a system variable for the memory quota of sessions.
+	sql := fmt.Sprintf(`INSERT IGNORE INTO %s.%s VALUES ('%s', '%s');`, mysql.SystemDB, mysql.GlobalVariablesTable,
+		variable.TiDBMemQuotaSession, variable.SysVars[variable.TiDBMemQuotaSession].Value)
+	mustExecute(s, sql)
+}
+
+// Update to version 4.
+func upgradeToVer4(s Session) {
+	// Version 4 add system variables for the memory quota of statements.
+	sql := fmt.Sprintf(`INSERT IGNORE INTO %s.%s VALUES ('%s', '%s'), ('%s', '%s');`, mysql.SystemDB, mysql.GlobalVariablesTable,
+		variable.TiDBMemQuotaQuery, variable.SysVars[variable.TiDBMemQuotaQuery].Value,
+		variable.TiDBMemOOMAction, variable.SysVars[variable.TiDBMemOOMAction].Value)
+	mustExecute(s, sql)
+}
+
+// Update to version 5.
+func upgradeToVer5(s Session) {
+	// Version 5 add system variables for the concurrent backfill of ADD INDEX.
+	sql := fmt.Sprintf(`INSERT IGNORE INTO %s.%s VALUES ('%s', '%s'), ('%s', '%s');`, mysql.SystemDB, mysql.GlobalVariablesTable,
+		variable.TiDBDDLReorgWorkerCount, variable.SysVars[variable.TiDBDDLReorgWorkerCount].Value,
+		variable.TiDBDDLReorgBatchSize, variable.SysVars[variable.TiDBDDLReorgBatchSize].Value)
+	mustExecute(s, sql)
+}
+
+// Update to version 6.
+func upgradeToVer6(s Session) {
+	// Version 6 add a system variable for the threshold of the slow log, and the PROCESS privilege.
+	sql := fmt.Sprintf(`INSERT IGNORE INTO %s.%s VALUES ('%s', '%s');`, mysql.SystemDB, mysql.GlobalVariablesTable,
+		variable.TiDBSlowLogThreshold, variable.SysVars[variable.TiDBSlowLogThreshold].Value)
+	mustExecute(s, sql)
+	sql = fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMN Process_priv ENUM('N','Y') NOT NULL DEFAULT 'N'", mysql.SystemDB, mysql.UserTable)
+	_, err := s.Execute(sql)
+	if err != nil && !terror.ErrorEqual(err, infoschema.ErrColumnExists) {
+		log.Fatal(err)
+	}
+	// The users who can create users are the administrators, they are given the new privilege.
+	sql = fmt.Sprintf(`UPDATE %s.%s SET Process_priv = 'Y' WHERE Create_user_priv = 'Y'`, mysql.SystemDB, mysql.UserTable)
+	mustExecute(s, sql)
+}
+
+// Update to version 7.
+func upgradeToVer7(s Session) {
+	// Version 7 add the FILE privilege.
+	sql := fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMN File_priv ENUM('N','Y') NOT NULL DEFAULT 'N'", mysql.SystemDB, mysql.UserTable)
+	_, err := s.Execute(sql)
+	if err != nil && !terror.ErrorEqual(err, infoschema.ErrColumnExists) {
+		log.Fatal(err)
+	}
+	// The users who can create users are the administrators, they are given the new privilege.
+	sql = fmt.Sprintf(`UPDATE %s.%s SET File_priv = 'Y' WHERE Create_user_priv = 'Y'`, mysql.SystemDB, mysql.UserTable)
+	mustExecute(s, sql)
+}
+
+// Update to version 8.
+func upgradeToVer8(s Session) {
+	// Version 8 add a system variable for the batch size of LOAD DATA.
+	sql := fmt.Sprintf(`INSERT IGNORE INTO %s.%s VALUES ('%s', '%s');`, mysql.SystemDB, mysql.GlobalVariablesTable,
+		variable.TiDBLoadDataBatchSize, variable.SysVars[variable.TiDBLoadDataBatchSize].Value)
+	mustExecute(s, sql)
+}
+
+// Update to version 9.
+func upgradeToVer9(s Session) {
+	// Version 9 add a system variable for the recursion depth of common table expressions.
+	sql := fmt.Sprintf(`INSERT IGNORE INTO %s.%s VALUES ('%s', '%s');`, mysql.SystemDB, mysql.GlobalVariablesTable,
+		variable.CTEMaxRecursionDepth, variable.SysVars[variable.CTEMaxRecursionDepth].Value)
+	mustExecute(s, sql)
+}
+
+// Update to version 10.
+func upgradeToVer10(s Session) {
+	// Version 10 add the authentication plugin and the authentication string of users.
+	for _, col := range []string{
+		fmt.Sprintf("plugin CHAR(64) NOT NULL DEFAULT '%s'", mysql.AuthNativePassword),
+		"authentication_string TEXT",
+	} {
+		sql := fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMN %s", mysql.SystemDB, mysql.UserTable, col)
+		_, err := s.Execute(sql)
+		if err != nil && !terror.ErrorEqual(err, infoschema.ErrColumnExists) {
+			log.Fatal(err)
+		}
+	}
+}
+
+func upgradeToVer11(s Session) {
+	// Version 11 add the resource limits of users.
+	for _, col := range []string{"max_questions", "max_updates", "max_connections", "max_user_connections"} {
+		sql := fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMN %s INT UNSIGNED NOT NULL DEFAULT 0", mysql.SystemDB, mysql.UserTable, col)
+		_, err := s.Execute(sql)
+		if err != nil && !terror.ErrorEqual(err, infoschema.ErrColumnExists) {
+			log.Fatal(err)
+		}
+	}
+}
+
+func upgradeToVer12(s Session) {
+	// Version 12 add the password expiration and the account locking of users.
+	for _, col := range []string{
+		"password_expired ENUM('N','Y') NOT NULL DEFAULT 'N'",
+		"password_last_changed TIMESTAMP NULL DEFAULT NULL",
+		"password_lifetime SMALLINT UNSIGNED NULL DEFAULT NULL",
+		"account_locked ENUM('N','Y') NOT NULL DEFAULT 'N'",
+	} {
+		sql := fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMN %s", mysql.SystemDB, mysql.UserTable, col)
+		_, err := s.Execute(sql)
+		if err != nil && !terror.ErrorEqual(err, infoschema.ErrColumnExists) {
+			log.Fatal(err)
+		}
+	}
+	// The passwords of the existing users are regarded as changed at the upgrade.
+	sql := fmt.Sprintf("UPDATE %s.%s SET password_last_changed = NOW() WHERE password_last_changed IS NULL", mysql.SystemDB, mysql.UserTable)
+	mustExecute(s, sql)
+}
+
+func upgradeToVer13(s Session) {
+	// Version 13 add the tables of roles.
+	mustExecute(s, CreateRoleEdgesTable)
+	mustExecute(s, CreateDefaultRolesTable)
+}
+
+// Update to version 14.
+func upgradeToVer14(s Session) {
+	// Version 14 add the system variables of the statement and transaction timeouts.
+	for _, name := range []string{variable.MaxExecutionTime, variable.TiDBIdleTransactionTimeout} {
+		sql := fmt.Sprintf(`INSERT IGNORE INTO %s.%s VALUES ('%s', '%s');`, mysql.SystemDB, mysql.GlobalVariablesTable,
+			name, variable.SysVars[name].Value)
+		mustExecute(s, sql)
+	}
+}
+
 // Update boostrap version variable in mysql.TiDB table.
 func updateBootstrapVer(s Session) {
 	// Update bootstrap version.
-	sql := fmt.Sprintf(`INSERT INTO %s.%s VALUES ("%s", "%d", "TiDB bootstrap version.") ON DUPLICATE KEY UPDATE VARIABLE_VALUE="%d"`,
+	sql := fmt.Sprintf(`INSERT INTO %s.%s VALUES ('%s', '%d', 'TiDB bootstrap version.') ON DUPLICATE KEY UPDATE VARIABLE_VALUE='%d'`,
 		mysql.SystemDB, mysql.TiDBTable, tidbServerVersionVar, currentBootstrapVersion, currentBootstrapVersion)
 	mustExecute(s, sql)
 }
@@ -282,6 +503,9 @@ func doDDLWorks(s Session) {
 	mustExecute(s, CreateDBPrivTable)
 	mustExecute(s, CreateTablePrivTable)
 	mustExecute(s, CreateColumnPrivTable)
+	// Create role tables.
+	mustExecute(s, CreateRoleEdgesTable)
+	mustExecute(s, CreateDefaultRolesTable)
 	// Create global system variable table.
 	mustExecute(s, CreateGloablVariablesTable)
 	// Create TiDB table.
@@ -297,24 +521,24 @@ func doDMLWorks(s Session) {
 
 	// Insert a default user with empty password.
 	mustExecute(s, `INSERT INTO mysql.user VALUES
-		("%", "root", "", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y")`)
+		('%', 'root', '', 'Y', 'Y', 'Y', 'Y', 'Y', 'Y', 'Y', 'Y', 'Y', 'Y', 'Y', 'Y', 'Y', 'Y', 'mysql_native_password', '', 0, 0, 0, 0, 'N', NOW(), NULL, 'N')`)
 
 	// Init global system variables table.
 	values := make([]string, 0, len(variable.SysVars))
 	for k, v := range variable.SysVars {
-		value := fmt.Sprintf(`("%s", "%s")`, strings.ToLower(k), v.Value)
+		value := fmt.Sprintf(`('%s', '%s')`, strings.ToLower(k), v.Value)
 		values = append(values, value)
 	}
 	sql := fmt.Sprintf("INSERT INTO %s.%s VALUES %s;", mysql.SystemDB, mysql.GlobalVariablesTable,
 		strings.Join(values, ", "))
 	mustExecute(s, sql)
 
-	sql = fmt.Sprintf(`INSERT INTO %s.%s VALUES("%s", "%s", "Bootstrap flag. Do not delete.")
-		ON DUPLICATE KEY UPDATE VARIABLE_VALUE="%s"`,
+	sql = fmt.Sprintf(`INSERT INTO %s.%s VALUES('%s', '%s', 'Bootstrap flag. Do not delete.')
+		ON DUPLICATE KEY UPDATE VARIABLE_VALUE='%s'`,
 		mysql.SystemDB, mysql.TiDBTable, bootstrappedVar, bootstrappedVarTrue, bootstrappedVarTrue)
 	mustExecute(s, sql)
 
-	sql = fmt.Sprintf(`INSERT INTO %s.%s VALUES("%s", "%d", "Bootstrap version. Do not delete")`,
+	sql = fmt.Sprintf(`INSERT INTO %s.%s VALUES('%s', '%d', 'Bootstrap version. Do not delete')`,
 		mysql.SystemDB, mysql.TiDBTable, tidbServerVersionVar, currentBootstrapVersion)
 	mustExecute(s, sql)
 
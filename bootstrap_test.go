@@ -39,7 +39,9 @@ func (s *testSessionSuite) TestBootstrap(c *C) {
 	row, err := r.Next()
 	c.Assert(err, IsNil)
 	c.Assert(row, NotNil)
-	match(c, row.Data, []byte("%"), []byte("root"), []byte(""), "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y")
+	match(c, row.Data, []byte("%"), []byte("root"), []byte(""), "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", []byte("mysql_native_password"), []byte(""), 0, 0, 0, 0,
+		"N", row.Data[24].GetValue(), nil, "N")
+	c.Assert(row.Data[24].IsNull(), IsFalse)
 
 	c.Assert(se.Auth("root@anyhost", []byte(""), []byte("")), IsTrue)
 	mustExecSQL(c, se, "USE test;")
@@ -93,6 +95,8 @@ func (s *testSessionSuite) bootstrapWithOnlyDDLWork(store kv.Storage, c *C) {
 		store:  store,
 		sid:    atomic.AddInt64(&sessionID, 1),
 		parser: parser.New(),
+
+		restrictedParser: parser.New(),
 	}
 	ss.SetValue(context.Initing, true)
 	domain, err := domap.Get(store)
@@ -124,7 +128,9 @@ func (s *testSessionSuite) TestBootstrapWithError(c *C) {
 	row, err := r.Next()
 	c.Assert(err, IsNil)
 	c.Assert(row, NotNil)
-	match(c, row.Data, []byte("%"), []byte("root"), []byte(""), "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y")
+	match(c, row.Data, []byte("%"), []byte("root"), []byte(""), "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", []byte("mysql_native_password"), []byte(""), 0, 0, 0, 0,
+		"N", row.Data[24].GetValue(), nil, "N")
+	c.Assert(row.Data[24].IsNull(), IsFalse)
 	mustExecSQL(c, se, "USE test;")
 	// Check privilege tables.
 	mustExecSQL(c, se, "SELECT * from mysql.db;")
@@ -177,8 +183,8 @@ func (s *testSessionSuite) TestUpgrade(c *C) {
 	err = txn.Commit()
 	c.Assert(err, IsNil)
 	mustExecSQL(c, se1, `delete from mysql.TiDB where VARIABLE_NAME="tidb_server_version";`)
-	mustExecSQL(c, se1, fmt.Sprintf(`delete from mysql.global_variables where VARIABLE_NAME="%s" or VARIABLE_NAME="%s";`,
-		variable.DistSQLScanConcurrencyVar, variable.DistSQLJoinConcurrencyVar))
+	mustExecSQL(c, se1, fmt.Sprintf(`delete from mysql.global_variables where VARIABLE_NAME in ("%s", "%s", "%s", "%s");`,
+		variable.DistSQLScanConcurrencyVar, variable.DistSQLJoinConcurrencyVar, variable.TiDBMemQuotaSession, variable.TiDBMemQuotaQuery))
 	mustExecSQL(c, se1, `commit;`)
 	delete(storeBootstrapped, store.UUID())
 	// Make sure the version is downgraded.
@@ -203,4 +209,25 @@ func (s *testSessionSuite) TestUpgrade(c *C) {
 	ver, err = getBootstrapVersion(se2)
 	c.Assert(err, IsNil)
 	c.Assert(ver, Equals, int64(currentBootstrapVersion))
+
+	r = mustExecSQL(c, se2, fmt.Sprintf(`SELECT VARIABLE_VALUE from mysql.global_variables where VARIABLE_NAME="%s";`,
+		variable.TiDBMemQuotaSession))
+	row, err = r.Next()
+	c.Assert(err, IsNil)
+	c.Assert(row, NotNil)
+	c.Assert(row.Data[0].GetString(), Equals, variable.SysVars[variable.TiDBMemQuotaSession].Value)
+
+	r = mustExecSQL(c, se2, fmt.Sprintf(`SELECT VARIABLE_VALUE from mysql.global_variables where VARIABLE_NAME="%s";`,
+		variable.TiDBMemQuotaQuery))
+	row, err = r.Next()
+	c.Assert(err, IsNil)
+	c.Assert(row, NotNil)
+	c.Assert(row.Data[0].GetString(), Equals, variable.SysVars[variable.TiDBMemQuotaQuery].Value)
+
+	r = mustExecSQL(c, se2, `SELECT Process_priv, File_priv from mysql.user where User="root";`)
+	row, err = r.Next()
+	c.Assert(err, IsNil)
+	c.Assert(row, NotNil)
+	c.Assert(row.Data[0].GetMysqlEnum().String(), Equals, "Y")
+	c.Assert(row.Data[1].GetMysqlEnum().String(), Equals, "Y")
 }
@@ -31,7 +31,10 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/store/localstore"
+	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/store/tikv/mock-tikv"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/table/tables"
 	"github.com/pingcap/tidb/tablecodec"
@@ -177,6 +180,16 @@ LOOP:
 }
 
 func (s *testDBSuite) testAddIndex(c *C) {
+	// Backfill the index by several workers with small batches.
+	s.mustExec(c, "set @@global.tidb_ddl_reorg_worker_cnt = 3")
+	s.mustExec(c, "set @@global.tidb_ddl_reorg_batch_size = 16")
+	defer func() {
+		s.mustExec(c, fmt.Sprintf("set @@global.tidb_ddl_reorg_worker_cnt = %d", variable.DefDDLReorgWorkerCount))
+		s.mustExec(c, fmt.Sprintf("set @@global.tidb_ddl_reorg_batch_size = %d", variable.DefDDLReorgBatchSize))
+	}()
+	// The variables are set on another server, the DDL worker loads them from the storage.
+	variable.SetDDLReorgWorkerCount(variable.DefDDLReorgWorkerCount)
+	variable.SetDDLReorgBatchSize(variable.DefDDLReorgBatchSize)
 	done := make(chan struct{}, 1)
 
 	num := 100
@@ -212,6 +225,9 @@ LOOP:
 		}
 	}
 
+	c.Assert(variable.GetDDLReorgWorkerCount(), Equals, int32(3))
+	c.Assert(variable.GetDDLReorgBatchSize(), Equals, int32(16))
+
 	// get exists keys
 	keys := make([]int, 0, num)
 	for i := 0; i < num; i++ {
@@ -652,6 +668,39 @@ func (s *testDBSuite) TestUpdateMultipleTable(c *C) {
 	tk.MustQuery("select * from t1").Check(testkit.Rows("8 1 9", "8 2 9"))
 }
 
+func (s *testDBSuite) TestAddIndexWithSplitRegions(c *C) {
+	defer testleak.AfterTest(c)()
+	cluster := mocktikv.NewCluster()
+	mocktikv.BootstrapWithSingleStore(cluster)
+	store, err := tikv.NewMockTikvStoreWithCluster(cluster)
+	c.Assert(err, IsNil)
+	defer store.Close()
+	tk := testkit.NewTestKit(c, store)
+	dom, err := tidb.GetDomain(store)
+	c.Assert(err, IsNil)
+	dom.SetLease(100 * time.Millisecond)
+
+	tk.MustExec("use test")
+	tk.MustExec("create table t (a int primary key, b int)")
+	tbl, err := dom.InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	// Split the rows into 4 Regions, the reorganization splits the handles at the start keys of the Regions.
+	for _, h := range []int64{25, 50, 75} {
+		key := tablecodec.EncodeRowKeyWithHandle(tbl.Meta().ID, h)
+		region, _ := cluster.GetRegionByKey(key)
+		peerID := cluster.AllocID()
+		cluster.Split(region.GetId(), cluster.AllocID(), key, []uint64{peerID}, peerID)
+	}
+	for i := 0; i < 100; i++ {
+		tk.MustExec("insert into t values (?, ?)", i, i)
+	}
+
+	tk.MustExec("alter table t add index idx_b(b)")
+	tk.MustExec("admin check table t")
+	tk.MustQuery("select count(*) from t use index(idx_b) where b >= 0").Check(testkit.Rows("100"))
+	tk.MustQuery("select a from t use index(idx_b) where b in (0, 24, 25, 99)").Check(testkit.Rows("0", "24", "25", "99"))
+}
+
 func (s *testDBSuite) TestTruncateTable(c *C) {
 	defer testleak.AfterTest(c)
 	store, err := tidb.NewStore("memory://truncate_table")
@@ -22,18 +22,25 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/sessionctx/binloginfo"
+	"github.com/pingcap/tidb/sessionctx/db"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/audit"
 	"github.com/pingcap/tipb/go-binlog"
 )
 
-func (d *ddl) doDDLJob(ctx context.Context, job *model.Job) error {
+func (d *ddl) doDDLJob(ctx context.Context, job *model.Job) (err error) {
+	defer func() {
+		auditDDLJob(ctx, job, err)
+	}()
 	// for every DDL, we must commit current transaction.
-	if err := ctx.CommitTxn(); err != nil {
+	if err = ctx.CommitTxn(); err != nil {
 		return errors.Trace(err)
 	}
 	var startTS uint64
-	err := kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
+	err = kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
 		t := meta.NewMeta(txn)
 		var err error
 		job.ID, err = t.GenGlobalID()
@@ -102,6 +109,31 @@ func (d *ddl) doDDLJob(ctx context.Context, job *model.Job) error {
 	}
 }
 
+// auditDDLJob writes the DDL job to the audit log when it finishes, jobErr is the error that the job fails with.
+func auditDDLJob(ctx context.Context, job *model.Job, jobErr error) {
+	if !audit.Enabled() {
+		return
+	}
+	_, digest := parser.NormalizeDigest(job.Query)
+	r := &audit.Record{
+		Event:     audit.EventDDLJob,
+		DB:        db.GetCurrentSchema(ctx),
+		Class:     audit.ClassDDL,
+		Digest:    digest,
+		SQL:       parser.RedactAuthStrings(job.Query),
+		JobID:     job.ID,
+		JobType:   job.Type.String(),
+		ErrorCode: audit.ErrorCode(jobErr),
+	}
+	if vars := variable.GetSessionVars(ctx); vars != nil {
+		r.ConnID = vars.ConnectionID
+		r.SetUser(vars.User)
+	}
+	if err := audit.Write(r); err != nil {
+		log.Errorf("[audit] write error: %v", errors.ErrorStack(err))
+	}
+}
+
 func (d *ddl) writePreDDLBinlog(job *model.Job, startTS uint64) error {
 	if binloginfo.PumpClient == nil {
 		return nil
@@ -244,6 +276,10 @@ func (d *ddl) finishDDLJob(t *meta.Meta, job *model.Job) error {
 		if err = d.prepareBgJob(t, job); err != nil {
 			return errors.Trace(err)
 		}
+	case model.ActionAddIndex:
+		if err = t.RemoveDDLReorgRanges(job); err != nil {
+			return errors.Trace(err)
+		}
 	}
 
 	err = t.AddHistoryDDLJob(job)
@@ -427,7 +463,15 @@ func (d *ddl) runDDLJob(t *meta.Meta, job *model.Job) {
 		return
 	}
 
-	if job.State != model.JobRollback {
+	if job.IsCancelling() && (job.Type != model.ActionAddIndex || job.SchemaState == model.StateNone) {
+		// The job has not changed the schema yet, so it can be cancelled directly.
+		// An add index job which has changed the schema is converted to a rollback job by onCreateIndex.
+		job.State = model.JobCancelled
+		job.Error = toTError(errCancelledDDLJob)
+		job.ErrorCount++
+		return
+	}
+	if job.State != model.JobRollback && job.State != model.JobCancelling {
 		job.State = model.JobRunning
 	}
 
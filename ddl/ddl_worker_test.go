@@ -20,6 +20,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/inspectkv"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
@@ -246,3 +247,81 @@ func doDDLJobErr(c *C, schemaID, tableID int64, tp model.ActionType, args []inte
 
 	return job
 }
+
+func (s *testDDLSuite) TestCancelJob(c *C) {
+	defer testleak.AfterTest(c)()
+	store := testCreateStore(c, "test_cancel_job")
+	defer store.Close()
+
+	d := newDDL(store, nil, nil, testLease)
+	defer d.close()
+
+	ctx := testNewContext(c, d)
+	dbInfo := testSchemaInfo(c, d, "test_cancel_job")
+	testCreateSchema(c, ctx, d, dbInfo)
+	tblInfo := testTableInfo(c, d, "t", 3)
+	testCreateTable(c, ctx, d, dbInfo, tblInfo)
+	tbl := testGetTable(c, d, dbInfo.ID, tblInfo.ID)
+	for i := 1; i <= 3; i++ {
+		_, err := tbl.AddRecord(ctx, types.MakeDatums(i, i, i))
+		c.Assert(err, IsNil)
+	}
+	c.Assert(ctx.CommitTxn(), IsNil)
+
+	var (
+		cancelState model.SchemaState
+		cancelErrs  []error
+		checkErr    error
+	)
+	tc := &testDDLCallback{}
+	tc.onJobUpdated = func(job *model.Job) {
+		if job.Type != model.ActionAddIndex || job.SchemaState != cancelState || cancelErrs != nil {
+			return
+		}
+		checkErr = kv.RunInNewTxn(store, false, func(txn kv.Transaction) error {
+			var err error
+			cancelErrs, err = inspectkv.CancelJobs(txn, []int64{job.ID, job.ID + 1000})
+			return err
+		})
+	}
+	d.setHook(tc)
+
+	for _, state := range []model.SchemaState{model.StateDeleteOnly, model.StateWriteOnly, model.StateWriteReorganization} {
+		cancelState, cancelErrs = state, nil
+		job := &model.Job{
+			SchemaID: dbInfo.ID,
+			TableID:  tblInfo.ID,
+			Type:     model.ActionAddIndex,
+			Args: []interface{}{false, model.NewCIStr("c1_index"), int64(1),
+				[]*ast.IndexColName{{Column: &ast.ColumnName{Name: model.NewCIStr("c1")}, Length: types.UnspecifiedLength}}},
+		}
+		err := d.doDDLJob(ctx, job)
+		c.Assert(terror.ErrorEqual(err, errCancelledDDLJob), IsTrue, Commentf("state %s, err %v", state, err))
+		c.Assert(checkErr, IsNil)
+		c.Assert(cancelErrs, HasLen, 2)
+		c.Assert(cancelErrs[0], IsNil)
+		c.Assert(cancelErrs[1], NotNil)
+
+		kv.RunInNewTxn(store, false, func(txn kv.Transaction) error {
+			t := meta.NewMeta(txn)
+			historyJob, err := t.GetHistoryDDLJob(job.ID)
+			c.Assert(err, IsNil)
+			c.Assert(historyJob.State, Equals, model.JobRollbackDone)
+			info, err := t.GetTable(dbInfo.ID, tblInfo.ID)
+			c.Assert(err, IsNil)
+			c.Assert(info.Indices, HasLen, 0)
+			return nil
+		})
+	}
+
+	// The finished job can't be cancelled.
+	d.setHook(&testDDLCallback{})
+	job := testCreateIndex(c, ctx, d, dbInfo, tblInfo, false, "c1_index", "c1")
+	err := kv.RunInNewTxn(store, false, func(txn kv.Transaction) error {
+		errs, err := inspectkv.CancelJobs(txn, []int64{job.ID})
+		c.Assert(errs, HasLen, 1)
+		c.Assert(errs[0], NotNil)
+		return err
+	})
+	c.Assert(err, IsNil)
+}
@@ -14,6 +14,9 @@
 package ddl
 
 import (
+	"math"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/juju/errors"
@@ -167,6 +170,20 @@ func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) error {
 		return errors.Trace(err)
 	}
 
+	if job.IsCancelling() {
+		if indexInfo.State == model.StateWriteReorganization {
+			// Wait for the running reorganization to exit before rolling back, it exits when it finds the job is cancelling.
+			err = d.runReorgJob(func() error {
+				return errCancelledDDLJob
+			})
+			if terror.ErrorEqual(err, errWaitReorgTimeout) {
+				return nil
+			}
+		}
+		log.Warnf("[ddl] DDL job %v is cancelled, convert job to rollback job", job)
+		return d.convert2RollbackJob(t, job, tblInfo, indexInfo, errCancelledDDLJob)
+	}
+
 	switch indexInfo.State {
 	case model.StateNone:
 		// none -> delete only
@@ -214,7 +231,7 @@ func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) error {
 		if err != nil {
 			if terror.ErrorEqual(err, kv.ErrKeyExists) {
 				log.Warnf("[ddl] run DDL job %v err %v, convert job to rollback job", job, err)
-				err = d.convert2RollbackJob(t, job, tblInfo, indexInfo)
+				err = d.convert2RollbackJob(t, job, tblInfo, indexInfo, kv.ErrKeyExists.Gen("Duplicate for key %s", indexInfo.Name.O))
 			}
 			return errors.Trace(err)
 		}
@@ -236,7 +253,9 @@ func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) error {
 	}
 }
 
-func (d *ddl) convert2RollbackJob(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, indexInfo *model.IndexInfo) error {
+// convert2RollbackJob converts the add index job to a rollback job which drops the index, the cause of the rollback
+// is returned and saved in the job.
+func (d *ddl) convert2RollbackJob(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, indexInfo *model.IndexInfo, cause error) error {
 	job.State = model.JobRollback
 	job.Args = []interface{}{indexInfo.Name}
 	// If add index job rollbacks in write reorganization state, its need to delete all keys which has been added.
@@ -249,8 +268,7 @@ func (d *ddl) convert2RollbackJob(t *meta.Meta, job *model.Job, tblInfo *model.T
 	if err != nil {
 		return errors.Trace(err)
 	}
-	err = kv.ErrKeyExists.Gen("Duplicate for key %s", indexInfo.Name.O)
-	return errors.Trace(err)
+	return errors.Trace(cause)
 }
 
 func (d *ddl) onDropIndex(t *meta.Meta, job *model.Job) error {
@@ -380,55 +398,111 @@ const defaultBatchSize = 1024
 
 // How to add index in reorganization state?
 //  1. Generate a snapshot with special version.
-//  2. Traverse the snapshot, get every row in the table.
-//  3. For one row, if the row has been already deleted, skip to next row.
-//  4. If not deleted, check whether index has existed, if existed, skip to next row.
-//  5. If index doesn't exist, create the index and then continue to handle next row.
+//  2. Split the handles of the snapshot into ranges, save the ranges so that the job can be resumed.
+//  3. Backfill the ranges by workers concurrently, each worker traverses the rows of its range in batches.
+//  4. For one row, if the row has been already deleted, skip to next row.
+//  5. If not deleted, check whether index has existed, if existed, skip to next row.
+//  6. If index doesn't exist, create the index and then continue to handle next row.
+//  7. A batch of rows and the progress of its range are handled in one transaction.
 func (d *ddl) addTableIndex(t table.Table, indexInfo *model.IndexInfo, reorgInfo *reorgInfo, job *model.Job) error {
-	seekHandle := reorgInfo.Handle
-	version := reorgInfo.SnapshotVer
-	count := job.GetRowCount()
+	// The variables are loaded whenever the job starts or resumes. The worker count takes effect only before the
+	// ranges are saved, the batch size takes effect for the rest of the ranges.
+	workerCnt, batchSize := d.loadReorgVars()
+	ranges, err := d.getReorgRanges(t, reorgInfo, workerCnt)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	var count int64
+	for _, r := range ranges {
+		count += r.RowCount
+	}
+	job.SetRowCount(count)
 
-	for {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		failed   int32
+	)
+	for i, r := range ranges {
+		if r.Done {
+			continue
+		}
+		wg.Add(1)
+		go func(idx int, r *model.ReorgRange) {
+			defer wg.Done()
+			err := d.backfillIndexRange(t, indexInfo, reorgInfo, idx, r, batchSize, &count, &failed)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				atomic.StoreInt32(&failed, 1)
+			}
+		}(i, r)
+	}
+	wg.Wait()
+	return errors.Trace(firstErr)
+}
+
+// backfillIndexRange backfills the index for the rows in the idx-th range of the reorganization.
+// It stops when another worker failed.
+func (d *ddl) backfillIndexRange(t table.Table, indexInfo *model.IndexInfo, reorgInfo *reorgInfo, idx int,
+	r *model.ReorgRange, batchSize int, count *int64, failed *int32) error {
+	for !r.Done && atomic.LoadInt32(failed) == 0 {
 		startTS := time.Now()
-		handles, err := d.getSnapshotRows(t, version, seekHandle)
+		handles, err := d.getSnapshotRowsInRange(t, reorgInfo.SnapshotVer, r.StartHandle, r.EndHandle, batchSize)
 		if err != nil {
 			return errors.Trace(err)
-		} else if len(handles) == 0 {
-			return nil
 		}
 
-		count += int64(len(handles))
-		seekHandle = handles[len(handles)-1] + 1
-		err = d.backfillTableIndex(t, indexInfo, handles, reorgInfo)
+		next := *r
+		next.RowCount += int64(len(handles))
+		if len(handles) < batchSize || handles[len(handles)-1] == r.EndHandle {
+			next.Done = true
+		} else {
+			next.StartHandle = handles[len(handles)-1] + 1
+		}
+		err = d.backfillTableIndex(t, indexInfo, handles, func(txn kv.Transaction) error {
+			return errors.Trace(reorgInfo.UpdateRange(txn, idx, &next))
+		})
 		sub := time.Since(startTS).Seconds()
 		if err != nil {
-			log.Warnf("[ddl] added index for %v rows failed, take time %v", count, sub)
+			log.Warnf("[ddl] added index for %v rows in range %d failed, take time %v", next.RowCount, idx, sub)
 			return errors.Trace(err)
 		}
 
-		job.SetRowCount(count)
+		*r = next
+		total := atomic.AddInt64(count, int64(len(handles)))
+		reorgInfo.SetRowCount(total)
 		batchHandleDataHistogram.WithLabelValues(batchAddIdx).Observe(sub)
-		log.Infof("[ddl] added index for %v rows, take time %v", count, sub)
-
+		log.Infof("[ddl] added index for %v rows in range %d, %v rows in total, take time %v", next.RowCount, idx, total, sub)
 	}
+	return nil
 }
 
 func (d *ddl) getSnapshotRows(t table.Table, version uint64, seekHandle int64) ([]int64, error) {
+	return d.getSnapshotRowsInRange(t, version, seekHandle, math.MaxInt64, defaultBatchSize)
+}
+
+// getSnapshotRowsInRange gets at most limit handles in [startHandle, endHandle] of the snapshot.
+func (d *ddl) getSnapshotRowsInRange(t table.Table, version uint64, startHandle, endHandle int64, limit int) ([]int64, error) {
 	ver := kv.Version{Ver: version}
 	snap, err := d.store.GetSnapshot(ver)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
 
-	firstKey := t.RecordKey(seekHandle)
+	firstKey := t.RecordKey(startHandle)
 	it, err := snap.Seek(firstKey)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
 	defer it.Close()
 
-	handles := make([]int64, 0, defaultBatchSize)
+	handles := make([]int64, 0, limit)
 	for it.Valid() {
 		if !it.Key().HasPrefix(t.RecordPrefix()) {
 			break
@@ -439,9 +513,12 @@ func (d *ddl) getSnapshotRows(t table.Table, version uint64, seekHandle int64) (
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		if handle > endHandle {
+			break
+		}
 
 		handles = append(handles, handle)
-		if len(handles) == defaultBatchSize {
+		if len(handles) == limit {
 			break
 		}
 
@@ -457,21 +534,23 @@ func (d *ddl) getSnapshotRows(t table.Table, version uint64, seekHandle int64) (
 	return handles, nil
 }
 
-func (d *ddl) backfillTableIndex(t table.Table, indexInfo *model.IndexInfo, handles []int64, reorgInfo *reorgInfo) error {
+// backfillTableIndex creates the index for the rows of the handles in one transaction,
+// updateProgress is called in the transaction to save the progress of the reorganization.
+func (d *ddl) backfillTableIndex(t table.Table, indexInfo *model.IndexInfo, handles []int64,
+	updateProgress func(txn kv.Transaction) error) error {
 	kvX := tables.NewIndex(t.Meta(), indexInfo)
 
-	for _, handle := range handles {
-		log.Debug("[ddl] backfill index...", handle)
-
-		err := kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
-			if err := d.isReorgRunnable(txn, ddlJobFlag); err != nil {
-				return errors.Trace(err)
-			}
+	err := kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
+		if err := d.isReorgRunnable(txn, ddlJobFlag); err != nil {
+			return errors.Trace(err)
+		}
 
+		for _, handle := range handles {
+			log.Debug("[ddl] backfill index...", handle)
 			rowKey, vals, err1 := fetchRowColVals(txn, t, handle, indexInfo)
 			if terror.ErrorEqual(err1, kv.ErrNotExist) {
 				// row doesn't exist, skip it.
-				return nil
+				continue
 			}
 			if err1 != nil {
 				return errors.Trace(err1)
@@ -482,7 +561,7 @@ func (d *ddl) backfillTableIndex(t table.Table, indexInfo *model.IndexInfo, hand
 				return errors.Trace(err1)
 			} else if exist {
 				// index already exists, skip it.
-				return nil
+				continue
 			}
 			err1 = txn.LockKeys(rowKey)
 			if err1 != nil {
@@ -494,17 +573,12 @@ func (d *ddl) backfillTableIndex(t table.Table, indexInfo *model.IndexInfo, hand
 			if err1 != nil {
 				return errors.Trace(err1)
 			}
-
-			// update reorg next handle
-			return errors.Trace(reorgInfo.UpdateHandle(txn, handle))
-		})
-
-		if err != nil {
-			return errors.Trace(err)
 		}
-	}
 
-	return nil
+		// update reorg progress
+		return errors.Trace(updateProgress(txn))
+	})
+	return errors.Trace(err)
 }
 
 func (d *ddl) dropTableIndex(t table.Table, indexInfo *model.IndexInfo, job *model.Job) error {
@@ -15,6 +15,9 @@ package ddl
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/juju/errors"
@@ -23,7 +26,12 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/types"
 )
 
 var _ context.Context = &reorgContext{}
@@ -165,6 +173,16 @@ func (d *ddl) isReorgRunnable(txn kv.Transaction, flag JobType) error {
 		log.Infof("[ddl] %s job, self id %s owner %s, txnTS:%d", flag, d.uuid, owner, txn.StartTS())
 		return errors.Trace(errNotOwner)
 	}
+	if flag == ddlJobFlag {
+		// The running job may be cancelled by ADMIN CANCEL DDL JOBS, the reorganization should exit then.
+		job, err := t.GetDDLJob(0)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if job != nil && job.IsCancelling() {
+			return errors.Trace(errCancelledDDLJob)
+		}
+	}
 
 	return nil
 }
@@ -298,3 +316,177 @@ func (r *reorgInfo) UpdateHandle(txn kv.Transaction, handle int64) error {
 	t := meta.NewMeta(txn)
 	return errors.Trace(t.UpdateDDLReorgHandle(r.Job, handle))
 }
+
+// UpdateRange saves the progress of the idx-th handle range.
+func (r *reorgInfo) UpdateRange(txn kv.Transaction, idx int, rg *model.ReorgRange) error {
+	t := meta.NewMeta(txn)
+	return errors.Trace(t.UpdateDDLReorgRange(r.Job, idx, rg))
+}
+
+// getReorgRanges gets the saved handle ranges of the reorganization. If they are not saved yet,
+// it splits the handles of the snapshot from reorgInfo.Handle into at most cnt ranges and saves them.
+func (d *ddl) getReorgRanges(t table.Table, reorgInfo *reorgInfo, cnt int) ([]*model.ReorgRange, error) {
+	var ranges []*model.ReorgRange
+	err := kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
+		if err := d.isReorgRunnable(txn, ddlJobFlag); err != nil {
+			return errors.Trace(err)
+		}
+
+		m := meta.NewMeta(txn)
+		var err error
+		ranges, err = m.GetDDLReorgRanges(reorgInfo.Job)
+		if err != nil || len(ranges) > 0 {
+			return errors.Trace(err)
+		}
+		ranges, err = d.splitTableHandles(t, reorgInfo.SnapshotVer, reorgInfo.Handle, cnt)
+		if err != nil || len(ranges) == 0 {
+			return errors.Trace(err)
+		}
+		log.Infof("[ddl] job %d splits handles into %d ranges, from %d to %d", reorgInfo.ID, len(ranges),
+			ranges[0].StartHandle, ranges[len(ranges)-1].EndHandle)
+		return errors.Trace(m.SetDDLReorgRanges(reorgInfo.Job, ranges))
+	})
+	return ranges, errors.Trace(err)
+}
+
+// splitTableHandles splits the handles of the snapshot not less than startHandle into at most cnt ranges evenly.
+func (d *ddl) splitTableHandles(t table.Table, version uint64, startHandle int64, cnt int) ([]*model.ReorgRange, error) {
+	snap, err := d.store.GetSnapshot(kv.Version{Ver: version})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	it, err := snap.Seek(t.RecordKey(startHandle))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer it.Close()
+	if !it.Valid() || !it.Key().HasPrefix(t.RecordPrefix()) {
+		// no rows to handle.
+		return nil, nil
+	}
+	minHandle, err := tablecodec.DecodeRowKey(it.Key())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if store, ok := d.store.(kv.SplittableStorage); ok {
+		// TiKV can't seek the max handle in reverse, the handles are split at the boundaries of the Regions instead.
+		return splitTableHandlesByRegions(store, t, minHandle, cnt)
+	}
+
+	rit, err := snap.SeekReverse(t.RecordPrefix().PrefixNext())
+	if terror.ErrorEqual(err, kv.ErrNotImplemented) {
+		// The max handle is unknown, all the handles are backfilled by one worker.
+		return []*model.ReorgRange{{StartHandle: minHandle, EndHandle: math.MaxInt64}}, nil
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer rit.Close()
+	if !rit.Valid() || !rit.Key().HasPrefix(t.RecordPrefix()) {
+		return nil, nil
+	}
+	maxHandle, err := tablecodec.DecodeRowKey(rit.Key())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	// Use unsigned integers to avoid overflow when the handles are far apart.
+	span := uint64(maxHandle - minHandle)
+	step := span/uint64(cnt) + 1
+	if step == 0 {
+		// span is the max uint64 and cnt is 1.
+		step = span
+	}
+	ranges := make([]*model.ReorgRange, 0, cnt)
+	for start := uint64(0); start <= span; start += step {
+		end := start + step - 1
+		if end > span || end < start {
+			end = span
+		}
+		ranges = append(ranges, &model.ReorgRange{
+			StartHandle: minHandle + int64(start),
+			EndHandle:   minHandle + int64(end),
+		})
+		if end == span {
+			break
+		}
+	}
+	return ranges, nil
+}
+
+// splitTableHandlesByRegions splits the handles not less than minHandle at the start keys of the Regions,
+// the adjacent Regions are merged into one range if there are more than cnt Regions.
+func splitTableHandlesByRegions(store kv.SplittableStorage, t table.Table, minHandle int64, cnt int) ([]*model.ReorgRange, error) {
+	keys, err := store.GetSplitKeys(t.RecordKey(minHandle), t.RecordPrefix().PrefixNext())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	starts := []int64{minHandle}
+	for _, key := range keys {
+		handle, err := tablecodec.DecodeRowKey(key)
+		if err != nil {
+			// The Region doesn't start with a row key, the rows in it are backfilled with the previous Region.
+			continue
+		}
+		if handle > starts[len(starts)-1] {
+			starts = append(starts, handle)
+		}
+	}
+
+	step := (len(starts) + cnt - 1) / cnt
+	ranges := make([]*model.ReorgRange, 0, cnt)
+	for i := 0; i < len(starts); i += step {
+		// The max handle is unknown, so the last range ends at the max int64.
+		end := int64(math.MaxInt64)
+		if i+step < len(starts) {
+			end = starts[i+step] - 1
+		}
+		ranges = append(ranges, &model.ReorgRange{StartHandle: starts[i], EndHandle: end})
+	}
+	return ranges, nil
+}
+
+// loadReorgVars loads the reorganization variables from the global variables in the storage, so the values set on
+// any server are used. The values of this server are used if they can't be loaded.
+func (d *ddl) loadReorgVars() (workerCnt, batchSize int) {
+	err := d.loadReorgVarsFromStorage()
+	if err != nil {
+		log.Warnf("[ddl] load reorganization variables failed, use the values of this server: %v", err)
+	}
+	return int(variable.GetDDLReorgWorkerCount()), int(variable.GetDDLReorgBatchSize())
+}
+
+func (d *ddl) loadReorgVarsFromStorage() error {
+	if d.infoHandle == nil || d.infoHandle.Get() == nil {
+		// The DDL is created without the information schema in tests.
+		return nil
+	}
+	tbl, err := d.infoHandle.Get().TableByName(model.NewCIStr(mysql.SystemDB), model.NewCIStr(mysql.GlobalVariablesTable))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ctx := d.newReorgContext()
+	defer ctx.RollbackTxn()
+	// The table has the columns VARIABLE_NAME and VARIABLE_VALUE.
+	err = tbl.IterRecords(ctx, tbl.FirstKey(), tbl.Cols(), func(h int64, rec []types.Datum, cols []*table.Column) (bool, error) {
+		if len(rec) < 2 {
+			return true, nil
+		}
+		name := strings.ToLower(rec[0].GetString())
+		if name != variable.TiDBDDLReorgWorkerCount && name != variable.TiDBDDLReorgBatchSize {
+			return true, nil
+		}
+		val, err := strconv.ParseInt(rec[1].GetString(), 10, 32)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		if name == variable.TiDBDDLReorgWorkerCount {
+			variable.SetDDLReorgWorkerCount(int32(val))
+		} else {
+			variable.SetDDLReorgBatchSize(int32(val))
+		}
+		return true, nil
+	})
+	return errors.Trace(err)
+}
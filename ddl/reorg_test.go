@@ -14,6 +14,7 @@
 package ddl
 
 import (
+	"math"
 	"time"
 
 	. "github.com/pingcap/check"
@@ -164,3 +165,106 @@ func (s *testDDLSuite) TestReorgOwner(c *C) {
 	})
 	c.Assert(err, IsNil)
 }
+
+func (s *testDDLSuite) TestSplitTableHandles(c *C) {
+	defer testleak.AfterTest(c)()
+	store := testCreateStore(c, "test_split_table_handles")
+	defer store.Close()
+
+	d := newDDL(store, nil, nil, testLease)
+	defer d.close()
+
+	ctx := testNewContext(c, d)
+	dbInfo := testSchemaInfo(c, d, "test")
+	testCreateSchema(c, ctx, d, dbInfo)
+	tblInfo := testTableInfo(c, d, "t", 3)
+	testCreateTable(c, ctx, d, dbInfo, tblInfo)
+	t := testGetTable(c, d, dbInfo.ID, tblInfo.ID)
+
+	ver, err := store.CurrentVersion()
+	c.Assert(err, IsNil)
+	ranges, err := d.splitTableHandles(t, ver.Ver, 0, 4)
+	c.Assert(err, IsNil)
+	c.Assert(ranges, HasLen, 0)
+
+	for i := 1; i <= 100; i++ {
+		_, err = t.AddRecord(ctx, types.MakeDatums(i, i, i))
+		c.Assert(err, IsNil)
+	}
+	c.Assert(ctx.CommitTxn(), IsNil)
+
+	ver, err = store.CurrentVersion()
+	c.Assert(err, IsNil)
+	ranges, err = d.splitTableHandles(t, ver.Ver, 0, 4)
+	c.Assert(err, IsNil)
+	c.Assert(ranges, DeepEquals, []*model.ReorgRange{
+		{StartHandle: 1, EndHandle: 25},
+		{StartHandle: 26, EndHandle: 50},
+		{StartHandle: 51, EndHandle: 75},
+		{StartHandle: 76, EndHandle: 100},
+	})
+	// Resume from a handle saved by the old reorganization.
+	ranges, err = d.splitTableHandles(t, ver.Ver, 91, 4)
+	c.Assert(err, IsNil)
+	c.Assert(ranges, DeepEquals, []*model.ReorgRange{
+		{StartHandle: 91, EndHandle: 93},
+		{StartHandle: 94, EndHandle: 96},
+		{StartHandle: 97, EndHandle: 99},
+		{StartHandle: 100, EndHandle: 100},
+	})
+	ranges, err = d.splitTableHandles(t, ver.Ver, 0, 1)
+	c.Assert(err, IsNil)
+	c.Assert(ranges, DeepEquals, []*model.ReorgRange{{StartHandle: 1, EndHandle: 100}})
+
+	// The handles are split at the Regions that start with row keys.
+	splitStore := &splittableStore{Storage: store, splitKeys: []kv.Key{
+		t.RecordKey(21),
+		t.RecordKey(41),
+		t.RecordKey(61)[:len(t.RecordKey(61))-1],
+		t.RecordKey(81),
+	}}
+	ranges, err = splitTableHandlesByRegions(splitStore, t, 1, 4)
+	c.Assert(err, IsNil)
+	c.Assert(ranges, DeepEquals, []*model.ReorgRange{
+		{StartHandle: 1, EndHandle: 20},
+		{StartHandle: 21, EndHandle: 40},
+		{StartHandle: 41, EndHandle: 80},
+		{StartHandle: 81, EndHandle: math.MaxInt64},
+	})
+	ranges, err = splitTableHandlesByRegions(splitStore, t, 1, 2)
+	c.Assert(err, IsNil)
+	c.Assert(ranges, DeepEquals, []*model.ReorgRange{
+		{StartHandle: 1, EndHandle: 40},
+		{StartHandle: 41, EndHandle: math.MaxInt64},
+	})
+	ranges, err = splitTableHandlesByRegions(splitStore, t, 50, 4)
+	c.Assert(err, IsNil)
+	c.Assert(ranges, DeepEquals, []*model.ReorgRange{
+		{StartHandle: 50, EndHandle: 80},
+		{StartHandle: 81, EndHandle: math.MaxInt64},
+	})
+
+	handles, err := d.getSnapshotRowsInRange(t, ver.Ver, 26, 50, 10)
+	c.Assert(err, IsNil)
+	c.Assert(handles, HasLen, 10)
+	c.Assert(handles[0], Equals, int64(26))
+	handles, err = d.getSnapshotRowsInRange(t, ver.Ver, 46, 50, 10)
+	c.Assert(err, IsNil)
+	c.Assert(handles, DeepEquals, []int64{46, 47, 48, 49, 50})
+}
+
+// splittableStore splits the keys at the splitKeys like the Regions of TiKV.
+type splittableStore struct {
+	kv.Storage
+	splitKeys []kv.Key
+}
+
+func (s *splittableStore) GetSplitKeys(startKey, endKey kv.Key) ([]kv.Key, error) {
+	var keys []kv.Key
+	for _, key := range s.splitKeys {
+		if key.Cmp(startKey) > 0 && key.Cmp(endKey) < 0 {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
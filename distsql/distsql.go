@@ -16,6 +16,7 @@ package distsql
 import (
 	"io"
 	"io/ioutil"
+	"sync/atomic"
 	"time"
 
 	"github.com/juju/errors"
@@ -53,6 +54,16 @@ type SelectResult interface {
 	// IgnoreData sets ignore data attr to true.
 	// For index double scan, we do not need row data when scanning index.
 	IgnoreData()
+	// CopStats returns the runtime statistics of the coprocessor tasks.
+	CopStats() CopStats
+}
+
+// CopStats is the runtime statistics of the coprocessor tasks of a select request.
+type CopStats struct {
+	// Tasks is the count of the coprocessor tasks that have responded.
+	Tasks int64
+	// WaitTime is the total time waiting for the responses of the tasks.
+	WaitTime time.Duration
 }
 
 // PartialResult is the result from a single region server.
@@ -76,6 +87,13 @@ type selectResult struct {
 	done    chan error
 
 	closed chan struct{}
+
+	// deadline is the time that the request must be done before, zero means no limit.
+	deadline time.Time
+
+	// copTasks and copWaitTime are accessed atomically.
+	copTasks    int64
+	copWaitTime int64
 }
 
 func (r *selectResult) Fetch() {
@@ -85,6 +103,7 @@ func (r *selectResult) Fetch() {
 func (r *selectResult) fetch() {
 	defer close(r.results)
 	for {
+		startTs := time.Now()
 		reader, err := r.resp.Next()
 		if err != nil {
 			r.done <- errors.Trace(err)
@@ -93,6 +112,8 @@ func (r *selectResult) fetch() {
 		if reader == nil {
 			return
 		}
+		atomic.AddInt64(&r.copTasks, 1)
+		atomic.AddInt64(&r.copWaitTime, int64(time.Since(startTs)))
 		pr := &partialResult{
 			index:      r.index,
 			fields:     r.fields,
@@ -114,10 +135,24 @@ func (r *selectResult) fetch() {
 
 // Next returns the next row.
 func (r *selectResult) Next() (pr PartialResult, err error) {
-	var ok bool
+	var (
+		ok      bool
+		timeout <-chan time.Time
+	)
+	if !r.deadline.IsZero() {
+		d := r.deadline.Sub(time.Now())
+		if d <= 0 {
+			return nil, errors.Trace(kv.ErrQueryTimeout)
+		}
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
 	select {
 	case pr, ok = <-r.results:
 	case err = <-r.done:
+	case <-timeout:
+		err = errors.Trace(kv.ErrQueryTimeout)
 	}
 	if err != nil {
 		return nil, err
@@ -137,6 +172,14 @@ func (r *selectResult) IgnoreData() {
 	r.ignoreData = true
 }
 
+// CopStats implements SelectResult CopStats interface.
+func (r *selectResult) CopStats() CopStats {
+	return CopStats{
+		Tasks:    atomic.LoadInt64(&r.copTasks),
+		WaitTime: time.Duration(atomic.LoadInt64(&r.copWaitTime)),
+	}
+}
+
 // Close closes SelectResult.
 func (r *selectResult) Close() error {
 	// close this channel tell fetch goroutine to exit
@@ -274,7 +317,9 @@ func (pr *partialResult) Close() error {
 // conncurrency: The max concurrency for underlying coprocessor request.
 // keepOrder: If the result should returned in key order. For example if we need keep data in order by
 //            scan index, we should set keepOrder to true.
-func Select(client kv.Client, req *tipb.SelectRequest, keyRanges []kv.KeyRange, concurrency int, keepOrder bool) (SelectResult, error) {
+// deadline: If it is not zero, the request fails with kv.ErrQueryTimeout when the results are not fetched before it.
+func Select(client kv.Client, req *tipb.SelectRequest, keyRanges []kv.KeyRange, concurrency int, keepOrder bool,
+	deadline time.Time) (SelectResult, error) {
 	var err error
 	startTs := time.Now()
 	defer func() {
@@ -293,6 +338,7 @@ func Select(client kv.Client, req *tipb.SelectRequest, keyRanges []kv.KeyRange,
 		err = errors.Trace(err1)
 		return nil, err
 	}
+	kvReq.Deadline = deadline
 
 	resp := client.Send(kvReq)
 	if resp == nil {
@@ -300,10 +346,11 @@ func Select(client kv.Client, req *tipb.SelectRequest, keyRanges []kv.KeyRange,
 		return nil, err
 	}
 	result := &selectResult{
-		resp:    resp,
-		results: make(chan PartialResult, 5),
-		done:    make(chan error, 1),
-		closed:  make(chan struct{}),
+		resp:     resp,
+		results:  make(chan PartialResult, 5),
+		done:     make(chan error, 1),
+		closed:   make(chan struct{}),
+		deadline: deadline,
 	}
 	// If Aggregates is not nil, we should set result fields latter.
 	if len(req.Aggregates) == 0 && len(req.GroupBy) == 0 {
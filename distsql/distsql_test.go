@@ -23,8 +23,10 @@ import (
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
@@ -88,6 +90,41 @@ func (s *testTableCodecSuite) TestGoroutineLeak(c *C) {
 	c.Error("distsql goroutine leak!")
 }
 
+func (s *testTableCodecSuite) TestDeadline(c *C) {
+	defer testleak.AfterTest(c)()
+	resp := &blockedResponse{closed: make(chan struct{})}
+	sr := &selectResult{
+		resp:     resp,
+		results:  make(chan PartialResult, 5),
+		done:     make(chan error, 1),
+		closed:   make(chan struct{}),
+		deadline: time.Now().Add(50 * time.Millisecond),
+	}
+	go sr.Fetch()
+	// The response never returns, Next fails when the deadline is exceeded.
+	_, err := sr.Next()
+	c.Assert(terror.ErrorEqual(err, kv.ErrQueryTimeout), IsTrue)
+	// Next fails at once after the deadline.
+	_, err = sr.Next()
+	c.Assert(terror.ErrorEqual(err, kv.ErrQueryTimeout), IsTrue)
+	c.Assert(sr.Close(), IsNil)
+}
+
+// blockedResponse is a response that blocks Next until it is closed.
+type blockedResponse struct {
+	closed chan struct{}
+}
+
+func (resp *blockedResponse) Next() (io.ReadCloser, error) {
+	<-resp.closed
+	return nil, nil
+}
+
+func (resp *blockedResponse) Close() error {
+	close(resp.closed)
+	return nil
+}
+
 type mockResponse struct {
 	count int
 }
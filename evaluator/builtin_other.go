@@ -349,14 +349,14 @@ func bitOpFactory(op opcode.Op) BuiltinFunc {
 }
 
 func arithmeticFuncFactory(op opcode.Op) BuiltinFunc {
-	return func(args []types.Datum, _ context.Context) (d types.Datum, err error) {
+	return func(args []types.Datum, ctx context.Context) (d types.Datum, err error) {
 		a, err := types.CoerceArithmetic(args[0])
-		if err != nil {
+		if err = handleTruncateError(ctx, err); err != nil {
 			return d, errors.Trace(err)
 		}
 
 		b, err := types.CoerceArithmetic(args[1])
-		if err != nil {
+		if err = handleTruncateError(ctx, err); err != nil {
 			return d, errors.Trace(err)
 		}
 		a, b, err = types.CoerceDatum(a, b)
@@ -375,14 +375,18 @@ func arithmeticFuncFactory(op opcode.Op) BuiltinFunc {
 		case opcode.Mul:
 			return types.ComputeMul(a, b)
 		case opcode.Div:
-			return types.ComputeDiv(a, b)
+			d, err = types.ComputeDiv(a, b)
 		case opcode.Mod:
-			return types.ComputeMod(a, b)
+			d, err = types.ComputeMod(a, b)
 		case opcode.IntDiv:
-			return types.ComputeIntDiv(a, b)
+			d, err = types.ComputeIntDiv(a, b)
 		default:
 			return d, ErrInvalidOperation.Gen("invalid op %v in arithmetic operation", op)
 		}
+		if err == nil && d.IsNull() {
+			err = handleDivisionByZero(ctx)
+		}
+		return d, errors.Trace(err)
 	}
 }
 
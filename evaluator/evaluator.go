@@ -29,13 +29,61 @@ import (
 // Error instances.
 var (
 	ErrInvalidOperation = terror.ClassEvaluator.New(CodeInvalidOperation, "invalid operation")
+	ErrDivisionByZero   = terror.ClassEvaluator.New(CodeDivisionByZero, "Division by 0")
 )
 
 // Error codes.
 const (
 	CodeInvalidOperation terror.ErrCode = 1
+	CodeDivisionByZero   terror.ErrCode = 2
 )
 
+func init() {
+	evaluatorMySQLErrCodes := map[terror.ErrCode]uint16{
+		CodeDivisionByZero: mysql.ErrDivisionByZero,
+	}
+	terror.ErrClassToMySQLCodes[terror.ClassEvaluator] = evaluatorMySQLErrCodes
+}
+
+// handleDivisionByZero handles a division by zero according to the ERROR_FOR_DIVISION_BY_ZERO sql mode,
+// the result is NULL, and it's an error when writing data in strict mode, otherwise a warning.
+func handleDivisionByZero(ctx context.Context) error {
+	if ctx == nil {
+		return nil
+	}
+	sessVars := variable.GetSessionVars(ctx)
+	if sessVars == nil || !sessVars.SQLMode.HasErrorForDivisionByZeroMode() {
+		return nil
+	}
+	return warnOrError(sessVars, ErrDivisionByZero.Gen("Division by 0"))
+}
+
+// handleTruncateError handles a truncated conversion, like '1a' to DOUBLE 1, the truncated value is used,
+// and it's an error when writing data in strict mode, otherwise a warning.
+// The constant folding has no context, the truncation is ignored there.
+func handleTruncateError(ctx context.Context, err error) error {
+	if err == nil || !terror.ErrorEqual(err, types.ErrTruncatedWrongVal) {
+		return errors.Trace(err)
+	}
+	if ctx == nil {
+		return nil
+	}
+	sessVars := variable.GetSessionVars(ctx)
+	if sessVars == nil {
+		return nil
+	}
+	return warnOrError(sessVars, err)
+}
+
+// warnOrError returns err when writing data in strict mode, otherwise appends it as a warning.
+func warnOrError(sessVars *variable.SessionVars, err error) error {
+	if sessVars.StrictSQLMode && (sessVars.InInsertStmt || sessVars.InUpdateStmt) {
+		return errors.Trace(err)
+	}
+	sessVars.AppendWarning(err)
+	return nil
+}
+
 // Eval evaluates an expression to a datum.
 func Eval(ctx context.Context, expr ast.ExprNode) (d types.Datum, err error) {
 	if ast.IsEvaluated(expr) {
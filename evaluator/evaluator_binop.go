@@ -255,12 +255,12 @@ func (e *Evaluator) handleBitOp(o *ast.BinaryOperationExpr) bool {
 
 func (e *Evaluator) handleArithmeticOp(o *ast.BinaryOperationExpr) bool {
 	a, err := types.CoerceArithmetic(*o.L.GetDatum())
-	if err != nil {
+	if err = handleTruncateError(e.ctx, err); err != nil {
 		e.err = errors.Trace(err)
 		return false
 	}
 	b, err := types.CoerceArithmetic(*o.R.GetDatum())
-	if err != nil {
+	if err = handleTruncateError(e.ctx, err); err != nil {
 		e.err = errors.Trace(err)
 		return false
 	}
@@ -293,6 +293,9 @@ func (e *Evaluator) handleArithmeticOp(o *ast.BinaryOperationExpr) bool {
 		e.err = ErrInvalidOperation.Gen("invalid op %v in arithmetic operation", o.Op)
 		return false
 	}
+	if e.err == nil && result.IsNull() {
+		e.err = handleDivisionByZero(e.ctx)
+	}
 	o.SetDatum(result)
 	return e.err == nil
 }
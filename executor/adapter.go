@@ -14,21 +14,32 @@
 package executor
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/memory"
 )
 
 // recordSet wraps an executor, implements ast.RecordSet interface
 type recordSet struct {
-	fields   []*ast.ResultField
-	executor Executor
-	schema   expression.Schema
+	ctx        context.Context
+	fields     []*ast.ResultField
+	executor   Executor
+	schema     expression.Schema
+	memTracker *memory.Tracker
+	// deadline is the deadline of the statement, it is got when the statement starts, so the later
+	// statements of the session don't change it.
+	deadline time.Time
 }
 
 func (a *recordSet) Fields() ([]*ast.ResultField, error) {
@@ -50,6 +61,9 @@ func (a *recordSet) Fields() ([]*ast.ResultField, error) {
 }
 
 func (a *recordSet) Next() (*ast.Row, error) {
+	if err := checkDeadline(a.deadline); err != nil {
+		return nil, errors.Trace(err)
+	}
 	row, err := a.executor.Next()
 	if err != nil || row == nil {
 		return nil, errors.Trace(err)
@@ -58,9 +72,73 @@ func (a *recordSet) Next() (*ast.Row, error) {
 }
 
 func (a *recordSet) Close() error {
+	a.memTracker.Detach()
 	return a.executor.Close()
 }
 
+// cancelOnExceed cancels the statement with an error when its memory usage exceeds the quota.
+type cancelOnExceed struct{}
+
+// Action implements the memory.ActionOnExceed interface.
+func (a *cancelOnExceed) Action(t *memory.Tracker) error {
+	return ErrMemExceedQuota.Gen("%s consumes %s, exceeds the quota %s", t.Label(),
+		memory.FormatBytes(t.BytesConsumed()), memory.FormatBytes(t.BytesLimit()))
+}
+
+// newStmtMemTracker creates the memory tracker of a statement and attaches it to the session tracker.
+func newStmtMemTracker(ctx context.Context) (*memory.Tracker, error) {
+	sessVars := variable.GetSessionVars(ctx)
+	if sessVars.InRestrictedSQL {
+		// Reading the quota may execute restricted SQL, so restricted SQL is not limited.
+		t := memory.NewTracker("statement", -1)
+		t.AttachTo(sessVars.MemTracker)
+		return t, nil
+	}
+	quota, err := sessVars.GetTiDBSystemVar(ctx, variable.TiDBMemQuotaQuery)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	bytesLimit, err := strconv.ParseInt(quota, 10, 64)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	action, err := sessVars.GetTiDBSystemVar(ctx, variable.TiDBMemOOMAction)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	t := memory.NewTracker("statement", bytesLimit)
+	switch strings.ToLower(action) {
+	case variable.OOMActionLog:
+		t.SetActionOnExceed(&memory.LogOnExceed{})
+	case variable.OOMActionCancel:
+		t.SetActionOnExceed(&cancelOnExceed{})
+	default:
+		return nil, errors.Errorf("unknown %s %s", variable.TiDBMemOOMAction, action)
+	}
+	t.AttachTo(sessVars.MemTracker)
+	return t, nil
+}
+
+// stmtDeadline returns the deadline of the current statement, zero means no limit.
+// The restricted SQL has no deadline. It is got once when the statement starts, the session sets it
+// for the next statement before the record set of the current one is read.
+func stmtDeadline(ctx context.Context) time.Time {
+	sessVars := variable.GetSessionVars(ctx)
+	if sessVars.InRestrictedSQL {
+		return time.Time{}
+	}
+	return sessVars.StmtDeadline
+}
+
+// checkDeadline returns kv.ErrQueryTimeout if the deadline of the statement is exceeded.
+// The executors get the deadline from the executorBuilder and check it in their loops.
+func checkDeadline(deadline time.Time) error {
+	if !deadline.IsZero() && time.Now().After(deadline) {
+		return errors.Trace(kv.ErrQueryTimeout)
+	}
+	return nil
+}
+
 type statement struct {
 	is    infoschema.InfoSchema
 	plan  plan.Plan
@@ -82,22 +160,36 @@ func (a *statement) IsDDL() bool {
 }
 
 func (a *statement) Exec(ctx context.Context) (ast.RecordSet, error) {
+	memTracker, err := newStmtMemTracker(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	b := newExecutorBuilder(ctx, a.is)
+	b.memTracker = memTracker
+	b.deadline = stmtDeadline(ctx)
 	e := b.build(a.plan)
 	if b.err != nil {
+		memTracker.Detach()
 		return nil, errors.Trace(b.err)
 	}
 
 	if executorExec, ok := e.(*ExecuteExec); ok {
-		err := executorExec.Build()
+		err = executorExec.Build()
 		if err != nil {
+			memTracker.Detach()
 			return nil, errors.Trace(err)
 		}
 		e = executorExec.StmtExec
 	}
 
 	if len(e.Fields()) == 0 && len(e.Schema()) == 0 {
+		defer memTracker.Detach()
 		// Write statements do not have record set, check if snapshot ts is set.
+		var (
+			txn        kv.Transaction
+			txnTracker *memory.Tracker
+			txnSize    int
+		)
 		switch e.(type) {
 		case *DeleteExec, *InsertExec, *UpdateExec, *ReplaceExec, *LoadData, *DDLExec:
 			snapshotTS := variable.GetSnapshotTS(ctx)
@@ -105,6 +197,31 @@ func (a *statement) Exec(ctx context.Context) (ast.RecordSet, error) {
 				return nil, errors.New("Can not execute write statement when 'tidb_snapshot' is set.")
 			}
 		}
+		// The flags decide whether some invalid values are errors or warnings in strict mode.
+		sessVars := variable.GetSessionVars(ctx)
+		switch e.(type) {
+		case *InsertExec, *ReplaceExec, *LoadData:
+			sessVars.InInsertStmt = true
+			defer func() {
+				sessVars.InInsertStmt = false
+			}()
+		case *UpdateExec:
+			sessVars.InUpdateStmt = true
+			defer func() {
+				sessVars.InUpdateStmt = false
+			}()
+		}
+		switch e.(type) {
+		case *DeleteExec, *InsertExec, *UpdateExec, *ReplaceExec:
+			// Track the growth of the transaction buffer caused by the statement.
+			txn, err = ctx.GetTxn(false)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			txnTracker = memory.NewTracker("txn buffer", -1)
+			txnTracker.AttachTo(memTracker)
+			txnSize = txn.Size()
+		}
 
 		// No result fields means no Recordset.
 		defer e.Close()
@@ -113,6 +230,11 @@ func (a *statement) Exec(ctx context.Context) (ast.RecordSet, error) {
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
+			if txnTracker != nil {
+				if err = txnTracker.ReplaceBytes(int64(txn.Size() - txnSize)); err != nil {
+					return nil, errors.Trace(err)
+				}
+			}
 			if row == nil {
 				return nil, nil
 			}
@@ -127,8 +249,11 @@ func (a *statement) Exec(ctx context.Context) (ast.RecordSet, error) {
 	}
 
 	return &recordSet{
-		executor: e,
-		fields:   fs,
-		schema:   e.Schema(),
+		ctx:        ctx,
+		executor:   e,
+		fields:     fs,
+		schema:     e.Schema(),
+		memTracker: memTracker,
+		deadline:   b.deadline,
 	}, nil
 }
@@ -15,6 +15,7 @@ package executor
 
 import (
 	"math"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
@@ -27,6 +28,7 @@ import (
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx/autocommit"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -36,6 +38,14 @@ type executorBuilder struct {
 	ctx context.Context
 	is  infoschema.InfoSchema
 	err error
+	// memTracker is the memory tracker of the statement, the trackers of executors are attached to it.
+	memTracker *memory.Tracker
+	// stats collects the runtime statistics of the executors for EXPLAIN ANALYZE, it is nil otherwise.
+	stats *runtimeStatsColl
+	// cteStorages are the materialized common table expressions shared by the scans on them.
+	cteStorages map[*plan.CTEDefinition]*cteStorage
+	// deadline is the deadline of the statement, it is got when the statement starts, zero means no limit.
+	deadline time.Time
 }
 
 func newExecutorBuilder(ctx context.Context, is infoschema.InfoSchema) *executorBuilder {
@@ -45,12 +55,38 @@ func newExecutorBuilder(ctx context.Context, is infoschema.InfoSchema) *executor
 	}
 }
 
+// newMemTracker creates a memory tracker for an executor and attaches it to the statement tracker.
+func (b *executorBuilder) newMemTracker(label string) *memory.Tracker {
+	t := memory.NewTracker(label, -1)
+	if b.memTracker != nil {
+		t.AttachTo(b.memTracker)
+	}
+	if b.stats != nil {
+		b.stats.memTrackers[label] = t
+	}
+	return t
+}
+
 func (b *executorBuilder) build(p plan.Plan) Executor {
+	e := b.buildExec(p)
+	if b.stats == nil || p == nil {
+		return e
+	}
+	return &analyzeExec{Executor: e, stats: b.stats.get(p.GetID())}
+}
+
+func (b *executorBuilder) buildExec(p plan.Plan) Executor {
 	switch v := p.(type) {
 	case nil:
 		return nil
 	case *plan.CheckTable:
 		return b.buildCheckTable(v)
+	case *plan.CheckIndex:
+		return b.buildCheckIndex(v)
+	case *plan.RecoverIndex:
+		return b.buildRecoverIndex(v)
+	case *plan.CleanupIndex:
+		return b.buildCleanupIndex(v)
 	case *plan.DDL:
 		return b.buildDDL(v)
 	case *plan.Deallocate:
@@ -70,6 +106,8 @@ func (b *executorBuilder) build(p plan.Plan) Executor {
 		return b.buildInsert(v)
 	case *plan.LoadData:
 		return b.buildLoadData(v)
+	case *plan.SelectInto:
+		return b.buildSelectInto(v)
 	case *plan.Limit:
 		return b.buildLimit(v)
 	case *plan.Prepare:
@@ -78,6 +116,10 @@ func (b *executorBuilder) build(p plan.Plan) Executor {
 		return b.buildSelectLock(v)
 	case *plan.ShowDDL:
 		return b.buildShowDDL(v)
+	case *plan.ShowDDLJobs:
+		return b.buildShowDDLJobs(v)
+	case *plan.CancelDDLJobs:
+		return b.buildCancelDDLJobs(v)
 	case *plan.Show:
 		return b.buildShow(v)
 	case *plan.Simple:
@@ -92,6 +134,10 @@ func (b *executorBuilder) build(p plan.Plan) Executor {
 		return b.buildUnionScanExec(v)
 	case *plan.PhysicalHashJoin:
 		return b.buildJoin(v)
+	case *plan.PhysicalMergeJoin:
+		return b.buildMergeJoin(v)
+	case *plan.PhysicalIndexJoin:
+		return b.buildIndexJoin(v)
 	case *plan.PhysicalHashSemiJoin:
 		return b.buildSemiJoin(v)
 	case *plan.Selection:
@@ -106,6 +152,8 @@ func (b *executorBuilder) build(p plan.Plan) Executor {
 		return b.buildIndexScan(v)
 	case *plan.TableDual:
 		return b.buildTableDual(v)
+	case *plan.CTEScan:
+		return b.buildCTEScan(v)
 	case *plan.PhysicalApply:
 		return b.buildApply(v)
 	case *plan.Exists:
@@ -140,6 +188,50 @@ func (b *executorBuilder) buildShowDDL(v *plan.ShowDDL) Executor {
 	}
 }
 
+func (b *executorBuilder) buildShowDDLJobs(v *plan.ShowDDLJobs) Executor {
+	return &ShowDDLJobsExec{
+		fields: v.Fields(),
+		ctx:    b.ctx,
+		is:     b.is,
+	}
+}
+
+func (b *executorBuilder) buildCancelDDLJobs(v *plan.CancelDDLJobs) Executor {
+	return &CancelDDLJobsExec{
+		fields: v.Fields(),
+		ctx:    b.ctx,
+		jobIDs: v.JobIDs,
+	}
+}
+
+func (b *executorBuilder) buildCheckIndex(v *plan.CheckIndex) Executor {
+	return &CheckIndexExec{
+		adminIndexResult: adminIndexResult{fields: v.Fields()},
+		ctx:              b.ctx,
+		table:            v.Table,
+		indexName:        v.IndexName,
+		handleRanges:     v.HandleRanges,
+	}
+}
+
+func (b *executorBuilder) buildRecoverIndex(v *plan.RecoverIndex) Executor {
+	return &RecoverIndexExec{
+		adminIndexResult: adminIndexResult{fields: v.Fields()},
+		ctx:              b.ctx,
+		table:            v.Table,
+		indexName:        v.IndexName,
+	}
+}
+
+func (b *executorBuilder) buildCleanupIndex(v *plan.CleanupIndex) Executor {
+	return &CleanupIndexExec{
+		adminIndexResult: adminIndexResult{fields: v.Fields()},
+		ctx:              b.ctx,
+		table:            v.Table,
+		indexName:        v.IndexName,
+	}
+}
+
 func (b *executorBuilder) buildCheckTable(v *plan.CheckTable) Executor {
 	return &CheckTableExec{
 		tables: v.Tables,
@@ -219,11 +311,13 @@ func (b *executorBuilder) buildPrepare(v *plan.Prepare) Executor {
 
 func (b *executorBuilder) buildExecute(v *plan.Execute) Executor {
 	return &ExecuteExec{
-		Ctx:       b.ctx,
-		IS:        b.is,
-		Name:      v.Name,
-		UsingVars: v.UsingVars,
-		ID:        v.ID,
+		Ctx:        b.ctx,
+		IS:         b.is,
+		Name:       v.Name,
+		UsingVars:  v.UsingVars,
+		ID:         v.ID,
+		memTracker: b.memTracker,
+		deadline:   b.deadline,
 	}
 }
 
@@ -304,19 +398,41 @@ func (b *executorBuilder) buildLoadData(v *plan.LoadData) Executor {
 		return nil
 	}
 
+	batchSize, err := getLoadDataBatchSize(b.ctx)
+	if err != nil {
+		b.err = errors.Trace(err)
+		return nil
+	}
 	return &LoadData{
 		IsLocal: v.IsLocal,
 		loadDataInfo: &LoadDataInfo{
-			row:        make([]types.Datum, len(tbl.Cols())),
-			insertVal:  &InsertValues{ctx: b.ctx, Table: tbl},
-			Path:       v.Path,
-			Table:      tbl,
-			FieldsInfo: v.FieldsInfo,
-			LinesInfo:  v.LinesInfo,
+			row:       make([]types.Datum, len(tbl.Cols())),
+			insertVal: &InsertValues{ctx: b.ctx, Table: tbl},
+			// The errors of LOAD DATA LOCAL are ignored by default, because the client can't stop sending the file.
+			// The duplicate rows of REPLACE are replaced, so its other errors are returned.
+			ignoreErr:   v.IsLocal || v.OnDuplicate == ast.OnDuplicateKeyHandlingIgnore,
+			Path:        v.Path,
+			OnDuplicate: v.OnDuplicate,
+			Table:       tbl,
+			FieldsInfo:  v.FieldsInfo,
+			LinesInfo:   v.LinesInfo,
+			BatchSize:   batchSize,
 		},
 	}
 }
 
+func (b *executorBuilder) buildSelectInto(v *plan.SelectInto) Executor {
+	src := b.build(v.TargetPlan)
+	if b.err != nil {
+		return nil
+	}
+	return &SelectIntoExec{
+		ctx:     b.ctx,
+		Src:     src,
+		IntoOpt: v.IntoOpt,
+	}
+}
+
 func (b *executorBuilder) buildReplace(vals *InsertValues) Executor {
 	return &ReplaceExec{
 		InsertValues: vals,
@@ -338,24 +454,38 @@ func (b *executorBuilder) buildDDL(v *plan.DDL) Executor {
 }
 
 func (b *executorBuilder) buildExplain(v *plan.Explain) Executor {
-	return &ExplainExec{
+	e := &ExplainExec{
 		StmtPlan: v.StmtPlan,
 		schema:   v.GetSchema(),
+		format:   v.Format,
+	}
+	if v.Analyze {
+		sb := newExecutorBuilder(b.ctx, b.is)
+		sb.memTracker, sb.deadline = b.memTracker, b.deadline
+		sb.stats = newRuntimeStatsColl()
+		e.stmtExec = sb.build(v.StmtPlan)
+		if sb.err != nil {
+			b.err = errors.Trace(sb.err)
+			return nil
+		}
+		e.stats = sb.stats
 	}
+	return e
 }
 
 func (b *executorBuilder) buildUnionScanExec(v *plan.PhysicalUnionScan) *UnionScanExec {
-	src := b.build(v.GetChildByIndex(0))
+	// The concrete type of the source is needed, it is wrapped for EXPLAIN ANALYZE at the end.
+	src := b.buildExec(v.GetChildByIndex(0))
 	if b.err != nil {
 		return nil
 	}
-	us := &UnionScanExec{ctx: b.ctx, Src: src}
+	us := &UnionScanExec{ctx: b.ctx, Src: src, memTracker: b.newMemTracker(v.GetID())}
 	switch x := src.(type) {
 	case *XSelectTableExec:
 		us.desc = x.desc
 		us.dirty = getDirtyDB(b.ctx).getDirtyTable(x.table.Meta().ID)
 		us.condition = v.Condition
-		us.buildAndSortAddedRows(x.table, x.asName)
+		b.err = us.buildAndSortAddedRows(x.table, x.asName)
 	case *XSelectIndexExec:
 		us.desc = x.indexPlan.Desc
 		for _, ic := range x.indexPlan.Index.Columns {
@@ -368,10 +498,13 @@ func (b *executorBuilder) buildUnionScanExec(v *plan.PhysicalUnionScan) *UnionSc
 		}
 		us.dirty = getDirtyDB(b.ctx).getDirtyTable(x.table.Meta().ID)
 		us.condition = v.Condition
-		us.buildAndSortAddedRows(x.table, x.asName)
+		b.err = us.buildAndSortAddedRows(x.table, x.asName)
 	default:
 		b.err = ErrUnknownPlan.Gen("Unknown Plan %T", src)
 	}
+	if b.stats != nil {
+		us.Src = &analyzeExec{Executor: src, stats: b.stats.get(v.GetChildByIndex(0).GetID())}
+	}
 	return us
 }
 
@@ -392,6 +525,8 @@ func (b *executorBuilder) buildJoin(v *plan.PhysicalHashJoin) Executor {
 		ctx:         b.ctx,
 		targetTypes: targetTypes,
 		concurrency: v.Concurrency,
+		memTracker:  b.newMemTracker(v.GetID()),
+		deadline:    b.deadline,
 	}
 	if v.SmallTable == 1 {
 		e.smallFilter = expression.ComposeCNFCondition(v.RightConditions)
@@ -431,6 +566,67 @@ func (b *executorBuilder) buildJoin(v *plan.PhysicalHashJoin) Executor {
 	return e
 }
 
+func (b *executorBuilder) buildMergeJoin(v *plan.PhysicalMergeJoin) Executor {
+	e := &MergeJoinExec{
+		ctx:         b.ctx,
+		schema:      v.GetSchema(),
+		otherFilter: expression.ComposeCNFCondition(v.OtherConditions),
+		outer:       v.JoinType == plan.LeftOuterJoin || v.JoinType == plan.RightOuterJoin,
+		outerIsLeft: v.JoinType != plan.RightOuterJoin,
+	}
+	leftExec, rightExec := b.build(v.GetChildByIndex(0)), b.build(v.GetChildByIndex(1))
+	leftFilter, rightFilter := expression.ComposeCNFCondition(v.LeftConditions), expression.ComposeCNFCondition(v.RightConditions)
+	if e.outerIsLeft {
+		e.outerExec, e.innerExec = leftExec, rightExec
+		e.outerKeys, e.innerKeys = v.LeftKeys, v.RightKeys
+		e.outerFilter, e.innerFilter = leftFilter, rightFilter
+	} else {
+		e.outerExec, e.innerExec = rightExec, leftExec
+		e.outerKeys, e.innerKeys = v.RightKeys, v.LeftKeys
+		e.outerFilter, e.innerFilter = rightFilter, leftFilter
+	}
+	return e
+}
+
+func (b *executorBuilder) buildIndexJoin(v *plan.PhysicalIndexJoin) Executor {
+	var targetTypes []*types.FieldType
+	for i, outerKey := range v.OuterJoinKeys {
+		targetTypes = append(targetTypes, types.NewFieldType(types.MergeFieldType(outerKey.GetType().Tp, v.InnerJoinKeys[i].GetType().Tp)))
+	}
+	innerPlan := v.GetChildByIndex(1 - v.OuterIndex)
+	e := &IndexLookUpJoin{
+		ctx:         b.ctx,
+		schema:      v.GetSchema(),
+		outerExec:   b.build(v.GetChildByIndex(v.OuterIndex)),
+		builder:     b,
+		outerKeys:   v.OuterJoinKeys,
+		innerKeys:   v.InnerJoinKeys,
+		keyOffset:   v.KeyOffset,
+		targetTypes: targetTypes,
+		otherFilter: expression.ComposeCNFCondition(v.OtherConditions),
+		outer:       v.JoinType == plan.LeftOuterJoin || v.JoinType == plan.RightOuterJoin,
+		outerIsLeft: v.OuterIndex == 0,
+		innerLen:    len(innerPlan.GetSchema()),
+		batchSize:   indexJoinBatchSize,
+		memTracker:  b.newMemTracker(v.GetID()),
+	}
+	innerConds := append([]expression.Expression{}, v.InnerConditions...)
+	if e.outerIsLeft {
+		e.outerFilter = expression.ComposeCNFCondition(v.LeftConditions)
+		innerConds = append(innerConds, v.RightConditions...)
+	} else {
+		e.outerFilter = expression.ComposeCNFCondition(v.RightConditions)
+		innerConds = append(innerConds, v.LeftConditions...)
+	}
+	e.innerFilter = expression.ComposeCNFCondition(innerConds)
+	if sel, ok := innerPlan.(*plan.Selection); ok {
+		e.innerSel = sel
+		innerPlan = sel.GetChildByIndex(0)
+	}
+	e.innerScan = innerPlan.(plan.PhysicalPlan)
+	return e
+}
+
 func (b *executorBuilder) buildSemiJoin(v *plan.PhysicalHashSemiJoin) Executor {
 	var leftHashKey, rightHashKey []*expression.Column
 	var targetTypes []*types.FieldType
@@ -478,6 +674,7 @@ func (b *executorBuilder) buildAggregation(v *plan.PhysicalAggregation) Executor
 		GroupByItems: v.GroupByItems,
 		aggType:      v.AggType,
 		hasGby:       v.HasGby,
+		memTracker:   b.newMemTracker(v.GetID()),
 	}
 }
 
@@ -504,6 +701,32 @@ func (b *executorBuilder) buildTableDual(v *plan.TableDual) Executor {
 	return &TableDualExec{schema: v.GetSchema()}
 }
 
+func (b *executorBuilder) buildCTEScan(v *plan.CTEScan) Executor {
+	if b.cteStorages == nil {
+		b.cteStorages = make(map[*plan.CTEDefinition]*cteStorage)
+	}
+	storage, ok := b.cteStorages[v.CTE]
+	if !ok {
+		storage = &cteStorage{
+			ctx:        b.ctx,
+			def:        v.CTE,
+			b:          b,
+			memTracker: b.newMemTracker(v.GetID()),
+		}
+		// The storage is registered before building the seed, so the scans inside it can find the storage.
+		b.cteStorages[v.CTE] = storage
+		storage.seed = b.build(v.CTE.Seed)
+		if b.err != nil {
+			return nil
+		}
+	}
+	return &CTEScanExec{
+		schema:  v.GetSchema(),
+		storage: storage,
+		working: v.Working,
+	}
+}
+
 func (b *executorBuilder) getStartTS() uint64 {
 	startTS := variable.GetSnapshotTS(b.ctx)
 	if startTS == 0 {
@@ -529,6 +752,12 @@ func (b *executorBuilder) buildTableScan(v *plan.PhysicalTableScan) Executor {
 	case "information_schema", "performance_schema":
 		memDB = true
 	}
+	if memDB && infoschema.IsSlowQueryTable(v.DBName.L, v.Table.Name.L) {
+		table, b.err = infoschema.SlowQueryTable(b.ctx, v.Table)
+		if b.err != nil {
+			return nil
+		}
+	}
 	supportDesc := client.SupportRequestType(kv.ReqTypeSelect, kv.ReqSubTypeDesc)
 	if !memDB && client.SupportRequestType(kv.ReqTypeSelect, 0) {
 		st := &XSelectTableExec{
@@ -550,6 +779,8 @@ func (b *executorBuilder) buildTableScan(v *plan.PhysicalTableScan) Executor {
 			aggFields:   v.AggFields,
 			byItems:     v.GbyItems,
 			orderByList: v.SortItems,
+			stats:       b.stats.get(v.GetID()),
+			deadline:    b.deadline,
 		}
 		return st
 	}
@@ -596,6 +827,8 @@ func (b *executorBuilder) buildIndexScan(v *plan.PhysicalIndexScan) Executor {
 			aggFuncs:    v.AggFuncs,
 			aggFields:   v.AggFields,
 			byItems:     v.GbyItems,
+			stats:       b.stats.get(v.GetID()),
+			deadline:    b.deadline,
 		}
 		return st
 	}
@@ -608,18 +841,22 @@ func (b *executorBuilder) buildSort(v *plan.Sort) Executor {
 	if v.ExecLimit != nil {
 		return &TopnExec{
 			SortExec: SortExec{
-				Src:     src,
-				ByItems: v.ByItems,
-				ctx:     b.ctx,
-				schema:  v.GetSchema()},
+				Src:        src,
+				ByItems:    v.ByItems,
+				ctx:        b.ctx,
+				schema:     v.GetSchema(),
+				memTracker: b.newMemTracker(v.GetID()),
+				deadline:   b.deadline},
 			limit: v.ExecLimit,
 		}
 	}
 	return &SortExec{
-		Src:     src,
-		ByItems: v.ByItems,
-		ctx:     b.ctx,
-		schema:  v.GetSchema(),
+		Src:        src,
+		ByItems:    v.ByItems,
+		ctx:        b.ctx,
+		schema:     v.GetSchema(),
+		memTracker: b.newMemTracker(v.GetID()),
+		deadline:   b.deadline,
 	}
 }
 
@@ -0,0 +1,204 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/distinct"
+	"github.com/pingcap/tidb/util/memory"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// cteStorage materializes the rows of a common table expression, it is shared by all the scans on it.
+// A recursive common table expression is evaluated iteratively: the seed produces the first rows,
+// then the recursive part runs on the rows produced by the last iteration until it produces nothing.
+type cteStorage struct {
+	ctx context.Context
+	def *plan.CTEDefinition
+	// b builds the executor of the recursive part for every iteration.
+	b    *executorBuilder
+	seed Executor
+
+	// mu protects the materialization, the scans on the storage may run concurrently, like the two sides of a hash join.
+	mu   sync.Mutex
+	done bool
+	// err is the error of the materialization, it is returned to all the scans.
+	err  error
+	rows []*Row
+	// working are the rows produced by the last iteration, they are read by the working scan.
+	working []*Row
+	checker *distinct.Checker
+
+	memTracker *memory.Tracker
+	// memQuota is the memory quota of the session, zero means no limit.
+	memQuota int64
+}
+
+// materialize evaluates the common table expression if it has not been evaluated.
+func (s *cteStorage) materialize() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return errors.Trace(s.err)
+	}
+	s.done = true
+	s.err = s.evaluate()
+	return errors.Trace(s.err)
+}
+
+func (s *cteStorage) evaluate() error {
+	var err error
+	s.memQuota, err = getMemQuota(s.ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if s.def.Distinct {
+		s.checker = distinct.CreateDistinctChecker()
+	}
+	rows, err := s.drain(s.seed)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if s.def.Recursive == nil {
+		return nil
+	}
+	maxDepth, err := getCTEMaxRecursionDepth(s.ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for iteration := uint64(1); len(rows) > 0; iteration++ {
+		if iteration > maxDepth {
+			return ErrCTEMaxRecursionDepth.Gen("Recursive query aborted after %d iterations. Try increasing @@cte_max_recursion_depth to a larger value.", iteration)
+		}
+		s.working = rows
+		exec := s.b.build(s.def.Recursive)
+		if s.b.err != nil {
+			return errors.Trace(s.b.err)
+		}
+		rows, err = s.drain(exec)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	s.working = nil
+	return nil
+}
+
+// drain reads all the rows of e and appends the new ones to the storage, the new rows are returned.
+func (s *cteStorage) drain(e Executor) ([]*Row, error) {
+	var rows []*Row
+	for {
+		row, err := e.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		if s.checker != nil {
+			ok, err := s.checker.Check(types.DatumsToInterfaces(row.Data))
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		row = &Row{Data: row.Data}
+		// The rows can't be spilled, the evaluation fails once they exceed the memory quota.
+		if err = s.memTracker.Consume(rowMemUsage(row)); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if sessionMemExceeds(s.ctx, s.memQuota) {
+			return nil, ErrMemExceedQuota.Gen("session exceeds the memory quota %d bytes, common table expression uses %d bytes", s.memQuota, s.memTracker.BytesConsumed())
+		}
+		rows = append(rows, row)
+	}
+	s.rows = append(s.rows, rows...)
+	return rows, errors.Trace(e.Close())
+}
+
+// getCTEMaxRecursionDepth gets the maximum number of iterations of a recursive common table expression.
+func getCTEMaxRecursionDepth(ctx context.Context) (uint64, error) {
+	sessionVars := variable.GetSessionVars(ctx)
+	value := sessionVars.GetSystemVar(variable.CTEMaxRecursionDepth)
+	if value.IsNull() {
+		globalVal, err := variable.GetGlobalVarAccessor(ctx).GetGlobalSysVar(ctx, variable.CTEMaxRecursionDepth)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		value.SetString(globalVal)
+	}
+	str, err := value.ToString()
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	depth, err := strconv.ParseUint(str, 10, 64)
+	return depth, errors.Trace(err)
+}
+
+// CTEScanExec reads the rows of a materialized common table expression.
+type CTEScanExec struct {
+	schema  expression.Schema
+	storage *cteStorage
+	// working means it reads the rows produced by the last iteration of a recursive common table expression.
+	working bool
+	cursor  int
+}
+
+// Schema implements Executor Schema interface.
+func (e *CTEScanExec) Schema() expression.Schema {
+	return e.schema
+}
+
+// Fields implements Executor Fields interface.
+func (e *CTEScanExec) Fields() []*ast.ResultField {
+	return nil
+}
+
+// Next implements Executor Next interface.
+func (e *CTEScanExec) Next() (*Row, error) {
+	rows := e.storage.working
+	if !e.working {
+		if err := e.storage.materialize(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		rows = e.storage.rows
+	}
+	if e.cursor >= len(rows) {
+		return nil, nil
+	}
+	src := rows[e.cursor]
+	e.cursor++
+	// The stored rows are shared, so the columns are copied into a new row.
+	row := &Row{Data: make([]types.Datum, 0, len(e.schema))}
+	for _, col := range e.schema {
+		row.Data = append(row.Data, src.Data[col.Position])
+	}
+	return row, nil
+}
+
+// Close implements Executor Close interface.
+func (e *CTEScanExec) Close() error {
+	e.cursor = 0
+	return nil
+}
@@ -14,11 +14,16 @@
 package executor
 
 import (
+	"bufio"
 	"container/heap"
+	"fmt"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/juju/errors"
+	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/evaluator"
@@ -32,20 +37,29 @@ import (
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/db"
 	"github.com/pingcap/tidb/sessionctx/forupdate"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/distinct"
+	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/types"
 )
 
 var (
 	_ Executor = &CheckTableExec{}
+	_ Executor = &CheckIndexExec{}
+	_ Executor = &RecoverIndexExec{}
+	_ Executor = &CleanupIndexExec{}
 	_ Executor = &LimitExec{}
 	_ Executor = &SelectLockExec{}
 	_ Executor = &ShowDDLExec{}
+	_ Executor = &ShowDDLJobsExec{}
+	_ Executor = &CancelDDLJobsExec{}
 	_ Executor = &TableDualExec{}
+	_ Executor = &SelectIntoExec{}
+	_ Executor = &CTEScanExec{}
 )
 
 // Error instances.
@@ -57,6 +71,16 @@ var (
 	ErrWrongParamCount = terror.ClassExecutor.New(CodeWrongParamCount, "Wrong parameter count")
 	ErrRowKeyCount     = terror.ClassExecutor.New(CodeRowKeyCount, "Wrong row key entry count")
 	ErrPrepareDDL      = terror.ClassExecutor.New(CodePrepareDDL, "Can not prepare DDL statements")
+	ErrMemExceedQuota  = terror.ClassExecutor.New(CodeMemExceedQuota, "Memory usage exceeds the quota")
+
+	ErrSpecificAccessDenied    = terror.ClassExecutor.New(CodeSpecificAccessDenied, "Access denied")
+	ErrFileExists              = terror.ClassExecutor.New(CodeFileExists, "File already exists")
+	ErrOptionPreventsStatement = terror.ClassExecutor.New(CodeOptionPreventsStatement, "Option prevents statement")
+	ErrTooManyRows             = terror.ClassExecutor.New(CodeTooManyRows, "Result consisted of more than one row")
+	ErrCTEMaxRecursionDepth    = terror.ClassExecutor.New(CodeCTEMaxRecursionDepth, "Recursive query aborted")
+	ErrPluginIsNotLoaded       = terror.ClassExecutor.New(CodePluginIsNotLoaded, "Plugin is not loaded")
+	ErrUnknownAuthID           = terror.ClassExecutor.New(CodeUnknownAuthID, "Unknown authorization ID")
+	ErrRoleNotGranted          = terror.ClassExecutor.New(CodeRoleNotGranted, "Role is not granted")
 )
 
 // Error codes.
@@ -68,8 +92,17 @@ const (
 	CodeWrongParamCount terror.ErrCode = 5
 	CodeRowKeyCount     terror.ErrCode = 6
 	CodePrepareDDL      terror.ErrCode = 7
+	CodeMemExceedQuota  terror.ErrCode = 8
 	// MySQL error code
-	CodeCannotUser terror.ErrCode = 1396
+	CodeCannotUser              terror.ErrCode = 1396
+	CodeSpecificAccessDenied    terror.ErrCode = 1227
+	CodeFileExists              terror.ErrCode = 1086
+	CodeOptionPreventsStatement terror.ErrCode = 1290
+	CodeTooManyRows             terror.ErrCode = 1172
+	CodeCTEMaxRecursionDepth    terror.ErrCode = 3636
+	CodePluginIsNotLoaded       terror.ErrCode = 1524
+	CodeUnknownAuthID           terror.ErrCode = 3523
+	CodeRoleNotGranted          terror.ErrCode = 3530
 )
 
 // Row represents a record row.
@@ -174,6 +207,148 @@ func (e *ShowDDLExec) Close() error {
 	return nil
 }
 
+// ShowDDLJobsExec represents a show DDL jobs executor, it shows the jobs in the queue and the recent history jobs.
+type ShowDDLJobsExec struct {
+	fields []*ast.ResultField
+	ctx    context.Context
+	is     infoschema.InfoSchema
+	rows   []*Row
+	cursor int
+	done   bool
+}
+
+// Schema implements Executor Schema interface.
+func (e *ShowDDLJobsExec) Schema() expression.Schema {
+	return nil
+}
+
+// Fields implements Executor Fields interface.
+func (e *ShowDDLJobsExec) Fields() []*ast.ResultField {
+	return e.fields
+}
+
+// Next implements Executor Next interface.
+func (e *ShowDDLJobsExec) Next() (*Row, error) {
+	if !e.done {
+		txn, err := e.ctx.GetTxn(false)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		jobs, err := inspectkv.GetDDLJobs(txn)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		historyJobs, err := inspectkv.GetHistoryDDLJobs(txn)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, job := range append(jobs, historyJobs...) {
+			e.rows = append(e.rows, e.jobToRow(job))
+		}
+		e.done = true
+	}
+	if e.cursor >= len(e.rows) {
+		return nil, nil
+	}
+	row := e.rows[e.cursor]
+	for i, f := range e.fields {
+		f.Expr.SetValue(row.Data[i].GetValue())
+	}
+	e.cursor++
+	return row, nil
+}
+
+func (e *ShowDDLJobsExec) jobToRow(job *model.Job) *Row {
+	var dbName, tableName string
+	if db, ok := e.is.SchemaByID(job.SchemaID); ok {
+		dbName = db.Name.O
+	}
+	if tbl, ok := e.is.TableByID(job.TableID); ok {
+		tableName = tbl.Meta().Name.O
+	}
+	row := &Row{Data: types.MakeDatums(job.ID, dbName, tableName, job.Type.String(), job.SchemaState.String(),
+		job.State.String(), job.GetRowCount())}
+	row.Data = append(row.Data, unixNanoToDatum(job.StartTS))
+	if job.IsFinished() {
+		row.Data = append(row.Data, unixNanoToDatum(job.LastUpdateTS))
+	} else {
+		row.Data = append(row.Data, types.Datum{})
+	}
+	return row
+}
+
+func unixNanoToDatum(ts int64) types.Datum {
+	if ts == 0 {
+		return types.Datum{}
+	}
+	t := mysql.Time{Time: time.Unix(0, ts), Type: mysql.TypeDatetime, Fsp: mysql.DefaultFsp}
+	return types.NewDatum(t)
+}
+
+// Close implements Executor Close interface.
+func (e *ShowDDLJobsExec) Close() error {
+	e.rows = nil
+	e.cursor = 0
+	e.done = false
+	return nil
+}
+
+// CancelDDLJobsExec represents a cancel DDL jobs executor.
+type CancelDDLJobsExec struct {
+	fields []*ast.ResultField
+	ctx    context.Context
+	jobIDs []int64
+	errs   []error
+	cursor int
+	done   bool
+}
+
+// Schema implements Executor Schema interface.
+func (e *CancelDDLJobsExec) Schema() expression.Schema {
+	return nil
+}
+
+// Fields implements Executor Fields interface.
+func (e *CancelDDLJobsExec) Fields() []*ast.ResultField {
+	return e.fields
+}
+
+// Next implements Executor Next interface.
+func (e *CancelDDLJobsExec) Next() (*Row, error) {
+	if !e.done {
+		txn, err := e.ctx.GetTxn(false)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		e.errs, err = inspectkv.CancelJobs(txn, e.jobIDs)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		e.done = true
+	}
+	if e.cursor >= len(e.jobIDs) {
+		return nil, nil
+	}
+	result := "successful"
+	if err := e.errs[e.cursor]; err != nil {
+		result = fmt.Sprintf("error: %v", err)
+	}
+	row := &Row{Data: types.MakeDatums(e.jobIDs[e.cursor], result)}
+	for i, f := range e.fields {
+		f.Expr.SetValue(row.Data[i].GetValue())
+	}
+	e.cursor++
+	return row, nil
+}
+
+// Close implements Executor Close interface.
+func (e *CancelDDLJobsExec) Close() error {
+	e.errs = nil
+	e.cursor = 0
+	e.done = false
+	return nil
+}
+
 // CheckTableExec represents a check table executor.
 type CheckTableExec struct {
 	tables []*ast.TableName
@@ -226,6 +401,218 @@ func (e *CheckTableExec) Close() error {
 	return nil
 }
 
+// getAdminIndex returns the table and the index that an admin index statement operates on.
+func getAdminIndex(ctx context.Context, tn *ast.TableName, idxName string) (table.Table, table.Index, error) {
+	dbName := tn.Schema
+	if dbName.L == "" {
+		dbName = model.NewCIStr(db.GetCurrentSchema(ctx))
+	}
+	is := sessionctx.GetDomain(ctx).InfoSchema()
+	tb, err := is.TableByName(dbName, tn.Name)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	for _, idx := range tb.Indices() {
+		if idx.Meta().Name.L == model.NewCIStr(idxName).L {
+			return tb, idx, nil
+		}
+	}
+	return nil, nil, plan.ErrKeyDoesNotExist.Gen("Key '%s' doesn't exist in table '%s'", idxName, tn.Name.O)
+}
+
+// adminIndexResult holds the result rows of an admin index executor.
+type adminIndexResult struct {
+	fields []*ast.ResultField
+	rows   []*Row
+	cursor int
+	done   bool
+}
+
+func (r *adminIndexResult) next() *Row {
+	if r.cursor >= len(r.rows) {
+		return nil
+	}
+	row := r.rows[r.cursor]
+	for i, f := range r.fields {
+		f.Expr.SetValue(row.Data[i].GetValue())
+	}
+	r.cursor++
+	return row
+}
+
+func (r *adminIndexResult) reset() {
+	r.rows = nil
+	r.cursor = 0
+	r.done = false
+}
+
+// CheckIndexExec represents a check index executor, it returns a row for every inconsistency
+// between the index entries and the rows in the handle ranges.
+type CheckIndexExec struct {
+	adminIndexResult
+	ctx          context.Context
+	table        *ast.TableName
+	indexName    string
+	handleRanges []ast.HandleRange
+}
+
+// Schema implements Executor Schema interface.
+func (e *CheckIndexExec) Schema() expression.Schema {
+	return nil
+}
+
+// Fields implements Executor Fields interface.
+func (e *CheckIndexExec) Fields() []*ast.ResultField {
+	return e.fields
+}
+
+// Next implements Executor Next interface.
+func (e *CheckIndexExec) Next() (*Row, error) {
+	if !e.done {
+		tb, idx, err := getAdminIndex(e.ctx, e.table, e.indexName)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if err = e.ctx.CommitTxn(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		ranges := inspectkv.FullHandleRanges
+		if len(e.handleRanges) > 0 {
+			ranges = make([]inspectkv.HandleRange, 0, len(e.handleRanges))
+			for _, r := range e.handleRanges {
+				ranges = append(ranges, inspectkv.HandleRange{Begin: r.Begin, End: r.End})
+			}
+		}
+		store := sessionctx.GetDomain(e.ctx).Store()
+		incs, err := inspectkv.CheckIndexRanges(store, tb, idx, ranges)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, inc := range incs {
+			row := &Row{}
+			row.Data = append(row.Data, recordDataToDatums(inc.Index)...)
+			row.Data = append(row.Data, recordDataToDatums(inc.Record)...)
+			e.rows = append(e.rows, row)
+		}
+		e.done = true
+	}
+	return e.next(), nil
+}
+
+// recordDataToDatums returns the handle and the formatted values of the record, or NULLs if it is nil.
+func recordDataToDatums(r *inspectkv.RecordData) []types.Datum {
+	if r == nil {
+		return []types.Datum{{}, {}}
+	}
+	vals := make([]string, 0, len(r.Values))
+	for _, v := range r.Values {
+		if v.IsNull() {
+			vals = append(vals, "NULL")
+			continue
+		}
+		str, err := v.ToString()
+		if err != nil {
+			str = fmt.Sprintf("%v", v.GetValue())
+		}
+		vals = append(vals, str)
+	}
+	return types.MakeDatums(r.Handle, "("+strings.Join(vals, ", ")+")")
+}
+
+// Close implements Executor Close interface.
+func (e *CheckIndexExec) Close() error {
+	e.reset()
+	return nil
+}
+
+// RecoverIndexExec represents a recover index executor, it adds the missing index entries of the rows.
+type RecoverIndexExec struct {
+	adminIndexResult
+	ctx       context.Context
+	table     *ast.TableName
+	indexName string
+}
+
+// Schema implements Executor Schema interface.
+func (e *RecoverIndexExec) Schema() expression.Schema {
+	return nil
+}
+
+// Fields implements Executor Fields interface.
+func (e *RecoverIndexExec) Fields() []*ast.ResultField {
+	return e.fields
+}
+
+// Next implements Executor Next interface.
+func (e *RecoverIndexExec) Next() (*Row, error) {
+	if !e.done {
+		tb, idx, err := getAdminIndex(e.ctx, e.table, e.indexName)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if err = e.ctx.CommitTxn(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		added, scanned, err := inspectkv.RecoverIndex(sessionctx.GetDomain(e.ctx).Store(), tb, idx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		e.rows = append(e.rows, &Row{Data: types.MakeDatums(added, scanned)})
+		e.done = true
+	}
+	return e.next(), nil
+}
+
+// Close implements Executor Close interface.
+func (e *RecoverIndexExec) Close() error {
+	e.reset()
+	return nil
+}
+
+// CleanupIndexExec represents a cleanup index executor, it deletes the index entries without matching rows.
+type CleanupIndexExec struct {
+	adminIndexResult
+	ctx       context.Context
+	table     *ast.TableName
+	indexName string
+}
+
+// Schema implements Executor Schema interface.
+func (e *CleanupIndexExec) Schema() expression.Schema {
+	return nil
+}
+
+// Fields implements Executor Fields interface.
+func (e *CleanupIndexExec) Fields() []*ast.ResultField {
+	return e.fields
+}
+
+// Next implements Executor Next interface.
+func (e *CleanupIndexExec) Next() (*Row, error) {
+	if !e.done {
+		tb, idx, err := getAdminIndex(e.ctx, e.table, e.indexName)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if err = e.ctx.CommitTxn(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		removed, err := inspectkv.CleanupIndex(sessionctx.GetDomain(e.ctx).Store(), tb, idx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		e.rows = append(e.rows, &Row{Data: types.MakeDatums(removed)})
+		e.done = true
+	}
+	return e.next(), nil
+}
+
+// Close implements Executor Close interface.
+func (e *CleanupIndexExec) Close() error {
+	e.reset()
+	return nil
+}
+
 // FilterExec represents a filter executor.
 type FilterExec struct {
 	Src       Executor
@@ -476,7 +863,7 @@ func (e *ReverseExec) Close() error {
 
 func init() {
 	plan.EvalSubquery = func(p plan.PhysicalPlan, is infoschema.InfoSchema, ctx context.Context) (d []types.Datum, err error) {
-		e := &executorBuilder{is: is, ctx: ctx}
+		e := &executorBuilder{is: is, ctx: ctx, deadline: stmtDeadline(ctx)}
 		exec := e.build(p)
 		row, err := exec.Next()
 		if err != nil {
@@ -488,7 +875,16 @@ func init() {
 		return row.Data, nil
 	}
 	tableMySQLErrCodes := map[terror.ErrCode]uint16{
-		CodeCannotUser: mysql.ErrCannotUser,
+		CodeCannotUser:              mysql.ErrCannotUser,
+		CodeMemExceedQuota:          mysql.ErrOutOfResources,
+		CodeSpecificAccessDenied:    mysql.ErrSpecificAccessDenied,
+		CodeFileExists:              mysql.ErrFileExists,
+		CodeOptionPreventsStatement: mysql.ErrOptionPreventsStatement,
+		CodeTooManyRows:             mysql.ErrTooManyRows,
+		CodeCTEMaxRecursionDepth:    mysql.ErrCTEMaxRecursionDepth,
+		CodePluginIsNotLoaded:       mysql.ErrPluginIsNotLoaded,
+		CodeUnknownAuthID:           mysql.ErrUnknownAuthID,
+		CodeRoleNotGranted:          mysql.ErrRoleNotGranted,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassExecutor] = tableMySQLErrCodes
 }
@@ -525,6 +921,12 @@ type HashJoinExec struct {
 	// Channels for output.
 	resultErr  chan error
 	resultRows chan *Row
+
+	// memTracker tracks the memory used by the hash table, the memory of the session can not exceed memQuota.
+	memTracker *memory.Tracker
+	memQuota   int64
+	// deadline is the deadline of the statement, the hash table building and the joined rows check it.
+	deadline time.Time
 }
 
 type hashJoinCtx struct {
@@ -539,6 +941,8 @@ type hashJoinCtx struct {
 func (e *HashJoinExec) Close() error {
 	e.prepared = false
 	e.cursor = 0
+	e.hashTable = nil
+	e.memTracker.Release(e.memTracker.BytesConsumed())
 	return e.smallExec.Close()
 }
 
@@ -646,7 +1050,17 @@ func (e *HashJoinExec) prepare() error {
 
 	e.hashTable = make(map[string][]*Row)
 	e.cursor = 0
+	e.memTracker.Release(e.memTracker.BytesConsumed())
+	var err error
+	e.memQuota, err = getMemQuota(e.ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
 	for {
+		if err = checkDeadline(e.deadline); err != nil {
+			e.finished = true
+			return errors.Trace(err)
+		}
 		row, err := e.smallExec.Next()
 		if err != nil {
 			return errors.Trace(err)
@@ -673,11 +1087,21 @@ func (e *HashJoinExec) prepare() error {
 		if hasNull {
 			continue
 		}
+		rowUsage := rowMemUsage(row)
 		if rows, ok := e.hashTable[string(hashcode)]; !ok {
 			e.hashTable[string(hashcode)] = []*Row{row}
+			rowUsage += int64(len(hashcode))
 		} else {
 			e.hashTable[string(hashcode)] = append(rows, row)
 		}
+		if err = e.memTracker.Consume(rowUsage); err != nil {
+			e.finished = true
+			return errors.Trace(err)
+		}
+		if sessionMemExceeds(e.ctx, e.memQuota) {
+			e.finished = true
+			return ErrMemExceedQuota.Gen("session exceeds the memory quota %d bytes, hash join table uses %d bytes", e.memQuota, e.memTracker.BytesConsumed())
+		}
 	}
 
 	e.resultRows = make(chan *Row, e.concurrency*1000)
@@ -823,6 +1247,10 @@ func (e *HashJoinExec) Next() (*Row, error) {
 		err error
 		ok  bool
 	)
+	if err = checkDeadline(e.deadline); err != nil {
+		e.finished = true
+		return nil, errors.Trace(err)
+	}
 	select {
 	case row, ok = <-e.resultRows:
 	case err, ok = <-e.resultErr:
@@ -1016,6 +1444,9 @@ type HashAggExec struct {
 	groups            [][]byte
 	currentGroupIndex int
 	GroupByItems      []expression.Expression
+	// memTracker tracks the memory used by the groups, the memory of the session can not exceed memQuota.
+	memTracker *memory.Tracker
+	memQuota   int64
 }
 
 // Close implements Executor Close interface.
@@ -1023,6 +1454,7 @@ func (e *HashAggExec) Close() error {
 	e.executed = false
 	e.groups = nil
 	e.currentGroupIndex = 0
+	e.memTracker.Release(e.memTracker.BytesConsumed())
 	for _, agg := range e.AggFuncs {
 		agg.Clear()
 	}
@@ -1044,6 +1476,11 @@ func (e *HashAggExec) Next() (*Row, error) {
 	// In this stage we consider all data from src as a single group.
 	if !e.executed {
 		e.groupMap = make(map[string]bool)
+		var err error
+		e.memQuota, err = getMemQuota(e.ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
 		for {
 			hasMore, err := e.innerNext()
 			if err != nil {
@@ -1126,6 +1563,13 @@ func (e *HashAggExec) innerNext() (ret bool, err error) {
 	if _, ok := e.groupMap[string(groupKey)]; !ok {
 		e.groupMap[string(groupKey)] = true
 		e.groups = append(e.groups, groupKey)
+		// The group key is kept by the group map, the group list and every aggregate function.
+		if err = e.memTracker.Consume(int64(len(groupKey)*(2+len(e.AggFuncs))) + datumSize*int64(len(e.AggFuncs))); err != nil {
+			return false, errors.Trace(err)
+		}
+		if sessionMemExceeds(e.ctx, e.memQuota) {
+			return false, ErrMemExceedQuota.Gen("session exceeds the memory quota %d bytes, hash aggregation uses %d bytes", e.memQuota, e.memTracker.BytesConsumed())
+		}
 	}
 	for _, af := range e.AggFuncs {
 		af.Update(srcRow.Data, groupKey, e.ctx)
@@ -1452,6 +1896,7 @@ func (e *TableScanExec) Next() (*Row, error) {
 			return nil, errors.Trace(err)
 		}
 		e.seekHandle = handle + 1
+		variable.GetSessionVars(e.ctx).AddExaminedRows(1)
 		return row, nil
 	}
 }
@@ -1506,6 +1951,8 @@ func (e *TableScanExec) Close() error {
 }
 
 // SortExec represents sorting executor.
+// When the buffered rows make the session exceed its memory quota, or they would make the statement
+// exceed its quota, they are sorted and spilled to a temporary file as a sorted run, and all the runs are merged when returning rows.
 type SortExec struct {
 	Src     Executor
 	ByItems []*plan.ByItems
@@ -1515,15 +1962,41 @@ type SortExec struct {
 	fetched bool
 	err     error
 	schema  expression.Schema
+
+	// memTracker tracks the memory used by the buffered rows, they are spilled when the session exceeds memQuota.
+	memTracker *memory.Tracker
+	memQuota   int64
+	// runs are the sorted runs spilled to temporary files.
+	runs   []*sortedRun
+	merger *mergeHeap
+	// runLimit is the max number of rows kept in the sorted runs, the rows after them are never returned.
+	// If it is positive, the runs are merged after each spill, so at most runLimit rows are kept on the disk.
+	runLimit int
+	// rowKeyTables keeps the tables of the spilled row keys, the spilled rows only keep their offsets.
+	rowKeyTables []*RowKeyEntry
+	encodeBuf    []byte
+	// deadline is the deadline of the statement, reading the rows checks it.
+	deadline time.Time
 }
 
 // Close implements Executor Close interface.
 func (e *SortExec) Close() error {
 	e.fetched = false
 	e.Rows = nil
+	e.memTracker.Release(e.memTracker.BytesConsumed())
+	e.closeRuns()
 	return e.Src.Close()
 }
 
+func (e *SortExec) closeRuns() {
+	for _, run := range e.runs {
+		run.close()
+	}
+	e.runs = nil
+	e.merger = nil
+	e.rowKeyTables = nil
+}
+
 // Schema implements Executor Schema interface.
 func (e *SortExec) Schema() expression.Schema {
 	return e.schema
@@ -1546,9 +2019,13 @@ func (e *SortExec) Swap(i, j int) {
 
 // Less implements sort.Interface Less interface.
 func (e *SortExec) Less(i, j int) bool {
+	return e.lessRow(e.Rows[i], e.Rows[j])
+}
+
+func (e *SortExec) lessRow(r1, r2 *orderByRow) bool {
 	for index, by := range e.ByItems {
-		v1 := e.Rows[i].key[index]
-		v2 := e.Rows[j].key[index]
+		v1 := r1.key[index]
+		v2 := r2.key[index]
 
 		ret, err := v1.CompareDatum(v2)
 		if err != nil {
@@ -1570,49 +2047,353 @@ func (e *SortExec) Less(i, j int) bool {
 	return false
 }
 
-// Next implements Executor Next interface.
-func (e *SortExec) Next() (*Row, error) {
-	if !e.fetched {
-		for {
-			srcRow, err := e.Src.Next()
-			if err != nil {
-				return nil, errors.Trace(err)
-			}
-			if srcRow == nil {
-				break
+func (e *SortExec) newOrderByRow(srcRow *Row) (*orderByRow, error) {
+	orderRow := &orderByRow{
+		row: srcRow,
+		key: make([]types.Datum, len(e.ByItems)),
+	}
+	for i, byItem := range e.ByItems {
+		var err error
+		orderRow.key[i], err = byItem.Expr.Eval(srcRow.Data, e.ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return orderRow, nil
+}
+
+// fetchAll reads all the remaining rows from Src and sorts them.
+func (e *SortExec) fetchAll() error {
+	for {
+		if err := checkDeadline(e.deadline); err != nil {
+			return errors.Trace(err)
+		}
+		srcRow, err := e.Src.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if srcRow == nil {
+			break
+		}
+		orderRow, err := e.newOrderByRow(srcRow)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		usage := orderRow.memUsage()
+		// Spill before the statement exceeds its quota, so the statement isn't cancelled by the quota.
+		if len(e.Rows) > 0 && e.memTracker.WouldExceed(usage) {
+			if err = e.spill(); err != nil {
+				return errors.Trace(err)
 			}
-			orderRow := &orderByRow{
-				row: srcRow,
-				key: make([]types.Datum, len(e.ByItems)),
+		}
+		e.Rows = append(e.Rows, orderRow)
+		if err = e.memTracker.Consume(usage); err != nil {
+			return errors.Trace(err)
+		}
+		if e.exceedsSessionQuota() {
+			if err = e.spill(); err != nil {
+				return errors.Trace(err)
 			}
-			for i, byItem := range e.ByItems {
-				orderRow.key[i], err = byItem.Expr.Eval(srcRow.Data, e.ctx)
-				if err != nil {
+		}
+	}
+	if len(e.runs) == 0 {
+		sort.Sort(e)
+		return nil
+	}
+	if len(e.Rows) > 0 {
+		if err := e.spill(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(e.mergeRuns())
+}
+
+// exceedsSessionQuota returns whether the buffered rows should be spilled for the memory quota of the session.
+// The rows are kept until they reach MinSortRunSize, so the sorted runs aren't too small.
+func (e *SortExec) exceedsSessionQuota() bool {
+	return e.memTracker.BytesConsumed() >= MinSortRunSize && sessionMemExceeds(e.ctx, e.memQuota)
+}
+
+// spill sorts the buffered rows and writes them to a new sorted run.
+func (e *SortExec) spill() error {
+	sort.Sort(e)
+	if e.err != nil {
+		return errors.Trace(e.err)
+	}
+	rows := e.Rows
+	if e.runLimit > 0 && len(rows) > e.runLimit {
+		rows = rows[:e.runLimit]
+	}
+	run, err := newSortedRun()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	e.runs = append(e.runs, run)
+	w := bufio.NewWriter(run.file)
+	for _, r := range rows {
+		e.encodeBuf, err = e.encodeRow(e.encodeBuf[:0], r)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err = run.write(w, e.encodeBuf); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if err = w.Flush(); err != nil {
+		return errors.Trace(err)
+	}
+	if err = run.finishWrite(); err != nil {
+		return errors.Trace(err)
+	}
+	log.Infof("[sort] spill %d rows (%d bytes) to %s", len(rows), e.memTracker.BytesConsumed(), run.name)
+	e.Rows = nil
+	e.memTracker.Release(e.memTracker.BytesConsumed())
+	if e.runLimit > 0 && len(e.runs) > 1 {
+		if run, err = e.mergeToRun(e.runs); err != nil {
+			return errors.Trace(err)
+		}
+		e.runs = []*sortedRun{run}
+	}
+	return nil
+}
+
+// encodeRow encodes a row and its order values for spilling.
+// Layout: data length, row key count, order values, data, (table offset, handle) of each row key.
+func (e *SortExec) encodeRow(b []byte, r *orderByRow) ([]byte, error) {
+	b, err := codec.EncodeValue(b, types.NewIntDatum(int64(len(r.row.Data))), types.NewIntDatum(int64(len(r.row.RowKeys))))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, d := range r.key {
+		if b, err = encodeSpillDatum(b, d); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	for _, d := range r.row.Data {
+		if b, err = encodeSpillDatum(b, d); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	for _, rk := range r.row.RowKeys {
+		offset := types.NewIntDatum(int64(e.rowKeyTableOffset(rk)))
+		if b, err = codec.EncodeValue(b, offset, types.NewIntDatum(rk.Handle)); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return b, nil
+}
+
+func (e *SortExec) rowKeyTableOffset(rk *RowKeyEntry) int {
+	for i, t := range e.rowKeyTables {
+		if t.Tbl == rk.Tbl && t.TableAsName == rk.TableAsName {
+			return i
+		}
+	}
+	e.rowKeyTables = append(e.rowKeyTables, &RowKeyEntry{Tbl: rk.Tbl, TableAsName: rk.TableAsName})
+	return len(e.rowKeyTables) - 1
+}
+
+// decodeRow decodes a row encoded by encodeRow.
+func (e *SortExec) decodeRow(b []byte) (*orderByRow, error) {
+	vals := make([]types.Datum, 2)
+	var err error
+	for i := range vals {
+		if b, vals[i], err = codec.DecodeOne(b); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	r := &orderByRow{
+		key: make([]types.Datum, len(e.ByItems)),
+		row: &Row{Data: make([]types.Datum, vals[0].GetInt64())},
+	}
+	for i := range r.key {
+		if b, r.key[i], err = decodeSpillDatum(b); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	for i := range r.row.Data {
+		if b, r.row.Data[i], err = decodeSpillDatum(b); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if cnt := vals[1].GetInt64(); cnt > 0 {
+		r.row.RowKeys = make([]*RowKeyEntry, cnt)
+		for i := range r.row.RowKeys {
+			for j := range vals {
+				if b, vals[j], err = codec.DecodeOne(b); err != nil {
 					return nil, errors.Trace(err)
 				}
 			}
-			e.Rows = append(e.Rows, orderRow)
+			t := e.rowKeyTables[vals[0].GetInt64()]
+			r.row.RowKeys[i] = &RowKeyEntry{Tbl: t.Tbl, TableAsName: t.TableAsName, Handle: vals[1].GetInt64()}
+		}
+	}
+	return r, nil
+}
+
+// readRun reads the next row of a sorted run, it returns nil when the run is exhausted.
+func (e *SortExec) readRun(run *sortedRun) (*mergeItem, error) {
+	data, err := run.read()
+	if err != nil || data == nil {
+		return nil, errors.Trace(err)
+	}
+	r, err := e.decodeRow(data)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &mergeItem{row: r, data: data, run: run}, nil
+}
+
+// mergeRuns merges the sorted runs in passes of SortMergeFanIn runs until they can be merged at once,
+// then it reads the first row of every run into the merge heap.
+func (e *SortExec) mergeRuns() error {
+	for len(e.runs) > SortMergeFanIn {
+		run, err := e.mergeToRun(e.runs[:SortMergeFanIn])
+		if err != nil {
+			return errors.Trace(err)
+		}
+		e.runs = append(e.runs[SortMergeFanIn:], run)
+	}
+	var err error
+	e.merger, err = e.newMerger(e.runs)
+	return errors.Trace(err)
+}
+
+// mergeToRun merges the sorted runs into a new sorted run and removes them.
+func (e *SortExec) mergeToRun(runs []*sortedRun) (*sortedRun, error) {
+	merger, err := e.newMerger(runs)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	run, err := newSortedRun()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = e.writeMerged(merger, run); err != nil {
+		run.close()
+		return nil, errors.Trace(err)
+	}
+	log.Infof("[sort] merge %d sorted runs to %s", len(runs), run.name)
+	for _, r := range runs {
+		r.close()
+	}
+	return run, nil
+}
+
+// writeMerged writes the merged rows to the sorted run, at most runLimit rows are written if it is positive.
+func (e *SortExec) writeMerged(merger *mergeHeap, run *sortedRun) error {
+	w := bufio.NewWriter(run.file)
+	for n := 0; e.runLimit <= 0 || n < e.runLimit; n++ {
+		item, err := e.popMerger(merger)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if item == nil {
+			break
+		}
+		if err = run.write(w, item.data); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(run.finishWrite())
+}
+
+// newMerger opens the sorted runs and reads their first rows into a merge heap.
+func (e *SortExec) newMerger(runs []*sortedRun) (*mergeHeap, error) {
+	merger := &mergeHeap{sorter: e}
+	for _, run := range runs {
+		if err := run.rewind(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		item, err := e.readRun(run)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if item != nil {
+			merger.items = append(merger.items, item)
+		}
+	}
+	heap.Init(merger)
+	return merger, errors.Trace(e.err)
+}
+
+// popMerger returns the smallest head row of the merge heap and reads the next row of its run,
+// it returns nil when all the runs are exhausted.
+func (e *SortExec) popMerger(merger *mergeHeap) (*mergeItem, error) {
+	if merger.Len() == 0 {
+		return nil, nil
+	}
+	item := merger.items[0]
+	next, err := e.readRun(item.run)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if next == nil {
+		heap.Pop(merger)
+	} else {
+		merger.items[0] = next
+		heap.Fix(merger, 0)
+	}
+	return item, errors.Trace(e.err)
+}
+
+// nextRow returns the next row in order, either from the buffered rows or from the sorted runs.
+func (e *SortExec) nextRow() (*Row, error) {
+	if e.merger == nil {
+		if e.Idx >= len(e.Rows) {
+			return nil, nil
+		}
+		row := e.Rows[e.Idx].row
+		e.Idx++
+		return row, nil
+	}
+	item, err := e.popMerger(e.merger)
+	if err != nil || item == nil {
+		return nil, errors.Trace(err)
+	}
+	return item.row.row, nil
+}
+
+// Next implements Executor Next interface.
+func (e *SortExec) Next() (*Row, error) {
+	if !e.fetched {
+		var err error
+		e.memQuota, err = getMemQuota(e.ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if err = e.fetchAll(); err != nil {
+			return nil, errors.Trace(err)
 		}
-		sort.Sort(e)
 		e.fetched = true
 	}
 	if e.err != nil {
 		return nil, errors.Trace(e.err)
 	}
-	if e.Idx >= len(e.Rows) {
-		return nil, nil
-	}
-	row := e.Rows[e.Idx].row
-	e.Idx++
-	return row, nil
+	return e.nextRow()
 }
 
 // TopnExec implements a top n algo.
+// Only a heap of offset+count rows is kept. If the heap alone makes the session exceed its memory quota, or it would make
+// the statement exceed its quota, it falls back to the external sort of SortExec, which keeps at most offset+count rows on the disk.
 type TopnExec struct {
 	SortExec
 	limit      *plan.Limit
 	totalCount int
 	heapSize   int
+	spilled    bool
+	// returned is the count of returned rows after falling back to the external sort.
+	returned uint64
+}
+
+// Close implements Executor Close interface.
+func (e *TopnExec) Close() error {
+	e.spilled = false
+	e.returned = 0
+	return e.SortExec.Close()
 }
 
 // Less implements heap.Interface Less interface.
@@ -1661,6 +2442,11 @@ func (e *TopnExec) Pop() interface{} {
 // Next implements Executor Next interface.
 func (e *TopnExec) Next() (*Row, error) {
 	if !e.fetched {
+		var err error
+		e.memQuota, err = getMemQuota(e.ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
 		e.Idx = int(e.limit.Offset)
 		e.totalCount = int(e.limit.Offset + e.limit.Count)
 		e.Rows = make([]*orderByRow, 0, e.totalCount+1)
@@ -1673,28 +2459,44 @@ func (e *TopnExec) Next() (*Row, error) {
 			if srcRow == nil {
 				break
 			}
-			orderRow := &orderByRow{
-				row: srcRow,
-				key: make([]types.Datum, len(e.ByItems)),
-			}
-			for i, byItem := range e.ByItems {
-				orderRow.key[i], err = byItem.Expr.Eval(srcRow.Data, e.ctx)
-				if err != nil {
-					return nil, errors.Trace(err)
-				}
+			orderRow, err := e.newOrderByRow(srcRow)
+			if err != nil {
+				return nil, errors.Trace(err)
 			}
 			if e.totalCount == e.heapSize {
+				// The full heap is bounded, so it isn't spilled when a row replaces its top.
 				e.Rows = append(e.Rows, orderRow)
 				if e.Less(0, e.heapSize) {
+					err = e.memTracker.Consume(orderRow.memUsage() - e.Rows[0].memUsage())
 					e.Swap(0, e.heapSize)
 					heap.Fix(e, 0)
 				}
 				e.Rows = e.Rows[:e.heapSize]
-			} else {
-				heap.Push(e, orderRow)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				continue
+			}
+			if e.heapSize > 0 && e.memTracker.WouldExceed(orderRow.memUsage()) {
+				// The row is spilled with the heap, the statement doesn't exceed its quota.
+				e.Rows = append(e.Rows[:e.heapSize], orderRow)
+				e.spilled = true
+				break
+			}
+			heap.Push(e, orderRow)
+			if err = e.memTracker.Consume(orderRow.memUsage()); err != nil {
+				return nil, errors.Trace(err)
+			}
+			if e.exceedsSessionQuota() {
+				e.spilled = true
+				break
 			}
 		}
-		if e.limit.Offset == 0 {
+		if e.spilled {
+			if err := e.fetchSpilled(); err != nil {
+				return nil, errors.Trace(err)
+			}
+		} else if e.limit.Offset == 0 {
 			sort.Sort(&e.SortExec)
 		} else {
 			for i := 0; i < int(e.limit.Count) && e.Len() > 0; i++ {
@@ -1703,6 +2505,13 @@ func (e *TopnExec) Next() (*Row, error) {
 		}
 		e.fetched = true
 	}
+	if e.spilled {
+		if e.returned >= e.limit.Count {
+			return nil, nil
+		}
+		e.returned++
+		return e.SortExec.nextRow()
+	}
 	if e.Idx >= len(e.Rows) {
 		return nil, nil
 	}
@@ -1711,6 +2520,28 @@ func (e *TopnExec) Next() (*Row, error) {
 	return row, nil
 }
 
+// fetchSpilled spills the heap, sorts the remaining rows of Src externally and skips the offset rows.
+// Only the first offset+count rows of the sorted runs are kept, so the spilled rows don't grow with Src.
+func (e *TopnExec) fetchSpilled() error {
+	e.runLimit = e.totalCount
+	if err := e.SortExec.spill(); err != nil {
+		return errors.Trace(err)
+	}
+	if err := e.SortExec.fetchAll(); err != nil {
+		return errors.Trace(err)
+	}
+	for i := uint64(0); i < e.limit.Offset; i++ {
+		row, err := e.SortExec.nextRow()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+	}
+	return nil
+}
+
 // ApplyExec represents apply executor.
 // Apply gets one row from outer executor and gets one row from inner executor according to outer row.
 type ApplyExec struct {
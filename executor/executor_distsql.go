@@ -236,6 +236,13 @@ func convertIndexRangeTypes(ran *plan.IndexRange, fieldTypes []*types.FieldType)
 	return nil
 }
 
+// recordCopStats records the coprocessor statistics of a select request to the runtime statistics
+// of the executor and the statement.
+func recordCopStats(ctx context.Context, stats *RuntimeStats, copStats distsql.CopStats) {
+	stats.recordCop(copStats)
+	variable.GetSessionVars(ctx).AddCopTime(copStats.WaitTime)
+}
+
 // extractHandlesFromIndexResult gets some handles from SelectResult.
 // It should be called in a loop until finished or error happened.
 func extractHandlesFromIndexResult(idxResult distsql.SelectResult) (handles []int64, finish bool, err error) {
@@ -314,6 +321,10 @@ type XSelectIndexExec struct {
 	indexPlan *plan.PhysicalIndexScan
 
 	returnedRows uint64 // returned row count
+	// stats collects the coprocessor statistics for EXPLAIN ANALYZE, it is nil otherwise.
+	stats *RuntimeStats
+	// deadline is the deadline of the statement, the coprocessor requests stop after it.
+	deadline time.Time
 
 	mu sync.Mutex
 
@@ -343,6 +354,9 @@ func (e *XSelectIndexExec) Schema() expression.Schema {
 
 // Close implements Exec Close interface.
 func (e *XSelectIndexExec) Close() error {
+	if e.result != nil {
+		recordCopStats(e.ctx, e.stats, e.result.CopStats())
+	}
 	err := closeAll(e.result, e.partialResult)
 	if err != nil {
 		return errors.Trace(err)
@@ -361,10 +375,19 @@ func (e *XSelectIndexExec) Next() (*Row, error) {
 		return nil, nil
 	}
 	e.returnedRows++
+	var (
+		row *Row
+		err error
+	)
 	if e.indexPlan.DoubleRead {
-		return e.nextForDoubleRead()
+		row, err = e.nextForDoubleRead()
+	} else {
+		row, err = e.nextForSingleRead()
 	}
-	return e.nextForSingleRead()
+	if row != nil {
+		variable.GetSessionVars(e.ctx).AddExaminedRows(1)
+	}
+	return row, errors.Trace(err)
 }
 
 func (e *XSelectIndexExec) nextForSingleRead() (*Row, error) {
@@ -485,6 +508,7 @@ func (e *XSelectIndexExec) fetchHandles(idxResult distsql.SelectResult, ch chan<
 	for {
 		handles, finish, err := extractHandlesFromIndexResult(idxResult)
 		if err != nil || finish {
+			recordCopStats(e.ctx, e.stats, idxResult.CopStats())
 			e.tasksErr = errors.Trace(err)
 			log.Debugf("[TIME_INDEX_SCAN] time: %v handles: %d concurrency: %d",
 				time.Since(startTs),
@@ -555,7 +579,7 @@ func (e *XSelectIndexExec) doIndexRequest() (distsql.SelectResult, error) {
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	return distsql.Select(e.ctx.GetClient(), selIdxReq, keyRanges, concurrency, !e.indexPlan.OutOfOrder)
+	return distsql.Select(e.ctx.GetClient(), selIdxReq, keyRanges, concurrency, !e.indexPlan.OutOfOrder, e.deadline)
 }
 
 func (e *XSelectIndexExec) buildTableTasks(handles []int64) []*lookupTableTask {
@@ -614,6 +638,7 @@ func (e *XSelectIndexExec) executeTask(task *lookupTableTask) error {
 		return errors.Trace(err)
 	}
 	task.rows, err = e.extractRowsFromTableResult(e.table, tblResult)
+	recordCopStats(e.ctx, e.stats, tblResult.CopStats())
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -686,7 +711,7 @@ func (e *XSelectIndexExec) doTableRequest(handles []int64) (distsql.SelectResult
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	resp, err := distsql.Select(e.ctx.GetClient(), selTableReq, keyRanges, concurrency, false)
+	resp, err := distsql.Select(e.ctx.GetClient(), selTableReq, keyRanges, concurrency, false, e.deadline)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -716,8 +741,11 @@ type XSelectTableExec struct {
 	limitCount    *int64
 	returnedRows  uint64 // returned rowCount
 	keepOrder     bool
+	stats         *RuntimeStats
 	startTS       uint64
 	orderByList   []*tipb.ByItem
+	// deadline is the deadline of the statement, the coprocessor requests stop after it.
+	deadline time.Time
 
 	/*
 	   The following attributes are used for aggregation push down.
@@ -764,7 +792,7 @@ func (e *XSelectTableExec) doRequest() error {
 	if err != nil {
 		return errors.Trace(err)
 	}
-	e.result, err = distsql.Select(e.ctx.GetClient(), selReq, kvRanges, concurrency, e.keepOrder)
+	e.result, err = distsql.Select(e.ctx.GetClient(), selReq, kvRanges, concurrency, e.keepOrder, e.deadline)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -779,6 +807,9 @@ func (e *XSelectTableExec) doRequest() error {
 
 // Close implements Executor Close interface.
 func (e *XSelectTableExec) Close() error {
+	if e.result != nil {
+		recordCopStats(e.ctx, e.stats, e.result.CopStats())
+	}
 	err := closeAll(e.result, e.partialResult)
 	if err != nil {
 		return errors.Trace(err)
@@ -827,6 +858,7 @@ func (e *XSelectTableExec) Next() (*Row, error) {
 			continue
 		}
 		e.returnedRows++
+		variable.GetSessionVars(e.ctx).AddExaminedRows(1)
 		if e.aggregate {
 			// compose aggreagte row
 			return &Row{Data: rowData}, nil
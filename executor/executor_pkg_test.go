@@ -14,9 +14,26 @@
 package executor
 
 import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/juju/errors"
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/sessionctx/autocommit"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/memory"
+	"github.com/pingcap/tidb/util/mock"
+	"github.com/pingcap/tidb/util/types"
 )
 
 var _ = Suite(&testExecSuite{})
@@ -61,3 +78,190 @@ func (s *testExecSuite) TestMergeHandles(c *C) {
 		c.Assert(kr.EndKey, DeepEquals, ekr.EndKey)
 	}
 }
+
+func (s *testExecSuite) TestSpillDatum(c *C) {
+	t, err := mysql.ParseTime("2016-10-01 12:34:56.789", mysql.TypeDatetime, 3)
+	c.Assert(err, IsNil)
+	datums := []types.Datum{
+		{},
+		types.NewIntDatum(-1),
+		types.NewUintDatum(1),
+		types.NewFloat32Datum(1.5),
+		types.NewFloat64Datum(2.5),
+		types.NewStringDatum("abc"),
+		types.NewBytesDatum([]byte("def")),
+		types.NewDecimalDatum(mysql.NewDecFromInt(123)),
+		types.NewDurationDatum(mysql.Duration{Duration: time.Hour, Fsp: 2}),
+		types.NewDatum(t),
+		types.NewDatum(mysql.Enum{Name: "a", Value: 1}),
+		types.NewDatum(mysql.Set{Name: "a,b", Value: 3}),
+		types.NewDatum(mysql.Bit{Value: 5, Width: 8}),
+		types.NewDatum(mysql.Hex{Value: 10}),
+	}
+	var b []byte
+	for _, d := range datums {
+		b, err = encodeSpillDatum(b, d)
+		c.Assert(err, IsNil)
+	}
+	for _, d := range datums {
+		var got types.Datum
+		b, got, err = decodeSpillDatum(b)
+		c.Assert(err, IsNil)
+		c.Assert(got.Kind(), Equals, d.Kind())
+		cmp, err := got.CompareDatum(d)
+		c.Assert(err, IsNil)
+		c.Assert(cmp, Equals, 0)
+	}
+	c.Assert(b, HasLen, 0)
+}
+
+// mockFailExec fails in Next and counts how many times it is closed.
+type mockFailExec struct {
+	closed int
+}
+
+func (e *mockFailExec) Fields() []*ast.ResultField { return nil }
+
+func (e *mockFailExec) Schema() expression.Schema { return nil }
+
+func (e *mockFailExec) Next() (*Row, error) { return nil, errors.New("mock error") }
+
+func (e *mockFailExec) Close() error {
+	e.closed++
+	return nil
+}
+
+func (s *testExecSuite) TestExplainAnalyzeClose(c *C) {
+	// The analyzed statement is closed once when it fails.
+	stmt := &mockFailExec{}
+	e := &ExplainExec{stmtExec: stmt}
+	_, err := e.Next()
+	c.Assert(err, NotNil)
+	c.Assert(stmt.closed, Equals, 1)
+	c.Assert(e.Close(), IsNil)
+	c.Assert(stmt.closed, Equals, 1)
+
+	// The analyzed statement is closed with EXPLAIN ANALYZE if it isn't executed.
+	stmt = &mockFailExec{}
+	e = &ExplainExec{stmtExec: stmt}
+	c.Assert(e.Close(), IsNil)
+	c.Assert(stmt.closed, Equals, 1)
+}
+
+type mockAutocommitChecker struct{}
+
+func (c mockAutocommitChecker) ShouldAutocommit(ctx context.Context) (bool, error) { return false, nil }
+
+func (s *testExecSuite) TestBuildLoadData(c *C) {
+	tblInfo := &model.TableInfo{
+		Name: model.NewCIStr("t"),
+		Columns: []*model.ColumnInfo{{
+			Name:      model.NewCIStr("a"),
+			FieldType: *types.NewFieldType(mysql.TypeLong),
+			State:     model.StatePublic,
+		}},
+		State: model.StatePublic,
+	}
+	is := infoschema.MockInfoSchema([]*model.TableInfo{tblInfo})
+	ctx := mock.NewContext()
+	autocommit.BindAutocommitChecker(ctx, mockAutocommitChecker{})
+
+	// Only the errors of LOAD DATA LOCAL or LOAD DATA IGNORE are ignored, REPLACE only replaces the duplicate rows.
+	cases := []struct {
+		isLocal     bool
+		onDuplicate ast.OnDuplicateKeyHandlingType
+		ignoreErr   bool
+	}{
+		{false, ast.OnDuplicateKeyHandlingError, false},
+		{false, ast.OnDuplicateKeyHandlingIgnore, true},
+		{false, ast.OnDuplicateKeyHandlingReplace, false},
+		{true, ast.OnDuplicateKeyHandlingError, true},
+		{true, ast.OnDuplicateKeyHandlingReplace, true},
+	}
+	for _, ca := range cases {
+		b := newExecutorBuilder(ctx, is)
+		e := b.build(&plan.LoadData{IsLocal: ca.isLocal, OnDuplicate: ca.onDuplicate, Table: &ast.TableName{TableInfo: tblInfo}})
+		c.Assert(b.err, IsNil)
+		c.Assert(e.(*LoadData).loadDataInfo.ignoreErr, Equals, ca.ignoreErr)
+	}
+}
+
+// mockSrcExec is a source executor that returns the rows in order.
+type mockSrcExec struct {
+	rows []*Row
+	idx  int
+}
+
+func (e *mockSrcExec) Fields() []*ast.ResultField {
+	return nil
+}
+
+func (e *mockSrcExec) Schema() expression.Schema {
+	return nil
+}
+
+func (e *mockSrcExec) Next() (*Row, error) {
+	if e.idx >= len(e.rows) {
+		return nil, nil
+	}
+	e.idx++
+	return e.rows[e.idx-1], nil
+}
+
+func (e *mockSrcExec) Close() error {
+	return nil
+}
+
+func (s *testExecSuite) TestTopnSpill(c *C) {
+	dir, err := ioutil.TempDir("", "tidb-spill")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	oldSpillDir := SpillDir
+	SpillDir = dir
+	defer func() {
+		SpillDir = oldSpillDir
+	}()
+
+	ctx := mock.NewContext()
+	variable.BindSessionVars(ctx)
+	err = variable.GetSessionVars(ctx).SetSystemVar(variable.TiDBMemQuotaSession, types.NewStringDatum("0"))
+	c.Assert(err, IsNil)
+	src := &mockSrcExec{}
+	for i := 0; i < 1000; i++ {
+		src.rows = append(src.rows, &Row{Data: types.MakeDatums((i * 37) % 1000)})
+	}
+	first := &orderByRow{row: src.rows[0], key: src.rows[0].Data}
+	e := &TopnExec{
+		SortExec: SortExec{
+			Src:     src,
+			ByItems: []*plan.ByItems{{Expr: &expression.Column{Index: 0}}},
+			ctx:     ctx,
+			// The heap of offset+count rows exceeds the quota of the statement, so it is spilled.
+			memTracker: memory.NewTracker("topn", first.memUsage()*5),
+		},
+		limit: &plan.Limit{Offset: 10, Count: 10},
+	}
+	for i := 10; i < 20; i++ {
+		row, err1 := e.Next()
+		c.Assert(err1, IsNil)
+		c.Assert(row.Data[0].GetInt64(), Equals, int64(i))
+	}
+	row, err := e.Next()
+	c.Assert(err, IsNil)
+	c.Assert(row, IsNil)
+	c.Assert(e.spilled, IsTrue)
+
+	// Only one sorted run of at most offset+count rows is kept on the disk, instead of all the rows of Src.
+	c.Assert(e.runs, HasLen, 1)
+	files, err := ioutil.ReadDir(dir)
+	c.Assert(err, IsNil)
+	c.Assert(files, HasLen, 1)
+	encoded, err := e.encodeRow(nil, first)
+	c.Assert(err, IsNil)
+	c.Assert(files[0].Size(), LessEqual, int64(20*(len(encoded)+1)))
+
+	c.Assert(e.Close(), IsNil)
+	files, err = ioutil.ReadDir(dir)
+	c.Assert(err, IsNil)
+	c.Assert(files, HasLen, 0)
+}
@@ -16,6 +16,8 @@ package executor
 import (
 	"fmt"
 	"math/rand"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/juju/errors"
@@ -83,8 +85,18 @@ func (e *SimpleExec) Next() (*Row, error) {
 		err = e.executeRollback(x)
 	case *ast.CreateUserStmt:
 		err = e.executeCreateUser(x)
+	case *ast.AlterUserStmt:
+		err = e.executeAlterUser(x)
+	case *ast.RenameUserStmt:
+		err = e.executeRenameUser(x)
 	case *ast.DropUserStmt:
 		err = e.executeDropUser(x)
+	case *ast.GrantRoleStmt:
+		err = e.executeGrantRole(x)
+	case *ast.SetRoleStmt:
+		err = e.executeSetRole(x)
+	case *ast.SetDefaultRoleStmt:
+		err = e.executeSetDefaultRole(x)
 	case *ast.SetPwdStmt:
 		err = e.executeSetPwd(x)
 	case *ast.AnalyzeTableStmt:
@@ -311,6 +323,12 @@ func (e *SimpleExec) executeRollback(s *ast.RollbackStmt) error {
 }
 
 func (e *SimpleExec) executeCreateUser(s *ast.CreateUserStmt) error {
+	pwdOpts := s.PasswordOrLockOptions
+	if s.IsCreateRole {
+		// A role is a locked account with an expired password, so nobody can log in as a role.
+		pwdOpts = append(pwdOpts, &ast.PasswordOrLockOption{Tp: ast.AccountLock}, &ast.PasswordOrLockOption{Tp: ast.PasswordExpire})
+	}
+	optCols, optValues := userOptionColumns(s.ResourceOptions, pwdOpts)
 	users := make([]string, 0, len(s.Specs))
 	for _, spec := range s.Specs {
 		userName, host := parseUser(spec.User)
@@ -319,26 +337,26 @@ func (e *SimpleExec) executeCreateUser(s *ast.CreateUserStmt) error {
 			return errors.Trace(err1)
 		}
 		if exists {
-			if !s.IfNotExists {
-				return errors.New("Duplicate user")
+			if s.IfNotExists {
+				continue
 			}
-			continue
-		}
-		pwd := ""
-		if spec.AuthOpt != nil {
-			if spec.AuthOpt.ByAuthString {
-				pwd = util.EncodePassword(spec.AuthOpt.AuthString)
-			} else {
-				pwd = util.EncodePassword(spec.AuthOpt.HashString)
+			if s.IsCreateRole {
+				return terror.ClassExecutor.New(CodeCannotUser, "Operation CREATE ROLE failed for "+spec.User)
 			}
+			return errors.New("Duplicate user")
 		}
-		user := fmt.Sprintf(`("%s", "%s", "%s")`, host, userName, pwd)
-		users = append(users, user)
+		plugin, pwd, authString, err1 := encodeAuthOption(spec.AuthOpt)
+		if err1 != nil {
+			return errors.Trace(err1)
+		}
+		values := append([]string{fmt.Sprintf(`"%s", "%s", "%s", "%s", "%s", NOW()`, host, userName, pwd, plugin, authString)}, optValues...)
+		users = append(users, "("+strings.Join(values, ", ")+")")
 	}
 	if len(users) == 0 {
 		return nil
 	}
-	sql := fmt.Sprintf(`INSERT INTO %s.%s (Host, User, Password) VALUES %s;`, mysql.SystemDB, mysql.UserTable, strings.Join(users, ", "))
+	cols := append([]string{"Host, User, Password, plugin, authentication_string, password_last_changed"}, optCols...)
+	sql := fmt.Sprintf(`INSERT INTO %s.%s (%s) VALUES %s;`, mysql.SystemDB, mysql.UserTable, strings.Join(cols, ", "), strings.Join(users, ", "))
 	_, err := e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
 	if err != nil {
 		return errors.Trace(err)
@@ -346,6 +364,224 @@ func (e *SimpleExec) executeCreateUser(s *ast.CreateUserStmt) error {
 	return nil
 }
 
+func (e *SimpleExec) executeAlterUser(s *ast.AlterUserStmt) error {
+	optCols, optValues := userOptionColumns(s.ResourceOptions, s.PasswordOrLockOptions)
+	failedUsers := make([]string, 0, len(s.Specs))
+	for _, spec := range s.Specs {
+		userName, host := parseUser(spec.User)
+		exists, err := userExists(e.ctx, userName, host)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !exists {
+			if !s.IfExists {
+				failedUsers = append(failedUsers, spec.User)
+			}
+			continue
+		}
+		var assignments []string
+		if spec.AuthOpt != nil {
+			opt := *spec.AuthOpt
+			if opt.AuthPlugin == "" {
+				// The password is changed for the current authentication plugin of the user.
+				if opt.AuthPlugin, err = userPlugin(e.ctx, userName, host); err != nil {
+					return errors.Trace(err)
+				}
+			}
+			plugin, pwd, authString, err := encodeAuthOption(&opt)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			assignments = append(assignments, fmt.Sprintf(`Password="%s", plugin="%s", authentication_string="%s"`, pwd, plugin, authString),
+				"password_last_changed=NOW()")
+			if !hasPasswordOrLockOption(s.PasswordOrLockOptions, ast.PasswordExpire) {
+				assignments = append(assignments, `password_expired="N"`)
+			}
+		}
+		for i, col := range optCols {
+			assignments = append(assignments, col+"="+optValues[i])
+		}
+		if len(assignments) == 0 {
+			continue
+		}
+		sql := fmt.Sprintf(`UPDATE %s.%s SET %s WHERE User="%s" AND Host="%s";`,
+			mysql.SystemDB, mysql.UserTable, strings.Join(assignments, ", "), userName, host)
+		_, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+		if err != nil {
+			failedUsers = append(failedUsers, spec.User)
+		}
+	}
+	if len(failedUsers) > 0 {
+		errMsg := "Operation ALTER USER failed for " + strings.Join(failedUsers, ",")
+		return terror.ClassExecutor.New(CodeCannotUser, errMsg)
+	}
+	return nil
+}
+
+// userOptionColumns returns the columns of mysql.user and their values set by the resource options and
+// the password expiration or account locking options, the later option overrides the earlier one of the same column.
+func userOptionColumns(resOpts []*ast.ResourceOption, pwdOpts []*ast.PasswordOrLockOption) (cols, values []string) {
+	colValues := make(map[string]string)
+	for _, opt := range resOpts {
+		count := strconv.FormatUint(opt.Count, 10)
+		switch opt.Tp {
+		case ast.ResourceOptionMaxQueriesPerHour:
+			colValues["max_questions"] = count
+		case ast.ResourceOptionMaxUpdatesPerHour:
+			colValues["max_updates"] = count
+		case ast.ResourceOptionMaxConnectionsPerHour:
+			colValues["max_connections"] = count
+		case ast.ResourceOptionMaxUserConnections:
+			colValues["max_user_connections"] = count
+		}
+	}
+	for _, opt := range pwdOpts {
+		switch opt.Tp {
+		case ast.PasswordExpire:
+			colValues["password_expired"] = `"Y"`
+		case ast.PasswordExpireDefault:
+			// NULL means the password lifetime is default_password_lifetime.
+			colValues["password_lifetime"] = "NULL"
+		case ast.PasswordExpireNever:
+			colValues["password_lifetime"] = "0"
+		case ast.PasswordExpireInterval:
+			colValues["password_lifetime"] = strconv.FormatUint(opt.Count, 10)
+		case ast.AccountLock:
+			colValues["account_locked"] = `"Y"`
+		case ast.AccountUnlock:
+			colValues["account_locked"] = `"N"`
+		}
+	}
+	for col := range colValues {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	for _, col := range cols {
+		values = append(values, colValues[col])
+	}
+	return cols, values
+}
+
+func hasPasswordOrLockOption(opts []*ast.PasswordOrLockOption, tp ast.PasswordOrLockOptionType) bool {
+	for _, opt := range opts {
+		if opt.Tp == tp {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *SimpleExec) executeRenameUser(s *ast.RenameUserStmt) error {
+	failedUsers := make([]string, 0, len(s.UserToUsers))
+	for _, userToUser := range s.UserToUsers {
+		oldName, oldHost := parseUser(userToUser.OldUser)
+		newName, newHost := parseUser(userToUser.NewUser)
+		oldExists, err := userExists(e.ctx, oldName, oldHost)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		newExists, err := userExists(e.ctx, newName, newHost)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !oldExists || newExists {
+			failedUsers = append(failedUsers, userToUser.OldUser+" TO "+userToUser.NewUser)
+			continue
+		}
+		// The privileges and the roles of the user are moved to the new user.
+		var sqls []string
+		for _, table := range []string{mysql.UserTable, mysql.DBTable, mysql.TablePrivTable, mysql.ColumnPrivTable} {
+			sqls = append(sqls, fmt.Sprintf(`UPDATE %s.%s SET User="%s", Host="%s" WHERE User="%s" AND Host="%s";`,
+				mysql.SystemDB, table, newName, newHost, oldName, oldHost))
+		}
+		for _, cols := range [][2]string{{"FROM_USER", "FROM_HOST"}, {"TO_USER", "TO_HOST"}} {
+			sqls = append(sqls, fmt.Sprintf(`UPDATE %s.%s SET %s="%s", %s="%s" WHERE %s="%s" AND %s="%s";`,
+				mysql.SystemDB, mysql.RoleEdgeTable, cols[0], newName, cols[1], newHost, cols[0], oldName, cols[1], oldHost))
+		}
+		for _, cols := range [][2]string{{"USER", "HOST"}, {"DEFAULT_ROLE_USER", "DEFAULT_ROLE_HOST"}} {
+			sqls = append(sqls, fmt.Sprintf(`UPDATE %s.%s SET %s="%s", %s="%s" WHERE %s="%s" AND %s="%s";`,
+				mysql.SystemDB, mysql.DefaultRoleTable, cols[0], newName, cols[1], newHost, cols[0], oldName, cols[1], oldHost))
+		}
+		for _, sql := range sqls {
+			if _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	err := e.ctx.CommitTxn()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(failedUsers) > 0 {
+		errMsg := "Operation RENAME USER failed for " + strings.Join(failedUsers, ",")
+		return terror.ClassExecutor.New(CodeCannotUser, errMsg)
+	}
+	return nil
+}
+
+// encodeAuthOption returns the authentication plugin, the password and the authentication string of mysql.user.
+// The password is only used by mysql_native_password, the other plugins use the authentication string.
+func encodeAuthOption(opt *ast.AuthOption) (plugin, pwd, authString string, err error) {
+	plugin = mysql.AuthNativePassword
+	if opt == nil {
+		return
+	}
+	if opt.AuthPlugin != "" {
+		plugin = opt.AuthPlugin
+	}
+	switch plugin {
+	case mysql.AuthNativePassword:
+		if opt.ByAuthString {
+			pwd = util.EncodePassword(opt.AuthString)
+		} else {
+			pwd = util.EncodePassword(opt.HashString)
+		}
+	case mysql.AuthCachingSha2Password:
+		if opt.ByAuthString {
+			authString = util.EncodeSha2Password(opt.AuthString)
+		} else {
+			authString = opt.HashString
+		}
+	case mysql.AuthSocket:
+		// The authentication string is the name of the operating system user, it is the user name if it is empty.
+		authString = opt.AuthString + opt.HashString
+	default:
+		err = ErrPluginIsNotLoaded.Gen("Plugin '%s' is not loaded", plugin)
+	}
+	return
+}
+
+// userPlugin gets the authentication plugin of the user, it is mysql_native_password if the user doesn't exist.
+func userPlugin(ctx context.Context, name, host string) (string, error) {
+	sql := fmt.Sprintf(`SELECT plugin FROM %s.%s WHERE User="%s" AND Host="%s";`, mysql.SystemDB, mysql.UserTable, name, host)
+	rs, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	defer rs.Close()
+	row, err := rs.Next()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if row == nil || row.Data[0].GetString() == "" {
+		return mysql.AuthNativePassword, nil
+	}
+	return row.Data[0].GetString(), nil
+}
+
+// encodePassword encodes the plaintext password for the authentication plugin of the user, it returns the column
+// of mysql.user to store the password and the encoded password.
+func encodePassword(ctx context.Context, name, host, password string) (string, string, error) {
+	plugin, err := userPlugin(ctx, name, host)
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+	if plugin == mysql.AuthCachingSha2Password {
+		return "authentication_string", util.EncodeSha2Password(password), nil
+	}
+	return "password", util.EncodePassword(password), nil
+}
+
 func (e *SimpleExec) executeDropUser(s *ast.DropUserStmt) error {
 	failedUsers := make([]string, 0, len(s.UserList))
 	for _, user := range s.UserList {
@@ -364,6 +600,18 @@ func (e *SimpleExec) executeDropUser(s *ast.DropUserStmt) error {
 		_, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
 		if err != nil {
 			failedUsers = append(failedUsers, user)
+			continue
+		}
+		// The privileges of the account are revoked, so a new account of the same name doesn't get them.
+		for _, table := range []string{mysql.DBTable, mysql.TablePrivTable, mysql.ColumnPrivTable} {
+			sql = fmt.Sprintf(`DELETE FROM %s.%s WHERE Host = "%s" and User = "%s";`, mysql.SystemDB, table, host, userName)
+			if _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		// The account is removed from the granted roles and the default roles, both as a user and as a role.
+		if err = dropRoleEdges(e.ctx, userName, host); err != nil {
+			return errors.Trace(err)
 		}
 	}
 	err := e.ctx.CommitTxn()
@@ -371,12 +619,184 @@ func (e *SimpleExec) executeDropUser(s *ast.DropUserStmt) error {
 		return errors.Trace(err)
 	}
 	if len(failedUsers) > 0 {
-		errMsg := "Operation DROP USER failed for " + strings.Join(failedUsers, ",")
+		stmt := "DROP USER"
+		if s.IsDropRole {
+			stmt = "DROP ROLE"
+		}
+		errMsg := fmt.Sprintf("Operation %s failed for %s", stmt, strings.Join(failedUsers, ","))
 		return terror.ClassExecutor.New(CodeCannotUser, errMsg)
 	}
 	return nil
 }
 
+func dropRoleEdges(ctx context.Context, name, host string) error {
+	sqls := []string{
+		fmt.Sprintf(`DELETE FROM %s.%s WHERE FROM_USER="%s" AND FROM_HOST="%s";`, mysql.SystemDB, mysql.RoleEdgeTable, name, host),
+		fmt.Sprintf(`DELETE FROM %s.%s WHERE TO_USER="%s" AND TO_HOST="%s";`, mysql.SystemDB, mysql.RoleEdgeTable, name, host),
+		fmt.Sprintf(`DELETE FROM %s.%s WHERE DEFAULT_ROLE_USER="%s" AND DEFAULT_ROLE_HOST="%s";`, mysql.SystemDB, mysql.DefaultRoleTable, name, host),
+		fmt.Sprintf(`DELETE FROM %s.%s WHERE USER="%s" AND HOST="%s";`, mysql.SystemDB, mysql.DefaultRoleTable, name, host),
+	}
+	for _, sql := range sqls {
+		if _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+func (e *SimpleExec) executeGrantRole(s *ast.GrantRoleStmt) error {
+	for _, user := range append(s.Roles, s.Users...) {
+		name, host := parseUser(user)
+		exists, err := userExists(e.ctx, name, host)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !exists {
+			return ErrUnknownAuthID.Gen("Unknown authorization ID `%s`@`%s`", name, host)
+		}
+	}
+	for _, user := range s.Users {
+		userName, userHost := parseUser(user)
+		for _, role := range s.Roles {
+			roleName, roleHost := parseUser(role)
+			sql := fmt.Sprintf(`REPLACE INTO %s.%s (FROM_HOST, FROM_USER, TO_HOST, TO_USER) VALUES ("%s", "%s", "%s", "%s");`,
+				mysql.SystemDB, mysql.RoleEdgeTable, roleHost, roleName, userHost, userName)
+			if _, err := e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	return errors.Trace(e.ctx.CommitTxn())
+}
+
+func (e *SimpleExec) executeSetRole(s *ast.SetRoleStmt) error {
+	name, host, err := currentUserAccount(e.ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var roles []string
+	switch s.SetRoleOpt {
+	case ast.SetRoleDefault:
+		roles, err = LoadDefaultRoles(e.ctx, name, host)
+	case ast.SetRoleAll:
+		roles, err = loadGrantedRoles(e.ctx, name, host)
+	case ast.SetRoleAllExcept:
+		var granted []string
+		if granted, err = loadGrantedRoles(e.ctx, name, host); err == nil {
+			for _, role := range granted {
+				if !containsRole(s.RoleList, role) {
+					roles = append(roles, role)
+				}
+			}
+		}
+	case ast.SetRoleRegular:
+		if err = checkRolesGranted(e.ctx, name, host, s.RoleList); err == nil {
+			roles = s.RoleList
+		}
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	variable.GetSessionVars(e.ctx).ActiveRoles = roles
+	return nil
+}
+
+func (e *SimpleExec) executeSetDefaultRole(s *ast.SetDefaultRoleStmt) error {
+	for _, user := range s.UserList {
+		name, host := parseUser(user)
+		exists, err := userExists(e.ctx, name, host)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !exists {
+			return ErrUnknownAuthID.Gen("Unknown authorization ID `%s`@`%s`", name, host)
+		}
+		var roles []string
+		switch s.SetRoleOpt {
+		case ast.SetRoleAll:
+			// All the roles granted to the user when the statement runs are the default roles.
+			roles, err = loadGrantedRoles(e.ctx, name, host)
+		case ast.SetRoleRegular:
+			err = checkRolesGranted(e.ctx, name, host, s.RoleList)
+			roles = s.RoleList
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+		sqls := []string{fmt.Sprintf(`DELETE FROM %s.%s WHERE USER="%s" AND HOST="%s";`, mysql.SystemDB, mysql.DefaultRoleTable, name, host)}
+		for _, role := range roles {
+			roleName, roleHost := parseUser(role)
+			sqls = append(sqls, fmt.Sprintf(`INSERT INTO %s.%s (HOST, USER, DEFAULT_ROLE_HOST, DEFAULT_ROLE_USER) VALUES ("%s", "%s", "%s", "%s");`,
+				mysql.SystemDB, mysql.DefaultRoleTable, host, name, roleHost, roleName))
+		}
+		for _, sql := range sqls {
+			if _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	return errors.Trace(e.ctx.CommitTxn())
+}
+
+// checkRolesGranted checks whether the roles are granted to the user.
+func checkRolesGranted(ctx context.Context, name, host string, roles []string) error {
+	granted, err := loadGrantedRoles(ctx, name, host)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, role := range roles {
+		if !containsRole(granted, role) {
+			roleName, roleHost := parseUser(role)
+			return ErrRoleNotGranted.Gen("`%s`@`%s` is not granted to `%s`@`%s`", roleName, roleHost, name, host)
+		}
+	}
+	return nil
+}
+
+func containsRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// loadGrantedRoles loads the roles granted to the user in the format of name@host.
+func loadGrantedRoles(ctx context.Context, name, host string) ([]string, error) {
+	sql := fmt.Sprintf(`SELECT FROM_USER, FROM_HOST FROM %s.%s WHERE TO_USER="%s" AND TO_HOST="%s";`,
+		mysql.SystemDB, mysql.RoleEdgeTable, name, host)
+	return loadRoles(ctx, sql)
+}
+
+// LoadDefaultRoles loads the default roles of the user in the format of name@host,
+// they are activated when the user logs in.
+func LoadDefaultRoles(ctx context.Context, name, host string) ([]string, error) {
+	sql := fmt.Sprintf(`SELECT DEFAULT_ROLE_USER, DEFAULT_ROLE_HOST FROM %s.%s WHERE USER="%s" AND HOST="%s";`,
+		mysql.SystemDB, mysql.DefaultRoleTable, name, host)
+	return loadRoles(ctx, sql)
+}
+
+func loadRoles(ctx context.Context, sql string) ([]string, error) {
+	rs, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer rs.Close()
+	var roles []string
+	for {
+		row, err := rs.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		roles = append(roles, row.Data[0].GetString()+"@"+row.Data[1].GetString())
+	}
+	return roles, nil
+}
+
 // parse user string into username and host
 // root@localhost -> root, localhost
 func parseUser(user string) (string, string) {
@@ -384,6 +804,24 @@ func parseUser(user string) (string, string) {
 	return strs[0], strs[1]
 }
 
+// currentUserAccount gets the account of the current user in mysql.user, the account for any host(%) is used
+// if there is no account for the host of the user, which is the same as the authentication.
+func currentUserAccount(ctx context.Context) (string, string, error) {
+	user := variable.GetSessionVars(ctx).User
+	if !strings.Contains(user, "@") {
+		return "", "", errors.Errorf("invalid current user: %s", user)
+	}
+	name, host := parseUser(user)
+	exists, err := userExists(ctx, name, host)
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+	if !exists {
+		host = "%"
+	}
+	return name, host, nil
+}
+
 func userExists(ctx context.Context, name string, host string) (bool, error) {
 	sql := fmt.Sprintf(`SELECT * FROM %s.%s WHERE User="%s" AND Host="%s";`, mysql.SystemDB, mysql.UserTable, name, host)
 	rs, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
@@ -399,11 +837,23 @@ func userExists(ctx context.Context, name string, host string) (bool, error) {
 }
 
 func (e *SimpleExec) executeSetPwd(s *ast.SetPwdStmt) error {
-	// TODO: If len(s.User) == 0, use CURRENT_USER()
-	userName, host := parseUser(s.User)
-	// Update mysql.user
-	sql := fmt.Sprintf(`UPDATE %s.%s SET password="%s" WHERE User="%s" AND Host="%s";`, mysql.SystemDB, mysql.UserTable, util.EncodePassword(s.Password), userName, host)
-	_, err := e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	var userName, host string
+	if len(s.User) == 0 {
+		var err error
+		if userName, host, err = currentUserAccount(e.ctx); err != nil {
+			return errors.Trace(err)
+		}
+	} else {
+		userName, host = parseUser(s.User)
+	}
+	col, pwd, err := encodePassword(e.ctx, userName, host, s.Password)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// Update mysql.user, the expired password is reset.
+	sql := fmt.Sprintf(`UPDATE %s.%s SET %s="%s", password_last_changed=NOW(), password_expired="N" WHERE User="%s" AND Host="%s";`,
+		mysql.SystemDB, mysql.UserTable, col, pwd, userName, host)
+	_, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
 	return errors.Trace(err)
 }
 
@@ -18,12 +18,14 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/plan/statistics"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
@@ -202,6 +204,13 @@ func (s *testSuite) TestCreateUser(c *C) {
 	dropUserSQL = `DROP USER IF EXISTS 'test1'@'localhost' ;`
 	tk.MustExec(dropUserSQL)
 
+	// Create user with resource limits, the later option overrides the earlier one.
+	tk.MustExec(`CREATE USER 'test1'@'localhost' WITH MAX_QUERIES_PER_HOUR 10 MAX_UPDATES_PER_HOUR 5
+		MAX_CONNECTIONS_PER_HOUR 3 MAX_USER_CONNECTIONS 2 MAX_QUERIES_PER_HOUR 20`)
+	result = tk.MustQuery(`SELECT max_questions, max_updates, max_connections, max_user_connections FROM mysql.User WHERE User="test1" and Host="localhost"`)
+	result.Check(testkit.Rows("20 5 3 2"))
+	tk.MustExec(dropUserSQL)
+
 	// Test drop user if exists.
 	createUserSQL = `CREATE USER 'test1'@'localhost', 'test3'@'localhost';`
 	tk.MustExec(createUserSQL)
@@ -226,6 +235,101 @@ func (s *testSuite) TestCreateUser(c *C) {
 	tk.MustExec(dropUserSQL)
 }
 
+func (s *testSuite) TestAlterUser(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec(`CREATE USER 'alter1'@'localhost' IDENTIFIED BY '123' PASSWORD EXPIRE INTERVAL 90 DAY ACCOUNT LOCK`)
+	result := tk.MustQuery(`SELECT password_lifetime, account_locked, password_expired FROM mysql.User WHERE User="alter1" and Host="localhost"`)
+	result.Check(testkit.Rows("90 Y N"))
+	result = tk.MustQuery(`SELECT password_last_changed IS NULL FROM mysql.User WHERE User="alter1" and Host="localhost"`)
+	result.Check(testkit.Rows("0"))
+
+	tk.MustExec(`ALTER USER 'alter1'@'localhost' IDENTIFIED BY '456' WITH MAX_QUERIES_PER_HOUR 10 ACCOUNT UNLOCK PASSWORD EXPIRE NEVER`)
+	result = tk.MustQuery(`SELECT Password, max_questions, password_lifetime, account_locked FROM mysql.User WHERE User="alter1" and Host="localhost"`)
+	result.Check(testkit.Rows(fmt.Sprintf("%v 10 0 N", []byte(util.EncodePassword("456")))))
+
+	tk.MustExec(`ALTER USER 'alter1'@'localhost' PASSWORD EXPIRE`)
+	result = tk.MustQuery(`SELECT password_expired FROM mysql.User WHERE User="alter1" and Host="localhost"`)
+	result.Check(testkit.Rows("Y"))
+	// Changing the password resets the expired password.
+	tk.MustExec(`ALTER USER 'alter1'@'localhost' IDENTIFIED BY '789' PASSWORD EXPIRE DEFAULT`)
+	result = tk.MustQuery(`SELECT password_expired, password_lifetime FROM mysql.User WHERE User="alter1" and Host="localhost"`)
+	result.Check(testkit.Rows("N <nil>"))
+
+	_, err := tk.Exec(`ALTER USER 'alter1'@'localhost', 'alter2'@'localhost' ACCOUNT LOCK`)
+	c.Check(err, NotNil)
+	tk.MustExec(`ALTER USER IF EXISTS 'alter1'@'localhost', 'alter2'@'localhost' ACCOUNT LOCK`)
+	result = tk.MustQuery(`SELECT account_locked FROM mysql.User WHERE User="alter1" and Host="localhost"`)
+	result.Check(testkit.Rows("Y"))
+	tk.MustExec(`DROP USER 'alter1'@'localhost'`)
+}
+
+func (s *testSuite) TestRenameUser(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec(`CREATE USER 'rename1'@'localhost' IDENTIFIED BY '123', 'rename2'@'localhost'`)
+	tk.MustExec(`GRANT SELECT ON test.* TO 'rename1'@'localhost'`)
+	tk.MustExec(`RENAME USER 'rename1'@'localhost' TO 'rename3'@'%'`)
+	tk.MustQuery(`SELECT User FROM mysql.User WHERE User="rename1"`).Check(nil)
+	result := tk.MustQuery(`SELECT Password FROM mysql.User WHERE User="rename3" and Host="%"`)
+	result.Check(testkit.Rows(fmt.Sprintf("%v", []byte(util.EncodePassword("123")))))
+	result = tk.MustQuery(`SELECT Select_priv FROM mysql.DB WHERE User="rename3" and Host="%"`)
+	result.Check(testkit.Rows("Y"))
+
+	// The new user must not exist.
+	_, err := tk.Exec(`RENAME USER 'rename3'@'%' TO 'rename2'@'localhost'`)
+	c.Check(err, NotNil)
+	// The old user must exist.
+	_, err = tk.Exec(`RENAME USER 'rename1'@'localhost' TO 'rename4'@'localhost'`)
+	c.Check(err, NotNil)
+	tk.MustExec(`DROP USER 'rename2'@'localhost', 'rename3'@'%'`)
+}
+
+func (s *testSuite) TestRoles(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec(`CREATE ROLE 'r1', 'r2'@'localhost'`)
+	result := tk.MustQuery(`SELECT account_locked, password_expired FROM mysql.User WHERE User="r1" and Host="%"`)
+	result.Check(testkit.Rows("Y Y"))
+	_, err := tk.Exec(`CREATE ROLE 'r1'`)
+	c.Check(err, NotNil)
+	tk.MustExec(`CREATE ROLE IF NOT EXISTS 'r1'`)
+
+	tk.MustExec(`CREATE USER 'u1'@'localhost', 'u2'@'localhost'`)
+	tk.MustExec(`GRANT 'r1', 'r2'@'localhost' TO 'u1'@'localhost', 'u2'@'localhost'`)
+	// Granting a role twice is fine.
+	tk.MustExec(`GRANT 'r1' TO 'u1'@'localhost'`)
+	tk.MustQuery(`SELECT COUNT(*) FROM mysql.role_edges`).Check(testkit.Rows("4"))
+	_, err = tk.Exec(`GRANT 'r3' TO 'u1'@'localhost'`)
+	c.Assert(terror.ErrorEqual(err, executor.ErrUnknownAuthID), IsTrue)
+	_, err = tk.Exec(`GRANT 'r1' TO 'u3'@'localhost'`)
+	c.Assert(terror.ErrorEqual(err, executor.ErrUnknownAuthID), IsTrue)
+
+	tk.MustExec(`SET DEFAULT ROLE ALL TO 'u1'@'localhost'`)
+	tk.MustQuery(`SELECT COUNT(*) FROM mysql.default_roles WHERE USER="u1"`).Check(testkit.Rows("2"))
+	tk.MustExec(`SET DEFAULT ROLE 'r1' TO 'u1'@'localhost', 'u2'@'localhost'`)
+	tk.MustQuery(`SELECT COUNT(*) FROM mysql.default_roles WHERE DEFAULT_ROLE_USER="r1"`).Check(testkit.Rows("2"))
+	tk.MustQuery(`SELECT COUNT(*) FROM mysql.default_roles`).Check(testkit.Rows("2"))
+	tk.MustExec(`SET DEFAULT ROLE NONE TO 'u2'@'localhost'`)
+	tk.MustQuery(`SELECT COUNT(*) FROM mysql.default_roles`).Check(testkit.Rows("1"))
+	tk.MustExec(`CREATE ROLE 'r3'`)
+	_, err = tk.Exec(`SET DEFAULT ROLE 'r3' TO 'u1'@'localhost'`)
+	c.Assert(terror.ErrorEqual(err, executor.ErrRoleNotGranted), IsTrue)
+
+	// The roles are renamed and dropped with the accounts.
+	tk.MustExec(`RENAME USER 'r1'@'%' TO 'r4'@'%'`)
+	tk.MustQuery(`SELECT COUNT(*) FROM mysql.role_edges WHERE FROM_USER="r4"`).Check(testkit.Rows("2"))
+	tk.MustQuery(`SELECT COUNT(*) FROM mysql.default_roles WHERE DEFAULT_ROLE_USER="r4"`).Check(testkit.Rows("1"))
+	tk.MustExec(`DROP ROLE 'r4', 'r3'`)
+	tk.MustQuery(`SELECT COUNT(*) FROM mysql.role_edges`).Check(testkit.Rows("2"))
+	tk.MustQuery(`SELECT COUNT(*) FROM mysql.default_roles`).Check(testkit.Rows("0"))
+	_, err = tk.Exec(`DROP ROLE 'r4'`)
+	c.Check(err, NotNil)
+	tk.MustExec(`DROP USER 'u1'@'localhost', 'u2'@'localhost'`)
+	tk.MustQuery(`SELECT COUNT(*) FROM mysql.role_edges`).Check(testkit.Rows("0"))
+	tk.MustExec(`DROP ROLE IF EXISTS 'r2'@'localhost', 'r4'`)
+}
+
 func (s *testSuite) TestSetPwd(c *C) {
 	defer testleak.AfterTest(c)()
 	tk := testkit.NewTestKit(c, s.store)
@@ -243,6 +347,34 @@ func (s *testSuite) TestSetPwd(c *C) {
 	result.Check(testkit.Rows(rowStr))
 }
 
+func (s *testSuite) TestAuthPlugin(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec(`CREATE USER 'sha2'@'localhost' IDENTIFIED WITH caching_sha2_password BY '123', 'socket'@'localhost' IDENTIFIED WITH auth_socket AS 'root'`)
+	tk.MustQuery(`SELECT Password, plugin FROM mysql.User WHERE User="sha2" and Host="localhost"`).Check(testkit.Rows(fmt.Sprintf("%v %v", []byte(""), []byte(mysql.AuthCachingSha2Password))))
+	plugin, credential, err := tk.Se.GetAuthInfo("sha2@localhost")
+	c.Assert(err, IsNil)
+	c.Assert(plugin, Equals, mysql.AuthCachingSha2Password)
+	c.Assert(util.CheckSha2Password(credential, []byte("123")), IsTrue)
+
+	tk.MustExec(`SET PASSWORD FOR 'sha2'@'localhost' = '456'`)
+	_, credential, err = tk.Se.GetAuthInfo("sha2@localhost")
+	c.Assert(err, IsNil)
+	c.Assert(util.CheckSha2Password(credential, []byte("456")), IsTrue)
+
+	plugin, credential, err = tk.Se.GetAuthInfo("socket@localhost")
+	c.Assert(err, IsNil)
+	c.Assert(plugin, Equals, mysql.AuthSocket)
+	c.Assert(credential, Equals, "root")
+
+	// A user of mysql_native_password can not be verified by the scramble of the other plugins.
+	c.Assert(tk.Se.Auth("sha2@localhost", nil, nil), IsFalse)
+
+	_, err = tk.Exec(`CREATE USER 'unknown'@'localhost' IDENTIFIED WITH unknown_plugin`)
+	c.Assert(terror.ErrorEqual(err, executor.ErrPluginIsNotLoaded), IsTrue)
+	tk.MustExec(`DROP USER 'sha2'@'localhost', 'socket'@'localhost'`)
+}
+
 func (s *testSuite) TestAnalyzeTable(c *C) {
 	defer testleak.AfterTest(c)()
 	tk := testkit.NewTestKit(c, s.store)
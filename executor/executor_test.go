@@ -16,6 +16,7 @@ package executor_test
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 	"testing"
@@ -26,13 +27,16 @@ import (
 	"github.com/pingcap/tidb"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/domain"
+	"github.com/pingcap/tidb/evaluator"
 	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/inspectkv"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
@@ -107,6 +111,27 @@ func (s *testSuite) TestAdmin(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(row, IsNil)
 
+	// show DDL jobs test
+	r, err = tk.Exec("admin show ddl jobs")
+	c.Assert(err, IsNil)
+	row, err = r.Next()
+	c.Assert(err, IsNil)
+	c.Assert(row.Data, HasLen, 9)
+	historyJobs, err := inspectkv.GetHistoryDDLJobs(txn)
+	c.Assert(err, IsNil)
+	c.Assert(len(historyJobs), Greater, 1)
+	c.Assert(row.Data[0].GetInt64(), Equals, historyJobs[0].ID)
+	c.Assert(row.Data[1].GetString(), Equals, "test")
+	c.Assert(row.Data[2].GetString(), Equals, "admin_test")
+	c.Assert(row.Data[3].GetString(), Equals, "create table")
+	c.Assert(row.Data[5].GetString(), Equals, "done")
+	c.Assert(row.Data[7].IsNull(), IsFalse)
+	c.Assert(row.Data[8].IsNull(), IsFalse)
+
+	// cancel DDL jobs test
+	tk.MustQuery(fmt.Sprintf("admin cancel ddl jobs %d", historyJobs[0].ID)).Check(testkit.Rows(
+		fmt.Sprintf("%d error: [inspectkv:4]DDL Job:%d not found", historyJobs[0].ID, historyJobs[0].ID)))
+
 	// check table test
 	tk.MustExec("create table admin_test1 (c1 int, c2 int default 1, index (c1))")
 	tk.MustExec("insert admin_test1 (c1) values (21),(22)")
@@ -132,6 +157,71 @@ func (s *testSuite) TestAdmin(c *C) {
 	c.Assert(err, NotNil)
 }
 
+func (s *testSuite) TestAdminIndexRepair(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists admin_repair")
+	tk.MustExec("create table admin_repair (a int primary key, b int, index idx(b))")
+	tk.MustExec("insert admin_repair values (1, 10), (2, 20), (3, 30), (4, 40), (5, 50)")
+	tk.MustQuery("admin check index admin_repair idx").Check(testkit.Rows())
+
+	origBatchSize := inspectkv.AdminBatchSize
+	inspectkv.AdminBatchSize = 2
+	defer func() { inspectkv.AdminBatchSize = origBatchSize }()
+
+	// Remove the index entries of two rows and add two dangling entries.
+	domain, err := domain.NewDomain(s.store, 1*time.Second)
+	c.Assert(err, IsNil)
+	tb, err := domain.InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("admin_repair"))
+	c.Assert(err, IsNil)
+	c.Assert(tb.Indices(), HasLen, 1)
+	idx := tb.Indices()[0]
+	txn, err := s.store.Begin()
+	c.Assert(err, IsNil)
+	c.Assert(idx.Delete(txn, types.MakeDatums(int64(20)), 2), IsNil)
+	c.Assert(idx.Delete(txn, types.MakeDatums(int64(30)), 3), IsNil)
+	_, err = idx.Create(txn, types.MakeDatums(int64(99)), 9)
+	c.Assert(err, IsNil)
+	_, err = idx.Create(txn, types.MakeDatums(int64(11)), 1)
+	c.Assert(err, IsNil)
+	_, err = idx.Create(txn, types.MakeDatums(int64(40)), 5)
+	c.Assert(err, IsNil)
+	c.Assert(txn.Commit(), IsNil)
+
+	_, err = tk.Exec("admin check table admin_repair")
+	c.Assert(err, NotNil)
+	tk.MustQuery("admin check index admin_repair idx").Check(testkit.Rows(
+		"<nil> <nil> 2 (20)",
+		"<nil> <nil> 3 (30)",
+		"1 (11) 1 (10)",
+		"5 (40) 5 (50)",
+		"9 (99) <nil> <nil>",
+	))
+	// Only the index entries that have the same values as the rows in the ranges are checked.
+	tk.MustQuery("admin check index admin_repair idx (3, 5), (-10, 1)").Check(testkit.Rows(
+		"<nil> <nil> 3 (30)",
+		"5 (40) 5 (50)",
+	))
+	rs, err := tk.Exec("admin check index admin_repair idx_error")
+	c.Assert(err, IsNil)
+	_, err = rs.Next()
+	c.Assert(terror.ErrorEqual(err, plan.ErrKeyDoesNotExist), IsTrue)
+
+	tk.MustQuery("admin recover index admin_repair idx").Check(testkit.Rows("2 5"))
+	tk.MustQuery("admin check index admin_repair idx").Check(testkit.Rows(
+		"1 (11) 1 (10)",
+		"5 (40) 5 (50)",
+		"9 (99) <nil> <nil>",
+	))
+	tk.MustQuery("admin recover index admin_repair idx").Check(testkit.Rows("0 5"))
+	tk.MustQuery("admin cleanup index test.admin_repair idx").Check(testkit.Rows("3"))
+	tk.MustQuery("admin cleanup index admin_repair idx").Check(testkit.Rows("0"))
+	tk.MustQuery("admin check index admin_repair idx").Check(testkit.Rows())
+	tk.MustExec("admin check table admin_repair")
+	tk.MustQuery("select a from admin_repair use index(idx) where b > 0").Check(testkit.Rows("1", "2", "3", "4", "5"))
+}
+
 func (s *testSuite) fillData(tk *testkit.TestKit, table string) {
 	tk.MustExec("use test")
 	tk.MustExec(fmt.Sprintf("create table %s(id int not null default 1, name varchar(255), PRIMARY KEY(id));", table))
@@ -456,6 +546,85 @@ func (s *testSuite) TestUnion(c *C) {
 	tk.MustExec("commit")
 }
 
+func (s *testSuite) TestCTE(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists emp")
+	tk.MustExec("create table emp (id int, manager_id int)")
+	tk.MustExec("insert emp values (1, null), (2, 1), (3, 1), (4, 2), (5, 4), (6, 7)")
+
+	// Inlined and materialized common table expressions.
+	tk.MustQuery("with c as (select id, manager_id from emp where manager_id = 1) select id from c order by id").Check(testkit.Rows("2", "3"))
+	tk.MustQuery("with c (a, b) as (select id, manager_id from emp) select b from c where a > 4 order by a").Check(testkit.Rows("4", "7"))
+	tk.MustQuery(`with c as (select id, manager_id from emp), d as (select id from c where manager_id = 1)
+		select c.id, d.id from c join d on c.manager_id = d.id order by c.id`).Check(testkit.Rows("4 2"))
+	tk.MustQuery("with c as (select count(*) as n from emp) select a.n + b.n from c a, c b").Check(testkit.Rows("12"))
+	tk.MustQuery("select * from emp where id in (with c as (select 2 as x union select 3) select x from c) order by id").Check(testkit.Rows("2 1", "3 1"))
+	tk.MustQuery("with c as (select 1 as x) select * from c union all select x + 1 from c").Check(testkit.Rows("1", "2"))
+
+	// Recursive common table expressions.
+	tk.MustQuery("with recursive c (n) as (select 1 union all select n + 1 from c where n < 5) select * from c").Check(testkit.Rows("1", "2", "3", "4", "5"))
+	tk.MustQuery(`with recursive tree as (
+			select id, 0 as depth from emp where manager_id is null
+			union all
+			select e.id, tree.depth + 1 from emp e join tree on e.manager_id = tree.id)
+		select id, depth from tree order by depth, id`).Check(testkit.Rows("1 0", "2 1", "3 1", "4 2", "5 3"))
+	tk.MustQuery(`with recursive chain as (
+			select id, manager_id from emp where id = 5
+			union all
+			select emp.id, emp.manager_id from chain, emp where emp.id = chain.manager_id)
+		select count(*), max(id) from chain`).Check(testkit.Rows("4 5"))
+	tk.MustQuery("with recursive c (n) as (select 1 union select n % 3 + 1 from c) select * from c order by n").Check(testkit.Rows("1", "2", "3"))
+	tk.MustQuery("with recursive c (n) as (select 1 union all select n + 1 from c where n < 3) select a.n, b.n from c a join c b on a.n = b.n - 1 order by a.n").Check(testkit.Rows("1 2", "2 3"))
+
+	rs, err := tk.Exec("with recursive c (n) as (select 1 union all select n + 1 from c) select * from c")
+	c.Assert(err, IsNil)
+	_, err = tidb.GetRows(rs)
+	c.Check(terror.ErrorEqual(err, executor.ErrCTEMaxRecursionDepth), IsTrue, Commentf("err %v", err))
+	rs.Close()
+	tk.MustExec("set @@cte_max_recursion_depth = 10")
+	rs, err = tk.Exec("with recursive c (n) as (select 1 union all select n + 1 from c where n < 20) select * from c")
+	c.Assert(err, IsNil)
+	_, err = tidb.GetRows(rs)
+	c.Check(terror.ErrorEqual(err, executor.ErrCTEMaxRecursionDepth), IsTrue, Commentf("err %v", err))
+	rs.Close()
+	tk.MustQuery("with recursive c (n) as (select 1 union all select n + 1 from c where n < 10) select count(*) from c").Check(testkit.Rows("10"))
+
+	// The evaluation fails once the rows exceed the memory quota.
+	tk.MustExec("set @@cte_max_recursion_depth = 1000")
+	tk.MustExec("set @@tidb_mem_quota_query = 1024")
+	tk.MustExec("set @@tidb_mem_oom_action = 'cancel'")
+	rs, err = tk.Exec("with recursive c (n) as (select 1 union all select n + 1 from c where n < 500) select count(*) from c")
+	c.Assert(err, IsNil)
+	_, err = tidb.GetRows(rs)
+	c.Check(executor.ErrMemExceedQuota.Equal(err), IsTrue, Commentf("err %v", err))
+	rs.Close()
+	tk.MustExec("set @@tidb_mem_quota_query = 0")
+	tk.MustExec("set @@tidb_mem_oom_action = 'log'")
+	tk.MustExec("set @@tidb_mem_quota_session = 1024")
+	rs, err = tk.Exec("with recursive c (n) as (select 1 union all select n + 1 from c where n < 500) select count(*) from c")
+	c.Assert(err, IsNil)
+	_, err = tidb.GetRows(rs)
+	c.Check(executor.ErrMemExceedQuota.Equal(err), IsTrue, Commentf("err %v", err))
+	rs.Close()
+	tk.MustExec("set @@tidb_mem_quota_session = 0")
+	tk.MustQuery("with recursive c (n) as (select 1 union all select n + 1 from c where n < 500) select count(*) from c").Check(testkit.Rows("500"))
+
+	_, err = tk.Exec("with c (a, b) as (select 1) select * from c")
+	c.Check(terror.ErrorEqual(err, plan.ErrViewWrongList), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("with c as (select 1), c as (select 2) select * from c")
+	c.Check(terror.ErrorEqual(err, plan.ErrNonUniqTable), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("with recursive c as (select * from c) select * from c")
+	c.Check(terror.ErrorEqual(err, plan.ErrCTERecursiveRequiresUnion), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("with recursive c (n) as (select n from c union all select 1) select * from c")
+	c.Check(terror.ErrorEqual(err, plan.ErrCTERecursiveRequiresNonrecursiveFirst), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("with recursive c (n) as (select 1 union all select count(*) from c) select * from c")
+	c.Check(terror.ErrorEqual(err, plan.ErrCTERecursiveForbidsAggregation), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("with recursive c (n) as (select 1 union all select a.n + 1 from c a, c b where a.n < 3) select * from c")
+	c.Check(terror.ErrorEqual(err, plan.ErrCTERecursiveRequiresSingleReference), IsTrue, Commentf("err %v", err))
+}
+
 func (s *testSuite) TestTablePKisHandleScan(c *C) {
 	defer testleak.AfterTest(c)()
 	tk := testkit.NewTestKit(c, s.store)
@@ -695,6 +864,79 @@ AND b44=a42`)
 	result.Check(testkit.Rows("7 7 7 7 7 7 7 7 7 7 7 7 7 7 7 7 7 7 7 7 7"))
 }
 
+func (s *testSuite) TestJoinHints(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1(a int primary key, b int, c int, index idx_b(b))")
+	tk.MustExec("create table t2(a int primary key, b int, c int, index idx_b(b))")
+	tk.MustExec("insert into t1 values(1,1,1),(2,2,2),(3,2,3),(4,null,4),(5,5,5)")
+	tk.MustExec("insert into t2 values(1,2,1),(2,2,2),(3,3,3),(4,null,4),(5,1,5),(6,6,6)")
+
+	joinHints := []string{"", "/*+ HASH_JOIN(t1) */", "/*+ HASH_JOIN(t2) */", "/*+ MERGE_JOIN(t1, t2) */", "/*+ INL_JOIN(t1) */", "/*+ INL_JOIN(t2) */"}
+	cases := []struct {
+		sql    string
+		result [][]interface{}
+	}{
+		{
+			"select %s t1.a, t2.a from t1 join t2 on t1.b = t2.b order by t1.a, t2.a",
+			testkit.Rows("1 5", "2 1", "2 2", "3 1", "3 2"),
+		},
+		{
+			"select %s t1.a, t2.a from t1 left join t2 on t1.b = t2.b order by t1.a, t2.a",
+			testkit.Rows("1 5", "2 1", "2 2", "3 1", "3 2", "4 <nil>", "5 <nil>"),
+		},
+		{
+			"select %s t1.a, t2.a from t1 right join t2 on t1.b = t2.b order by t2.a, t1.a",
+			testkit.Rows("2 1", "3 1", "2 2", "3 2", "<nil> 3", "<nil> 4", "1 5", "<nil> 6"),
+		},
+		{
+			"select %s t1.a, t2.a from t1 join t2 on t1.a = t2.b and t1.c > 1 and t2.c < 5 order by t1.a, t2.a",
+			testkit.Rows("2 1", "2 2", "3 3"),
+		},
+		{
+			"select %s t1.a, t2.a from t1 left join t2 on t1.a = t2.b and t2.c > 1 and t1.c + t2.c > 4 order by t1.a, t2.a",
+			testkit.Rows("1 5", "2 <nil>", "3 3", "4 <nil>", "5 <nil>"),
+		},
+	}
+	for _, ca := range cases {
+		for _, hint := range joinHints {
+			tk.MustQuery(fmt.Sprintf(ca.sql, hint)).Check(ca.result)
+		}
+	}
+
+	// The look up values are converted to the types of the inner index columns.
+	tk.MustExec("drop table if exists t3")
+	tk.MustExec("create table t3(a int primary key, b varchar(10), c double, index idx_b(b), index idx_c(c))")
+	tk.MustExec("insert into t3 values(1,'2',2),(2,'5',1.5),(3,'x',5)")
+	for _, hint := range []string{"", "/*+ INL_JOIN(t1) */", "/*+ INL_JOIN(t3) */"} {
+		tk.MustQuery(fmt.Sprintf("select %s t1.a, t3.a from t1 join t3 on t1.b = t3.b order by t1.a", hint)).Check(testkit.Rows("2 1", "3 1", "5 2"))
+		tk.MustQuery(fmt.Sprintf("select %s t1.a, t3.a from t1 join t3 on t1.b = t3.c order by t1.a", hint)).Check(testkit.Rows("2 1", "3 1", "5 3"))
+	}
+
+	// The inner rows in a dirty transaction are read by a hash join.
+	tk.MustExec("begin")
+	tk.MustExec("insert into t2 values(7,5,7)")
+	tk.MustQuery("select /*+ INL_JOIN(t2) */ t1.a, t2.a from t1 join t2 on t1.b = t2.b where t1.a = 5").Check(testkit.Rows("5 7"))
+	tk.MustExec("rollback")
+
+	// The unknown or malformed hints are ignored with warnings.
+	tk.MustQuery("select /*+ NO_SUCH_HINT(t1), INL_JOIN() */ t1.a from t1 where t1.a = 5").Check(testkit.Rows("5"))
+	tk.MustQuery("show warnings").Check(testkit.Rows(
+		"Warning 1064 Optimizer hint NO_SUCH_HINT is not supported, it is ignored",
+		"Warning 1064 Optimizer hint INL_JOIN requires table names, it is ignored"))
+	tk.MustQuery("select 1 /*+ NO_SUCH_HINT */").Check(testkit.Rows("1"))
+	tk.MustQuery("show warnings").Check(testkit.Rows())
+
+	tk.MustQuery("select t1.a from t1 force index(idx_b) where t1.b = 2 order by t1.a").Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select t1.a from t1 use index(primary) where t1.a > 4").Check(testkit.Rows("5"))
+	_, err := tk.Exec("select * from t1 use index(idx_c)")
+	c.Assert(terror.ErrorEqual(err, plan.ErrKeyDoesNotExist), IsTrue, Commentf("err %v", err))
+	// Unknown hints are ignored.
+	tk.MustQuery("select /*+ NO_SUCH_HINT(t1) */ a from t1 where a = 1").Check(testkit.Rows("1"))
+}
+
 func (s *testSuite) TestIndexScan(c *C) {
 	defer testleak.AfterTest(c)()
 	tk := testkit.NewTestKit(c, s.store)
@@ -1070,6 +1312,99 @@ func (s *testSuite) TestSQLMode(c *C) {
 	tk.MustExec("insert t values ()")
 	tk.MustExec("insert t values (1000)")
 	tk.MustQuery("select * from t").Check(testkit.Rows("0", "127"))
+
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b int)")
+	tk.MustExec("insert t values (1, 2), (1, 3)")
+	tk.MustExec("set sql_mode = 'ONLY_FULL_GROUP_BY'")
+	_, err = tk.Exec("select a, b from t group by a")
+	c.Check(terror.ErrorEqual(err, plan.ErrWrongFieldWithGroup), IsTrue)
+	_, err = tk.Exec("select a, count(*) from t")
+	c.Check(terror.ErrorEqual(err, plan.ErrMixOfGroupFuncAndFields), IsTrue)
+	tk.MustQuery("select a, max(b) from t group by a").Check(testkit.Rows("1 3"))
+	tk.MustQuery("select a + 1 as c, sum(b) from t group by c").Check(testkit.Rows("2 5"))
+	tk.MustQuery("select t.a, count(*) from t group by 1").Check(testkit.Rows("1 2"))
+	tk.MustExec("set sql_mode = ''")
+	tk.MustQuery("select a, b from t group by a").Check(testkit.Rows("1 2"))
+
+	tk.MustExec("set sql_mode = 'PIPES_AS_CONCAT,ANSI_QUOTES,HIGH_NOT_PRECEDENCE'")
+	tk.MustQuery(`select "a" || 'x', not 1 between -5 and 5 from t where "b" = 2`).Check(testkit.Rows("1x 1"))
+	// The internal SQL of the statements isn't parsed with the SQL mode of the session.
+	tk.MustExec("create user 'ansi_quotes'@'localhost' identified by 'secret', 'ansi_quotes'@'%'")
+	tk.MustExec("set password for 'ansi_quotes'@'%' = 'secret'")
+	tk.MustQuery("select count(*) from mysql.user where User = 'ansi_quotes'").Check(testkit.Rows("2"))
+	tk.MustExec("drop user 'ansi_quotes'@'localhost', 'ansi_quotes'@'%'")
+	tk.MustExec("set global tidb_load_data_batch_size = 100")
+	tk.MustQuery("select @@global.tidb_load_data_batch_size").Check(testkit.Rows("100"))
+	tk.MustExec(fmt.Sprintf("set global tidb_load_data_batch_size = %d", variable.DefLoadDataBatchSize))
+	tk.MustQuery("show global variables like 'tidb_load_data_batch_size'").Check(testkit.Rows(fmt.Sprintf("tidb_load_data_batch_size %d", variable.DefLoadDataBatchSize)))
+	tk.MustExec("set sql_mode = 'NO_BACKSLASH_ESCAPES'")
+	tk.MustQuery(`select 'a\\b'`).Check(testkit.Rows(`a\\b`))
+	tk.MustExec("set sql_mode = ''")
+	tk.MustQuery(`select 1 || 0, not 1 between -5 and 5`).Check(testkit.Rows("1 0"))
+
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int auto_increment primary key, d datetime, i int)")
+	tk.MustExec("set sql_mode = 'NO_AUTO_VALUE_ON_ZERO'")
+	tk.MustExec("insert t (id) values (0)")
+	tk.MustExec("insert t (id) values (null)")
+	tk.MustQuery("select id from t").Check(testkit.Rows("0", "1"))
+	tk.MustExec("set sql_mode = ''")
+	tk.MustExec("insert t (id) values (0)")
+	tk.MustQuery("select id from t where id > 1").Check(testkit.Rows("2"))
+
+	tk.MustExec("set sql_mode = 'STRICT_TRANS_TABLES,NO_ZERO_DATE,ERROR_FOR_DIVISION_BY_ZERO'")
+	_, err = tk.Exec("insert t (d) values ('0000-00-00 00:00:00')")
+	c.Check(terror.ErrorEqual(err, types.ErrTruncatedWrongVal), IsTrue)
+	_, err = tk.Exec("insert t (i) values (1 / 0)")
+	c.Check(terror.ErrorEqual(err, evaluator.ErrDivisionByZero), IsTrue)
+	tk.MustQuery("select id / 0 from t where id = 1").Check(testkit.Rows("<nil>"))
+	tk.MustQuery("show warnings").Check(testkit.Rows("Warning 1365 Division by 0"))
+	tk.MustExec("set sql_mode = 'NO_ZERO_DATE'")
+	tk.MustExec("insert t (d, i) values ('0000-00-00 00:00:00', 1 / 0)")
+	tk.MustExec("set sql_mode = ''")
+	tk.MustQuery("select id / 0 from t where id = 1").Check(testkit.Rows("<nil>"))
+	tk.MustQuery("show warnings").Check(testkit.Rows())
+	_, err = tk.Exec("set sql_mode = 'NO_SUCH_MODE'")
+	c.Check(err, NotNil)
+}
+
+func (s *testSuite) TestWarnings(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int primary key, b double, c varchar(3))")
+	tk.MustExec("set sql_mode = ''")
+	tk.MustExec("insert t values (1, '1.5a', 'abcdef')")
+	warns := testkit.Rows("Warning 1292 Truncated incorrect DOUBLE value: '1.5a'", "Warning 1406 Data Too Long, field len 3, data len 6")
+	tk.MustQuery("show warnings").Check(warns)
+	tk.MustQuery("select @@warning_count, @@error_count").Check(testkit.Rows("2 0"))
+	tk.MustQuery("show warnings").Check(warns)
+	tk.MustQuery("show errors").Check(testkit.Rows())
+	tk.MustQuery("select a, b from t where c = 'abc'").Check(testkit.Rows("1 1.5"))
+	tk.MustQuery("show warnings").Check(testkit.Rows())
+
+	tk.MustExec("insert ignore t values (1, 1, 'a')")
+	tk.MustQuery("show warnings").Check(testkit.Rows("Warning 1062 Duplicate entry '1' for key 'PRIMARY'"))
+	tk.MustQuery("select c + 1 from t").Check(testkit.Rows("1"))
+	tk.MustQuery("show warnings").Check(testkit.Rows("Warning 1292 Truncated incorrect DOUBLE value: 'abc'"))
+
+	_, err := tk.Exec("select * from t_not_exists")
+	c.Assert(err, NotNil)
+	tk.MustQuery("show errors").Check(testkit.Rows("Error 1146 table test.t_not_exists does not exist"))
+	tk.MustQuery("select @@warning_count, @@error_count").Check(testkit.Rows("1 1"))
+
+	tk.MustExec("set max_error_count = 1")
+	tk.MustExec("insert t values (2, '1.5a', 'abcdef')")
+	tk.MustQuery("select @@warning_count").Check(testkit.Rows("2"))
+	tk.MustQuery("show warnings").Check(testkit.Rows("Warning 1292 Truncated incorrect DOUBLE value: '1.5a'"))
+	_, err = tk.Exec("set warning_count = 1")
+	c.Assert(err, NotNil)
+
+	tk.MustExec("set sql_mode = 'STRICT_TRANS_TABLES'")
+	_, err = tk.Exec("insert t values (3, '1.5a', 'a')")
+	c.Assert(terror.ErrorEqual(err, types.ErrTruncatedWrongVal), IsTrue)
 }
 
 func (s *testSuite) TestNewSubquery(c *C) {
@@ -1281,3 +1616,107 @@ func (s *testSuite) TestHistoryRead(c *C) {
 	tk.MustExec("set @@tidb_snapshot = ''")
 	tk.MustQuery("select * from history_read order by a").Check(testkit.Rows("2 <nil>", "4 <nil>", "8 8", "9 9"))
 }
+
+func (s *testSuite) TestMemQuota(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists mem_quota")
+	tk.MustExec("create table mem_quota (a int, b varchar(20), c datetime, d decimal(10, 2))")
+	var sortedRows, topnRows, ascRows []string
+	for i := 0; i < 100; i++ {
+		a := (i * 37) % 100
+		tk.MustExec(fmt.Sprintf("insert mem_quota values (%d, 'str%d', '2016-10-%02d 10:00:00', %d.5)", a, a%7, a%28+1, a))
+		ascRows = append(ascRows, fmt.Sprint(i))
+	}
+	for i := 99; i >= 0; i-- {
+		row := fmt.Sprintf("%d %v 2016-10-%02d 10:00:00 %d.50", i, []byte(fmt.Sprintf("str%d", i%7)), i%28+1, i)
+		sortedRows = append(sortedRows, row)
+		if i < 90 && i >= 80 {
+			topnRows = append(topnRows, row)
+		}
+	}
+	// Sort and TopN spill to temporary files, the sorted runs are merged in several passes.
+	spillDir, err := ioutil.TempDir("", "tidb-spill")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(spillDir)
+	oldSpillDir, oldFanIn, oldMinRunSize := executor.SpillDir, executor.SortMergeFanIn, executor.MinSortRunSize
+	executor.SpillDir, executor.SortMergeFanIn, executor.MinSortRunSize = spillDir, 2, 0
+	defer func() {
+		executor.SpillDir, executor.SortMergeFanIn, executor.MinSortRunSize = oldSpillDir, oldFanIn, oldMinRunSize
+	}()
+	tk.MustExec("set @@tidb_mem_quota_session = 1024")
+	tk.MustQuery("select * from mem_quota order by a desc").Check(testkit.Rows(sortedRows...))
+	tk.MustQuery("select * from mem_quota order by a desc limit 10, 10").Check(testkit.Rows(topnRows...))
+	tk.MustQuery("select * from mem_quota order by a desc limit 10, 10").Check(testkit.Rows(topnRows...))
+	tk.MustExec("update mem_quota set b = 'x' order by a limit 50")
+	tk.MustQuery("select count(*) from mem_quota where b = 'x' and a < 50").Check(testkit.Rows("50"))
+
+	// Hash aggregation and hash join return errors.
+	for _, sql := range []string{
+		"select a, count(*) from mem_quota group by a",
+		"select * from mem_quota t1 join mem_quota t2 on t1.a = t2.a",
+	} {
+		rs, err := tk.Exec(sql)
+		c.Assert(err, IsNil)
+		_, err = tidb.GetRows(rs)
+		c.Assert(executor.ErrMemExceedQuota.Equal(err), IsTrue, Commentf("sql: %s", sql))
+		rs.Close()
+	}
+
+	tk.MustExec("set @@tidb_mem_quota_session = 0")
+	tk.MustQuery("select count(*) from (select a, count(*) from mem_quota group by a) t").Check(testkit.Rows("100"))
+	tk.MustQuery("select count(*) from mem_quota t1 join mem_quota t2 on t1.a = t2.a").Check(testkit.Rows("100"))
+	// The executors release their memory from the session when they are closed.
+	c.Assert(variable.GetSessionVars(tk.Se.(context.Context)).MemTracker.BytesConsumed(), Equals, int64(0))
+	// The spill files are removed.
+	files, err := ioutil.ReadDir(spillDir)
+	c.Assert(err, IsNil)
+	c.Assert(files, HasLen, 0)
+
+	// The rows below the minimum run size are not spilled for the session quota, spilling to a missing directory fails.
+	executor.SpillDir, executor.MinSortRunSize = spillDir+"/missing", 1<<20
+	tk.MustExec("set @@tidb_mem_quota_session = 1024")
+	tk.MustQuery("select a from mem_quota order by a desc limit 3").Check(testkit.Rows("99", "98", "97"))
+	tk.MustQuery("select a from mem_quota order by a").Check(testkit.Rows(ascRows...))
+	tk.MustExec("set @@tidb_mem_quota_session = 0")
+}
+
+func (s *testSuite) TestMemQuotaQuery(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists mem_query")
+	tk.MustExec("create table mem_query (a int, b varchar(20))")
+	for i := 0; i < 100; i++ {
+		tk.MustExec(fmt.Sprintf("insert mem_query values (%d, 'str%d')", i, i))
+	}
+
+	// The log action only logs the statement.
+	tk.MustExec("set @@tidb_mem_quota_query = 1024")
+	tk.MustQuery("select count(*) from (select a from mem_query order by b) t").Check(testkit.Rows("100"))
+
+	// The cancel action cancels the statement, but Sort and TopN spill before the statement exceeds the quota.
+	tk.MustExec("set @@tidb_mem_oom_action = 'cancel'")
+	tk.MustQuery("select count(*) from (select a from mem_query order by b) t").Check(testkit.Rows("100"))
+	tk.MustQuery("select a from mem_query order by a desc limit 95, 10").Check(testkit.Rows("4", "3", "2", "1", "0"))
+	for _, sql := range []string{
+		"select a, count(*) from mem_query group by a",
+		"select * from mem_query t1 join mem_query t2 on t1.a = t2.a",
+	} {
+		rs, err := tk.Exec(sql)
+		c.Assert(err, IsNil)
+		_, err = tidb.GetRows(rs)
+		c.Assert(executor.ErrMemExceedQuota.Equal(err), IsTrue, Commentf("sql: %s", sql))
+		rs.Close()
+	}
+	// The growth of the transaction buffer is tracked.
+	tk.MustExec("begin")
+	_, err := tk.Exec("insert mem_query select * from mem_query")
+	c.Assert(executor.ErrMemExceedQuota.Equal(err), IsTrue)
+	tk.MustExec("rollback")
+
+	tk.MustExec("set @@tidb_mem_quota_query = 0")
+	tk.MustQuery("select count(*) from (select a, count(*) from mem_query group by a) t").Check(testkit.Rows("100"))
+	tk.MustExec("set @@tidb_mem_oom_action = 'log'")
+}
@@ -15,16 +15,19 @@ package executor
 
 import (
 	"bytes"
+	"io"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/juju/errors"
-	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/evaluator"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/autocommit"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/terror"
@@ -277,11 +280,13 @@ func (e *DeleteExec) Close() error {
 }
 
 // NewLoadDataInfo returns a LoadDataInfo structure, and it's only used for tests now.
+// The errors are ignored as LOAD DATA LOCAL does.
 func NewLoadDataInfo(row []types.Datum, ctx context.Context, tbl table.Table) *LoadDataInfo {
 	return &LoadDataInfo{
 		row:       row,
 		insertVal: &InsertValues{ctx: ctx, Table: tbl},
 		Table:     tbl,
+		ignoreErr: true,
 	}
 }
 
@@ -289,11 +294,19 @@ func NewLoadDataInfo(row []types.Datum, ctx context.Context, tbl table.Table) *L
 type LoadDataInfo struct {
 	row       []types.Datum
 	insertVal *InsertValues
-
-	Path       string
-	Table      table.Table
-	FieldsInfo *ast.FieldsClause
-	LinesInfo  *ast.LinesClause
+	// ignoreErr indicates the rows that fail to insert are skipped and reported as warnings.
+	ignoreErr bool
+	// batchRows is the number of rows inserted in the current transaction.
+	batchRows int
+
+	Path        string
+	OnDuplicate ast.OnDuplicateKeyHandlingType
+	Table       table.Table
+	FieldsInfo  *ast.FieldsClause
+	LinesInfo   *ast.LinesClause
+	// BatchSize is the number of rows committed in a transaction, zero means the rows are committed
+	// with the statement.
+	BatchSize int
 }
 
 // getValidData returns prevData and curData that starts from starting symbol.
@@ -396,6 +409,16 @@ func (e *LoadDataInfo) getLine(prevData, curData []byte) ([]byte, []byte, bool)
 // If it has the rest of data isn't completed the processing, then is returns without completed data.
 // If prevData isn't nil and curData is nil, there are no other data to deal with and the isEOF is true.
 func (e *LoadDataInfo) InsertData(prevData, curData []byte) ([]byte, error) {
+	rows, curData := e.getRows(prevData, curData)
+	// The rows are sent by the client, so they can't be inserted again by retrying the statement.
+	e.insertVal.ctx.SetValue(LoadDataNoRetryKey, true)
+	err := e.insertRows(rows)
+	return curData, errors.Trace(err)
+}
+
+// getRows splits the data into the fields of rows according to the specified format, the rest of
+// data that isn't a complete line is returned. It doesn't modify e, so it can run concurrently with insertRows.
+func (e *LoadDataInfo) getRows(prevData, curData []byte) ([][]string, []byte) {
 	// TODO: support enclosed and escape.
 	if len(prevData) == 0 && len(curData) == 0 {
 		return nil, nil
@@ -403,7 +426,7 @@ func (e *LoadDataInfo) InsertData(prevData, curData []byte) ([]byte, error) {
 
 	var line []byte
 	var isEOF, hasStarting bool
-	cols := make([]string, 0, len(e.row))
+	var rows [][]string
 	if len(prevData) > 0 && len(curData) == 0 {
 		isEOF = true
 		prevData, curData = curData, prevData
@@ -430,15 +453,37 @@ func (e *LoadDataInfo) InsertData(prevData, curData []byte) ([]byte, error) {
 		}
 
 		rawCols := bytes.Split(line, []byte(e.FieldsInfo.Terminated))
-		cols = escapeCols(rawCols)
-		e.insertData(cols)
+		rows = append(rows, escapeCols(rawCols))
+	}
+	return rows, curData
+}
+
+// insertRows inserts the rows, the transaction is committed every BatchSize rows.
+// The committed batches are not inserted again by retrying the statement, so a retryable error
+// of a commit is returned instead, see LoadDataNoRetryKey.
+func (e *LoadDataInfo) insertRows(rows [][]string) error {
+	ctx := e.insertVal.ctx
+	if e.BatchSize > 0 {
+		ctx.SetValue(LoadDataNoRetryKey, true)
+	}
+	for _, cols := range rows {
+		if err := e.insertData(cols); err != nil {
+			return errors.Trace(err)
+		}
 		e.insertVal.currRow++
+		e.batchRows++
+		if e.BatchSize > 0 && e.batchRows >= e.BatchSize {
+			if err := ctx.CommitTxn(); err != nil {
+				return errors.Trace(err)
+			}
+			e.batchRows = 0
+			ctx.SetValue(LoadDataNoRetryKey, true)
+		}
 	}
 	if e.insertVal.lastInsertID != 0 {
-		variable.GetSessionVars(e.insertVal.ctx).LastInsertID = e.insertVal.lastInsertID
+		variable.GetSessionVars(ctx).LastInsertID = e.insertVal.lastInsertID
 	}
-
-	return curData, nil
+	return nil
 }
 
 func escapeCols(strs [][]byte) []string {
@@ -489,7 +534,7 @@ func escapeChar(c byte) (byte, bool) {
 	return c, false
 }
 
-func (e *LoadDataInfo) insertData(cols []string) {
+func (e *LoadDataInfo) insertData(cols []string) error {
 	for i := 0; i < len(e.row); i++ {
 		if i >= len(cols) {
 			e.row[i].SetString("")
@@ -498,12 +543,105 @@ func (e *LoadDataInfo) insertData(cols []string) {
 		e.row[i].SetString(cols[i])
 	}
 	row, err := e.insertVal.fillRowData(e.Table.Cols(), e.row, true)
+	if err == nil {
+		err = e.addRecord(row)
+	}
+	if err != nil && e.ignoreErr {
+		variable.GetSessionVars(e.insertVal.ctx).AppendWarning(err)
+		return nil
+	}
+	return errors.Trace(err)
+}
+
+func (e *LoadDataInfo) addRecord(row []types.Datum) error {
+	if e.OnDuplicate == ast.OnDuplicateKeyHandlingReplace {
+		return errors.Trace(e.insertVal.replaceRow(row))
+	}
+	h, err := e.Table.AddRecord(e.insertVal.ctx, row)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	getDirtyDB(e.insertVal.ctx).addRow(e.Table.Meta().ID, h, row)
+	return nil
+}
+
+// getLoadDataBatchSize gets the number of rows that LOAD DATA commits in a transaction. The rows are
+// never committed in batches in an explicit transaction.
+func getLoadDataBatchSize(ctx context.Context) (int, error) {
+	ac, err := autocommit.ShouldAutocommit(ctx)
+	if err != nil || !ac {
+		return 0, errors.Trace(err)
+	}
+	val, err := variable.GetSessionVars(ctx).GetTiDBSystemVar(ctx, variable.TiDBLoadDataBatchSize)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	size, err := strconv.Atoi(val)
 	if err != nil {
-		log.Warnf("Load Data: insert data:%v failed:%v", e.row, errors.ErrorStack(err))
+		return 0, errors.Trace(err)
+	}
+	if size < 0 {
+		size = 0
 	}
-	_, err = e.Table.AddRecord(e.insertVal.ctx, row)
+	return size, nil
+}
+
+// loadDataChunkSize is the size of the data that LOAD DATA reads from a file at a time.
+const loadDataChunkSize = 64 * 1024
+
+// loadFile loads the file on the server. The file is read and parsed in another goroutine,
+// so it overlaps with the insertion of the parsed rows.
+func (e *LoadDataInfo) loadFile() error {
+	f, err := os.Open(e.Path)
 	if err != nil {
-		log.Warnf("Load Data: insert data:%v failed:%v", row, errors.ErrorStack(err))
+		return errors.Trace(err)
+	}
+	defer f.Close()
+
+	rowsCh := make(chan [][]string, 4)
+	errCh := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go e.parseFile(f, rowsCh, errCh, done)
+	for rows := range rowsCh {
+		if err = e.insertRows(rows); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(<-errCh)
+}
+
+// parseFile reads the data from r and sends the parsed rows to rowsCh until the end of r or done is closed.
+// The result is sent to errCh before rowsCh is closed.
+func (e *LoadDataInfo) parseFile(r io.Reader, rowsCh chan<- [][]string, errCh chan<- error, done <-chan struct{}) {
+	defer close(rowsCh)
+	var prevData []byte
+	for {
+		curData := make([]byte, loadDataChunkSize)
+		n, err := io.ReadFull(r, curData)
+		isEOF := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !isEOF {
+			errCh <- errors.Trace(err)
+			return
+		}
+		var rows [][]string
+		if isEOF {
+			rows, _ = e.getRows(append(prevData, curData[:n]...), nil)
+		} else {
+			rows, prevData = e.getRows(prevData, curData)
+		}
+		if len(rows) > 0 {
+			select {
+			case rowsCh <- rows:
+			case <-done:
+				errCh <- nil
+				return
+			}
+		}
+		if isEOF {
+			errCh <- nil
+			return
+		}
 	}
 }
 
@@ -511,6 +649,7 @@ func (e *LoadDataInfo) insertData(cols []string) {
 type LoadData struct {
 	IsLocal      bool
 	loadDataInfo *LoadDataInfo
+	done         bool
 }
 
 // loadDataVarKeyType is a dummy type to avoid naming collision in context.
@@ -524,16 +663,38 @@ func (k loadDataVarKeyType) String() string {
 // LoadDataVarKey is a variable key for load data.
 const LoadDataVarKey loadDataVarKeyType = 0
 
+// loadDataNoRetryKeyType is a dummy type to avoid naming collision in context.
+type loadDataNoRetryKeyType int
+
+// String defines a Stringer function for debugging and pretty printing.
+func (k loadDataNoRetryKeyType) String() string {
+	return "load_data_no_retry"
+}
+
+// LoadDataNoRetryKey marks that the current transaction has the rows of LOAD DATA that retrying the
+// statements in the session history can't insert again, that is the rows sent by the client, or the rows
+// of a statement that has committed some batches. The session doesn't retry the transaction on a
+// retryable commit error, the error is returned.
+const LoadDataNoRetryKey loadDataNoRetryKeyType = 0
+
 // Next implements Executor Next interface.
 func (e *LoadData) Next() (*Row, error) {
-	// TODO: support load data without local field.
-	if !e.IsLocal {
-		return nil, errors.New("Load Data: don't support load data without local field")
+	if e.done {
+		return nil, nil
 	}
+	e.done = true
 	// TODO: support lines terminated is "".
 	if len(e.loadDataInfo.LinesInfo.Terminated) == 0 {
 		return nil, errors.New("Load Data: don't support load data terminated is nil")
 	}
+	if !e.IsLocal {
+		// The file is on the server, it is loaded in the statement.
+		err := checkFileAccess(e.loadDataInfo.insertVal.ctx, e.loadDataInfo.Path)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return nil, errors.Trace(e.loadDataInfo.loadFile())
+	}
 
 	ctx := e.loadDataInfo.insertVal.ctx
 	val := ctx.Value(LoadDataVarKey)
@@ -641,6 +802,7 @@ func (e *InsertExec) Next() (*Row, error) {
 			// For example, without IGNORE, a row that duplicates an existing UNIQUE index or PRIMARY KEY value in
 			// the table causes a duplicate-key error and the statement is aborted. With IGNORE, the row is discarded and no error occurs.
 			if e.Ignore {
+				variable.GetSessionVars(e.ctx).AppendWarning(err)
 				continue
 			}
 			return nil, errors.Trace(err)
@@ -887,7 +1049,8 @@ func (e *InsertValues) initDefaultValues(row []types.Datum, marked map[int]struc
 			if err != nil && !ignoreErr {
 				return errors.Trace(err)
 			}
-			if val != 0 {
+			// With the NO_AUTO_VALUE_ON_ZERO sql mode, only NULL generates the next sequence number.
+			if val != 0 || variable.GetSessionVars(e.ctx).SQLMode.HasNoAutoValueOnZeroMode() {
 				e.Table.RebaseAutoID(val, true)
 				continue
 			}
@@ -1008,6 +1171,41 @@ func (e *ReplaceExec) Schema() expression.Schema {
 	return nil
 }
 
+// replaceRow inserts the row, the existing rows that have the same unique keys are removed.
+// It is shared by REPLACE and LOAD DATA ... REPLACE.
+func (e *InsertValues) replaceRow(row []types.Datum) error {
+	for {
+		h, err := e.Table.AddRecord(e.ctx, row)
+		if err == nil {
+			getDirtyDB(e.ctx).addRow(e.Table.Meta().ID, h, row)
+			return nil
+		}
+		if !terror.ErrorEqual(err, kv.ErrKeyExists) {
+			return errors.Trace(err)
+		}
+		oldRow, err := e.Table.Row(e.ctx, h)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		rowUnchanged, err := types.EqualDatums(oldRow, row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if rowUnchanged {
+			// If row unchanged, we do not need to do insert.
+			variable.GetSessionVars(e.ctx).AddAffectedRows(1)
+			return nil
+		}
+		// Remove current row and try replace again.
+		err = e.Table.RemoveRecord(e.ctx, h, oldRow)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		getDirtyDB(e.ctx).deleteRow(e.Table.Meta().ID, h)
+		variable.GetSessionVars(e.ctx).AddAffectedRows(1)
+	}
+}
+
 // Fields implements Executor Fields interface.
 // Returns nil to indicate there is no output.
 func (e *ReplaceExec) Fields() []*ast.ResultField {
@@ -1054,43 +1252,10 @@ func (e *ReplaceExec) Next() (*Row, error) {
 	 * because in this case, one row was inserted after the duplicate was deleted.
 	 * See http://dev.mysql.com/doc/refman/5.7/en/mysql-affected-rows.html
 	 */
-	idx := 0
-	rowsLen := len(rows)
-	for {
-		if idx >= rowsLen {
-			break
-		}
-		row := rows[idx]
-		h, err1 := e.Table.AddRecord(e.ctx, row)
-		if err1 == nil {
-			getDirtyDB(e.ctx).addRow(e.Table.Meta().ID, h, row)
-			idx++
-			continue
-		}
-		if err1 != nil && !terror.ErrorEqual(err1, kv.ErrKeyExists) {
-			return nil, errors.Trace(err1)
-		}
-		oldRow, err1 := e.Table.Row(e.ctx, h)
-		if err1 != nil {
-			return nil, errors.Trace(err1)
-		}
-		rowUnchanged, err1 := types.EqualDatums(oldRow, row)
-		if err1 != nil {
-			return nil, errors.Trace(err1)
-		}
-		if rowUnchanged {
-			// If row unchanged, we do not need to do insert.
-			variable.GetSessionVars(e.ctx).AddAffectedRows(1)
-			idx++
-			continue
-		}
-		// Remove current row and try replace again.
-		err1 = e.Table.RemoveRecord(e.ctx, h, oldRow)
-		if err1 != nil {
-			return nil, errors.Trace(err1)
+	for _, row := range rows {
+		if err = e.replaceRow(row); err != nil {
+			return nil, errors.Trace(err)
 		}
-		getDirtyDB(e.ctx).deleteRow(e.Table.Meta().ID, h)
-		variable.GetSessionVars(e.ctx).AddAffectedRows(1)
 	}
 
 	if e.lastInsertID != 0 {
@@ -14,7 +14,11 @@
 package executor_test
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"time"
 
 	. "github.com/pingcap/check"
@@ -24,6 +28,7 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
@@ -781,3 +786,132 @@ func makeLoadDataInfo(column int, store kv.Storage, c *C) (ld *executor.LoadData
 	ld.LinesInfo = lines
 	return
 }
+
+func (s *testSuite) TestSelectIntoOutfile(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test; drop table if exists t, load_data_test;")
+	tk.MustExec("create table t (id int, v varchar(20), f double)")
+	tk.MustExec("insert into t values (1, 'a\tb\nc', 1.5), (2, 'x,y\"z\\\\', null), (3, null, 0)")
+	dir, err := ioutil.TempDir("", "select_into")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	// The file operations are disabled by default.
+	_, err = tk.Exec(fmt.Sprintf("select * from t into outfile '%s'", filepath.Join(dir, "t0.txt")))
+	c.Assert(executor.ErrOptionPreventsStatement.Equal(err), IsTrue)
+	sysVar := variable.SysVars[variable.SecureFilePriv]
+	sysVar.Value = dir
+	defer func() { sysVar.Value = "NULL" }()
+	readFile := func(name string) string {
+		data, err1 := ioutil.ReadFile(name)
+		c.Assert(err1, IsNil)
+		return string(data)
+	}
+
+	name := filepath.Join(dir, "t.txt")
+	tk.MustExec(fmt.Sprintf("select * from t order by id into outfile '%s'", name))
+	c.Assert(readFile(name), Equals, "1\ta\\tb\\nc\t1.5\n2\tx,y\"z\\\\\t\\N\n3\t\\N\t0\n")
+	c.Assert(tk.Se.AffectedRows(), Equals, uint64(3))
+	_, err = tk.Exec(fmt.Sprintf("select * from t into outfile '%s'", name))
+	c.Assert(executor.ErrFileExists.Equal(err), IsTrue)
+
+	// The file written by INTO OUTFILE can be loaded back by LOAD DATA.
+	tk.MustExec("create table load_data_test (id int, v varchar(20), f double)")
+	tk.MustExec("load data local infile '/tmp/nonexistence.csv' into table load_data_test")
+	ld, ctx := makeLoadDataInfo(3, s.store, c)
+	_, err = ld.InsertData(nil, []byte(readFile(name)))
+	c.Assert(err, IsNil)
+	c.Assert(ctx.CommitTxn(), IsNil)
+	tk.MustQuery("select id, v from load_data_test where id < 3 order by id").Check(testkit.Rows(
+		fmt.Sprintf("%v %v", 1, []byte("a\tb\nc")), fmt.Sprintf("%v %v", 2, []byte("x,y\"z\\"))))
+
+	name = filepath.Join(dir, "t.csv")
+	tk.MustExec(fmt.Sprintf(`select id, v from t order by id into outfile '%s'
+		fields terminated by ',' optionally enclosed by '"' lines starting by '>' terminated by '\r\n'`, name))
+	c.Assert(readFile(name), Equals, ">1,\"a\\tb\\nc\"\r\n>2,\"x,y\\\"z\\\\\"\r\n>3,\\N\r\n")
+	name = filepath.Join(dir, "t2.csv")
+	tk.MustExec(fmt.Sprintf("select v from t where id = 2 into outfile '%s' fields terminated by ',' escaped by ''", name))
+	c.Assert(readFile(name), Equals, "x,y\"z\\\n")
+
+	name = filepath.Join(dir, "t.bin")
+	tk.MustExec(fmt.Sprintf("select v from t where id = 1 into dumpfile '%s'", name))
+	c.Assert(readFile(name), Equals, "a\tb\nc")
+	_, err = tk.Exec(fmt.Sprintf("select v from t into dumpfile '%s'", filepath.Join(dir, "t2.bin")))
+	c.Assert(executor.ErrTooManyRows.Equal(err), IsTrue)
+
+	_, err = tk.Exec(fmt.Sprintf("select * from (select * from t into outfile '%s') a", filepath.Join(dir, "t3.txt")))
+	c.Assert(err, NotNil)
+	_, err = tk.Exec(fmt.Sprintf("select 1 union select 2 into outfile '%s'", filepath.Join(dir, "t3.txt")))
+	c.Assert(err, NotNil)
+
+	sysVar.Value = filepath.Join(dir, "secure")
+	_, err = tk.Exec(fmt.Sprintf("select * from t into outfile '%s'", filepath.Join(dir, "t3.txt")))
+	c.Assert(executor.ErrOptionPreventsStatement.Equal(err), IsTrue)
+	c.Assert(os.Mkdir(sysVar.Value, 0755), IsNil)
+	tk.MustExec(fmt.Sprintf("select * from t into outfile '%s'", filepath.Join(sysVar.Value, "t3.txt")))
+	// A symbolic link in the directory can't point to the outside.
+	c.Assert(os.Symlink(dir, filepath.Join(sysVar.Value, "link")), IsNil)
+	_, err = tk.Exec(fmt.Sprintf("select * from t into outfile '%s'", filepath.Join(sysVar.Value, "link", "t4.txt")))
+	c.Assert(executor.ErrOptionPreventsStatement.Equal(err), IsTrue)
+	sysVar.Value = "NULL"
+	_, err = tk.Exec(fmt.Sprintf("select * from t into outfile '%s'", filepath.Join(dir, "secure", "t4.txt")))
+	c.Assert(executor.ErrOptionPreventsStatement.Equal(err), IsTrue)
+}
+
+func (s *testSuite) TestLoadDataInfile(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test; drop table if exists load_data_test;")
+	tk.MustExec("create table load_data_test (id int primary key, v int)")
+	dir, err := ioutil.TempDir("", "load_data")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	sysVar := variable.SysVars[variable.SecureFilePriv]
+	sysVar.Value = dir
+	defer func() { sysVar.Value = "NULL" }()
+	name := filepath.Join(dir, "t.txt")
+	c.Assert(ioutil.WriteFile(name, []byte("1\t1\n2\t2\n3\t3\n1\t4\n5\t5"), 0644), IsNil)
+	loadSQL := fmt.Sprintf("load data infile '%s' %%s into table load_data_test", name)
+
+	// The rows are committed in batches, the batches before the error are kept.
+	tk.MustExec("set @@tidb_load_data_batch_size = 2")
+	_, err = tk.Exec(fmt.Sprintf(loadSQL, ""))
+	c.Assert(terror.ErrorEqual(err, kv.ErrKeyExists), IsTrue)
+	tk.MustQuery("select * from load_data_test").Check(testkit.Rows("1 1", "2 2"))
+
+	tk.MustExec("delete from load_data_test")
+	tk.MustExec(fmt.Sprintf(loadSQL, "ignore"))
+	c.Assert(tk.Se.AffectedRows(), Equals, uint64(4))
+	tk.MustQuery("show warnings").Check(testkit.Rows("Warning 1062 Duplicate entry '1' for key 'PRIMARY'"))
+	tk.MustQuery("select * from load_data_test").Check(testkit.Rows("1 1", "2 2", "3 3", "5 5"))
+	tk.MustQuery("show warnings").Check(nil)
+
+	tk.MustExec("delete from load_data_test")
+	tk.MustExec(fmt.Sprintf(loadSQL, "replace"))
+	tk.MustQuery("show warnings").Check(nil)
+	tk.MustQuery("select * from load_data_test").Check(testkit.Rows("1 4", "2 2", "3 3", "5 5"))
+
+	// The rows in an explicit transaction are not committed in batches.
+	tk.MustExec("delete from load_data_test")
+	tk.MustExec("begin")
+	_, err = tk.Exec(fmt.Sprintf(loadSQL, ""))
+	c.Assert(err, NotNil)
+	tk.MustExec("rollback")
+	tk.MustQuery("select count(*) from load_data_test").Check(testkit.Rows("0"))
+
+	// A file larger than a read chunk.
+	var b bytes.Buffer
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&b, "%d\t%d\n", i, i*2)
+	}
+	c.Assert(ioutil.WriteFile(name, b.Bytes(), 0644), IsNil)
+	tk.MustExec("set @@tidb_load_data_batch_size = 3000")
+	tk.MustExec(fmt.Sprintf(loadSQL, ""))
+	tk.MustQuery("select count(*), sum(v) from load_data_test").Check(testkit.Rows("20000 399980000"))
+
+	_, err = tk.Exec(fmt.Sprintf("load data infile '%s' into table load_data_test", filepath.Join(dir, "nonexistence.txt")))
+	c.Assert(err, NotNil)
+	sysVar.Value = filepath.Join(dir, "secure")
+	_, err = tk.Exec(fmt.Sprintf(loadSQL, "replace"))
+	c.Assert(executor.ErrOptionPreventsStatement.Equal(err), IsTrue)
+}
@@ -14,21 +14,117 @@
 package executor
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/juju/errors"
+	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/distsql"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/types"
 )
 
+// RuntimeStats is the runtime statistics of an executor collected by EXPLAIN ANALYZE.
+type RuntimeStats struct {
+	// All the fields are accessed atomically.
+	loops    int64
+	rows     int64
+	consume  int64
+	copTasks int64
+	copWait  int64
+}
+
+// record records a call of Next.
+func (s *RuntimeStats) record(d time.Duration, rowNum int) {
+	atomic.AddInt64(&s.loops, 1)
+	atomic.AddInt64(&s.rows, int64(rowNum))
+	atomic.AddInt64(&s.consume, int64(d))
+}
+
+// recordCop records the coprocessor statistics of a select request, it does nothing on a nil RuntimeStats.
+func (s *RuntimeStats) recordCop(stats distsql.CopStats) {
+	if s == nil {
+		return
+	}
+	atomic.AddInt64(&s.copTasks, stats.Tasks)
+	atomic.AddInt64(&s.copWait, int64(stats.WaitTime))
+}
+
+// String implements fmt.Stringer interface.
+func (s *RuntimeStats) String() string {
+	str := fmt.Sprintf("time:%v, loops:%d", time.Duration(atomic.LoadInt64(&s.consume)), atomic.LoadInt64(&s.loops))
+	if tasks := atomic.LoadInt64(&s.copTasks); tasks > 0 {
+		str += fmt.Sprintf(", cop_task:{num:%d, wait:%v}", tasks, time.Duration(atomic.LoadInt64(&s.copWait)))
+	}
+	return str
+}
+
+// runtimeStatsColl collects the runtime statistics and the memory trackers of the executors, keyed by plan ID.
+type runtimeStatsColl struct {
+	stats       map[string]*RuntimeStats
+	memTrackers map[string]*memory.Tracker
+}
+
+func newRuntimeStatsColl() *runtimeStatsColl {
+	return &runtimeStatsColl{
+		stats:       make(map[string]*RuntimeStats),
+		memTrackers: make(map[string]*memory.Tracker),
+	}
+}
+
+// get gets the runtime statistics of a plan, it returns nil on a nil runtimeStatsColl.
+func (c *runtimeStatsColl) get(planID string) *RuntimeStats {
+	if c == nil {
+		return nil
+	}
+	s, ok := c.stats[planID]
+	if !ok {
+		s = &RuntimeStats{}
+		c.stats[planID] = s
+	}
+	return s
+}
+
+// analyzeExec wraps an executor to collect its runtime statistics.
+type analyzeExec struct {
+	Executor
+	stats *RuntimeStats
+}
+
+// Next implements Executor Next interface.
+func (e *analyzeExec) Next() (*Row, error) {
+	start := time.Now()
+	row, err := e.Executor.Next()
+	rowNum := 0
+	if row != nil {
+		rowNum = 1
+	}
+	e.stats.record(time.Since(start), rowNum)
+	return row, errors.Trace(err)
+}
+
 // ExplainExec represents an explain executor.
 // See https://dev.mysql.com/doc/refman/5.7/en/explain-output.html
 type ExplainExec struct {
 	StmtPlan  plan.Plan
 	schema    expression.Schema
+	format    string
 	evaluated bool
+	rows      []*Row
+	cursor    int
+
+	// stmtExec and stats are used by EXPLAIN ANALYZE, which executes the statement first.
+	stmtExec Executor
+	stats    *runtimeStatsColl
+	// stmtDone indicates stmtExec is closed, either after it runs to completion or fails.
+	stmtDone bool
 }
 
 // Schema implements Executor Schema interface.
@@ -43,21 +139,139 @@ func (e *ExplainExec) Fields() []*ast.ResultField {
 
 // Next implements Execution Next interface.
 func (e *ExplainExec) Next() (*Row, error) {
-	if e.evaluated {
+	if !e.evaluated {
+		e.evaluated = true
+		if err := e.prepareRows(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if e.cursor >= len(e.rows) {
 		return nil, nil
 	}
-	e.evaluated = true
-	explain, err := json.MarshalIndent(e.StmtPlan, "", "    ")
-	if err != nil {
-		return nil, errors.Trace(err)
+	row := e.rows[e.cursor]
+	e.cursor++
+	return row, nil
+}
+
+func (e *ExplainExec) prepareRows() error {
+	switch e.format {
+	case ast.ExplainFormatJSON:
+		explain, err := json.MarshalIndent(e.StmtPlan, "", "    ")
+		if err != nil {
+			return errors.Trace(err)
+		}
+		e.rows = append(e.rows, &Row{Data: types.MakeDatums("EXPLAIN", string(explain))})
+	case ast.ExplainFormatDOT:
+		buffer := bytes.NewBufferString("")
+		fmt.Fprintf(buffer, "digraph %s {\n", e.StmtPlan.GetID())
+		explainDot(buffer, e.StmtPlan)
+		buffer.WriteString("}\n")
+		e.rows = append(e.rows, &Row{Data: types.MakeDatums(buffer.String())})
+	default:
+		if e.stmtExec != nil {
+			if err := e.runStmt(); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		e.explainRows(e.StmtPlan, "")
 	}
-	row := &Row{
-		Data: types.MakeDatums("EXPLAIN", string(explain)),
+	return nil
+}
+
+// runStmt executes the statement to completion for EXPLAIN ANALYZE.
+func (e *ExplainExec) runStmt() error {
+	for {
+		row, err := e.stmtExec.Next()
+		if err != nil {
+			if closeErr := e.closeStmt(); closeErr != nil {
+				log.Warnf("[explain] close the analyzed statement error %v", closeErr)
+			}
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
 	}
-	return row, nil
+	return errors.Trace(e.closeStmt())
+}
+
+// closeStmt closes stmtExec unless it is already closed.
+func (e *ExplainExec) closeStmt() error {
+	if e.stmtExec == nil || e.stmtDone {
+		return nil
+	}
+	e.stmtDone = true
+	return errors.Trace(e.stmtExec.Close())
+}
+
+// explainRows appends the rows of p and its children in pre-order.
+func (e *ExplainExec) explainRows(p plan.Plan, parent string) {
+	id := p.GetID()
+	info := plan.ExplainOperator(p)
+	estRows := "N/A"
+	if pp, ok := p.(plan.PhysicalPlan); ok {
+		estRows = strconv.FormatUint(pp.StatsCount(), 10)
+	}
+	data := types.MakeDatums(id, parent, info.Operator, info.AccessObject, estRows, info.Conditions, info.Task)
+	if e.stmtExec != nil {
+		actRows, execInfo := "N/A", "N/A"
+		if s, ok := e.stats.stats[id]; ok {
+			actRows = strconv.FormatInt(atomic.LoadInt64(&s.rows), 10)
+			execInfo = s.String()
+		}
+		mem := "N/A"
+		if t, ok := e.stats.memTrackers[id]; ok {
+			mem = memory.FormatBytes(t.MaxConsumed())
+		}
+		data = append(data, types.MakeDatums(actRows, execInfo, mem)...)
+	}
+	e.rows = append(e.rows, &Row{Data: data})
+	for _, child := range explainChildren(p) {
+		e.explainRows(child, id)
+	}
+}
+
+// explainDot writes the nodes and edges of p and its children in the DOT language.
+func explainDot(buffer *bytes.Buffer, p plan.Plan) {
+	info := plan.ExplainOperator(p)
+	label := info.Operator
+	if info.AccessObject != "" {
+		label += "\n" + info.AccessObject
+	}
+	if info.Conditions != "" {
+		label += "\n" + info.Conditions
+	}
+	fmt.Fprintf(buffer, "%q [label=%q];\n", p.GetID(), label)
+	for _, child := range explainChildren(p) {
+		fmt.Fprintf(buffer, "%q -> %q;\n", p.GetID(), child.GetID())
+		explainDot(buffer, child)
+	}
+}
+
+// explainChildren returns the children of p, including the inner plan of an apply
+// and the plans that materialize a common table expression.
+func explainChildren(p plan.Plan) []plan.Plan {
+	children := p.GetChildren()
+	switch x := p.(type) {
+	case *plan.PhysicalApply:
+		children = append(append([]plan.Plan{}, children...), x.InnerPlan)
+	case *plan.CTEScan:
+		if !x.Working {
+			children = append(append([]plan.Plan{}, children...), x.CTE.Seed)
+			if x.CTE.Recursive != nil {
+				children = append(children, x.CTE.Recursive)
+			}
+		}
+	}
+	return children
 }
 
 // Close implements Executor Close interface.
 func (e *ExplainExec) Close() error {
-	return nil
+	e.evaluated = false
+	e.rows = nil
+	e.cursor = 0
+	err := e.closeStmt()
+	e.stmtDone = false
+	return errors.Trace(err)
 }
@@ -14,6 +14,8 @@
 package executor_test
 
 import (
+	"strings"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
@@ -230,7 +232,73 @@ func (s *testSuite) TestExplain(c *C) {
 		},
 	}
 	for _, ca := range cases {
-		result := tk.MustQuery("explain " + ca.sql)
+		result := tk.MustQuery("explain format = json " + ca.sql)
 		result.Check(testkit.Rows("EXPLAIN " + ca.result))
 	}
 }
+
+func (s *testSuite) TestExplainAnalyze(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b int)")
+	tk.MustExec("insert t values (1, 1), (2, 2), (3, 3), (1, 4)")
+	rows := tk.MustQuery("explain analyze select a, count(*) from t group by a order by a").Rows()
+	expected := [][]string{
+		{"Sort_4", "", "2000", "3"},
+		{"Projection_3", "Sort_4", "2000", "3"},
+		{"HashAgg_5", "Projection_3", "2000", "3"},
+		{"TableScan_6", "HashAgg_5", "10000", "3"},
+	}
+	c.Assert(rows, HasLen, len(expected))
+	for i, row := range rows {
+		c.Assert(row, HasLen, 10)
+		c.Assert(row[0], Equals, expected[i][0], Commentf("row %d", i))
+		c.Assert(row[1], Equals, expected[i][1], Commentf("row %d", i))
+		c.Assert(row[4], Equals, expected[i][2], Commentf("row %d", i))
+		c.Assert(row[7], Equals, expected[i][3], Commentf("row %d", i))
+		c.Assert(strings.HasPrefix(row[8].(string), "time:"), IsTrue)
+	}
+	c.Assert(strings.Contains(rows[3][8].(string), "cop_task:{num:1"), IsTrue)
+	c.Assert(rows[0][9], Not(Equals), "N/A")
+	c.Assert(rows[1][9], Equals, "N/A")
+
+	_, err := tk.Exec("explain analyze format = json select * from t")
+	c.Assert(err, NotNil)
+
+	// The statement is executed.
+	tk.MustQuery("explain analyze insert t values (5, 5)")
+	tk.MustQuery("select count(*) from t").Check(testkit.Rows("5"))
+}
+
+func (s *testSuite) TestExplainFormat(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1 (c1 int primary key, c2 int, c3 int, index c2 (c2))")
+	tk.MustExec("create table t2 (c1 int unique, c2 int)")
+
+	tk.MustQuery("explain select * from t1 where c2 = 1").Check(testkit.Rows(
+		"Projection_3  Projection  50  root",
+		"IndexScan_4 Projection_3 IndexScan table:t1, index:c2, range:[1,1], double read 50 access:[eq(test.t1.c2, 1)] cop",
+	))
+
+	tk.MustQuery("explain select * from t1 join t2 on t1.c1 = t2.c1 where t1.c3 > 1").Check(testkit.Rows(
+		"Projection_5  Projection  24000000  root",
+		"HashJoin_7 Projection_5 HashJoin  24000000 inner join, equal:[eq(test.t1.c1, test.t2.c1)] root",
+		"TableScan_8 HashJoin_7 TableScan table:t1, range:[-9223372036854775808,9223372036854775807] 8000 pushed:[gt(test.t1.c3, 1)] cop",
+		"TableScan_9 HashJoin_7 TableScan table:t2, range:[-9223372036854775808,9223372036854775807] 10000  cop",
+	))
+
+	tk.MustQuery("explain format = 'dot' select * from t1 where c3 > 1 limit 1").Check(testkit.Rows(`digraph Projection_3 {
+"Projection_3" [label="Projection"];
+"Projection_3" -> "TableScan_5";
+"TableScan_5" [label="TableScan\ntable:t1, range:[-9223372036854775808,9223372036854775807]\npushed:[gt(test.t1.c3, 1)], pushed limit:1"];
+}
+`))
+
+	_, err := tk.Exec("explain format = 'xml' select * from t1")
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,561 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"sort"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/util/memory"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// MergeJoinExec implements the sort merge join algorithm, both children are sorted by the join keys.
+// The outer side drives the join, the inner rows are read group by group, a group has the same join keys.
+type MergeJoinExec struct {
+	ctx       context.Context
+	schema    expression.Schema
+	outerExec Executor
+	innerExec Executor
+	outerKeys []*expression.Column
+	innerKeys []*expression.Column
+	// outerFilter and innerFilter are the conditions on the rows of each side, otherFilter is the condition on the
+	// joined rows.
+	outerFilter expression.Expression
+	innerFilter expression.Expression
+	otherFilter expression.Expression
+	// outer is true for outer joins, an outer row without any match is joined with a null inner row.
+	outer       bool
+	outerIsLeft bool
+
+	prepared      bool
+	innerRow      *Row
+	innerRowKey   []types.Datum
+	innerGroup    []*Row
+	innerGroupKey []types.Datum
+	resultRows    []*Row
+	cursor        int
+}
+
+// Schema implements Executor Schema interface.
+func (e *MergeJoinExec) Schema() expression.Schema {
+	return e.schema
+}
+
+// Fields implements Executor Fields interface.
+func (e *MergeJoinExec) Fields() []*ast.ResultField {
+	return nil
+}
+
+// Close implements Executor Close interface.
+func (e *MergeJoinExec) Close() error {
+	e.prepared = false
+	e.innerRow = nil
+	e.innerGroup = nil
+	e.innerGroupKey = nil
+	e.resultRows = nil
+	e.cursor = 0
+	err := e.outerExec.Close()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(e.innerExec.Close())
+}
+
+// Next implements Executor Next interface.
+func (e *MergeJoinExec) Next() (*Row, error) {
+	if !e.prepared {
+		if err := e.nextInnerRow(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if err := e.nextInnerGroup(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		e.prepared = true
+	}
+	for e.cursor >= len(e.resultRows) {
+		outerRow, err := e.outerExec.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if outerRow == nil {
+			return nil, nil
+		}
+		e.resultRows, e.cursor = e.resultRows[:0], 0
+		if err = e.joinOuterRow(outerRow); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	row := e.resultRows[e.cursor]
+	e.cursor++
+	return row, nil
+}
+
+func (e *MergeJoinExec) joinOuterRow(outerRow *Row) error {
+	matched, err := evalFilter(e.ctx, e.outerFilter, outerRow)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var key []types.Datum
+	if matched {
+		key, err = evalJoinKeys(e.outerKeys, outerRow)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if key != nil {
+		// The outer keys are in ascending order, so the inner groups less than the key can be skipped.
+		cmp := -1
+		for e.innerGroupKey != nil {
+			cmp, err = compareJoinKeys(e.innerGroupKey, key)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if cmp >= 0 {
+				break
+			}
+			if err = e.nextInnerGroup(); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if e.innerGroupKey != nil && cmp == 0 {
+			for _, innerRow := range e.innerGroup {
+				joined := joinOuterInnerRow(outerRow, innerRow, e.outerIsLeft)
+				ok, err := evalFilter(e.ctx, e.otherFilter, joined)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				if ok {
+					e.resultRows = append(e.resultRows, joined)
+				}
+			}
+		}
+	}
+	if len(e.resultRows) == 0 && e.outer {
+		e.resultRows = append(e.resultRows, joinOuterInnerRow(outerRow, nullRow(len(e.innerExec.Schema())), e.outerIsLeft))
+	}
+	return nil
+}
+
+// nextInnerRow reads the next inner row that has no null join key and matches the inner filter.
+func (e *MergeJoinExec) nextInnerRow() error {
+	for {
+		row, err := e.innerExec.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			e.innerRow, e.innerRowKey = nil, nil
+			return nil
+		}
+		matched, err := evalFilter(e.ctx, e.innerFilter, row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !matched {
+			continue
+		}
+		key, err := evalJoinKeys(e.innerKeys, row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if key != nil {
+			e.innerRow, e.innerRowKey = row, key
+			return nil
+		}
+	}
+}
+
+// nextInnerGroup reads the next group of inner rows with the same join keys.
+func (e *MergeJoinExec) nextInnerGroup() error {
+	e.innerGroup, e.innerGroupKey = nil, e.innerRowKey
+	if e.innerRow == nil {
+		return nil
+	}
+	for e.innerRow != nil {
+		cmp, err := compareJoinKeys(e.innerRowKey, e.innerGroupKey)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if cmp != 0 {
+			break
+		}
+		e.innerGroup = append(e.innerGroup, e.innerRow)
+		if err = e.nextInnerRow(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// indexJoinBatchSize is the number of the outer rows that are joined in a batch by IndexLookUpJoin.
+const indexJoinBatchSize = 128
+
+// IndexLookUpJoin fetches the outer rows in batches, then reads the inner rows that match the join keys of a batch
+// by the ranges of the inner scan, and joins them by a hash table.
+type IndexLookUpJoin struct {
+	ctx       context.Context
+	schema    expression.Schema
+	outerExec Executor
+	// innerScan is the plan of the inner scan whose ranges are replaced, innerSel is the selection above it, it may be nil.
+	innerScan plan.PhysicalPlan
+	innerSel  *plan.Selection
+	builder   *executorBuilder
+	outerKeys []*expression.Column
+	innerKeys []*expression.Column
+	keyOffset int
+	// targetTypes are the types that both the outer keys and the inner keys are converted to.
+	targetTypes []*types.FieldType
+	outerFilter expression.Expression
+	innerFilter expression.Expression
+	otherFilter expression.Expression
+	outer       bool
+	outerIsLeft bool
+	innerLen    int
+	batchSize   int
+
+	finished   bool
+	resultRows []*Row
+	cursor     int
+	memTracker *memory.Tracker
+}
+
+// Schema implements Executor Schema interface.
+func (e *IndexLookUpJoin) Schema() expression.Schema {
+	return e.schema
+}
+
+// Fields implements Executor Fields interface.
+func (e *IndexLookUpJoin) Fields() []*ast.ResultField {
+	return nil
+}
+
+// Close implements Executor Close interface.
+func (e *IndexLookUpJoin) Close() error {
+	e.finished = false
+	e.resultRows = nil
+	e.cursor = 0
+	e.memTracker.Release(e.memTracker.BytesConsumed())
+	return errors.Trace(e.outerExec.Close())
+}
+
+// Next implements Executor Next interface.
+func (e *IndexLookUpJoin) Next() (*Row, error) {
+	for e.cursor >= len(e.resultRows) {
+		if e.finished {
+			return nil, nil
+		}
+		if err := e.joinBatch(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	row := e.resultRows[e.cursor]
+	e.cursor++
+	return row, nil
+}
+
+// joinBatch joins a batch of outer rows.
+func (e *IndexLookUpJoin) joinBatch() error {
+	e.resultRows, e.cursor = e.resultRows[:0], 0
+	outerRows := make([]*Row, 0, e.batchSize)
+	for len(outerRows) < e.batchSize {
+		row, err := e.outerExec.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			e.finished = true
+			break
+		}
+		outerRows = append(outerRows, row)
+	}
+	if len(outerRows) == 0 {
+		return nil
+	}
+	matched := make([]bool, len(outerRows))
+	lookUpValues := make([]types.Datum, 0, len(outerRows))
+	for i, row := range outerRows {
+		ok, err := evalFilter(e.ctx, e.outerFilter, row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !ok {
+			continue
+		}
+		matched[i] = true
+		v, err := e.outerKeys[e.keyOffset].Eval(row.Data, e.ctx)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !v.IsNull() {
+			lookUpValues = append(lookUpValues, v)
+		}
+	}
+	hashTable, err := e.lookUpInnerRows(lookUpValues)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	buffer := make([]types.Datum, len(e.outerKeys))
+	for i, outerRow := range outerRows {
+		hasMatch := false
+		if matched[i] {
+			hasNull, hashKey, err := getHashKey(e.outerKeys, outerRow, e.targetTypes, buffer, nil)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if !hasNull {
+				for _, innerRow := range hashTable[string(hashKey)] {
+					joined := joinOuterInnerRow(outerRow, innerRow, e.outerIsLeft)
+					ok, err := evalFilter(e.ctx, e.otherFilter, joined)
+					if err != nil {
+						return errors.Trace(err)
+					}
+					if ok {
+						hasMatch = true
+						e.resultRows = append(e.resultRows, joined)
+					}
+				}
+			}
+		}
+		if !hasMatch && e.outer {
+			e.resultRows = append(e.resultRows, joinOuterInnerRow(outerRow, nullRow(e.innerLen), e.outerIsLeft))
+		}
+	}
+	return nil
+}
+
+// lookUpInnerRows reads the inner rows that match the look up values, and builds a hash table on them.
+func (e *IndexLookUpJoin) lookUpInnerRows(values []types.Datum) (map[string][]*Row, error) {
+	e.memTracker.Release(e.memTracker.BytesConsumed())
+	hashTable := make(map[string][]*Row)
+	innerExec, err := e.buildInnerExec(values)
+	if err != nil || innerExec == nil {
+		return hashTable, errors.Trace(err)
+	}
+	defer innerExec.Close()
+	buffer := make([]types.Datum, len(e.innerKeys))
+	for {
+		row, err := innerExec.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		ok, err := evalFilter(e.ctx, e.innerFilter, row)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !ok {
+			continue
+		}
+		hasNull, hashKey, err := getHashKey(e.innerKeys, row, e.targetTypes, buffer, nil)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if hasNull {
+			continue
+		}
+		hashTable[string(hashKey)] = append(hashTable[string(hashKey)], row)
+		if err = e.memTracker.Consume(rowMemUsage(row) + int64(len(hashKey))); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return hashTable, nil
+}
+
+// buildInnerExec builds the executor of the inner scan reading the look up values, it returns nil if no row can match.
+func (e *IndexLookUpJoin) buildInnerExec(values []types.Datum) (Executor, error) {
+	values, err := sortAndDedupDatums(values)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var scan plan.PhysicalPlan
+	switch x := e.innerScan.(type) {
+	case *plan.PhysicalTableScan:
+		ts := x.Copy().(*plan.PhysicalTableScan)
+		ts.Ranges = make([]plan.TableRange, 0, len(values))
+		for _, v := range values {
+			handle, ok, err := datumToHandle(v)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if ok {
+				ts.Ranges = append(ts.Ranges, plan.TableRange{LowVal: handle, HighVal: handle})
+			}
+		}
+		if len(ts.Ranges) == 0 {
+			return nil, nil
+		}
+		scan = ts
+	case *plan.PhysicalIndexScan:
+		is := x.Copy().(*plan.PhysicalIndexScan)
+		is.Ranges = make([]*plan.IndexRange, 0, len(values))
+		ft := &is.Table.Columns[is.Index.Columns[0].Offset].FieldType
+		for _, v := range values {
+			converted, ok, err := datumToIndexValue(v, ft)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if ok {
+				is.Ranges = append(is.Ranges, &plan.IndexRange{LowVal: []types.Datum{converted}, HighVal: []types.Datum{converted}})
+			}
+		}
+		if len(is.Ranges) == 0 {
+			return nil, nil
+		}
+		scan = is
+	}
+	p := scan
+	if e.innerSel != nil {
+		sel := e.innerSel.Copy().(*plan.Selection)
+		sel.SetChildren(scan)
+		p = sel
+	}
+	b := newExecutorBuilder(e.builder.ctx, e.builder.is)
+	b.memTracker, b.stats, b.deadline = e.builder.memTracker, e.builder.stats, e.builder.deadline
+	exec := b.build(p)
+	if b.err != nil {
+		return nil, errors.Trace(b.err)
+	}
+	return exec, nil
+}
+
+// datumToHandle converts a look up value to a handle, ok is false if the value can not be equal to any handle.
+func datumToHandle(v types.Datum) (handle int64, ok bool, err error) {
+	converted, err := v.ConvertTo(types.NewFieldType(mysql.TypeLonglong))
+	if err != nil {
+		// The value out of the range of handles matches nothing.
+		return 0, false, nil
+	}
+	cmp, err := v.CompareDatum(converted)
+	if err != nil || cmp != 0 {
+		return 0, false, errors.Trace(err)
+	}
+	return converted.GetInt64(), true, nil
+}
+
+// datumToIndexValue converts a look up value to the type of the index column, so it is encoded like the index values.
+// ok is false if the value can not be equal to any value of the column.
+func datumToIndexValue(v types.Datum, ft *types.FieldType) (converted types.Datum, ok bool, err error) {
+	converted, err = v.ConvertTo(ft)
+	if err != nil {
+		// The value that can't be stored in the column matches nothing.
+		return converted, false, nil
+	}
+	cmp, err := v.CompareDatum(converted)
+	if err != nil || cmp != 0 {
+		return converted, false, errors.Trace(err)
+	}
+	return converted, true, nil
+}
+
+// datumSorter sorts the datums, the first error of the comparisons is kept in err.
+type datumSorter struct {
+	values []types.Datum
+	err    error
+}
+
+func (s *datumSorter) Len() int {
+	return len(s.values)
+}
+
+func (s *datumSorter) Swap(i, j int) {
+	s.values[i], s.values[j] = s.values[j], s.values[i]
+}
+
+func (s *datumSorter) Less(i, j int) bool {
+	cmp, err := s.values[i].CompareDatum(s.values[j])
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	return cmp < 0
+}
+
+func sortAndDedupDatums(values []types.Datum) ([]types.Datum, error) {
+	sorter := &datumSorter{values: values}
+	sort.Sort(sorter)
+	if sorter.err != nil {
+		return nil, errors.Trace(sorter.err)
+	}
+	result := values[:0]
+	for i, v := range values {
+		if i > 0 {
+			cmp, err := v.CompareDatum(result[len(result)-1])
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if cmp == 0 {
+				continue
+			}
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+// evalFilter evaluates a filter on a row, a nil filter matches all the rows.
+func evalFilter(ctx context.Context, filter expression.Expression, row *Row) (bool, error) {
+	if filter == nil {
+		return true, nil
+	}
+	matched, err := expression.EvalBool(filter, row.Data, ctx)
+	return matched, errors.Trace(err)
+}
+
+// evalJoinKeys evaluates the join keys of a row, it returns nil if any key is null because null matches nothing.
+func evalJoinKeys(keys []*expression.Column, row *Row) ([]types.Datum, error) {
+	values := make([]types.Datum, 0, len(keys))
+	for _, key := range keys {
+		v, err := key.Eval(row.Data, nil)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if v.IsNull() {
+			return nil, nil
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func compareJoinKeys(a, b []types.Datum) (int, error) {
+	for i := range a {
+		cmp, err := a[i].CompareDatum(b[i])
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		if cmp != 0 {
+			return cmp, nil
+		}
+	}
+	return 0, nil
+}
+
+func joinOuterInnerRow(outer, inner *Row, outerIsLeft bool) *Row {
+	if outerIsLeft {
+		return joinTwoRow(outer, inner)
+	}
+	return joinTwoRow(inner, outer)
+}
+
+func nullRow(length int) *Row {
+	// The zero values of datums are nulls.
+	return &Row{Data: make([]types.Datum, length)}
+}
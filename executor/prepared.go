@@ -15,6 +15,7 @@ package executor
 
 import (
 	"sort"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
@@ -26,6 +27,7 @@ import (
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/sqlexec"
 )
 
@@ -129,7 +131,9 @@ func (e *PrepareExec) DoPrepare() {
 	if sqlParser, ok := e.Ctx.(sqlexec.SQLParser); ok {
 		stmts, err = sqlParser.ParseSQL(e.SQLText, charset, collation)
 	} else {
-		stmts, err = parser.New().Parse(e.SQLText, charset, collation)
+		p := parser.New()
+		p.SetSQLMode(vars.SQLMode)
+		stmts, err = p.Parse(e.SQLText, charset, collation)
 	}
 	if err != nil {
 		e.Err = errors.Trace(err)
@@ -187,6 +191,9 @@ type ExecuteExec struct {
 	ID        uint32
 	StmtExec  Executor
 	Stmt      ast.StmtNode
+
+	memTracker *memory.Tracker
+	deadline   time.Time
 }
 
 // Schema implements Executor Schema interface.
@@ -252,6 +259,7 @@ func (e *ExecuteExec) Build() error {
 		return errors.Trace(err)
 	}
 	b := newExecutorBuilder(e.Ctx, e.IS)
+	b.memTracker, b.deadline = e.memTracker, e.deadline
 	stmtExec := b.build(p)
 	if b.err != nil {
 		return errors.Trace(b.err)
@@ -0,0 +1,265 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// SelectIntoExec writes the rows of its source into a file, it represents
+// SELECT ... INTO OUTFILE and SELECT ... INTO DUMPFILE.
+// See https://dev.mysql.com/doc/refman/5.7/en/select-into.html
+type SelectIntoExec struct {
+	ctx     context.Context
+	Src     Executor
+	IntoOpt *ast.SelectIntoOption
+	done    bool
+
+	w       *bufio.Writer
+	lineBuf []byte
+}
+
+// Schema implements Executor Schema interface.
+func (e *SelectIntoExec) Schema() expression.Schema {
+	return nil
+}
+
+// Fields implements Executor Fields interface.
+func (e *SelectIntoExec) Fields() []*ast.ResultField {
+	return nil
+}
+
+// Next implements Executor Next interface.
+func (e *SelectIntoExec) Next() (*Row, error) {
+	if e.done {
+		return nil, nil
+	}
+	e.done = true
+	if err := checkFileAccess(e.ctx, e.IntoOpt.FileName); err != nil {
+		return nil, errors.Trace(err)
+	}
+	f, err := os.OpenFile(e.IntoOpt.FileName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, ErrFileExists.Gen("File '%s' already exists", e.IntoOpt.FileName)
+		}
+		return nil, errors.Trace(err)
+	}
+	e.w = bufio.NewWriter(f)
+	err = e.writeRows()
+	if err1 := e.w.Flush(); err == nil {
+		err = err1
+	}
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	return nil, errors.Trace(err)
+}
+
+func (e *SelectIntoExec) writeRows() error {
+	schema := e.Src.Schema()
+	var count uint64
+	for {
+		row, err := e.Src.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		count++
+		if e.IntoOpt.Tp == ast.SelectIntoDumpfile {
+			if count > 1 {
+				return ErrTooManyRows.Gen("Result consisted of more than one row")
+			}
+			err = e.dumpRow(row)
+		} else {
+			err = e.writeRow(row, schema)
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	variable.GetSessionVars(e.ctx).AddAffectedRows(count)
+	return nil
+}
+
+// dumpRow writes the row without any field or line terminators and escaping.
+func (e *SelectIntoExec) dumpRow(row *Row) error {
+	for _, d := range row.Data {
+		if d.IsNull() {
+			continue
+		}
+		s, err := d.ToString()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if _, err = e.w.WriteString(s); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// writeRow writes the row with the FIELDS and LINES formats, a value written by writeRow
+// is read back as the same value by LOAD DATA with the same formats.
+func (e *SelectIntoExec) writeRow(row *Row, schema expression.Schema) error {
+	fields, lines := e.IntoOpt.FieldsInfo, e.IntoOpt.LinesInfo
+	buf := append(e.lineBuf[:0], lines.Starting...)
+	for i, d := range row.Data {
+		if i > 0 {
+			buf = append(buf, fields.Terminated...)
+		}
+		if d.IsNull() {
+			if fields.Escaped == 0 {
+				buf = append(buf, "NULL"...)
+			} else {
+				buf = append(buf, fields.Escaped, 'N')
+			}
+			continue
+		}
+		s, err := d.ToString()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		enclosed := fields.Enclosed != 0 && (!fields.OptEnclosed || (i < len(schema) && isStringField(schema[i].RetType.Tp)))
+		if enclosed {
+			buf = append(buf, fields.Enclosed)
+		}
+		buf = e.appendEscaped(buf, s)
+		if enclosed {
+			buf = append(buf, fields.Enclosed)
+		}
+	}
+	buf = append(buf, lines.Terminated...)
+	e.lineBuf = buf
+	_, err := e.w.Write(buf)
+	return errors.Trace(err)
+}
+
+// appendEscaped is the reverse of escape used by LOAD DATA. The escape character, the enclosed character
+// and, if the fields are not enclosed, the first characters of the terminators are escaped too.
+func (e *SelectIntoExec) appendEscaped(buf []byte, s string) []byte {
+	fields, lines := e.IntoOpt.FieldsInfo, e.IntoOpt.LinesInfo
+	esc := fields.Escaped
+	if esc == 0 {
+		return append(buf, s...)
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case 0:
+			buf = append(buf, esc, '0')
+		case '\b':
+			buf = append(buf, esc, 'b')
+		case '\n':
+			buf = append(buf, esc, 'n')
+		case '\r':
+			buf = append(buf, esc, 'r')
+		case '\t':
+			buf = append(buf, esc, 't')
+		case 26:
+			buf = append(buf, esc, 'Z')
+		default:
+			if c == esc || (fields.Enclosed != 0 && c == fields.Enclosed) ||
+				(fields.Enclosed == 0 && (strings.IndexByte(fields.Terminated, c) == 0 || strings.IndexByte(lines.Terminated, c) == 0)) {
+				buf = append(buf, esc)
+			}
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
+
+func isStringField(tp byte) bool {
+	switch tp {
+	case mysql.TypeVarString, mysql.TypeEnum, mysql.TypeSet:
+		return true
+	}
+	return types.IsTypeChar(tp) || types.IsTypeBlob(tp)
+}
+
+// checkFileAccess checks whether the user can read or write the file on the server,
+// the user needs the FILE privilege and the file must be allowed by secure_file_priv.
+func checkFileAccess(ctx context.Context, fileName string) error {
+	hasPriv, err := privilege.GetPrivilegeChecker(ctx).Check(ctx, nil, nil, mysql.FilePriv)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !hasPriv {
+		return ErrSpecificAccessDenied.Gen("Access denied; you need (at least one of) the FILE privilege(s) for this operation")
+	}
+	return errors.Trace(checkSecureFilePriv(fileName))
+}
+
+// checkSecureFilePriv checks whether the file is under the directory of the secure_file_priv variable.
+// An empty directory means no restriction, and "NULL" disables the file operations. The symbolic links are
+// resolved before the check, so a link in the directory can't point to a file outside of it.
+func checkSecureFilePriv(fileName string) error {
+	dir := variable.SysVars[variable.SecureFilePriv].Value
+	if dir == "" {
+		return nil
+	}
+	errPrevents := ErrOptionPreventsStatement.Gen("The MySQL server is running with the --secure-file-priv option so it cannot execute this statement")
+	if strings.EqualFold(dir, "NULL") {
+		return errPrevents
+	}
+	realDir, err := realPath(dir)
+	if err != nil {
+		// The directory doesn't exist, no file is under it.
+		return errPrevents
+	}
+	realFile, err := realPath(fileName)
+	if os.IsNotExist(err) {
+		// The file to write doesn't exist yet, the directory it is created in is resolved.
+		realFile, err = realPath(filepath.Dir(fileName))
+		realFile = filepath.Join(realFile, filepath.Base(fileName))
+	}
+	if err != nil {
+		return errPrevents
+	}
+	if !strings.HasSuffix(realDir, string(filepath.Separator)) {
+		realDir += string(filepath.Separator)
+	}
+	if !strings.HasPrefix(realFile, realDir) {
+		return errPrevents
+	}
+	return nil
+}
+
+// realPath returns the absolute path of the file with the symbolic links resolved.
+func realPath(name string) (string, error) {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// Close implements Executor Close interface.
+func (e *SelectIntoExec) Close() error {
+	return e.Src.Close()
+}
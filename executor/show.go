@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/tidb/privilege"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -115,7 +116,25 @@ func (e *ShowExec) fetchAll() error {
 	case ast.ShowVariables:
 		return e.fetchShowVariables()
 	case ast.ShowWarnings:
-		// empty result
+		return e.fetchShowWarnings(false)
+	case ast.ShowErrors:
+		return e.fetchShowWarnings(true)
+	}
+	return nil
+}
+
+// fetchShowWarnings shows the warnings of the previous statement, or only the errors if errOnly is true.
+func (e *ShowExec) fetchShowWarnings(errOnly bool) error {
+	for _, warn := range variable.GetSessionVars(e.ctx).GetWarnings() {
+		if errOnly && warn.Level != variable.WarnLevelError {
+			continue
+		}
+		code, msg := uint16(mysql.ErrUnknown), warn.Err.Error()
+		if te, ok := errors.Cause(warn.Err).(*terror.Error); ok {
+			sqlErr := te.ToSQLError()
+			code, msg = sqlErr.Code, sqlErr.Message
+		}
+		e.rows = append(e.rows, &Row{Data: types.MakeDatums(warn.Level, int64(code), msg)})
 	}
 	return nil
 }
@@ -0,0 +1,291 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"time"
+	"unsafe"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// SpillDir is the directory where executors write their temporary spill files.
+// An empty string means the default directory for temporary files.
+var SpillDir = ""
+
+// SortMergeFanIn is the maximum number of sorted runs merged at once, every run being merged keeps its file open.
+// When there are more runs, they are merged into larger runs in several passes first.
+var SortMergeFanIn = 64
+
+// MinSortRunSize is the minimum size in bytes of the rows spilled for the memory quota of the session,
+// so a small quota doesn't spill a lot of tiny sorted runs.
+var MinSortRunSize int64 = 1 << 20
+
+// datumSize is the in-memory size of a types.Datum without the data it refers to.
+const datumSize = int64(unsafe.Sizeof(types.Datum{}))
+
+// datumsMemUsage estimates the memory used by a slice of datums.
+func datumsMemUsage(data []types.Datum) int64 {
+	usage := datumSize * int64(len(data))
+	for i := range data {
+		switch data[i].Kind() {
+		case types.KindString, types.KindBytes, types.KindMysqlEnum, types.KindMysqlSet:
+			usage += int64(len(data[i].GetBytes()))
+		case types.KindMysqlDecimal, types.KindMysqlTime:
+			usage += datumSize
+		}
+	}
+	return usage
+}
+
+// rowMemUsage estimates the memory used by a row.
+func rowMemUsage(row *Row) int64 {
+	return datumsMemUsage(row.Data) + int64(len(row.RowKeys))*int64(unsafe.Sizeof(RowKeyEntry{}))
+}
+
+// memUsage estimates the memory used by the row and its order values.
+func (r *orderByRow) memUsage() int64 {
+	return rowMemUsage(r.row) + datumsMemUsage(r.key)
+}
+
+// getMemQuota gets the memory quota of the session in bytes. Zero means no limit.
+func getMemQuota(ctx context.Context) (int64, error) {
+	sessionVars := variable.GetSessionVars(ctx)
+	quota, err := sessionVars.GetTiDBSystemVar(ctx, variable.TiDBMemQuotaSession)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	q, err := strconv.ParseInt(quota, 10, 64)
+	return q, errors.Trace(err)
+}
+
+// sessionMemExceeds returns whether the memory tracked by the session exceeds quota. Zero quota means no limit.
+func sessionMemExceeds(ctx context.Context, quota int64) bool {
+	return quota > 0 && variable.GetSessionVars(ctx).MemTracker.BytesConsumed() > quota
+}
+
+// encodeSpillDatum appends a datum to b. Unlike codec.EncodeValue, the kind of the datum and
+// the extra information of mysql types are kept, so the datum can be restored exactly.
+func encodeSpillDatum(b []byte, d types.Datum) ([]byte, error) {
+	kind := types.NewUintDatum(uint64(d.Kind()))
+	switch d.Kind() {
+	case types.KindNull:
+		return codec.EncodeValue(b, kind)
+	case types.KindInt64, types.KindUint64, types.KindFloat32, types.KindFloat64,
+		types.KindString, types.KindBytes, types.KindMysqlDecimal:
+		return codec.EncodeValue(b, kind, d)
+	case types.KindMysqlTime:
+		t := d.GetMysqlTime()
+		return codec.EncodeValue(b, kind, types.NewUintDatum(t.ToPackedUint()),
+			types.NewIntDatum(int64(t.Type)), types.NewIntDatum(int64(t.Fsp)))
+	case types.KindMysqlDuration:
+		dur := d.GetMysqlDuration()
+		return codec.EncodeValue(b, kind, types.NewIntDatum(int64(dur.Duration)), types.NewIntDatum(int64(dur.Fsp)))
+	case types.KindMysqlEnum:
+		enum := d.GetMysqlEnum()
+		return codec.EncodeValue(b, kind, types.NewStringDatum(enum.Name), types.NewUintDatum(enum.Value))
+	case types.KindMysqlSet:
+		set := d.GetMysqlSet()
+		return codec.EncodeValue(b, kind, types.NewStringDatum(set.Name), types.NewUintDatum(set.Value))
+	case types.KindMysqlBit:
+		bit := d.GetMysqlBit()
+		return codec.EncodeValue(b, kind, types.NewUintDatum(bit.Value), types.NewIntDatum(int64(bit.Width)))
+	case types.KindMysqlHex:
+		return codec.EncodeValue(b, kind, types.NewIntDatum(d.GetMysqlHex().Value))
+	}
+	return nil, errors.Errorf("unsupported spill datum kind %d", d.Kind())
+}
+
+// decodeSpillDatum decodes a datum encoded by encodeSpillDatum.
+func decodeSpillDatum(b []byte) ([]byte, types.Datum, error) {
+	var d types.Datum
+	b, kind, err := codec.DecodeOne(b)
+	if err != nil {
+		return nil, d, errors.Trace(err)
+	}
+	k := byte(kind.GetUint64())
+	var vals [3]types.Datum
+	n := 0
+	switch k {
+	case types.KindNull:
+		return b, d, nil
+	case types.KindInt64, types.KindUint64, types.KindFloat32, types.KindFloat64,
+		types.KindString, types.KindBytes, types.KindMysqlDecimal, types.KindMysqlHex:
+		n = 1
+	case types.KindMysqlDuration, types.KindMysqlEnum, types.KindMysqlSet, types.KindMysqlBit:
+		n = 2
+	case types.KindMysqlTime:
+		n = 3
+	default:
+		return nil, d, errors.Errorf("unsupported spill datum kind %d", k)
+	}
+	for i := 0; i < n; i++ {
+		b, vals[i], err = codec.DecodeOne(b)
+		if err != nil {
+			return nil, d, errors.Trace(err)
+		}
+	}
+	switch k {
+	case types.KindFloat32:
+		d.SetFloat32(float32(vals[0].GetFloat64()))
+	case types.KindString:
+		d.SetBytesAsString(vals[0].GetBytes())
+	case types.KindMysqlTime:
+		t := mysql.Time{Type: uint8(vals[1].GetInt64()), Fsp: int(vals[2].GetInt64())}
+		if err = t.FromPackedUint(vals[0].GetUint64()); err != nil {
+			return nil, d, errors.Trace(err)
+		}
+		d.SetMysqlTime(t)
+	case types.KindMysqlDuration:
+		d.SetMysqlDuration(mysql.Duration{Duration: time.Duration(vals[0].GetInt64()), Fsp: int(vals[1].GetInt64())})
+	case types.KindMysqlEnum:
+		d.SetMysqlEnum(mysql.Enum{Name: string(vals[0].GetBytes()), Value: vals[1].GetUint64()})
+	case types.KindMysqlSet:
+		d.SetMysqlSet(mysql.Set{Name: string(vals[0].GetBytes()), Value: vals[1].GetUint64()})
+	case types.KindMysqlBit:
+		d.SetMysqlBit(mysql.Bit{Value: vals[0].GetUint64(), Width: int(vals[1].GetInt64())})
+	case types.KindMysqlHex:
+		d.SetMysqlHex(mysql.Hex{Value: vals[0].GetInt64()})
+	default:
+		d = vals[0]
+	}
+	return b, d, nil
+}
+
+// sortedRun is a sequence of sorted rows that SortExec has spilled to a temporary file.
+// The file is only open while the run is written or merged.
+type sortedRun struct {
+	name   string
+	file   *os.File
+	reader *bufio.Reader
+}
+
+// newSortedRun creates a temporary file for a sorted run.
+func newSortedRun() (*sortedRun, error) {
+	f, err := ioutil.TempFile(SpillDir, "tidb-sort-")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &sortedRun{name: f.Name(), file: f}, nil
+}
+
+// write writes an encoded row prefixed by its length.
+func (r *sortedRun) write(w *bufio.Writer, data []byte) error {
+	var lenBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(lenBuf[:], uint64(len(data)))
+	if _, err := w.Write(lenBuf[:n]); err != nil {
+		return errors.Trace(err)
+	}
+	_, err := w.Write(data)
+	return errors.Trace(err)
+}
+
+// finishWrite closes the file after the run is written.
+func (r *sortedRun) finishWrite() error {
+	err := r.file.Close()
+	r.file = nil
+	return errors.Trace(err)
+}
+
+// rewind opens the run for reading from the beginning.
+func (r *sortedRun) rewind() error {
+	f, err := os.Open(r.name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	r.file = f
+	r.reader = bufio.NewReader(f)
+	return nil
+}
+
+// read reads the next encoded row, it returns nil when the run is exhausted.
+// The decoded datums refer to the returned slice, so it is not reused.
+func (r *sortedRun) read() ([]byte, error) {
+	l, err := binary.ReadUvarint(r.reader)
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	data := make([]byte, l)
+	_, err = io.ReadFull(r.reader, data)
+	return data, errors.Trace(err)
+}
+
+// close closes and removes the temporary file.
+func (r *sortedRun) close() {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			log.Warnf("[sort] close spill file %s error %v", r.name, err)
+		}
+		r.file = nil
+		r.reader = nil
+	}
+	if err := os.Remove(r.name); err != nil {
+		log.Warnf("[sort] remove spill file %s error %v", r.name, err)
+	}
+}
+
+// mergeItem is the head row of a sorted run during merging, data is the encoded row.
+type mergeItem struct {
+	row  *orderByRow
+	data []byte
+	run  *sortedRun
+}
+
+// mergeHeap merges sorted runs, the top of the heap is the smallest head row.
+type mergeHeap struct {
+	sorter *SortExec
+	items  []*mergeItem
+}
+
+// Len implements heap.Interface Len interface.
+func (h *mergeHeap) Len() int {
+	return len(h.items)
+}
+
+// Less implements heap.Interface Less interface.
+func (h *mergeHeap) Less(i, j int) bool {
+	return h.sorter.lessRow(h.items[i].row, h.items[j].row)
+}
+
+// Swap implements heap.Interface Swap interface.
+func (h *mergeHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+// Push implements heap.Interface Push interface.
+func (h *mergeHeap) Push(x interface{}) {
+	h.items = append(h.items, x.(*mergeItem))
+}
+
+// Pop implements heap.Interface Pop interface.
+func (h *mergeHeap) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -105,6 +106,7 @@ type UnionScanExec struct {
 	condition expression.Expression
 
 	addedRows   []*Row
+	memTracker  *memory.Tracker
 	cursor      int
 	sortErr     error
 	snapshotRow *Row
@@ -277,6 +279,9 @@ func (us *UnionScanExec) buildAndSortAddedRows(t table.Table, asName *model.CISt
 		rowKeyEntry := &RowKeyEntry{Handle: h, Tbl: t, TableAsName: asName}
 		row := &Row{Data: newData, RowKeys: []*RowKeyEntry{rowKeyEntry}}
 		us.addedRows = append(us.addedRows, row)
+		if err := us.memTracker.Consume(rowMemUsage(row)); err != nil {
+			return errors.Trace(err)
+		}
 	}
 	if us.desc {
 		sort.Sort(sort.Reverse(us))
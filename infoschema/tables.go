@@ -16,13 +16,19 @@ package infoschema
 import (
 	"fmt"
 	"sort"
+	"strings"
 
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/meta/autoid"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/table/tables"
 	"github.com/pingcap/tidb/util/charset"
+	"github.com/pingcap/tidb/util/slowlog"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -38,6 +44,7 @@ const (
 	tableProfiling     = "PROFILING"
 	tablePartitions    = "PARTITIONS"
 	tableKeyColumm     = "KEY_COLUMN_USAGE"
+	tableSlowQuery     = "SLOW_QUERY"
 )
 
 type columnInfo struct {
@@ -182,6 +189,21 @@ var profilingCols = []columnInfo{
 	{"SOURCE_LINE", mysql.TypeLong, 20, 0, nil, nil},
 }
 
+var slowQueryCols = []columnInfo{
+	{"TIME", mysql.TypeDatetime, 26, 0, nil, nil},
+	{"TXN_START_TS", mysql.TypeLonglong, 20, 0, nil, nil},
+	{"USER", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"CONN_ID", mysql.TypeLonglong, 20, 0, nil, nil},
+	{"DB", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"QUERY_TIME", mysql.TypeDouble, 22, 0, nil, nil},
+	{"PARSE_TIME", mysql.TypeDouble, 22, 0, nil, nil},
+	{"COMPILE_TIME", mysql.TypeDouble, 22, 0, nil, nil},
+	{"COP_TIME", mysql.TypeDouble, 22, 0, nil, nil},
+	{"ROWS", mysql.TypeLonglong, 20, 0, nil, nil},
+	{"DIGEST", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"QUERY", mysql.TypeBlob, -1, 0, nil, nil},
+}
+
 var charsetCols = []columnInfo{
 	{"CHARACTER_SET_NAME", mysql.TypeVarchar, 32, 0, nil, nil},
 	{"DEFAULT_COLLATE_NAME", mysql.TypeVarchar, 32, 0, nil, nil},
@@ -302,6 +324,70 @@ var filesCols = []columnInfo{
 	{"EXTRA", mysql.TypeVarchar, 255, 0, nil, nil},
 }
 
+func dataForSlowQuery(entries []*slowlog.Entry) [][]types.Datum {
+	rows := make([][]types.Datum, 0, len(entries))
+	for _, e := range entries {
+		record := types.MakeDatums(
+			mysql.Time{Time: e.StartTime.Local(), Type: mysql.TypeDatetime, Fsp: mysql.MaxFsp}, // TIME
+			e.TxnStartTS,            // TXN_START_TS
+			e.User,                  // USER
+			e.ConnID,                // CONN_ID
+			e.DB,                    // DB
+			e.QueryTime.Seconds(),   // QUERY_TIME
+			e.ParseTime.Seconds(),   // PARSE_TIME
+			e.CompileTime.Seconds(), // COMPILE_TIME
+			e.CopTime.Seconds(),     // COP_TIME
+			e.Rows,                  // ROWS
+			e.Digest,                // DIGEST
+			e.SQL,                   // QUERY
+		)
+		rows = append(rows, record)
+	}
+	return rows
+}
+
+// IsSlowQueryTable checks whether the table is INFORMATION_SCHEMA.SLOW_QUERY, the names are in lower case.
+func IsSlowQueryTable(dbName, tblName string) bool {
+	return dbName == strings.ToLower(Name) && tblName == strings.ToLower(tableSlowQuery)
+}
+
+// SlowQueryTable returns a memory table of SLOW_QUERY filled with the entries in the slow log file.
+// The slow log file changes all the time, so it is read every time the table is scanned.
+// The users without the PROCESS privilege can only see their own statements.
+func SlowQueryTable(ctx context.Context, meta *model.TableInfo) (table.Table, error) {
+	entries, err := slowlog.ReadFile()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if checker := privilege.GetPrivilegeChecker(ctx); checker != nil {
+		hasPriv, err := checker.Check(ctx, nil, nil, mysql.ProcessPriv)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !hasPriv {
+			entries = filterSlowQueryByUser(entries, variable.GetSessionVars(ctx).User)
+		}
+	}
+	tbl, err := createMemoryTable(meta, autoid.NewMemoryAllocator(infoSchemaDB.ID))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = insertData(tbl, dataForSlowQuery(entries))
+	return tbl, errors.Trace(err)
+}
+
+// filterSlowQueryByUser returns the entries of the statements run by the user, both the user name
+// and the host in the form of "user@host" must match.
+func filterSlowQueryByUser(entries []*slowlog.Entry, user string) []*slowlog.Entry {
+	filtered := entries[:0]
+	for _, e := range entries {
+		if e.User == user {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
 func dataForSchemata(schemas []string) [][]types.Datum {
 	sort.Strings(schemas)
 	rows := [][]types.Datum{}
@@ -498,6 +584,7 @@ var tableNameToColumns = map[string]([]columnInfo){
 	tableProfiling:     profilingCols,
 	tablePartitions:    partitionsCols,
 	tableKeyColumm:     keyColumnUsageCols,
+	tableSlowQuery:     slowQueryCols,
 }
 
 func createMemoryTable(meta *model.TableInfo, alloc autoid.Allocator) (table.Table, error) {
@@ -14,7 +14,9 @@
 package inspectkv
 
 import (
+	"bytes"
 	"io"
+	"math"
 	"reflect"
 
 	"github.com/juju/errors"
@@ -34,6 +36,8 @@ import (
 type DDLInfo struct {
 	SchemaVer   int64
 	ReorgHandle int64 // it's only used for DDL information.
+	// ReorgRanges are the handle ranges of the reorganization, it's only used for DDL information.
+	ReorgRanges []*model.ReorgRange
 	Owner       *model.Owner
 	Job         *model.Job
 }
@@ -64,6 +68,18 @@ func GetDDLInfo(txn kv.Transaction) (*DDLInfo, error) {
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	info.ReorgRanges, err = t.GetDDLReorgRanges(info.Job)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(info.ReorgRanges) > 0 {
+		// The saved ranges have the latest progress, the row count of the job is only updated by the worker periodically.
+		var count int64
+		for _, r := range info.ReorgRanges {
+			count += r.RowCount
+		}
+		info.Job.RowCount = count
+	}
 
 	return info, nil
 }
@@ -90,6 +106,73 @@ func GetBgDDLInfo(txn kv.Transaction) (*DDLInfo, error) {
 	return info, nil
 }
 
+// GetDDLJobs returns the DDL jobs in the queue, the first job is the running one.
+func GetDDLJobs(txn kv.Transaction) ([]*model.Job, error) {
+	t := meta.NewMeta(txn)
+	cnt, err := t.DDLJobQueueLen()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	jobs := make([]*model.Job, 0, cnt)
+	for i := int64(0); i < cnt; i++ {
+		job, err := t.GetDDLJob(i)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// DefNumHistoryJobs is the default number of the history DDL jobs returned by GetHistoryDDLJobs.
+const DefNumHistoryJobs = 10
+
+// GetHistoryDDLJobs returns the most recent history DDL jobs, the latest job is the first.
+func GetHistoryDDLJobs(txn kv.Transaction) ([]*model.Job, error) {
+	jobs, err := meta.NewMeta(txn).GetLastNHistoryDDLJobs(DefNumHistoryJobs)
+	return jobs, errors.Trace(err)
+}
+
+// CancelJobs requests to cancel the DDL jobs in the queue, the DDL worker cancels a job or rolls it back later.
+// A job can be cancelled before it changes the schema, an add index job can also be rolled back after that.
+// The returned errors are the results of the jobs, a nil error means the job is cancelling.
+func CancelJobs(txn kv.Transaction, ids []int64) ([]error, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	t := meta.NewMeta(txn)
+	jobs, err := GetDDLJobs(txn)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	errs := make([]error, len(ids))
+	for i, id := range ids {
+		found := false
+		for j, job := range jobs {
+			if job.ID != id {
+				continue
+			}
+			found = true
+			switch {
+			case job.IsCancelling():
+				errs[i] = errCancelledDDLJob.Gen("This job:%v is cancelling", id)
+			case job.IsFinished() || job.State == model.JobRollback:
+				errs[i] = errCancelledDDLJob.Gen("This job:%v is finished, so can't be cancelled", id)
+			case job.Type != model.ActionAddIndex && job.SchemaState != model.StateNone:
+				errs[i] = errCancelledDDLJob.Gen("This job:%v is in state %s, so can't be cancelled", id, job.SchemaState)
+			default:
+				job.State = model.JobCancelling
+				errs[i] = errors.Trace(t.UpdateDDLJob(int64(j), job))
+			}
+			break
+		}
+		if !found {
+			errs[i] = errDDLJobNotFound.Gen("DDL Job:%v not found", id)
+		}
+	}
+	return errs, nil
+}
+
 func nextIndexVals(data []types.Datum) []types.Datum {
 	// Add 0x0 to the end of data.
 	return append(data, types.Datum{})
@@ -488,10 +571,341 @@ const (
 	codeDataNotEqual       terror.ErrCode = 1
 	codeRepeatHandle                      = 2
 	codeInvalidColumnState                = 3
+	codeDDLJobNotFound                    = 4
+	codeCancelledDDLJob                   = 5
 )
 
 var (
 	errDateNotEqual       = terror.ClassInspectkv.New(codeDataNotEqual, "data isn't equal")
 	errRepeatHandle       = terror.ClassInspectkv.New(codeRepeatHandle, "handle is repeated")
 	errInvalidColumnState = terror.ClassInspectkv.New(codeInvalidColumnState, "invalid column state")
+	errDDLJobNotFound     = terror.ClassInspectkv.New(codeDDLJobNotFound, "DDL Job not found")
+	errCancelledDDLJob    = terror.ClassInspectkv.New(codeCancelledDDLJob, "cancel DDL job failed")
 )
+
+// AdminBatchSize is the number of the rows or index entries handled in a transaction by the admin index functions.
+var AdminBatchSize = 1024
+
+// HandleRange is a range of row handles, both ends are included.
+type HandleRange struct {
+	Begin int64
+	End   int64
+}
+
+// FullHandleRanges are the ranges of all the row handles.
+var FullHandleRanges = []HandleRange{{Begin: math.MinInt64, End: math.MaxInt64}}
+
+func inHandleRanges(h int64, ranges []HandleRange) bool {
+	for _, r := range ranges {
+		if h >= r.Begin && h <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Inconsistency is a mismatch between an index entry and a row. Index is nil if the row has no index entry,
+// Record is nil if the index entry points to a row that doesn't exist.
+type Inconsistency struct {
+	Index  *RecordData
+	Record *RecordData
+}
+
+// CheckIndexRanges checks the index entries and the rows whose handles are in the ranges in batched transactions,
+// it returns all the inconsistencies found instead of stopping at the first one.
+// The whole index is scanned only for the full ranges, otherwise only the index entries that have the same values
+// as the rows in the ranges are checked, so a dangling entry whose values match none of these rows, like the entry
+// of a deleted row, is found only by checking the full ranges.
+func CheckIndexRanges(store kv.Storage, t table.Table, idx table.Index, ranges []HandleRange) ([]*Inconsistency, error) {
+	var result []*Inconsistency
+	full := isFullHandleRanges(ranges)
+	// The index entries found by the seeks of several rows are reported once.
+	checked := make(map[string]struct{})
+	// The transactions are read only and not retried, so the inconsistencies are found only once.
+	_, _, err := iterRecordBatches(store, t, idx, ranges, false, func(txn kv.Transaction, records []*RecordData) (int, error) {
+		for _, r := range records {
+			exist, h, err := idx.Exist(txn, r.Values, r.Handle)
+			if terror.ErrorEqual(err, kv.ErrKeyExists) {
+				result = append(result, &Inconsistency{Index: &RecordData{Handle: h, Values: r.Values}, Record: r})
+				continue
+			}
+			if err != nil {
+				return 0, errors.Trace(err)
+			}
+			if !exist {
+				result = append(result, &Inconsistency{Record: r})
+			}
+			if full {
+				continue
+			}
+			incs, err := checkIndexEntriesOfRecord(txn, t, idx, r, ranges, checked)
+			if err != nil {
+				return 0, errors.Trace(err)
+			}
+			result = append(result, incs...)
+		}
+		return 0, nil
+	})
+	if err != nil || !full {
+		return result, errors.Trace(err)
+	}
+	_, err = iterIndexBatches(store, t, idx, false, func(txn kv.Transaction, entries []*RecordData) (int, error) {
+		for _, entry := range entries {
+			record, ok, err := checkIndexEntry(txn, t, idx, entry)
+			if err != nil {
+				return 0, errors.Trace(err)
+			}
+			if !ok {
+				result = append(result, &Inconsistency{Index: entry, Record: record})
+			}
+		}
+		return 0, nil
+	})
+	return result, errors.Trace(err)
+}
+
+func isFullHandleRanges(ranges []HandleRange) bool {
+	for _, r := range ranges {
+		if r.Begin == math.MinInt64 && r.End == math.MaxInt64 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIndexEntriesOfRecord seeks the index entries that have the same values as the row, and checks the ones of
+// the other rows in the ranges. The entries in checked are skipped, and the checked entries are added to it.
+func checkIndexEntriesOfRecord(txn kv.Transaction, t table.Table, idx table.Index, r *RecordData, ranges []HandleRange,
+	checked map[string]struct{}) ([]*Inconsistency, error) {
+	// The index may store the prefixes of the values, so the values are compared by their index keys.
+	valsKey, _, err := idx.GenIndexKey(append([]types.Datum(nil), r.Values...), 0)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	it, _, err := idx.Seek(txn, r.Values)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer it.Close()
+	var result []*Inconsistency
+	for {
+		vals, h, err := it.Next()
+		if terror.ErrorEqual(err, io.EOF) {
+			return result, nil
+		} else if err != nil {
+			return nil, errors.Trace(err)
+		}
+		key, _, err := idx.GenIndexKey(append([]types.Datum(nil), vals...), 0)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !bytes.Equal(key, valsKey) {
+			return result, nil
+		}
+		// The entry of the row itself is checked by Exist.
+		if h == r.Handle || !inHandleRanges(h, ranges) {
+			continue
+		}
+		entryKey, _, err := idx.GenIndexKey(append([]types.Datum(nil), vals...), h)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if _, ok := checked[string(entryKey)]; ok {
+			continue
+		}
+		checked[string(entryKey)] = struct{}{}
+		entry := &RecordData{Handle: h, Values: vals}
+		record, ok, err := checkIndexEntry(txn, t, idx, entry)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !ok {
+			result = append(result, &Inconsistency{Index: entry, Record: record})
+		}
+	}
+}
+
+// RecoverIndex adds the missing index entries of the rows in batched transactions.
+// It returns the number of the added index entries and the number of the scanned rows.
+func RecoverIndex(store kv.Storage, t table.Table, idx table.Index) (added, scanned int64, err error) {
+	added, scanned, err = iterRecordBatches(store, t, idx, FullHandleRanges, true, func(txn kv.Transaction, records []*RecordData) (int, error) {
+		cnt := 0
+		for _, r := range records {
+			exist, h, err := idx.Exist(txn, r.Values, r.Handle)
+			if terror.ErrorEqual(err, kv.ErrKeyExists) {
+				// The unique index entry points to another row, it should be cleaned up first.
+				return 0, errDateNotEqual.Gen("index:%v != record:%v", &RecordData{Handle: h, Values: r.Values}, r)
+			}
+			if err != nil {
+				return 0, errors.Trace(err)
+			}
+			if exist {
+				continue
+			}
+			if err = txn.LockKeys(t.RecordKey(r.Handle)); err != nil {
+				return 0, errors.Trace(err)
+			}
+			if _, err = idx.Create(txn, r.Values, r.Handle); err != nil {
+				return 0, errors.Trace(err)
+			}
+			cnt++
+		}
+		return cnt, nil
+	})
+	return added, scanned, errors.Trace(err)
+}
+
+// CleanupIndex deletes the dangling index entries in batched transactions, a dangling entry points to a row that
+// doesn't exist or has different values. It returns the number of the deleted index entries.
+func CleanupIndex(store kv.Storage, t table.Table, idx table.Index) (int64, error) {
+	removed, err := iterIndexBatches(store, t, idx, true, func(txn kv.Transaction, entries []*RecordData) (int, error) {
+		cnt := 0
+		for _, entry := range entries {
+			_, ok, err := checkIndexEntry(txn, t, idx, entry)
+			if err != nil {
+				return 0, errors.Trace(err)
+			}
+			if ok {
+				continue
+			}
+			if err = idx.Delete(txn, entry.Values, entry.Handle); err != nil {
+				return 0, errors.Trace(err)
+			}
+			cnt++
+		}
+		return cnt, nil
+	})
+	return removed, errors.Trace(err)
+}
+
+// checkIndexEntry checks whether an index entry matches its row, the row data is returned if it exists.
+func checkIndexEntry(txn kv.Transaction, t table.Table, idx table.Index, entry *RecordData) (*RecordData, bool, error) {
+	vals, err := rowWithCols(txn, t, entry.Handle, indexColumns(t, idx))
+	if terror.ErrorEqual(err, kv.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Trace(err)
+	}
+	record := &RecordData{Handle: entry.Handle, Values: vals}
+	// The index may store the prefixes of the values, so the values are compared by their index keys.
+	key1, _, err := idx.GenIndexKey(append([]types.Datum(nil), entry.Values...), entry.Handle)
+	if err != nil {
+		return nil, false, errors.Trace(err)
+	}
+	key2, _, err := idx.GenIndexKey(append([]types.Datum(nil), vals...), entry.Handle)
+	if err != nil {
+		return nil, false, errors.Trace(err)
+	}
+	return record, bytes.Equal(key1, key2), nil
+}
+
+func indexColumns(t table.Table, idx table.Index) []*table.Column {
+	cols := make([]*table.Column, len(idx.Meta().Columns))
+	for i, col := range idx.Meta().Columns {
+		cols[i] = t.Cols()[col.Offset]
+	}
+	return cols
+}
+
+// batchFunc handles a batch of rows or index entries in a transaction, it returns the number of the changed ones.
+// A retryable transaction may call it again, so it shouldn't change the state outside of the transaction then.
+type batchFunc func(txn kv.Transaction, data []*RecordData) (int, error)
+
+// iterRecordBatches calls fn with the index column values of the rows whose handles are in the ranges,
+// every batch is handled in a new transaction. It returns the sum of the numbers returned by fn and the number of
+// the scanned rows.
+func iterRecordBatches(store kv.Storage, t table.Table, idx table.Index, ranges []HandleRange, retryable bool,
+	fn batchFunc) (changed, scanned int64, err error) {
+	cols := indexColumns(t, idx)
+	for _, r := range ranges {
+		start := r.Begin
+		for start <= r.End {
+			var (
+				records []*RecordData
+				next    int64
+				done    bool
+				cnt     int
+			)
+			err = kv.RunInNewTxn(store, retryable, func(txn kv.Transaction) error {
+				var err1 error
+				records, next, err1 = scanTableData(txn, t, cols, start, int64(AdminBatchSize))
+				if err1 != nil {
+					return errors.Trace(err1)
+				}
+				done = len(records) < AdminBatchSize
+				for i, record := range records {
+					if record.Handle > r.End {
+						records, done = records[:i], true
+						break
+					}
+				}
+				cnt, err1 = fn(txn, records)
+				return errors.Trace(err1)
+			})
+			if err != nil {
+				return 0, 0, errors.Trace(err)
+			}
+			changed += int64(cnt)
+			scanned += int64(len(records))
+			// The next handle overflows if the last handle is math.MaxInt64.
+			if done || next == math.MinInt64 {
+				break
+			}
+			start = next
+		}
+	}
+	return changed, scanned, nil
+}
+
+// iterIndexBatches calls fn with the index entries, every batch is handled in a new transaction.
+// It returns the sum of the numbers returned by fn.
+func iterIndexBatches(store kv.Storage, t table.Table, idx table.Index, retryable bool, fn batchFunc) (int64, error) {
+	var (
+		changed int64
+		last    *RecordData
+	)
+	for {
+		var (
+			entries []*RecordData
+			done    bool
+			cnt     int
+		)
+		err := kv.RunInNewTxn(store, retryable, func(txn kv.Transaction) error {
+			entries, done = nil, false
+			var startVals []types.Datum
+			if last != nil {
+				startVals = last.Values
+			}
+			it, _, err := idx.Seek(txn, startVals)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			defer it.Close()
+			for len(entries) < AdminBatchSize {
+				vals, h, err := it.Next()
+				if terror.ErrorEqual(err, io.EOF) {
+					done = true
+					break
+				} else if err != nil {
+					return errors.Trace(err)
+				}
+				// The seek starts from the values of the last entry, the entries handled by the last batch are skipped.
+				if last != nil && h <= last.Handle && reflect.DeepEqual(vals, last.Values) {
+					continue
+				}
+				entries = append(entries, &RecordData{Handle: h, Values: vals})
+			}
+			cnt, err = fn(txn, entries)
+			return errors.Trace(err)
+		})
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		changed += int64(cnt)
+		if done || len(entries) == 0 {
+			return changed, nil
+		}
+		last = entries[len(entries)-1]
+	}
+}
@@ -15,6 +15,7 @@ package kv
 
 import (
 	"io"
+	"time"
 )
 
 // Transaction options
@@ -65,7 +66,11 @@ type RetrieverMutator interface {
 }
 
 // MemBuffer is an in-memory kv collection, can be used to buffer write operations.
-type MemBuffer RetrieverMutator
+type MemBuffer interface {
+	RetrieverMutator
+	// Size returns the sum of the key and value lengths in the buffer.
+	Size() int
+}
 
 // Transaction defines the interface for operations inside a Transaction.
 // This is not thread safe.
@@ -73,6 +78,8 @@ type Transaction interface {
 	RetrieverMutator
 	// Commit commits the transaction operations to KV store.
 	Commit() error
+	// Size returns the size of the buffered write operations.
+	Size() int
 	// Rollback undoes the transaction operations to KV store.
 	Rollback() error
 	// String implements fmt.Stringer interface.
@@ -125,6 +132,8 @@ type Request struct {
 	// ResponseIterator.Next is called. If concurrency is greater than 1, the request will be
 	// sent to multiple storage units concurrently.
 	Concurrency int
+	// If Deadline is not zero, the request fails with ErrQueryTimeout when it is not done before the deadline.
+	Deadline time.Time
 }
 
 // Response represents the response returned from KV layer.
@@ -168,6 +177,13 @@ type Storage interface {
 	CurrentVersion() (Version, error)
 }
 
+// SplittableStorage is a storage that splits the keys into ranges stored separately, like the Regions of TiKV.
+type SplittableStorage interface {
+	Storage
+	// GetSplitKeys gets the keys in the range (startKey, endKey) where the storage splits the keys, in ascending order.
+	GetSplitKeys(startKey, endKey Key) ([]Key, error)
+}
+
 // FnKeyCmp is the function for iterator the keys
 type FnKeyCmp func(key Key) bool
 
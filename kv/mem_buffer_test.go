@@ -183,6 +183,21 @@ func (s *testKVSuite) TestBasicNewIterator(c *C) {
 	}
 }
 
+func (s *testKVSuite) TestSize(c *C) {
+	defer testleak.AfterTest(c)()
+	s.ResetMembuffers()
+	defer s.ResetMembuffers()
+	for _, buffer := range s.bs {
+		c.Assert(buffer.Size(), Equals, 0)
+		err := buffer.Set([]byte("key"), []byte("value"))
+		c.Assert(err, IsNil)
+		c.Assert(buffer.Size(), Equals, 8)
+		err = buffer.Set([]byte("key"), []byte("v"))
+		c.Assert(err, IsNil)
+		c.Assert(buffer.Size(), GreaterEqual, 4)
+	}
+}
+
 func (s *testKVSuite) TestNewIteratorMin(c *C) {
 	defer testleak.AfterTest(c)()
 	kvs := []struct {
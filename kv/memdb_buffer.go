@@ -86,6 +86,11 @@ func (m *memDbBuffer) Delete(k Key) error {
 	return errors.Trace(err)
 }
 
+// Size returns the sum of the key and value lengths in the buffer.
+func (m *memDbBuffer) Size() int {
+	return m.db.Size()
+}
+
 // Next implements the Iterator Next.
 func (i *memDbIter) Next() error {
 	if i.reverse {
@@ -35,6 +35,10 @@ func (t *mockTxn) String() string {
 	return ""
 }
 
+func (t *mockTxn) Size() int {
+	return 0
+}
+
 func (t *mockTxn) LockKeys(keys ...Key) error {
 	return nil
 }
@@ -36,6 +36,7 @@ func (pair *pairItem) Less(item llrb.Item) bool {
 
 type rbTreeBuffer struct {
 	tree *llrb.LLRB
+	size int
 }
 
 type rbTreeIter struct {
@@ -75,16 +76,30 @@ func (m *rbTreeBuffer) Set(k Key, v []byte) error {
 	if len(v) == 0 {
 		return errors.Trace(ErrCannotSetNilValue)
 	}
-	m.tree.ReplaceOrInsert(&pairItem{key: k, value: v})
+	m.replaceOrInsert(&pairItem{key: k, value: v})
 	return nil
 }
 
 // Delete removes the entry from buffer with provided key.
 func (m *rbTreeBuffer) Delete(k Key) error {
-	m.tree.ReplaceOrInsert(&pairItem{key: k, value: nil})
+	m.replaceOrInsert(&pairItem{key: k, value: nil})
 	return nil
 }
 
+func (m *rbTreeBuffer) replaceOrInsert(pair *pairItem) {
+	old := m.tree.ReplaceOrInsert(pair)
+	if old != nil {
+		oldPair := old.(*pairItem)
+		m.size -= len(oldPair.key) + len(oldPair.value)
+	}
+	m.size += len(pair.key) + len(pair.value)
+}
+
+// Size returns the sum of the key and value lengths in the buffer.
+func (m *rbTreeBuffer) Size() int {
+	return m.size
+}
+
 // Next implements the Iterator Next.
 func (i *rbTreeIter) Next() error {
 	i.pair = nil
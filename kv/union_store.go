@@ -123,6 +123,13 @@ func (lmb *lazyMemBuffer) Delete(k Key) error {
 	return lmb.mb.Delete(k)
 }
 
+func (lmb *lazyMemBuffer) Size() int {
+	if lmb.mb == nil {
+		return 0
+	}
+	return lmb.mb.Size()
+}
+
 func (lmb *lazyMemBuffer) Seek(k Key) (Iterator, error) {
 	if lmb.mb == nil {
 		return invalidIterator{}, nil
@@ -437,6 +437,8 @@ var (
 	mDDLJobListKey    = []byte("DDLJobList")
 	mDDLJobHistoryKey = []byte("DDLJobHistory")
 	mDDLJobReorgKey   = []byte("DDLJobReorg")
+	// mDDLJobHistoryLastIDKey is the largest ID of the history DDL jobs.
+	mDDLJobHistoryLastIDKey = []byte("DDLJobHistoryLastID")
 )
 
 func (m *Meta) getJobOwner(key []byte) (*model.Owner, error) {
@@ -469,6 +471,7 @@ func (m *Meta) SetDDLJobOwner(o *model.Owner) error {
 }
 
 func (m *Meta) enQueueDDLJob(key []byte, job *model.Job) error {
+	job.StartTS = time.Now().UnixNano()
 	b, err := job.Encode()
 	if err != nil {
 		return errors.Trace(err)
@@ -541,6 +544,7 @@ func (m *Meta) jobIDKey(id int64) []byte {
 }
 
 func (m *Meta) addHistoryDDLJob(key []byte, job *model.Job) error {
+	job.LastUpdateTS = time.Now().UnixNano()
 	b, err := job.Encode()
 	if err != nil {
 		return errors.Trace(err)
@@ -549,9 +553,16 @@ func (m *Meta) addHistoryDDLJob(key []byte, job *model.Job) error {
 	return m.txn.HSet(key, m.jobIDKey(job.ID), b)
 }
 
-// AddHistoryDDLJob adds DDL job to history.
+// AddHistoryDDLJob adds DDL job to history, the largest ID of the history jobs is kept to look up the latest jobs.
 func (m *Meta) AddHistoryDDLJob(job *model.Job) error {
-	return m.addHistoryDDLJob(mDDLJobHistoryKey, job)
+	if err := m.addHistoryDDLJob(mDDLJobHistoryKey, job); err != nil {
+		return errors.Trace(err)
+	}
+	lastID, err := m.txn.GetInt64(mDDLJobHistoryLastIDKey)
+	if err != nil || job.ID <= lastID {
+		return errors.Trace(err)
+	}
+	return errors.Trace(m.txn.Set(mDDLJobHistoryLastIDKey, []byte(strconv.FormatInt(job.ID, 10))))
 }
 
 func (m *Meta) getHistoryDDLJob(key []byte, id int64) (*model.Job, error) {
@@ -590,6 +601,51 @@ func (m *Meta) GetAllHistoryDDLJobs() ([]*model.Job, error) {
 	return jobs, nil
 }
 
+// maxHistoryDDLJobMisses is the max number of the IDs that are not history jobs when the latest history jobs
+// are looked up, the global IDs are also allocated for the schemas and the tables.
+const maxHistoryDDLJobMisses = 1000
+
+// GetLastNHistoryDDLJobs gets at most n latest history DDL jobs, the latest job is the first.
+// The job IDs are allocated by GenGlobalID, so the jobs are looked up from the largest ID of the history jobs
+// downwards, it stops at n jobs, at the number of the history jobs if it is smaller, or after
+// maxHistoryDDLJobMisses IDs are not history jobs. The history isn't scanned.
+func (m *Meta) GetLastNHistoryDDLJobs(n int) ([]*model.Job, error) {
+	cnt, err := m.txn.HLen(mDDLJobHistoryKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if cnt < int64(n) {
+		n = int(cnt)
+	}
+	id, err := m.txn.GetInt64(mDDLJobHistoryLastIDKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if id == 0 && n > 0 {
+		// The history jobs are added before the largest ID is kept, look them up from the current global ID.
+		id, err = m.GetGlobalID()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	var (
+		jobs   []*model.Job
+		misses int
+	)
+	for ; id > 0 && len(jobs) < n && misses < maxHistoryDDLJobMisses; id-- {
+		job, err := m.GetHistoryDDLJob(id)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if job == nil {
+			misses++
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
 // jobsSorter implements the sort.Interface interface.
 type jobsSorter struct {
 	jobs []*model.Job
@@ -638,6 +694,74 @@ func (m *Meta) GetDDLReorgHandle(job *model.Job) (int64, error) {
 	return value, errors.Trace(err)
 }
 
+func (m *Meta) reorgRangesKey(id int64) []byte {
+	return append(m.jobIDKey(id), 'r')
+}
+
+func (m *Meta) reorgRangeKey(id int64, idx int) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(idx))
+	return append(m.reorgRangesKey(id), b...)
+}
+
+// SetDDLReorgRanges saves the handle ranges that the job reorganization is split into.
+// Every range is saved in its own field, so the workers can update them concurrently.
+func (m *Meta) SetDDLReorgRanges(job *model.Job, ranges []*model.ReorgRange) error {
+	for i, r := range ranges {
+		if err := m.UpdateDDLReorgRange(job, i, r); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	err := m.txn.HSet(mDDLJobReorgKey, m.reorgRangesKey(job.ID), []byte(strconv.Itoa(len(ranges))))
+	return errors.Trace(err)
+}
+
+// UpdateDDLReorgRange saves the progress of the idx-th handle range of the job reorganization.
+func (m *Meta) UpdateDDLReorgRange(job *model.Job, idx int, r *model.ReorgRange) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = m.txn.HSet(mDDLJobReorgKey, m.reorgRangeKey(job.ID, idx), b)
+	return errors.Trace(err)
+}
+
+// GetDDLReorgRanges gets the handle ranges of the job reorganization, it returns nil if they are not set.
+func (m *Meta) GetDDLReorgRanges(job *model.Job) ([]*model.ReorgRange, error) {
+	cnt, err := m.txn.HGetInt64(mDDLJobReorgKey, m.reorgRangesKey(job.ID))
+	if err != nil || cnt == 0 {
+		return nil, errors.Trace(err)
+	}
+	ranges := make([]*model.ReorgRange, 0, cnt)
+	for i := 0; i < int(cnt); i++ {
+		value, err := m.txn.HGet(mDDLJobReorgKey, m.reorgRangeKey(job.ID, i))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		r := &model.ReorgRange{}
+		if err = json.Unmarshal(value, r); err != nil {
+			return nil, errors.Trace(err)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// RemoveDDLReorgRanges removes the handle ranges of the job reorganization.
+func (m *Meta) RemoveDDLReorgRanges(job *model.Job) error {
+	cnt, err := m.txn.HGetInt64(mDDLJobReorgKey, m.reorgRangesKey(job.ID))
+	if err != nil || cnt == 0 {
+		return errors.Trace(err)
+	}
+	fields := make([][]byte, 0, cnt+1)
+	for i := 0; i < int(cnt); i++ {
+		fields = append(fields, m.reorgRangeKey(job.ID, i))
+	}
+	fields = append(fields, m.reorgRangesKey(job.ID))
+	err = m.txn.HDel(mDDLJobReorgKey, fields...)
+	return errors.Trace(err)
+}
+
 // DDL background job structure
 //	BgJobOnwer: []byte
 //	BgJobList: list jobs
@@ -263,6 +263,23 @@ func (s *testSuite) TestDDL(c *C) {
 	err = t.RemoveDDLReorgHandle(job)
 	c.Assert(err, IsNil)
 
+	ranges, err := t.GetDDLReorgRanges(job)
+	c.Assert(err, IsNil)
+	c.Assert(ranges, IsNil)
+	ranges = []*model.ReorgRange{{StartHandle: 1, EndHandle: 10}, {StartHandle: 11, EndHandle: 20}}
+	err = t.SetDDLReorgRanges(job, ranges)
+	c.Assert(err, IsNil)
+	err = t.UpdateDDLReorgRange(job, 1, &model.ReorgRange{StartHandle: 15, EndHandle: 20, RowCount: 4})
+	c.Assert(err, IsNil)
+	ranges, err = t.GetDDLReorgRanges(job)
+	c.Assert(err, IsNil)
+	c.Assert(ranges, DeepEquals, []*model.ReorgRange{{StartHandle: 1, EndHandle: 10}, {StartHandle: 15, EndHandle: 20, RowCount: 4}})
+	err = t.RemoveDDLReorgRanges(job)
+	c.Assert(err, IsNil)
+	ranges, err = t.GetDDLReorgRanges(job)
+	c.Assert(err, IsNil)
+	c.Assert(ranges, IsNil)
+
 	v, err = t.DeQueueDDLJob()
 	c.Assert(err, IsNil)
 	c.Assert(v, DeepEquals, job)
@@ -281,6 +298,50 @@ func (s *testSuite) TestDDL(c *C) {
 		lastID = job.ID
 	}
 
+	// The latest history jobs are looked up from the largest ID of the history jobs.
+	for i := 0; i < 3; i++ {
+		// The IDs allocated for the schema objects are skipped.
+		_, err1 := t.GenGlobalID()
+		c.Assert(err1, IsNil)
+		lastID, err1 = t.GenGlobalID()
+		c.Assert(err1, IsNil)
+		c.Assert(t.AddHistoryDDLJob(&model.Job{ID: lastID}), IsNil)
+	}
+	// The job that is added to the history later has a smaller ID.
+	c.Assert(t.AddHistoryDDLJob(&model.Job{ID: lastID - 1}), IsNil)
+	for i := 0; i < 1000; i++ {
+		_, err = t.GenGlobalID()
+		c.Assert(err, IsNil)
+	}
+	latest, err := t.GetLastNHistoryDDLJobs(3)
+	c.Assert(err, IsNil)
+	c.Assert(latest, HasLen, 3)
+	c.Assert(latest[0].ID, Equals, lastID)
+	c.Assert(latest[1].ID, Equals, lastID-1)
+	c.Assert(latest[2].ID, Equals, lastID-2)
+	all, err = t.GetAllHistoryDDLJobs()
+	c.Assert(err, IsNil)
+	latest, err = t.GetLastNHistoryDDLJobs(10)
+	c.Assert(err, IsNil)
+	c.Assert(latest, HasLen, len(all))
+
+	// The lookup gives up after too many IDs that are not history jobs, the max number of the misses is 1000.
+	maxMisses := int64(1000)
+	gapID := lastID + maxMisses + 1
+	c.Assert(t.AddHistoryDDLJob(&model.Job{ID: gapID}), IsNil)
+	latest, err = t.GetLastNHistoryDDLJobs(10)
+	c.Assert(err, IsNil)
+	c.Assert(latest, HasLen, 1)
+	c.Assert(latest[0].ID, Equals, gapID)
+	// The jobs within the limit of the misses are found.
+	c.Assert(t.AddHistoryDDLJob(&model.Job{ID: gapID - maxMisses}), IsNil)
+	latest, err = t.GetLastNHistoryDDLJobs(10)
+	c.Assert(err, IsNil)
+	// The job added first is not found, the 1000 IDs allocated after it use up the misses.
+	c.Assert(latest, HasLen, len(all)+1)
+	c.Assert(latest[1].ID, Equals, gapID-maxMisses)
+	c.Assert(latest[2].ID, Equals, lastID)
+
 	// DDL background job test
 	err = t.SetBgJobOwner(owner)
 	c.Assert(err, IsNil)
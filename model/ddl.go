@@ -70,6 +70,16 @@ func (action ActionType) String() string {
 	}
 }
 
+// ReorgRange is a range of handles [StartHandle, EndHandle] that is reorganized by a worker,
+// StartHandle moves forward as the rows in the range are handled.
+type ReorgRange struct {
+	StartHandle int64 `json:"start_handle"`
+	EndHandle   int64 `json:"end_handle"`
+	// the number of rows handled in the range.
+	RowCount int64 `json:"row_count"`
+	Done     bool  `json:"done"`
+}
+
 // Job is for a DDL operation.
 type Job struct {
 	ID       int64         `json:"id"`
@@ -92,6 +102,8 @@ type Job struct {
 	// unix nano seconds
 	// TODO: use timestamp allocated by TSO.
 	LastUpdateTS int64 `json:"last_update_ts"`
+	// StartTS is the unix nano seconds when the job is queued.
+	StartTS int64 `json:"start_ts"`
 	// Query string of the ddl job.
 	Query string `json:"query"`
 }
@@ -115,9 +127,12 @@ func (job *Job) GetRowCount() int64 {
 // Encode encodes job with json format.
 func (job *Job) Encode() ([]byte, error) {
 	var err error
-	job.RawArgs, err = json.Marshal(job.Args)
-	if err != nil {
-		return nil, errors.Trace(err)
+	// A decoded job without calling DecodeArgs keeps its raw args.
+	if job.Args != nil || job.RawArgs == nil {
+		job.RawArgs, err = json.Marshal(job.Args)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
 	}
 
 	var b []byte
@@ -160,6 +175,11 @@ func (job *Job) IsDone() bool {
 	return job.State == JobDone
 }
 
+// IsCancelling returns whether job is requested to be cancelled.
+func (job *Job) IsCancelling() bool {
+	return job.State == JobCancelling
+}
+
 // IsRunning returns whether job is still running or not.
 func (job *Job) IsRunning() bool {
 	return job.State == JobRunning
@@ -179,6 +199,9 @@ const (
 	JobRollbackDone
 	JobDone
 	JobCancelled
+	// JobCancelling is the state of a job that is requested to be cancelled by ADMIN CANCEL DDL JOBS, the DDL worker
+	// cancels it or converts it to a rollback job.
+	JobCancelling
 )
 
 // String implements fmt.Stringer interface.
@@ -194,6 +217,8 @@ func (s JobState) String() string {
 		return "done"
 	case JobCancelled:
 		return "cancelled"
+	case JobCancelling:
+		return "cancelling"
 	default:
 		return "none"
 	}
@@ -127,6 +127,7 @@ func (testSuite) TestState(c *C) {
 		JobRunning,
 		JobDone,
 		JobCancelled,
+		JobCancelling,
 	}
 
 	for _, state := range jobTbl {
@@ -13,6 +13,12 @@
 
 package mysql
 
+import (
+	"strings"
+
+	"github.com/juju/errors"
+)
+
 // Version informations.
 const (
 	MinProtocolVersion byte   = 10
@@ -44,6 +50,14 @@ const (
 	ServerPSOutParams              uint16 = 0x1000
 )
 
+// Cursor types in the flags of COM_STMT_EXECUTE.
+const (
+	CursorTypeNoCursor   byte = 0x00
+	CursorTypeReadOnly   byte = 0x01
+	CursorTypeForUpdate  byte = 0x02
+	CursorTypeScrollable byte = 0x04
+)
+
 // Identifier length limitations.
 const (
 	MaxTableNameLength    int = 64
@@ -135,6 +149,10 @@ const (
 	TablePrivTable = "Tables_priv"
 	// ColumnPrivTable is the table in system db contains column scope privilege info.
 	ColumnPrivTable = "Columns_priv"
+	// RoleEdgeTable is the table in system db contains the roles granted to the users.
+	RoleEdgeTable = "role_edges"
+	// DefaultRoleTable is the table in system db contains the roles activated when the users log in.
+	DefaultRoleTable = "default_roles"
 	// GlobalVariablesTable is the table contains global system variables.
 	GlobalVariablesTable = "GLOBAL_VARIABLES"
 	// GlobalStatusTable is the table contains global status variables.
@@ -143,6 +161,17 @@ const (
 	TiDBTable = "tidb"
 )
 
+// Authentication plugins.
+const (
+	// AuthNativePassword verifies the SHA1 scramble of the password.
+	AuthNativePassword = "mysql_native_password"
+	// AuthCachingSha2Password verifies the SHA256 scramble of the password with a cache,
+	// or the password sent over a secure connection or encrypted by RSA.
+	AuthCachingSha2Password = "caching_sha2_password"
+	// AuthSocket verifies the operating system user of the client connected by the Unix socket.
+	AuthSocket = "auth_socket"
+)
+
 // PrivilegeType  privilege
 type PrivilegeType uint32
 
@@ -172,6 +201,10 @@ const (
 	ExecutePriv
 	// IndexPriv is the privilege to create/drop index.
 	IndexPriv
+	// ProcessPriv is the privilege to see the statements of the other users.
+	ProcessPriv
+	// FilePriv is the privilege to read and write files on the server host.
+	FilePriv
 	// AllPriv is the privilege for all actions.
 	AllPriv
 )
@@ -190,6 +223,8 @@ var Priv2UserCol = map[PrivilegeType]string{
 	AlterPriv:      "Alter_priv",
 	ExecutePriv:    "Execute_priv",
 	IndexPriv:      "Index_priv",
+	ProcessPriv:    "Process_priv",
+	FilePriv:       "File_priv",
 }
 
 // Col2PrivType is the privilege tables column name to privilege type.
@@ -206,10 +241,12 @@ var Col2PrivType = map[string]PrivilegeType{
 	"Alter_priv":       AlterPriv,
 	"Execute_priv":     ExecutePriv,
 	"Index_priv":       IndexPriv,
+	"Process_priv":     ProcessPriv,
+	"File_priv":        FilePriv,
 }
 
 // AllGlobalPrivs is all the privileges in global scope.
-var AllGlobalPrivs = []PrivilegeType{SelectPriv, InsertPriv, UpdatePriv, DeletePriv, CreatePriv, DropPriv, GrantPriv, AlterPriv, ShowDBPriv, ExecutePriv, IndexPriv, CreateUserPriv}
+var AllGlobalPrivs = []PrivilegeType{SelectPriv, InsertPriv, UpdatePriv, DeletePriv, CreatePriv, DropPriv, GrantPriv, AlterPriv, ShowDBPriv, ExecutePriv, IndexPriv, CreateUserPriv, ProcessPriv, FilePriv}
 
 // Priv2Str is the map for privilege to string.
 var Priv2Str = map[PrivilegeType]string{
@@ -225,6 +262,8 @@ var Priv2Str = map[PrivilegeType]string{
 	AlterPriv:      "Alter",
 	ExecutePriv:    "Execute",
 	IndexPriv:      "Index",
+	ProcessPriv:    "Process",
+	FilePriv:       "File",
 }
 
 // Priv2SetStr is the map for privilege to string.
@@ -266,3 +305,130 @@ var AllColumnPrivs = []PrivilegeType{SelectPriv, InsertPriv, UpdatePriv}
 
 // AllPrivilegeLiteral is the string literal for All Privilege.
 const AllPrivilegeLiteral = "ALL PRIVILEGES"
+
+// SQLMode is the type for MySQL sql_mode.
+// See https://dev.mysql.com/doc/refman/5.7/en/sql-mode.html
+type SQLMode int
+
+// HasStrictMode detects if 'STRICT_TRANS_TABLES' or 'STRICT_ALL_TABLES' mode is set in SQLMode
+func (m SQLMode) HasStrictMode() bool {
+	return m&ModeStrictTransTables == ModeStrictTransTables || m&ModeStrictAllTables == ModeStrictAllTables
+}
+
+// HasOnlyFullGroupBy detects if 'ONLY_FULL_GROUP_BY' mode is set in SQLMode
+func (m SQLMode) HasOnlyFullGroupBy() bool {
+	return m&ModeOnlyFullGroupBy == ModeOnlyFullGroupBy
+}
+
+// HasNoZeroDateMode detects if 'NO_ZERO_DATE' mode is set in SQLMode
+func (m SQLMode) HasNoZeroDateMode() bool {
+	return m&ModeNoZeroDate == ModeNoZeroDate
+}
+
+// HasNoZeroInDateMode detects if 'NO_ZERO_IN_DATE' mode is set in SQLMode
+func (m SQLMode) HasNoZeroInDateMode() bool {
+	return m&ModeNoZeroInDate == ModeNoZeroInDate
+}
+
+// HasErrorForDivisionByZeroMode detects if 'ERROR_FOR_DIVISION_BY_ZERO' mode is set in SQLMode
+func (m SQLMode) HasErrorForDivisionByZeroMode() bool {
+	return m&ModeErrorForDivisionByZero == ModeErrorForDivisionByZero
+}
+
+// HasPipesAsConcatMode detects if 'PIPES_AS_CONCAT' mode is set in SQLMode
+func (m SQLMode) HasPipesAsConcatMode() bool {
+	return m&ModePipesAsConcat == ModePipesAsConcat
+}
+
+// HasANSIQuotesMode detects if 'ANSI_QUOTES' mode is set in SQLMode
+func (m SQLMode) HasANSIQuotesMode() bool {
+	return m&ModeANSIQuotes == ModeANSIQuotes
+}
+
+// HasNoBackslashEscapesMode detects if 'NO_BACKSLASH_ESCAPES' mode is set in SQLMode
+func (m SQLMode) HasNoBackslashEscapesMode() bool {
+	return m&ModeNoBackslashEscapes == ModeNoBackslashEscapes
+}
+
+// HasHighNotPrecedenceMode detects if 'HIGH_NOT_PRECEDENCE' mode is set in SQLMode
+func (m SQLMode) HasHighNotPrecedenceMode() bool {
+	return m&ModeHighNotPrecedence == ModeHighNotPrecedence
+}
+
+// HasNoAutoValueOnZeroMode detects if 'NO_AUTO_VALUE_ON_ZERO' mode is set in SQLMode
+func (m SQLMode) HasNoAutoValueOnZeroMode() bool {
+	return m&ModeNoAutoValueOnZero == ModeNoAutoValueOnZero
+}
+
+// consts for sql modes.
+const (
+	ModeNone        SQLMode = 0
+	ModeRealAsFloat SQLMode = 1 << iota
+	ModePipesAsConcat
+	ModeANSIQuotes
+	ModeIgnoreSpace
+	ModeOnlyFullGroupBy
+	ModeNoUnsignedSubtraction
+	ModeNoDirInCreate
+	ModeNoAutoValueOnZero
+	ModeNoBackslashEscapes
+	ModeStrictTransTables
+	ModeStrictAllTables
+	ModeNoZeroInDate
+	ModeNoZeroDate
+	ModeAllowInvalidDates
+	ModeErrorForDivisionByZero
+	ModeNoAutoCreateUser
+	ModeHighNotPrecedence
+	ModeNoEngineSubstitution
+	ModePadCharToFullLength
+)
+
+// Combination sql modes, they are expanded to the modes they stand for.
+const (
+	ModeANSI        = ModeRealAsFloat | ModePipesAsConcat | ModeANSIQuotes | ModeIgnoreSpace | ModeOnlyFullGroupBy
+	ModeTraditional = ModeStrictTransTables | ModeStrictAllTables | ModeNoZeroInDate | ModeNoZeroDate |
+		ModeErrorForDivisionByZero | ModeNoAutoCreateUser | ModeNoEngineSubstitution
+)
+
+// Str2SQLMode is the map from the sql mode names to their values.
+var Str2SQLMode = map[string]SQLMode{
+	"REAL_AS_FLOAT":              ModeRealAsFloat,
+	"PIPES_AS_CONCAT":            ModePipesAsConcat,
+	"ANSI_QUOTES":                ModeANSIQuotes,
+	"IGNORE_SPACE":               ModeIgnoreSpace,
+	"ONLY_FULL_GROUP_BY":         ModeOnlyFullGroupBy,
+	"NO_UNSIGNED_SUBTRACTION":    ModeNoUnsignedSubtraction,
+	"NO_DIR_IN_CREATE":           ModeNoDirInCreate,
+	"NO_AUTO_VALUE_ON_ZERO":      ModeNoAutoValueOnZero,
+	"NO_BACKSLASH_ESCAPES":       ModeNoBackslashEscapes,
+	"STRICT_TRANS_TABLES":        ModeStrictTransTables,
+	"STRICT_ALL_TABLES":          ModeStrictAllTables,
+	"NO_ZERO_IN_DATE":            ModeNoZeroInDate,
+	"NO_ZERO_DATE":               ModeNoZeroDate,
+	"ALLOW_INVALID_DATES":        ModeAllowInvalidDates,
+	"ERROR_FOR_DIVISION_BY_ZERO": ModeErrorForDivisionByZero,
+	"NO_AUTO_CREATE_USER":        ModeNoAutoCreateUser,
+	"HIGH_NOT_PRECEDENCE":        ModeHighNotPrecedence,
+	"NO_ENGINE_SUBSTITUTION":     ModeNoEngineSubstitution,
+	"PAD_CHAR_TO_FULL_LENGTH":    ModePadCharToFullLength,
+	"ANSI":                       ModeANSI,
+	"TRADITIONAL":                ModeTraditional,
+}
+
+// GetSQLMode gets the sql mode from a comma separated list of sql mode names, the names are case insensitive.
+func GetSQLMode(str string) (SQLMode, error) {
+	var mode SQLMode
+	for _, name := range strings.Split(str, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		m, ok := Str2SQLMode[name]
+		if !ok {
+			return mode, errors.Errorf("invalid sql mode '%s'", name)
+		}
+		mode |= m
+	}
+	return mode, nil
+}
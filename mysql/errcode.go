@@ -883,3 +883,23 @@ const (
 	ErrRowInWrongPartition                                          = 1863
 	ErrErrorLast                                                    = 1863
 )
+
+// MySQL 8.0 error codes of common table expressions.
+const (
+	ErrCTERecursiveRequiresUnion             = 3573
+	ErrCTERecursiveRequiresNonrecursiveFirst = 3574
+	ErrCTERecursiveForbidsAggregation        = 3575
+	ErrCTERecursiveRequiresSingleReference   = 3577
+	ErrCTEMaxRecursionDepth                  = 3636
+)
+
+// MySQL 8.0 error codes of roles.
+const (
+	ErrUnknownAuthID  = 3523
+	ErrRoleNotGranted = 3530
+)
+
+// MySQL 5.7 error codes of the statement execution time limit.
+const (
+	ErrQueryTimeout = 3024
+)
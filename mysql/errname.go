@@ -879,4 +879,12 @@ var MySQLErrName = map[uint16]string{
 	ErrAlterOperationNotSupportedReasonNotNull:               "cannot silently convert NULL values, as required in this SQLMODE",
 	ErrMustChangePasswordLogin:                               "Your password has expired. To log in you must change it using a client that supports expired passwords.",
 	ErrRowInWrongPartition:                                   "Found a row in wrong partition %s",
+	ErrCTERecursiveRequiresUnion:                             "Recursive Common Table Expression '%s' should contain a UNION",
+	ErrCTERecursiveRequiresNonrecursiveFirst:                 "Recursive Common Table Expression '%s' should have one or more non-recursive query blocks followed by one or more recursive ones",
+	ErrCTERecursiveForbidsAggregation:                        "Recursive Common Table Expression '%s' can contain neither aggregation nor window functions in recursive query block",
+	ErrCTERecursiveRequiresSingleReference:                   "In recursive query block of Recursive Common Table Expression '%s', the recursive table must be referenced only once, and not in any subquery",
+	ErrCTEMaxRecursionDepth:                                  "Recursive query aborted after %d iterations. Try increasing @@cte_max_recursion_depth to a larger value.",
+	ErrUnknownAuthID:                                         "Unknown authorization ID `%s`@`%s`",
+	ErrRoleNotGranted:                                        "`%s`@`%s` is not granted to `%s`@`%s`",
+	ErrQueryTimeout:                                          "Query execution was interrupted, maximum statement execution time exceeded",
 }
@@ -0,0 +1,197 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Normalize returns the normalized form of a SQL statement, statements of the same shape have the same
+// normalized form. The literals are replaced with '?', the keywords are in lower case, the comments are
+// removed and the tokens are separated by single spaces. The value list of IN is collapsed to "(...)" and
+// the rows of VALUES with the same shape are collapsed to one.
+//
+// For example, "SELECT * FROM t WHERE a = 1 AND b IN (1, 2, 3)" is normalized to
+// "select * from t where a = ? and b in (...)".
+func Normalize(sql string) string {
+	tokens := normalizeTokens(sql)
+	tokens = collapseInList(tokens)
+	tokens = collapseValueRows(tokens)
+	return joinTokens(tokens)
+}
+
+// DigestHash returns the digest hash of a normalized SQL statement.
+func DigestHash(normalized string) string {
+	sum := md5.Sum([]byte(normalized))
+	return fmt.Sprintf("%x", sum)
+}
+
+// NormalizeDigest returns the normalized form and the digest hash of a SQL statement.
+func NormalizeDigest(sql string) (normalized, digest string) {
+	normalized = Normalize(sql)
+	return normalized, DigestHash(normalized)
+}
+
+const literalMarker = "?"
+
+// digestToken is a token of the normalized statement.
+type digestToken struct {
+	text string
+	// call is true if the token is a '(' follows a word directly, like "count(", it opens the arguments of a function.
+	// The spaces before '(' matter in MySQL, "count (" is not a function call if IGNORE_SPACE is not set.
+	call bool
+}
+
+func normalizeTokens(sql string) []digestToken {
+	s := NewScanner(sql)
+	var (
+		tokens  []digestToken
+		lastEnd = -1
+		word    bool
+	)
+	for {
+		tok, pos, lit := s.scan()
+		if tok == 0 || (tok == unicode.ReplacementChar && s.r.eof()) {
+			break
+		}
+		isWord := false
+		t := digestToken{}
+		switch tok {
+		case stringLit, intLit, floatLit, hexLit, bitLit:
+			t.text = literalMarker
+		case identifier:
+			isWord = true
+			if isTokenIdentifier(lit, &s.buf) != 0 {
+				t.text = strings.ToLower(lit)
+			} else {
+				t.text = lit
+			}
+		case quotedIdentifier:
+			isWord = true
+			t.text = "`" + lit + "`"
+		case ';':
+			// the trailing semicolon doesn't change the shape of the statement.
+			continue
+		default:
+			t.text = strings.ToLower(strings.TrimSpace(s.r.s[pos.Offset:s.r.p.Offset]))
+			if t.text == "" {
+				t.text = lit
+			}
+			t.call = t.text == "(" && word && pos.Offset == lastEnd
+		}
+		tokens = append(tokens, t)
+		word, lastEnd = isWord, s.r.p.Offset
+	}
+	return tokens
+}
+
+// collapseInList replaces "in ( ? , ? ... )" with "in (...)".
+func collapseInList(tokens []digestToken) []digestToken {
+	res := make([]digestToken, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		res = append(res, tokens[i])
+		if tokens[i].text != "in" || i+1 >= len(tokens) || tokens[i+1].text != "(" {
+			continue
+		}
+		end := i + 2
+		for end < len(tokens) && (tokens[end].text == literalMarker || tokens[end].text == ",") {
+			end++
+		}
+		if end < len(tokens) && end > i+2 && tokens[end].text == ")" {
+			res = append(res, digestToken{text: "("}, digestToken{text: "..."}, digestToken{text: ")"})
+			i = end
+		}
+	}
+	return res
+}
+
+// collapseValueRows keeps only the first one of the successive rows with the same shape after VALUES.
+func collapseValueRows(tokens []digestToken) []digestToken {
+	res := make([]digestToken, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		res = append(res, tokens[i])
+		if tokens[i].text != "values" && tokens[i].text != "value" {
+			continue
+		}
+		rowEnd := matchParen(tokens, i+1)
+		if rowEnd < 0 {
+			continue
+		}
+		row := tokens[i+1 : rowEnd+1]
+		res = append(res, row...)
+		i = rowEnd
+		for i+1 < len(tokens) && tokens[i+1].text == "," {
+			nextEnd := matchParen(tokens, i+2)
+			if nextEnd < 0 || !equalTokens(tokens[i+2:nextEnd+1], row) {
+				break
+			}
+			i = nextEnd
+		}
+	}
+	return res
+}
+
+// matchParen returns the index of the parenthesis matching tokens[start], or -1 if tokens[start] is not '('.
+func matchParen(tokens []digestToken, start int) int {
+	if start >= len(tokens) || tokens[start].text != "(" {
+		return -1
+	}
+	depth := 0
+	for i := start; i < len(tokens); i++ {
+		switch tokens[i].text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func equalTokens(a, b []digestToken) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].text != b[i].text {
+			return false
+		}
+	}
+	return true
+}
+
+func joinTokens(tokens []digestToken) string {
+	var b bytes.Buffer
+	for i, tok := range tokens {
+		if i > 0 {
+			prev := tokens[i-1].text
+			glued := prev == "(" || prev == "." || tok.text == ")" || tok.text == "," || tok.text == "."
+			if tok.call && prev != "in" && prev != "values" && prev != "value" {
+				glued = true
+			}
+			if !glued {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(tok.text)
+	}
+	return b.String()
+}
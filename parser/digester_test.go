@@ -0,0 +1,58 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+var _ = Suite(&testDigesterSuite{})
+
+type testDigesterSuite struct {
+}
+
+func (s *testDigesterSuite) TestNormalize(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []struct {
+		sql        string
+		normalized string
+	}{
+		{"SELECT 1", "select ?"},
+		{"select count (*), count(*), sum(a) from t where b in(1)", "select count (*), count(*), sum(a) from t where b in (...)"},
+		{"select * from t where a = 1 and b = 'abc'", "select * from t where a = ? and b = ?"},
+		{"SELECT  *  FROM   t WHERE a=1.5e3 AND b=\"x\" ;", "select * from t where a = ? and b = ?"},
+		{"select * from t where a = 0x10 or b = b'01' or c = 0b11", "select * from t where a = ? or b = ? or c = ?"},
+		{"select * from t where a in (1, 2, 3) and b not in ('a')", "select * from t where a in (...) and b not in (...)"},
+		{"select * from t where a in (select b from t1 where c = 1)", "select * from t where a in (select b from t1 where c = ?)"},
+		{"insert into t values (1, 'a'), (2, 'b'), (3, 'c')", "insert into t values (?, ?)"},
+		{"insert into t (a, b) VALUE (1, now()), (2, 3)", "insert into t (a, b) value (?, now()), (?, ?)"},
+		{"select t.a, `t`.`B` from test.t -- comment\n where /* comment */ c = -1", "select t.a, `t`.`B` from test.t where c = - ?"},
+		{"select count(*) from t group by a having count(*) > 10 limit 10, 20", "select count(*) from t group by a having count(*) > ? limit ?, ?"},
+		{"update t set a = a + 1 where id >= ?", "update t set a = a + ? where id >= ?"},
+		{"select @a, @@global.autocommit, null from dual", "select @a, @@global.autocommit, null from dual"},
+	}
+	for _, t := range table {
+		normalized := Normalize(t.sql)
+		c.Assert(normalized, Equals, t.normalized, Commentf("sql %s", t.sql))
+	}
+
+	n1, d1 := NormalizeDigest("select * from t where a in (1, 2) and b = 'x'")
+	n2, d2 := NormalizeDigest("SELECT * FROM t WHERE a IN (3, 4, 5, 6) AND b = 'yy'")
+	c.Assert(n1, Equals, n2)
+	c.Assert(d1, Equals, d2)
+	c.Assert(d1, HasLen, 32)
+	_, d3 := NormalizeDigest("select * from t where a in (1, 2) and c = 'x'")
+	c.Assert(d3, Not(Equals), d1)
+}
@@ -0,0 +1,163 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
+)
+
+// parseOptimizerHints parses the content of an optimizer hint comment, like "HASH_JOIN(t1, t2) MAX_EXECUTION_TIME(1000)".
+// Like MySQL, the statement is parsed anyway: a hint that is unknown or has wrong arguments is ignored with a warning,
+// and a syntax error ignores the rest of the comment with a warning.
+func parseOptimizerHints(text string) ([]*ast.TableOptimizerHint, []error) {
+	var (
+		hints []*ast.TableOptimizerHint
+		warns []error
+	)
+	s := &hintScanner{text: text}
+	for {
+		s.skipSpaces()
+		if len(hints)+len(warns) > 0 && s.peek() == ',' {
+			s.pos++
+			s.skipSpaces()
+		}
+		if s.eof() {
+			break
+		}
+		name, args, ok := s.scanHint()
+		if !ok {
+			warns = append(warns, ErrWarnOptimizerHint.Gen("Optimizer hint syntax error near '%s'", strings.TrimSpace(s.text[s.pos:])))
+			break
+		}
+		hint, err := newOptimizerHint(name, args)
+		if err != nil {
+			warns = append(warns, err)
+			continue
+		}
+		hints = append(hints, hint)
+	}
+	return hints, warns
+}
+
+// newOptimizerHint checks the arguments of a hint, the join hints take table names and MAX_EXECUTION_TIME takes a number.
+func newOptimizerHint(name string, args []string) (*ast.TableOptimizerHint, error) {
+	hint := &ast.TableOptimizerHint{HintName: model.NewCIStr(name)}
+	switch hint.HintName.L {
+	case "hash_join", "merge_join", "inl_join":
+		if len(args) == 0 {
+			return nil, ErrWarnOptimizerHint.Gen("Optimizer hint %s requires table names, it is ignored", name)
+		}
+		for _, arg := range args {
+			hint.Tables = append(hint.Tables, model.NewCIStr(arg))
+		}
+	case "max_execution_time":
+		if len(args) != 1 {
+			return nil, ErrWarnOptimizerHint.Gen("Optimizer hint %s requires one number, it is ignored", name)
+		}
+		n, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return nil, ErrWarnOptimizerHint.Gen("Optimizer hint %s requires one number, it is ignored", name)
+		}
+		hint.MaxExecutionTime = n
+	default:
+		return nil, ErrWarnOptimizerHint.Gen("Optimizer hint %s is not supported, it is ignored", name)
+	}
+	return hint, nil
+}
+
+// hintScanner scans the hints in an optimizer hint comment.
+type hintScanner struct {
+	text string
+	pos  int
+}
+
+func (s *hintScanner) eof() bool {
+	return s.pos >= len(s.text)
+}
+
+func (s *hintScanner) peek() byte {
+	if s.eof() {
+		return 0
+	}
+	return s.text[s.pos]
+}
+
+func (s *hintScanner) skipSpaces() {
+	for !s.eof() && isHintSpace(s.text[s.pos]) {
+		s.pos++
+	}
+}
+
+// scanHint scans a hint like NAME(arg1, arg2), ok is false if there is a syntax error.
+func (s *hintScanner) scanHint() (name string, args []string, ok bool) {
+	start := s.pos
+	name = s.scanWord()
+	s.skipSpaces()
+	if name == "" || s.peek() != '(' {
+		s.pos = start
+		return "", nil, false
+	}
+	s.pos++
+	s.skipSpaces()
+	if s.peek() == ')' {
+		s.pos++
+		return name, nil, true
+	}
+	for {
+		s.skipSpaces()
+		arg := s.scanWord()
+		if arg == "" {
+			s.pos = start
+			return "", nil, false
+		}
+		args = append(args, arg)
+		s.skipSpaces()
+		switch s.peek() {
+		case ',':
+			s.pos++
+		case ')':
+			s.pos++
+			return name, args, true
+		default:
+			s.pos = start
+			return "", nil, false
+		}
+	}
+}
+
+// scanWord scans an identifier, a quoted identifier or a number.
+func (s *hintScanner) scanWord() string {
+	if s.peek() == '`' {
+		end := strings.IndexByte(s.text[s.pos+1:], '`')
+		if end < 0 {
+			return ""
+		}
+		word := s.text[s.pos+1 : s.pos+1+end]
+		s.pos += end + 2
+		return word
+	}
+	start := s.pos
+	for !s.eof() && isIdentChar(rune(s.text[s.pos])) {
+		s.pos++
+	}
+	return s.text[start:s.pos]
+}
+
+func isHintSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
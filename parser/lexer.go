@@ -19,6 +19,8 @@ import (
 	"strings"
 	"unicode"
 	"unicode/utf8"
+
+	"github.com/pingcap/tidb/mysql"
 )
 
 var _ = yyLexer(&Scanner{})
@@ -37,6 +39,12 @@ type Scanner struct {
 
 	errs         []error
 	stmtStartPos int
+
+	// lastTok is the last token returned by Lex, optimizer hints are only scanned right after SELECT.
+	lastTok int
+
+	// sqlMode changes how some tokens are scanned, like the quotes, the escapes, '||' and NOT.
+	sqlMode mysql.SQLMode
 }
 
 // Errors returns the errors during a scan.
@@ -50,6 +58,7 @@ func (s *Scanner) reset(sql string) {
 	s.buf.Reset()
 	s.errs = s.errs[:0]
 	s.stmtStartPos = 0
+	s.lastTok = 0
 }
 
 func (s *Scanner) stmtText() string {
@@ -79,6 +88,12 @@ func (s *Scanner) Errorf(format string, a ...interface{}) {
 // Lex returns a token and store the token value in v.
 // Scanner satisfies yyLexer interface.
 func (s *Scanner) Lex(v *yySymType) int {
+	tok := s.lex(v)
+	s.lastTok = tok
+	return tok
+}
+
+func (s *Scanner) lex(v *yySymType) int {
 	tok, pos, lit := s.scan()
 	v.offset = pos.Offset
 	v.ident = lit
@@ -107,6 +122,14 @@ func (s *Scanner) Lex(v *yySymType) int {
 		v.item = nil
 	case quotedIdentifier:
 		tok = identifier
+	case oror:
+		if s.sqlMode.HasPipesAsConcatMode() {
+			return pipes
+		}
+	case not:
+		if s.sqlMode.HasHighNotPrecedenceMode() {
+			return not2
+		}
 	}
 	if tok == unicode.ReplacementChar && s.r.eof() {
 		return 0
@@ -218,6 +241,8 @@ func startWithSlash(s *Scanner) (tok int, pos Pos, lit string) {
 	ch0 := s.r.peek()
 	if ch0 == '*' {
 		s.r.inc()
+		// The comment right after SELECT like /*+ HASH_JOIN(t) */ is an optimizer hint, the others are skipped.
+		hint := s.r.peek() == '+' && s.lastTok == selectKwd
 		for {
 			ch0 = s.r.readByte()
 			if ch0 == unicode.ReplacementChar && s.r.eof() {
@@ -228,6 +253,11 @@ func startWithSlash(s *Scanner) (tok int, pos Pos, lit string) {
 				break
 			}
 		}
+		if hint {
+			comment := s.r.data(&pos)
+			tok, lit = hintComment, comment[3:len(comment)-2]
+			return
+		}
 		return s.scan()
 	}
 	tok = int('/')
@@ -274,7 +304,7 @@ var (
 
 func scanQuotedIdent(s *Scanner) (tok int, pos Pos, lit string) {
 	pos = s.r.pos()
-	s.r.inc()
+	quote := s.r.readByte()
 	s.buf.Reset()
 	for {
 		ch := s.r.readByte()
@@ -282,8 +312,8 @@ func scanQuotedIdent(s *Scanner) (tok int, pos Pos, lit string) {
 			tok = unicode.ReplacementChar
 			return
 		}
-		if ch == '`' {
-			if s.r.peek() != '`' {
+		if ch == quote {
+			if s.r.peek() != quote {
 				// don't return identifier in case that it's interpreted as keyword token later.
 				tok, lit = quotedIdentifier, s.buf.String()
 				return
@@ -295,12 +325,17 @@ func scanQuotedIdent(s *Scanner) (tok int, pos Pos, lit string) {
 }
 
 func startString(s *Scanner) (tok int, pos Pos, lit string) {
+	// With the ANSI_QUOTES sql mode, '"' quotes an identifier rather than a string.
+	ansiQuotes := s.sqlMode.HasANSIQuotesMode()
+	if ansiQuotes && s.r.peek() == '"' {
+		return scanQuotedIdent(s)
+	}
 	tok, pos, lit = s.scanString()
 
 	// Quoted strings placed next to each other are concatenated to a single string.
 	// See http://dev.mysql.com/doc/refman/5.7/en/string-literals.html
 	ch := s.skipWhitespace()
-	for ch == '\'' || ch == '"' {
+	for ch == '\'' || (ch == '"' && !ansiQuotes) {
 		_, _, lit1 := s.scanString()
 		lit = lit + lit1
 		ch = s.skipWhitespace()
@@ -362,7 +397,7 @@ func (s *Scanner) scanString() (tok int, pos Pos, lit string) {
 			}
 			str := mb.r.data(&pos)
 			mb.setUseBuf(str[1 : len(str)-1])
-		} else if ch0 == '\\' {
+		} else if ch0 == '\\' && !s.sqlMode.HasNoBackslashEscapesMode() {
 			mb.setUseBuf(mb.r.data(&pos)[1:])
 			ch0 = handleEscape(s)
 		}
@@ -47,6 +47,7 @@ import (
 %token	<ident>
 	/*yy:token "%c"     */	identifier      "identifier"
 	/*yy:token "\"%c\"" */	stringLit       "string literal"
+	hintComment	"optimizer hint comment"
 
 	with		"WITH"
 
@@ -116,6 +117,7 @@ import (
 	releaseLock	"RELEASE_LOCK"
 
 	/* the following tokens belong to UnReservedKeyword*/
+	account		"ACCOUNT"
 	action		"ACTION"
 	after		"AFTER"
 	any 		"ANY"
@@ -129,6 +131,8 @@ import (
 	booleanType	"BOOLEAN"
 	boolType	"BOOL"
 	btree		"BTREE"
+	cancel		"CANCEL"
+	cleanup		"CLEANUP"
 	charsetKwd	"CHARSET"
 	checksum	"CHECKSUM"
 	collation	"COLLATION"
@@ -148,43 +152,58 @@ import (
 	delayKeyWrite	"DELAY_KEY_WRITE"
 	disable		"DISABLE"
 	do		"DO"
+	dumpfile	"DUMPFILE"
 	dynamic		"DYNAMIC"
 	enable		"ENABLE"
 	end		"END"
 	engine		"ENGINE"
 	engines		"ENGINES"
+	errorsKwd	"ERRORS"
 	escape 		"ESCAPE"
 	execute		"EXECUTE"
+	expire		"EXPIRE"
 	fields		"FIELDS"
+	file		"FILE"
 	first		"FIRST"
 	fixed		"FIXED"
 	flush		"FLUSH"
+	format		"FORMAT"
 	full		"FULL"
 	function	"FUNCTION"
 	grants		"GRANTS"
 	hash		"HASH"
 	identified	"IDENTIFIED"
 	isolation	"ISOLATION"
+	jobs		"JOBS"
 	keyBlockSize	"KEY_BLOCK_SIZE"
 	local		"LOCAL"
 	level		"LEVEL"
 	mode		"MODE"
+	maxConnectionsPerHour	"MAX_CONNECTIONS_PER_HOUR"
+	maxQueriesPerHour	"MAX_QUERIES_PER_HOUR"
 	maxRows		"MAX_ROWS"
+	maxUpdatesPerHour	"MAX_UPDATES_PER_HOUR"
+	maxUserConnections	"MAX_USER_CONNECTIONS"
 	minRows		"MIN_ROWS"
 	noWriteToBinLog "NO_WRITE_TO_BINLOG"
 	names		"NAMES"
 	national	"NATIONAL"
+	never		"NEVER"
 	no		"NO"
+	none		"NONE"
 	offset		"OFFSET"
 	only		"ONLY"
 	password	"PASSWORD"
 	prepare		"PREPARE"
 	privileges	"PRIVILEGES"
+	process		"PROCESS"
 	quarter		"QUARTER"
 	quick		"QUICK"
+	recover		"RECOVER"
 	redundant	"REDUNDANT"
 	repeatable	"REPEATABLE"
 	reverse		"REVERSE"
+	role		"ROLE"
 	rollback	"ROLLBACK"
 	row 		"ROW"
 	rowFormat	"ROW_FORMAT"
@@ -269,6 +288,7 @@ import (
 	enum 		"ENUM"
 	eq		"="
 	escaped 	"ESCAPED"
+	except		"EXCEPT"
 	exists		"EXISTS"
 	explain		"EXPLAIN"
 	extract		"EXTRACT"
@@ -310,20 +330,26 @@ import (
 	neq		"!="
 	neqSynonym	"<>"
 	not		"NOT"
+	not2		"not2"
 	null		"NULL"
 	nulleq		"<=>"
 	on		"ON"
 	option		"OPTION"
+	optionally	"OPTIONALLY"
 	or		"OR"
 	order		"ORDER"
 	oror		"||"
 	outer		"OUTER"
+	outfile		"OUTFILE"
+	pipes		"pipes"
 	placeholder	"PLACEHOLDER"
 	primary		"PRIMARY"
 	procedure	"PROCEDURE"
 	read		"READ"
+	recursive	"RECURSIVE"
 	references	"REFERENCES"
 	regexpKwd	"REGEXP"
+	rename		"RENAME"
 	repeat		"REPEAT"
 	replace		"REPLACE"
 	right		"RIGHT"
@@ -412,6 +438,7 @@ import (
 %type   <item>
 	AdminStmt		"Check table statement or show ddl statement"
 	AlterTableStmt		"Alter table statement"
+	AlterUserStmt		"ALTER USER statement"
 	AlterTableSpec		"Alter table specification"
 	AlterTableSpecList	"Alter table specification list"
 	AnalyzeTableStmt	"Analyze table statement"
@@ -434,6 +461,9 @@ import (
 	ColumnSetValueList	"insert statement set value by column name list"
 	CommaOpt		"optional comma"
 	CommitStmt		"COMMIT statement"
+	CommonTableExpr		"Common table expression"
+	CTEColumnList		"Column name list of common table expression"
+	CTEColumnListOpt	"Optional column name list of common table expression"
 	CompareOp		"Compare opcode"
 	ColumnOption		"column definition option"
 	ColumnOptionList	"column definition option list"
@@ -468,6 +498,7 @@ import (
 	DropIndexStmt		"DROP INDEX statement"
 	DropTableStmt		"DROP TABLE statement"
 	DropUserStmt		"DROP USER"
+	DuplicateOpt		"[IGNORE|REPLACE] in LOAD DATA statement"
 	EmptyStmt		"empty statement"
 	Enclosed		"Enclosed by"
 	EqOpt			"= or empty"
@@ -476,6 +507,7 @@ import (
 	ExecuteStmt		"Execute statement"
 	ExplainSym		"EXPLAIN or DESCRIBE or DESC"
 	ExplainStmt		"EXPLAIN statement"
+	ExplainFormatType	"EXPLAIN format type"
 	Expression		"expression"
 	ExpressionList		"expression list"
 	ExpressionListOpt	"expression list opt"
@@ -516,6 +548,11 @@ import (
 	IndexHintType		"index hint type"
 	IndexName		"index name"
 	IndexNameList		"index name list"
+	NumList			"Some numbers"
+	HandleRangeList		"handle range list"
+	HandleRange		"handle range"
+	SignedNum		"signed number"
+	IndexHintName		"index name in index hint"
 	IndexOption		"Index Option"
 	IndexType		"index type"
 	IndexTypeOpt		"Optional index type"
@@ -540,6 +577,7 @@ import (
 	LowPriorityOptional	"LOW_PRIORITY or empty"
 	NationalOpt		"National option"
 	NotOpt			"optional NOT"
+	NotSym			"Not token"
 	NowSym			"CURRENT_TIMESTAMP/LOCALTIME/LOCALTIMESTAMP/NOW"
 	NumLiteral		"Num/Int/Float/Decimal Literal"
 	NoWriteToBinLogAliasOpt "NO_WRITE_TO_BINLOG alias LOCAL or empty"
@@ -574,10 +612,22 @@ import (
 	ReferOpt		"reference option"
 	RegexpSym		"REGEXP or RLIKE"
 	ReplaceIntoStmt		"REPLACE INTO statement"
+	RenameUserStmt		"RENAME USER statement"
 	ReplacePriority		"replace statement priority"
+	Rolename		"Role name"
+	RolenameList		"Role name list"
+	ResourceOption		"resource limit option of an account"
+	ResourceOptionList	"resource limit option list"
+	ResourceOptionListOpt	"optional WITH resource limit option list"
+	PasswordOrLockOption	"password expiration or account locking option"
+	PasswordOrLockOptionList	"password expiration or account locking option list"
+	PasswordOrLockOptionListOpt	"optional password expiration or account locking option list"
+	UserToUser		"old user TO new user"
+	UserToUserList		"old user TO new user list"
 	RollbackStmt		"ROLLBACK statement"
 	RowFormat		"Row format option"
 	SelectLockOpt		"FOR UPDATE or LOCK IN SHARE MODE,"
+	SelectIntoOpt		"INTO OUTFILE or INTO DUMPFILE"
 	SelectStmt		"SELECT statement"
 	SelectStmtCalcFoundRows	"SELECT statement optional SQL_CALC_FOUND_ROWS"
 	SelectStmtSQLCache	"SELECT statement optional SQL_CAHCE/SQL_NO_CACHE"
@@ -586,6 +636,8 @@ import (
 	SelectStmtLimit		"SELECT statement optional LIMIT clause"
 	SelectStmtOpts		"Select statement options"
 	SelectStmtGroup		"SELECT statement optional GROUP BY clause"
+	SetDefaultRoleOpt	"SET DEFAULT ROLE option"
+	SetRoleOpt		"SET ROLE option"
 	SetStmt			"Set variable statement"
 	ShowStmt		"Show engines/databases/tables/columns/warnings/status statement"
 	ShowTargetFilterable    "Show target that can be filtered by WHERE or LIKE"
@@ -613,6 +665,7 @@ import (
 	TableName		"Table name"
 	TableNameList		"Table name list"
 	TableNameListOpt	"Table name list opt"
+	TableOptimizerHints	"Table level optimizer hints"
 	TableOption		"create table option"
 	TableOptionList		"create table option list"
 	TableOptionListOpt	"create table option list opt"
@@ -645,6 +698,8 @@ import (
 	WhenClause		"When clause"
 	WhenClauseList		"When clause list"
 	WithReadLockOpt		"With Read Lock opt"
+	WithClause		"WITH clause"
+	WithList		"Common table expression list of WITH clause"
 	ElseOpt			"Optional else clause"
 	ExpressionOpt		"Optional expression"
 	Type			"Types"
@@ -712,6 +767,7 @@ import (
 %left 	'-' '+'
 %left 	'*' '/' '%' div mod
 %left 	'^'
+%left 	pipes
 %left 	'~' neg
 %right 	not
 %right	collate
@@ -956,7 +1012,7 @@ PrimaryOpt:
 	{} | "PRIMARY"
 
 ColumnOption:
-	"NOT" "NULL"
+	NotSym "NULL"
 	{
 		$$ = &ast.ColumnOption{Tp: ast.ColumnOptionNotNull}
 	}
@@ -1519,6 +1575,14 @@ DropUserStmt:
     {
         $$ = &ast.DropUserStmt{IfExists: true, UserList: $5.([]string)}
     }
+|	"DROP" "ROLE" RolenameList
+	{
+		$$ = &ast.DropUserStmt{IsDropRole: true, UserList: $3.([]string)}
+	}
+|	"DROP" "ROLE" "IF" "EXISTS" RolenameList
+	{
+		$$ = &ast.DropUserStmt{IsDropRole: true, IfExists: true, UserList: $5.([]string)}
+	}
 
 TableOrTables:
 	"TABLE"
@@ -1566,6 +1630,30 @@ ExplainStmt:
 	{
 		$$ = &ast.ExplainStmt{Stmt: $2.(ast.StmtNode)}
 	}
+|	ExplainSym "ANALYZE" ExplainableStmt
+	{
+		$$ = &ast.ExplainStmt{
+			Stmt:		$3.(ast.StmtNode),
+			Analyze:	true,
+		}
+	}
+|	ExplainSym "FORMAT" "=" ExplainFormatType ExplainableStmt
+	{
+		$$ = &ast.ExplainStmt{
+			Stmt:	$5.(ast.StmtNode),
+			Format:	$4.(string),
+		}
+	}
+
+ExplainFormatType:
+	Identifier
+	{
+		$$ = strings.ToLower($1)
+	}
+|	stringLit
+	{
+		$$ = strings.ToLower($1)
+	}
 
 LengthNum:
 	NUM
@@ -1794,11 +1882,20 @@ NotOpt:
 	{
 		$$ = false
 	}
-|	"NOT"
+|	NotSym
 	{
 		$$ = true
 	}
 
+/* not2 is the NOT keyword when the HIGH_NOT_PRECEDENCE sql mode is set. */
+NotSym:
+	"NOT"
+	{
+	}
+|	not2
+	{
+	}
+
 Field:
 	'*'
 	{
@@ -1898,7 +1995,7 @@ IfNotExists:
 	{
 		$$ = false
 	}
-|	"IF" "NOT" "EXISTS"
+|	"IF" NotSym "EXISTS"
 	{
 		$$ = true
 	}
@@ -1973,14 +2070,17 @@ identifier | UnReservedKeyword | NotKeywordToken
 UnReservedKeyword:
  "ACTION" | "ASCII" | "AUTO_INCREMENT" | "AFTER" | "AVG" | "BEGIN" | "BIT" | "BOOL" | "BOOLEAN" | "BTREE" | "CHARSET"
 |	"COLUMNS" | "COMMIT" | "COMPACT" | "COMPRESSED" | "CONSISTENT" | "DATA" | "DATE" | "DATETIME" | "DEALLOCATE" | "DO"
-|	"DYNAMIC"| "END" | "ENGINE" | "ENGINES" | "ESCAPE" | "EXECUTE" | "FIELDS" | "FIRST" | "FIXED" | "FULL" |"GLOBAL"
+|	"DYNAMIC"| "END" | "ENGINE" | "ENGINES" | "ERRORS" | "ESCAPE" | "EXECUTE" | "FIELDS" | "FIRST" | "FIXED" | "FULL" |"GLOBAL"
 |	"HASH" | "LOCAL" | "NAMES" | "OFFSET" | "PASSWORD" %prec lowerThanEq | "PREPARE" | "QUICK" | "REDUNDANT" | "ROLLBACK"
 |	"SESSION" | "SIGNED" | "SNAPSHOT" | "START" | "STATUS" | "TABLES" | "TEXT" | "TIME" | "TIMESTAMP" | "TRANSACTION"
 |	"TRUNCATE" | "UNKNOWN" | "VALUE" | "WARNINGS" | "YEAR" | "MODE"  | "WEEK"  | "ANY" | "SOME" | "USER" | "IDENTIFIED"
 |	"COLLATION" | "COMMENT" | "AVG_ROW_LENGTH" | "CONNECTION" | "CHECKSUM" | "COMPRESSION" | "KEY_BLOCK_SIZE" | "MAX_ROWS"
 |	"MIN_ROWS" | "NATIONAL" | "ROW" | "ROW_FORMAT" | "QUARTER" | "GRANTS" | "TRIGGERS" | "DELAY_KEY_WRITE" | "ISOLATION"
 |	"REPEATABLE" | "COMMITTED" | "UNCOMMITTED" | "ONLY" | "SERIALIZABLE" | "LEVEL" | "VARIABLES" | "SQL_CACHE"
-|	"SQL_NO_CACHE" | "DISABLE"  | "ENABLE" | "REVERSE" | "SPACE" | "PRIVILEGES" | "NO" | "BINLOG" | "FUNCTION"
+|	"SQL_NO_CACHE" | "DISABLE"  | "ENABLE" | "REVERSE" | "SPACE" | "PRIVILEGES" | "NO" | "BINLOG" | "FUNCTION" | "FORMAT"
+|	"JOBS" | "CANCEL" | "CLEANUP" | "RECOVER" | "PROCESS" | "DUMPFILE" | "FILE" | "MAX_QUERIES_PER_HOUR" | "MAX_UPDATES_PER_HOUR"
+|	"MAX_CONNECTIONS_PER_HOUR" | "MAX_USER_CONNECTIONS" | "ACCOUNT" | "EXPIRE" | "NEVER"
+|	"ROLE" | "NONE"
 
 NotKeywordToken:
 	"ABS" | "ADDDATE" | "ADMIN" | "COALESCE" | "CONCAT" | "CONCAT_WS" | "CONNECTION_ID" | "CUR_TIME"| "COUNT" | "DAY"
@@ -2295,6 +2395,10 @@ PrimaryExpression:
 	{
 		$$ = &ast.UnaryOperationExpr{Op: opcode.Not, V: $2.(ast.ExprNode)}
 	}
+|	not2 PrimaryExpression %prec neg
+	{
+		$$ = &ast.UnaryOperationExpr{Op: opcode.Not, V: $2.(ast.ExprNode)}
+	}
 |	'~'  PrimaryExpression %prec neg
 	{
 		$$ = &ast.UnaryOperationExpr{Op: opcode.BitNeg, V: $2.(ast.ExprNode)}
@@ -3100,6 +3204,10 @@ PrimaryFactor:
 	{
 		$$ = &ast.BinaryOperationExpr{Op: opcode.Xor, L: $1.(ast.ExprNode), R: $3.(ast.ExprNode)}
 	}
+|	PrimaryFactor pipes PrimaryFactor
+	{
+		$$ = &ast.FuncCallExpr{FnName: model.NewCIStr("concat"), Args: []ast.ExprNode{$1.(ast.ExprNode), $3.(ast.ExprNode)}}
+	}
 |	PrimaryExpression
 
 
@@ -3252,20 +3360,24 @@ RollbackStmt:
 	}
 
 SelectStmt:
-	"SELECT" SelectStmtOpts SelectStmtFieldList SelectStmtLimit SelectLockOpt
+	"SELECT" SelectStmtOpts SelectStmtFieldList SelectStmtLimit SelectIntoOpt SelectLockOpt
 	{
 		st := &ast.SelectStmt {
-			Distinct:      $2.(bool),
+			Distinct:      $2.(*ast.SelectStmtOpts).Distinct,
+			TableHints:    $2.(*ast.SelectStmtOpts).TableHints,
+			HintWarnings:  $2.(*ast.SelectStmtOpts).HintWarnings,
 			Fields:        $3.(*ast.FieldList),
-			LockTp:	       $5.(ast.SelectLockType),
+			LockTp:	       $6.(ast.SelectLockType),
 		}
 		lastField := st.Fields.Fields[len(st.Fields.Fields)-1]
 		if lastField.Expr != nil && lastField.AsName.O == "" {
 			src := parser.src
 			var lastEnd int
 			if $4 != nil {
+				lastEnd = yyS[yypt-2].offset-1
+			} else if $5 != nil {
 				lastEnd = yyS[yypt-1].offset-1
-			} else if $5 != ast.SelectLockNone {
+			} else if $6 != ast.SelectLockNone {
 				lastEnd = yyS[yypt].offset-1
 			} else {
 				lastEnd = len(src)
@@ -3278,18 +3390,23 @@ SelectStmt:
 		if $4 != nil {
 			st.Limit = $4.(*ast.Limit)
 		}
+		if $5 != nil {
+			st.SelectIntoOpt = $5.(*ast.SelectIntoOption)
+		}
 		$$ = st
 	}
-|	"SELECT" SelectStmtOpts SelectStmtFieldList FromDual WhereClauseOptional SelectStmtLimit SelectLockOpt
+|	"SELECT" SelectStmtOpts SelectStmtFieldList FromDual WhereClauseOptional SelectStmtLimit SelectIntoOpt SelectLockOpt
 	{
 		st := &ast.SelectStmt {
-			Distinct:      $2.(bool),
+			Distinct:      $2.(*ast.SelectStmtOpts).Distinct,
+			TableHints:    $2.(*ast.SelectStmtOpts).TableHints,
+			HintWarnings:  $2.(*ast.SelectStmtOpts).HintWarnings,
 			Fields:        $3.(*ast.FieldList),
-			LockTp:	       $7.(ast.SelectLockType),
+			LockTp:	       $8.(ast.SelectLockType),
 		}
 		lastField := st.Fields.Fields[len(st.Fields.Fields)-1]
 		if lastField.Expr != nil && lastField.AsName.O == "" {
-			lastEnd := yyS[yypt-3].offset-1
+			lastEnd := yyS[yypt-4].offset-1
 			lastField.SetText(parser.src[lastField.Offset:lastEnd])
 		}
 		if $5 != nil {
@@ -3298,22 +3415,27 @@ SelectStmt:
 		if $6 != nil {
 			st.Limit = $6.(*ast.Limit)
 		}
+		if $7 != nil {
+			st.SelectIntoOpt = $7.(*ast.SelectIntoOption)
+		}
 		$$ = st
 	}
 |	"SELECT" SelectStmtOpts SelectStmtFieldList "FROM"
 	TableRefsClause WhereClauseOptional SelectStmtGroup HavingClause OrderByOptional
-	SelectStmtLimit SelectLockOpt
+	SelectStmtLimit SelectIntoOpt SelectLockOpt
 	{
 		st := &ast.SelectStmt{
-			Distinct:	$2.(bool),
+			Distinct:	$2.(*ast.SelectStmtOpts).Distinct,
+			TableHints:	$2.(*ast.SelectStmtOpts).TableHints,
+			HintWarnings:	$2.(*ast.SelectStmtOpts).HintWarnings,
 			Fields:		$3.(*ast.FieldList),
 			From:		$5.(*ast.TableRefsClause),
-			LockTp:		$11.(ast.SelectLockType),
+			LockTp:		$12.(ast.SelectLockType),
 		}
 
 		lastField := st.Fields.Fields[len(st.Fields.Fields)-1]
 		if lastField.Expr != nil && lastField.AsName.O == "" {
-			lastEnd := parser.endOffset(&yyS[yypt-7])
+			lastEnd := parser.endOffset(&yyS[yypt-8])
 			lastField.SetText(parser.src[lastField.Offset:lastEnd])
 		}
 
@@ -3337,9 +3459,74 @@ SelectStmt:
 			st.Limit = $10.(*ast.Limit)
 		}
 
+		if $11 != nil {
+			st.SelectIntoOpt = $11.(*ast.SelectIntoOption)
+		}
+
+		$$ = st
+	}
+|	WithClause SelectStmt
+	{
+		st := $2.(*ast.SelectStmt)
+		if st.With != nil {
+			yylex.Errorf("Only one WITH clause is allowed")
+			return 1
+		}
+		st.With = $1.(*ast.WithClause)
 		$$ = st
 	}
 
+// See https://dev.mysql.com/doc/refman/8.0/en/with.html
+WithClause:
+	"WITH" WithList
+	{
+		$$ = &ast.WithClause{CTEs: $2.([]*ast.CommonTableExpression)}
+	}
+|	"WITH" "RECURSIVE" WithList
+	{
+		$$ = &ast.WithClause{IsRecursive: true, CTEs: $3.([]*ast.CommonTableExpression)}
+	}
+
+WithList:
+	CommonTableExpr
+	{
+		$$ = []*ast.CommonTableExpression{$1.(*ast.CommonTableExpression)}
+	}
+|	WithList ',' CommonTableExpr
+	{
+		$$ = append($1.([]*ast.CommonTableExpression), $3.(*ast.CommonTableExpression))
+	}
+
+CommonTableExpr:
+	Identifier CTEColumnListOpt "AS" SubSelect
+	{
+		$$ = &ast.CommonTableExpression{
+			Name:		model.NewCIStr($1),
+			ColNameList:	$2.([]model.CIStr),
+			Query:		$4.(*ast.SubqueryExpr).Query,
+		}
+	}
+
+CTEColumnListOpt:
+	{
+		$$ = []model.CIStr(nil)
+	}
+|	'(' CTEColumnList ')'
+	{
+		$$ = $2.([]model.CIStr)
+	}
+
+CTEColumnList:
+	Identifier
+	{
+		$$ = []model.CIStr{model.NewCIStr($1)}
+	}
+|	CTEColumnList ',' Identifier
+	{
+		$$ = append($1.([]model.CIStr), model.NewCIStr($3))
+	}
+
+
 FromDual:
 	"FROM" "DUAL"
 
@@ -3479,13 +3666,23 @@ IndexNameList:
 		var nameList []model.CIStr
 		$$ = nameList
 	}
-|	Identifier
+|	IndexHintName
 	{
-		$$ = []model.CIStr{model.NewCIStr($1)}
+		$$ = []model.CIStr{$1.(model.CIStr)}
 	}
-|	IndexNameList ',' Identifier
+|	IndexNameList ',' IndexHintName
 	{
-		$$ = append($1.([]model.CIStr), model.NewCIStr($3))
+		$$ = append($1.([]model.CIStr), $3.(model.CIStr))
+	}
+
+IndexHintName:
+	Identifier
+	{
+		$$ = model.NewCIStr($1)
+	}
+|	"PRIMARY"
+	{
+		$$ = model.NewCIStr("PRIMARY")
 	}
 
 
@@ -3591,10 +3788,26 @@ SelectStmtDistinct:
 	}
 
 SelectStmtOpts:
-	SelectStmtDistinct SelectStmtSQLCache SelectStmtCalcFoundRows
+	TableOptimizerHints SelectStmtDistinct SelectStmtSQLCache SelectStmtCalcFoundRows
 	{
 		// TODO: return calc_found_rows opt and support more other options
-		$$ = $1
+		opts := &ast.SelectStmtOpts{Distinct: $2.(bool)}
+		if $1 != nil {
+			hints := $1.(*ast.SelectStmtOpts)
+			opts.TableHints, opts.HintWarnings = hints.TableHints, hints.HintWarnings
+		}
+		$$ = opts
+	}
+
+TableOptimizerHints:
+	/* empty */
+	{
+		$$ = nil
+	}
+|	hintComment
+	{
+		hints, warns := parseOptimizerHints($1)
+		$$ = &ast.SelectStmtOpts{TableHints: hints, HintWarnings: warns}
 	}
 
 SelectStmtCalcFoundRows:
@@ -3669,6 +3882,28 @@ SelectLockOpt:
 		$$ = ast.SelectLockInShareMode
 	}
 
+// See https://dev.mysql.com/doc/refman/5.7/en/select-into.html
+SelectIntoOpt:
+	{
+		$$ = nil
+	}
+|	"INTO" "OUTFILE" stringLit Fields Lines
+	{
+		$$ = &ast.SelectIntoOption{
+			Tp:		ast.SelectIntoOutfile,
+			FileName:	$3,
+			FieldsInfo:	$4.(*ast.FieldsClause),
+			LinesInfo:	$5.(*ast.LinesClause),
+		}
+	}
+|	"INTO" "DUMPFILE" stringLit
+	{
+		$$ = &ast.SelectIntoOption{
+			Tp:		ast.SelectIntoDumpfile,
+			FileName:	$3,
+		}
+	}
+
 // See https://dev.mysql.com/doc/refman/5.7/en/union.html
 UnionStmt:
 	UnionClauseList "UNION" UnionOpt SelectStmt
@@ -3704,10 +3939,14 @@ UnionStmt:
 UnionClauseList:
 	UnionSelect
 	{
-		selectList := &ast.UnionSelectList{Selects: []*ast.SelectStmt{$1.(*ast.SelectStmt)}}
+		st := $1.(*ast.SelectStmt)
+		selectList := &ast.UnionSelectList{Selects: []*ast.SelectStmt{st}}
+		// The WITH clause before the first select belongs to the whole union.
 		$$ = &ast.UnionStmt{
-			SelectList: selectList,
+			With:		st.With,
+			SelectList:	selectList,
 		}
+		st.With = nil
 	}
 |	UnionClauseList "UNION" UnionOpt UnionSelect
 	{
@@ -3758,6 +3997,16 @@ SetStmt:
 	{
 		$$ = &ast.SetPwdStmt{User: $4.(string), Password: $6.(string)}
 	}
+|	"SET" "ROLE" SetRoleOpt
+	{
+		$$ = $3.(*ast.SetRoleStmt)
+	}
+|	"SET" "DEFAULT" "ROLE" SetDefaultRoleOpt "TO" UsernameList
+	{
+		stmt := $4.(*ast.SetDefaultRoleStmt)
+		stmt.UserList = $6.([]string)
+		$$ = stmt
+	}
 |	"SET" "GLOBAL" "TRANSACTION" TransactionChars
 	{
 		// Parsed but ignored
@@ -3767,6 +4016,35 @@ SetStmt:
 		// Parsed but ignored
 	}
 
+SetRoleOpt:
+	SetDefaultRoleOpt
+	{
+		stmt := $1.(*ast.SetDefaultRoleStmt)
+		$$ = &ast.SetRoleStmt{SetRoleOpt: stmt.SetRoleOpt, RoleList: stmt.RoleList}
+	}
+|	"DEFAULT"
+	{
+		$$ = &ast.SetRoleStmt{SetRoleOpt: ast.SetRoleDefault}
+	}
+|	"ALL" "EXCEPT" RolenameList
+	{
+		$$ = &ast.SetRoleStmt{SetRoleOpt: ast.SetRoleAllExcept, RoleList: $3.([]string)}
+	}
+
+SetDefaultRoleOpt:
+	"NONE"
+	{
+		$$ = &ast.SetDefaultRoleStmt{SetRoleOpt: ast.SetRoleNone}
+	}
+|	"ALL"
+	{
+		$$ = &ast.SetDefaultRoleStmt{SetRoleOpt: ast.SetRoleAll}
+	}
+|	RolenameList
+	{
+		$$ = &ast.SetDefaultRoleStmt{SetRoleOpt: ast.SetRoleRegular, RoleList: $1.([]string)}
+	}
+
 TransactionChars:
 	TransactionChar
 |	TransactionChars ',' TransactionChar
@@ -3918,6 +4196,24 @@ UsernameList:
         $$ = append($1.([]string), $3.(string))
     }
 
+/* The host of a role is '%' if it is omitted. */
+Rolename:
+	Username
+|	stringLit
+	{
+		$$ = $1 + "@%"
+	}
+
+RolenameList:
+	Rolename
+	{
+		$$ = []string{$1.(string)}
+	}
+|	RolenameList ',' Rolename
+	{
+		$$ = append($1.([]string), $3.(string))
+	}
+
 PasswordOpt:
 	stringLit
 	{
@@ -3940,6 +4236,50 @@ AdminStmt:
 	{
 		$$ = &ast.AdminStmt{Tp: ast.AdminShowDDL}
 	}
+|	"ADMIN" "CHECK" "INDEX" TableName Identifier
+	{
+		$$ = &ast.AdminStmt{
+			Tp:	ast.AdminCheckIndex,
+			Tables:	[]*ast.TableName{$4.(*ast.TableName)},
+			Index:	string($5),
+		}
+	}
+|	"ADMIN" "CHECK" "INDEX" TableName Identifier HandleRangeList
+	{
+		$$ = &ast.AdminStmt{
+			Tp:		ast.AdminCheckIndex,
+			Tables:		[]*ast.TableName{$4.(*ast.TableName)},
+			Index:		string($5),
+			HandleRanges:	$6.([]ast.HandleRange),
+		}
+	}
+|	"ADMIN" "RECOVER" "INDEX" TableName Identifier
+	{
+		$$ = &ast.AdminStmt{
+			Tp:	ast.AdminRecoverIndex,
+			Tables:	[]*ast.TableName{$4.(*ast.TableName)},
+			Index:	string($5),
+		}
+	}
+|	"ADMIN" "CLEANUP" "INDEX" TableName Identifier
+	{
+		$$ = &ast.AdminStmt{
+			Tp:	ast.AdminCleanupIndex,
+			Tables:	[]*ast.TableName{$4.(*ast.TableName)},
+			Index:	string($5),
+		}
+	}
+|	"ADMIN" "SHOW" "DDL" "JOBS"
+	{
+		$$ = &ast.AdminStmt{Tp: ast.AdminShowDDLJobs}
+	}
+|	"ADMIN" "CANCEL" "DDL" "JOBS" NumList
+	{
+		$$ = &ast.AdminStmt{
+			Tp:	ast.AdminCancelDDLJobs,
+			JobIDs:	$5.([]int64),
+		}
+	}
 |	"ADMIN" "CHECK" "TABLE" TableNameList
 	{
 		$$ = &ast.AdminStmt{
@@ -3948,6 +4288,42 @@ AdminStmt:
 		}
 	}
 
+HandleRangeList:
+	HandleRange
+	{
+		$$ = []ast.HandleRange{$1.(ast.HandleRange)}
+	}
+|	HandleRangeList ',' HandleRange
+	{
+		$$ = append($1.([]ast.HandleRange), $3.(ast.HandleRange))
+	}
+
+HandleRange:
+	'(' SignedNum ',' SignedNum ')'
+	{
+		$$ = ast.HandleRange{Begin: $2.(int64), End: $4.(int64)}
+	}
+
+SignedNum:
+	LengthNum
+	{
+		$$ = int64($1.(uint64))
+	}
+|	'-' LengthNum
+	{
+		$$ = -int64($2.(uint64))
+	}
+
+NumList:
+	LengthNum
+	{
+		$$ = []int64{int64($1.(uint64))}
+	}
+|	NumList ',' LengthNum
+	{
+		$$ = append($1.([]int64), int64($3.(uint64)))
+	}
+
 /****************************Show Statement*******************************/
 ShowStmt:
 	"SHOW" ShowTargetFilterable ShowLikeOrWhereOpt
@@ -4052,6 +4428,10 @@ ShowTargetFilterable:
 	{
 		$$ = &ast.ShowStmt{Tp: ast.ShowWarnings}
 	}
+|	"ERRORS"
+	{
+		$$ = &ast.ShowStmt{Tp: ast.ShowErrors}
+	}
 |	GlobalScope "VARIABLES"
 	{
 		$$ = &ast.ShowStmt{
@@ -4202,6 +4582,7 @@ Statement:
 	EmptyStmt
 |	AdminStmt
 |	AlterTableStmt
+|	AlterUserStmt
 |	AnalyzeTableStmt
 |	BeginTransactionStmt
 |	BinlogStmt
@@ -4224,6 +4605,7 @@ Statement:
 |	InsertIntoStmt
 |	LoadDataStmt
 |	PreparedStmt
+|	RenameUserStmt
 |	RollbackStmt
 |	ReplaceIntoStmt
 |	SelectStmt
@@ -4956,15 +5338,154 @@ CommaOpt:
  *  https://dev.mysql.com/doc/refman/5.7/en/account-management-sql.html
  ************************************************************************************/
 CreateUserStmt:
-	"CREATE" "USER" IfNotExists UserSpecList
+	"CREATE" "USER" IfNotExists UserSpecList ResourceOptionListOpt PasswordOrLockOptionListOpt
 	{
  		// See https://dev.mysql.com/doc/refman/5.7/en/create-user.html
 		$$ = &ast.CreateUserStmt{
 			IfNotExists: $3.(bool),
 			Specs: $4.([]*ast.UserSpec),
+			ResourceOptions: $5.([]*ast.ResourceOption),
+			PasswordOrLockOptions: $6.([]*ast.PasswordOrLockOption),
+		}
+	}
+|	"CREATE" "ROLE" IfNotExists RolenameList
+	{
+		// See https://dev.mysql.com/doc/refman/8.0/en/create-role.html
+		specs := make([]*ast.UserSpec, 0, len($4.([]string)))
+		for _, role := range $4.([]string) {
+			specs = append(specs, &ast.UserSpec{User: role})
+		}
+		$$ = &ast.CreateUserStmt{
+			IsCreateRole: true,
+			IfNotExists: $3.(bool),
+			Specs: specs,
+		}
+	}
+
+/*******************************************************************
+ *
+ *  Alter User Statement
+ *
+ *  See https://dev.mysql.com/doc/refman/5.7/en/alter-user.html
+ *******************************************************************/
+AlterUserStmt:
+	"ALTER" "USER" IfExists UserSpecList ResourceOptionListOpt PasswordOrLockOptionListOpt
+	{
+		$$ = &ast.AlterUserStmt{
+			IfExists: $3.(bool),
+			Specs: $4.([]*ast.UserSpec),
+			ResourceOptions: $5.([]*ast.ResourceOption),
+			PasswordOrLockOptions: $6.([]*ast.PasswordOrLockOption),
 		}
 	}
 
+/*******************************************************************
+ *
+ *  Rename User Statement
+ *
+ *  See https://dev.mysql.com/doc/refman/5.7/en/rename-user.html
+ *******************************************************************/
+RenameUserStmt:
+	"RENAME" "USER" UserToUserList
+	{
+		$$ = &ast.RenameUserStmt{UserToUsers: $3.([]*ast.UserToUser)}
+	}
+
+UserToUserList:
+	UserToUser
+	{
+		$$ = []*ast.UserToUser{$1.(*ast.UserToUser)}
+	}
+|	UserToUserList ',' UserToUser
+	{
+		$$ = append($1.([]*ast.UserToUser), $3.(*ast.UserToUser))
+	}
+
+UserToUser:
+	Username "TO" Username
+	{
+		$$ = &ast.UserToUser{OldUser: $1.(string), NewUser: $3.(string)}
+	}
+
+PasswordOrLockOptionListOpt:
+	{
+		$$ = []*ast.PasswordOrLockOption{}
+	}
+|	PasswordOrLockOptionList
+
+PasswordOrLockOptionList:
+	PasswordOrLockOption
+	{
+		$$ = []*ast.PasswordOrLockOption{$1.(*ast.PasswordOrLockOption)}
+	}
+|	PasswordOrLockOptionList PasswordOrLockOption
+	{
+		$$ = append($1.([]*ast.PasswordOrLockOption), $2.(*ast.PasswordOrLockOption))
+	}
+
+PasswordOrLockOption:
+	"ACCOUNT" "LOCK"
+	{
+		$$ = &ast.PasswordOrLockOption{Tp: ast.AccountLock}
+	}
+|	"ACCOUNT" "UNLOCK"
+	{
+		$$ = &ast.PasswordOrLockOption{Tp: ast.AccountUnlock}
+	}
+|	"PASSWORD" "EXPIRE"
+	{
+		$$ = &ast.PasswordOrLockOption{Tp: ast.PasswordExpire}
+	}
+|	"PASSWORD" "EXPIRE" "DEFAULT"
+	{
+		$$ = &ast.PasswordOrLockOption{Tp: ast.PasswordExpireDefault}
+	}
+|	"PASSWORD" "EXPIRE" "NEVER"
+	{
+		$$ = &ast.PasswordOrLockOption{Tp: ast.PasswordExpireNever}
+	}
+|	"PASSWORD" "EXPIRE" "INTERVAL" LengthNum "DAY"
+	{
+		$$ = &ast.PasswordOrLockOption{Tp: ast.PasswordExpireInterval, Count: $4.(uint64)}
+	}
+
+ResourceOptionListOpt:
+	{
+		$$ = []*ast.ResourceOption{}
+	}
+|	"WITH" ResourceOptionList
+	{
+		$$ = $2
+	}
+
+ResourceOptionList:
+	ResourceOption
+	{
+		$$ = []*ast.ResourceOption{$1.(*ast.ResourceOption)}
+	}
+|	ResourceOptionList ResourceOption
+	{
+		$$ = append($1.([]*ast.ResourceOption), $2.(*ast.ResourceOption))
+	}
+
+ResourceOption:
+	"MAX_QUERIES_PER_HOUR" LengthNum
+	{
+		$$ = &ast.ResourceOption{Tp: ast.ResourceOptionMaxQueriesPerHour, Count: $2.(uint64)}
+	}
+|	"MAX_UPDATES_PER_HOUR" LengthNum
+	{
+		$$ = &ast.ResourceOption{Tp: ast.ResourceOptionMaxUpdatesPerHour, Count: $2.(uint64)}
+	}
+|	"MAX_CONNECTIONS_PER_HOUR" LengthNum
+	{
+		$$ = &ast.ResourceOption{Tp: ast.ResourceOptionMaxConnectionsPerHour, Count: $2.(uint64)}
+	}
+|	"MAX_USER_CONNECTIONS" LengthNum
+	{
+		$$ = &ast.ResourceOption{Tp: ast.ResourceOptionMaxUserConnections, Count: $2.(uint64)}
+	}
+
 UserSpec:
 	Username AuthOption
 	{
@@ -5004,6 +5525,27 @@ AuthOption:
 			HashString: $4.(string),
 		}
 	}
+|	"IDENTIFIED" "WITH" StringName
+	{
+		$$ = &ast.AuthOption{
+			AuthPlugin: strings.ToLower($3.(string)),
+		}
+	}
+|	"IDENTIFIED" "WITH" StringName "BY" AuthString
+	{
+		$$ = &ast.AuthOption{
+			AuthPlugin: strings.ToLower($3.(string)),
+			AuthString: $5.(string),
+			ByAuthString: true,
+		}
+	}
+|	"IDENTIFIED" "WITH" StringName "AS" HashString
+	{
+		$$ = &ast.AuthOption{
+			AuthPlugin: strings.ToLower($3.(string)),
+			HashString: $5.(string),
+		}
+	}
 
 HashString:
 	stringLit
@@ -5025,6 +5567,10 @@ GrantStmt:
 			Users: $7.([]*ast.UserSpec),
 		}
 	 }
+|	"GRANT" RolenameList "TO" UsernameList
+	{
+		$$ = &ast.GrantRoleStmt{Roles: $2.([]string), Users: $4.([]string)}
+	}
 
 PrivElem:
 	PrivType
@@ -5084,6 +5630,10 @@ PrivType:
 	{
 		$$ = mysql.ExecutePriv
 	}
+|	"FILE"
+	{
+		$$ = mysql.FilePriv
+	}
 |	"INDEX"
 	{
 		$$ = mysql.IndexPriv
@@ -5092,6 +5642,10 @@ PrivType:
 	{
 		$$ = mysql.InsertPriv
 	}
+|	"PROCESS"
+	{
+		$$ = mysql.ProcessPriv
+	}
 |	"SELECT"
 	{
 		$$ = mysql.SelectPriv
@@ -5158,24 +5712,38 @@ PrivLevel:
  * See https://dev.mysql.com/doc/refman/5.7/en/load-data.html
  *******************************************************************************************/
 LoadDataStmt:
-	"LOAD" "DATA" LocalOpt "INFILE" stringLit "INTO" "TABLE" TableName Fields Lines
+	"LOAD" "DATA" LocalOpt "INFILE" stringLit DuplicateOpt "INTO" "TABLE" TableName Fields Lines
 	{
 		x := &ast.LoadDataStmt{
-			Path:       $5,
-			Table:      $8.(*ast.TableName),
+			Path:		$5,
+			OnDuplicate:	$6.(ast.OnDuplicateKeyHandlingType),
+			Table:		$9.(*ast.TableName),
 		}
 		if $3 != nil {
 			x.IsLocal = true
 		}
-		if $9 != nil {
-			x.FieldsInfo = $9.(*ast.FieldsClause)
-		}
 		if $10 != nil {
-			x.LinesInfo = $10.(*ast.LinesClause)
+			x.FieldsInfo = $10.(*ast.FieldsClause)
+		}
+		if $11 != nil {
+			x.LinesInfo = $11.(*ast.LinesClause)
 		}
 		$$ = x
 	}
 
+DuplicateOpt:
+	{
+		$$ = ast.OnDuplicateKeyHandlingError
+	}
+|	"IGNORE"
+	{
+		$$ = ast.OnDuplicateKeyHandlingIgnore
+	}
+|	"REPLACE"
+	{
+		$$ = ast.OnDuplicateKeyHandlingReplace
+	}
+
 LocalOpt:
 	{
 		$$ = nil 
@@ -5200,19 +5768,12 @@ Fields:
 			yylex.Errorf("Incorrect arguments %s to ESCAPE", escape)
 			return 1
 		}
-		var enclosed byte
-		str := $3.(string)
-		if len(str) > 1 {
-			yylex.Errorf("Incorrect arguments %s to ENCLOSED", escape)
-			return 1
-		}else if len(str) != 0 {
-			enclosed = str[0]
-		}
-		$$ = &ast.FieldsClause{
-			Terminated: $2.(string),
-			Enclosed:   enclosed,
-			Escaped:    escape[0],
+		fields := $3.(*ast.FieldsClause)
+		fields.Terminated = $2.(string)
+		if len(escape) != 0 {
+			fields.Escaped = escape[0]
 		}
+		$$ = fields
 	}
 
 FieldsOrColumns:
@@ -5230,11 +5791,31 @@ FieldsTerminated:
 
 Enclosed:
 	{
-		$$ = ""
+		$$ = &ast.FieldsClause{}
 	}
 |	"ENCLOSED" "BY" stringLit
 	{
-		$$ = $3
+		if len($3) > 1 {
+			yylex.Errorf("Incorrect arguments %s to ENCLOSED", $3)
+			return 1
+		}
+		fields := &ast.FieldsClause{}
+		if len($3) != 0 {
+			fields.Enclosed = $3[0]
+		}
+		$$ = fields
+	}
+|	"OPTIONALLY" "ENCLOSED" "BY" stringLit
+	{
+		if len($4) > 1 {
+			yylex.Errorf("Incorrect arguments %s to ENCLOSED", $4)
+			return 1
+		}
+		fields := &ast.FieldsClause{OptEnclosed: true}
+		if len($4) != 0 {
+			fields.Enclosed = $4[0]
+		}
+		$$ = fields
 	}
 
 Escaped:
@@ -21,6 +21,9 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/testleak"
 )
 
@@ -42,14 +45,16 @@ func (s *testParserSuite) TestSimple(c *C) {
 		"date", "datetime", "deallocate", "do", "end", "engine", "engines", "execute", "first", "full",
 		"local", "names", "offset", "password", "prepare", "quick", "rollback", "session", "signed",
 		"start", "global", "tables", "text", "time", "timestamp", "transaction", "truncate", "unknown",
-		"value", "warnings", "year", "now", "substr", "substring", "mode", "any", "some", "user", "identified",
+		"value", "warnings", "errors", "year", "now", "substr", "substring", "mode", "any", "some", "user", "identified",
 		"collation", "comment", "avg_row_length", "checksum", "compression", "connection", "key_block_size",
 		"max_rows", "min_rows", "national", "row", "quarter", "escape", "grants", "status", "fields", "triggers",
 		"delay_key_write", "isolation", "repeatable", "committed", "uncommitted", "only", "serializable", "level",
 		"curtime", "variables", "dayname", "version", "btree", "hash", "row_format", "dynamic", "fixed", "compressed",
 		"compact", "redundant", "sql_no_cache sql_no_cache", "sql_cache sql_cache", "action", "round",
 		"enable", "disable", "reverse", "space", "privileges", "get_lock", "release_lock", "sleep", "no", "greatest",
-		"binlog", "hex", "unhex", "function",
+		"binlog", "hex", "unhex", "function", "format", "jobs", "cancel", "cleanup", "recover",
+		"process",
+		"dumpfile", "file", "role", "none",
 	}
 	for _, kw := range unreservedKws {
 		src := fmt.Sprintf("SELECT %s FROM tbl;", kw)
@@ -219,6 +224,9 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{"load data infile '/tmp/t.csv' into table t fields terminated by 'ab' lines terminated by 'xy'", true},
 		{"load data infile '/tmp/t.csv' into table t terminated by 'xy' fields terminated by 'ab'", false},
 		{"load data local infile '/tmp/t.csv' into table t", true},
+		{"load data infile '/tmp/t.csv' ignore into table t", true},
+		{"load data local infile '/tmp/t.csv' replace into table t fields terminated by ','", true},
+		{"load data infile '/tmp/t.csv' ignore replace into table t", false},
 		{"load data local infile '/tmp/t.csv' into table t fields terminated by 'ab'", true},
 		{"load data local infile '/tmp/t.csv' into table t columns terminated by 'ab'", true},
 		{"load data local infile '/tmp/t.csv' into table t fields terminated by 'ab' enclosed by 'b'", true},
@@ -228,10 +236,24 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{"load data local infile '/tmp/t.csv' into table t fields terminated by 'ab' lines terminated by 'xy'", true},
 		{"load data local infile '/tmp/t.csv' into table t terminated by 'xy' fields terminated by 'ab'", false},
 
+		{"load data infile '/tmp/t.csv' into table t fields optionally enclosed by '\"'", true},
+
 		// Select for update
 		{"SELECT * from t for update", true},
 		{"SELECT * from t lock in share mode", true},
 
+		// Select into outfile
+		{"select * from t into outfile '/tmp/t.csv'", true},
+		{"select a, b from t where a > 1 order by a limit 10 into outfile '/tmp/t.csv' fields terminated by ',' optionally enclosed by '\"' escaped by '\\\\' lines starting by '>' terminated by '\\r\\n'", true},
+		{"select 1 into outfile '/tmp/t.csv' columns terminated by ','", true},
+		{"select 1 into outfile '/tmp/t.csv' fields escaped by ''", true},
+		{"select 1 from dual into outfile '/tmp/t.csv' lines terminated by ';'", true},
+		{"select * from t into outfile '/tmp/t.csv' for update", true},
+		{"select a from t into dumpfile '/tmp/t.bin'", true},
+		{"select a from t into dumpfile '/tmp/t.bin' fields terminated by ','", false},
+		{"select * from t into outfile", false},
+		{"select * from t into outfile '/tmp/t.csv' fields enclosed by 'ab'", false},
+
 		// For alter table
 		{"ALTER TABLE t ADD COLUMN a SMALLINT UNSIGNED", true},
 		{"ALTER TABLE t ADD COLUMN a SMALLINT UNSIGNED FIRST", true},
@@ -248,6 +270,18 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		// For admin
 		{"admin show ddl;", true},
 		{"admin check table t1, t2;", true},
+		{"admin show ddl jobs;", true},
+		{"admin check index t idx", true},
+		{"admin check index t idx (0, 10)", true},
+		{"admin check index t idx (-10, 0), (5, 10)", true},
+		{"admin check index t idx (0, 10), ", false},
+		{"admin recover index t idx", true},
+		{"admin cleanup index test.t idx", true},
+		{"admin cleanup index t", false},
+		{"admin cancel ddl jobs 1", true},
+		{"admin cancel ddl jobs 1, 2", true},
+		{"admin cancel ddl jobs", false},
+		{"admin cancel ddl jobs a", false},
 
 		// For on duplicate key update
 		{"INSERT INTO t (a,b,c) VALUES (1,2,3),(4,5,6) ON DUPLICATE KEY UPDATE c=VALUES(a)+VALUES(b);", true},
@@ -288,6 +322,12 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{`SELECT /*!40001 SQL_NO_CACHE */ * FROM test WHERE 1 limit 0, 2000;`, true},
 
 		{`ANALYZE TABLE t`, true},
+		{`EXPLAIN ANALYZE SELECT * FROM t`, true},
+		{`DESC ANALYZE SELECT * FROM t WHERE a > 1`, true},
+		{`EXPLAIN ANALYZE t`, false},
+		{`EXPLAIN FORMAT = JSON SELECT * FROM t`, true},
+		{`EXPLAIN FORMAT = 'dot' SELECT * FROM t`, true},
+		{`EXPLAIN format`, true},
 
 		// For Binlog stmt
 		{`BINLOG '
@@ -309,6 +349,8 @@ func (s *testParserSuite) TestDBAStmt(c *C) {
 		{"SHOW GLOBAL VARIABLES LIKE 'character_set_results'", true},
 		{"SHOW SESSION VARIABLES LIKE 'character_set_results'", true},
 		{"SHOW VARIABLES", true},
+		{"SHOW WARNINGS", true},
+		{"SHOW ERRORS", true},
 		{"SHOW GLOBAL VARIABLES", true},
 		{"SHOW GLOBAL VARIABLES WHERE Variable_name = 'autocommit'", true},
 		{"SHOW STATUS", true},
@@ -926,6 +968,28 @@ func (s *testParserSuite) TestPrivilege(c *C) {
 		{`CREATE USER 'root'@'localhost' IDENTIFIED BY 'new-password'`, true},
 		{`CREATE USER 'root'@'localhost' IDENTIFIED BY PASSWORD 'hashstring'`, true},
 		{`CREATE USER 'root'@'localhost' IDENTIFIED BY 'new-password', 'root'@'127.0.0.1' IDENTIFIED BY PASSWORD 'hashstring'`, true},
+		{`CREATE USER 'root'@'localhost' IDENTIFIED WITH caching_sha2_password`, true},
+		{`CREATE USER 'root'@'localhost' IDENTIFIED WITH 'caching_sha2_password' BY 'new-password'`, true},
+		{`CREATE USER 'root'@'localhost' IDENTIFIED WITH auth_socket AS 'root'`, true},
+		{`CREATE USER 'root'@'localhost' IDENTIFIED WITH auth_socket BY PASSWORD 'hashstring'`, false},
+		{`CREATE USER 'root'@'localhost' WITH MAX_QUERIES_PER_HOUR 10 MAX_UPDATES_PER_HOUR 5`, true},
+		{`CREATE USER 'root'@'localhost' IDENTIFIED BY 'new-password' WITH MAX_CONNECTIONS_PER_HOUR 10 MAX_USER_CONNECTIONS 2`, true},
+		{`CREATE USER 'root'@'localhost' WITH MAX_USER_CONNECTIONS`, false},
+		{`CREATE USER 'root'@'localhost' WITH`, false},
+		{`CREATE USER 'root'@'localhost' PASSWORD EXPIRE INTERVAL 90 DAY ACCOUNT LOCK`, true},
+		// For alter user
+		{`ALTER USER 'root'@'localhost' IDENTIFIED BY 'new-password'`, true},
+		{`ALTER USER IF EXISTS 'root'@'localhost' IDENTIFIED BY 'new-password', 'test'@'%' ACCOUNT UNLOCK`, true},
+		{`ALTER USER 'root'@'localhost' WITH MAX_USER_CONNECTIONS 10 PASSWORD EXPIRE NEVER ACCOUNT LOCK`, true},
+		{`ALTER USER 'root'@'localhost' PASSWORD EXPIRE`, true},
+		{`ALTER USER 'root'@'localhost' PASSWORD EXPIRE DEFAULT`, true},
+		{`ALTER USER 'root'@'localhost' PASSWORD EXPIRE INTERVAL 90 DAY`, true},
+		{`ALTER USER 'root'@'localhost' PASSWORD EXPIRE INTERVAL 90`, false},
+		{`ALTER USER 'root'@'localhost' ACCOUNT`, false},
+		// For rename user
+		{`RENAME USER 'root'@'localhost' TO 'admin'@'localhost'`, true},
+		{`RENAME USER 'a'@'%' TO 'b'@'%', 'c'@'%' TO 'd'@'%'`, true},
+		{`RENAME USER 'a'@'%'`, false},
 		{`DROP USER 'root'@'localhost', 'root1'@'localhost'`, true},
 		{`DROP USER IF EXISTS 'root'@'localhost'`, true},
 
@@ -934,14 +998,42 @@ func (s *testParserSuite) TestPrivilege(c *C) {
 		{"GRANT SELECT ON db2.invoice TO 'jeffrey'@'localhost';", true},
 		{"GRANT ALL ON *.* TO 'someuser'@'somehost';", true},
 		{"GRANT SELECT, INSERT ON *.* TO 'someuser'@'somehost';", true},
+		{"GRANT PROCESS ON *.* TO 'someuser'@'somehost';", true},
+		{"GRANT FILE ON *.* TO 'someuser'@'somehost';", true},
 		{"GRANT ALL ON mydb.* TO 'someuser'@'somehost';", true},
 		{"GRANT SELECT, INSERT ON mydb.* TO 'someuser'@'somehost';", true},
 		{"GRANT ALL ON mydb.mytbl TO 'someuser'@'somehost';", true},
 		{"GRANT SELECT, INSERT ON mydb.mytbl TO 'someuser'@'somehost';", true},
 		{"GRANT SELECT (col1), INSERT (col1,col2) ON mydb.mytbl TO 'someuser'@'somehost';", true},
 		{"grant all privileges on zabbix.* to 'zabbix'@'localhost' identified by 'password';", true},
+
+		// For roles
+		{`CREATE ROLE 'developer', 'app'@'localhost'`, true},
+		{`CREATE ROLE IF NOT EXISTS 'developer'`, true},
+		{`CREATE ROLE 'developer' IDENTIFIED BY 'password'`, false},
+		{`DROP ROLE 'developer', 'app'@'localhost'`, true},
+		{`DROP ROLE IF EXISTS 'developer'`, true},
+		{`GRANT 'developer', 'app'@'localhost' TO 'someuser'@'somehost', 'root'@'%'`, true},
+		{`GRANT 'developer' TO 'someuser'`, false},
+		{`SET ROLE DEFAULT`, true},
+		{`SET ROLE NONE`, true},
+		{`SET ROLE ALL`, true},
+		{`SET ROLE ALL EXCEPT 'developer', 'app'@'localhost'`, true},
+		{`SET ROLE 'developer', 'app'@'localhost'`, true},
+		{`SET ROLE ALL EXCEPT`, false},
+		{`SET DEFAULT ROLE NONE TO 'someuser'@'somehost'`, true},
+		{`SET DEFAULT ROLE ALL TO 'someuser'@'somehost', 'root'@'%'`, true},
+		{`SET DEFAULT ROLE 'developer', 'app'@'localhost' TO 'someuser'@'somehost'`, true},
+		{`SET DEFAULT ROLE DEFAULT TO 'someuser'@'somehost'`, false},
+		{`SET role = 1`, true},
 	}
 	s.RunTest(c, table)
+
+	stmt, err := New().ParseOneStmt(`SET ROLE ALL EXCEPT 'developer', 'app'@'localhost'`, "", "")
+	c.Assert(err, IsNil)
+	setRole := stmt.(*ast.SetRoleStmt)
+	c.Assert(setRole.SetRoleOpt, Equals, ast.SetRoleAllExcept)
+	c.Assert(setRole.RoleList, DeepEquals, []string{"developer@%", "app@localhost"})
 }
 
 func (s *testParserSuite) TestComment(c *C) {
@@ -1001,6 +1093,37 @@ func (s *testParserSuite) TestUnion(c *C) {
 	s.RunTest(c, table)
 }
 
+func (s *testParserSuite) TestWith(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"with t as (select 1) select * from t", true},
+		{"with t (a, b) as (select 1, 2) select a, b from t", true},
+		{"with t1 as (select 1), t2 as (select * from t1) select * from t1, t2", true},
+		{"with t as (select 1 union select 2) select * from t", true},
+		{"with recursive t (n) as (select 1 union all select n + 1 from t where n < 5) select * from t", true},
+		{"with t as (select 1) select * from t union select * from t", true},
+		{"select * from (with t as (select 1) select * from t) as a", true},
+		{"select * from t1 where a in (with t as (select 1) select * from t)", true},
+		{"insert into t1 with t as (select 1) select * from t", true},
+		{"with t as (select 1) with t2 as (select 2) select * from t", false},
+		{"with t as select 1 select * from t", false},
+		{"with t () as (select 1) select * from t", false},
+		{"with recursive as (select 1) select 1", false},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	stmt, err := parser.ParseOneStmt("with recursive t (n) as (select 1 union all select n + 1 from t) select * from t union select 2", "", "")
+	c.Assert(err, IsNil)
+	union := stmt.(*ast.UnionStmt)
+	c.Assert(union.With, NotNil)
+	c.Assert(union.With.IsRecursive, IsTrue)
+	c.Assert(union.With.CTEs, HasLen, 1)
+	c.Assert(union.With.CTEs[0].Name.O, Equals, "t")
+	c.Assert(union.With.CTEs[0].ColNameList, DeepEquals, []model.CIStr{model.NewCIStr("n")})
+	c.Assert(union.SelectList.Selects[0].With, IsNil)
+}
+
 func (s *testParserSuite) TestLikeEscape(c *C) {
 	defer testleak.AfterTest(c)()
 	table := []testCase{
@@ -1032,6 +1155,7 @@ func (s *testParserSuite) TestIndexHint(c *C) {
 		{`select * from t use index ();`, true},
 		{`select * from t use index (idx);`, true},
 		{`select * from t use index (idx1, idx2);`, true},
+		{`select * from t use index (primary, idx);`, true},
 		{`select * from t ignore key (idx1)`, true},
 		{`select * from t force index for join (idx1)`, true},
 		{`select * from t use index for order by (idx1)`, true},
@@ -1041,6 +1165,60 @@ func (s *testParserSuite) TestIndexHint(c *C) {
 	s.RunTest(c, table)
 }
 
+func (s *testParserSuite) TestOptimizerHints(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{`select /*+ HASH_JOIN(t1) */ * from t1 join t2 on t1.a = t2.a`, true},
+		{`select /*+ MERGE_JOIN(t1, t2), INL_JOIN(t3) */ * from t1, t2, t3`, true},
+		{`select /*+ merge_join(t1) inl_join(t2) */ distinct * from t1, t2`, true},
+		{`select * from t1 where a in (select /*+ hash_join(t2) */ a from t2, t3)`, true},
+		{`select /*+ HASH_JOIN() */ * from t1`, true},
+		{`select /*+ HASH_JOIN(t1) * from t1`, false},
+		{`select 2 */*+ comment */ 3`, true},
+		{`select /*+ MAX_EXECUTION_TIME(1000) */ * from t1`, true},
+		{`select /*+ max_execution_time(10), hash_join(t1) */ * from t1, t2`, true},
+		{`select /*+ MAX_EXECUTION_TIME(-1) */ * from t1`, true},
+		{`select /*+ NO_INDEX_MERGE() */ 1`, true},
+		{`select /*+ hash_join(t1 */ 1`, true},
+		{`select 1 /*+ foo */`, true},
+		{`/*+ c */ select 1`, true},
+		{`insert /*+ x */ into t values (1)`, true},
+		{`update /*+ x */ t set a = 1`, true},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	stmt, err := parser.ParseOneStmt("select /*+ HASH_JOIN(t1), MERGE_JOIN(t1, T2) */ a * 2 from t1 /* comment */", "", "")
+	c.Assert(err, IsNil)
+	sel := stmt.(*ast.SelectStmt)
+	c.Assert(sel.TableHints, HasLen, 2)
+	c.Assert(sel.TableHints[0].HintName.L, Equals, "hash_join")
+	c.Assert(sel.TableHints[0].Tables, DeepEquals, []model.CIStr{model.NewCIStr("t1")})
+	c.Assert(sel.TableHints[1].HintName.O, Equals, "MERGE_JOIN")
+	c.Assert(sel.TableHints[1].Tables[1].L, Equals, "t2")
+
+	stmt, err = parser.ParseOneStmt("select /*+ MAX_EXECUTION_TIME(1000), no_such_hint(5) */ * from t1", "", "")
+	c.Assert(err, IsNil)
+	sel = stmt.(*ast.SelectStmt)
+	c.Assert(sel.TableHints, HasLen, 1)
+	c.Assert(sel.TableHints[0].MaxExecutionTime, Equals, uint64(1000))
+	c.Assert(sel.HintWarnings, HasLen, 1)
+
+	stmt, err = parser.ParseOneStmt("select /*+ MAX_EXECUTION_TIME(a), hash_join(`t 1`) inl_join(t2 */ * from t1", "", "")
+	c.Assert(err, IsNil)
+	sel = stmt.(*ast.SelectStmt)
+	c.Assert(sel.TableHints, HasLen, 1)
+	c.Assert(sel.TableHints[0].Tables[0].O, Equals, "t 1")
+	c.Assert(sel.HintWarnings, HasLen, 2)
+	c.Assert(terror.ErrorEqual(sel.HintWarnings[1], ErrWarnOptimizerHint), IsTrue)
+
+	stmt, err = parser.ParseOneStmt("select 1 /*+ hash_join(t1) */", "", "")
+	c.Assert(err, IsNil)
+	sel = stmt.(*ast.SelectStmt)
+	c.Assert(sel.TableHints, HasLen, 0)
+	c.Assert(sel.HintWarnings, HasLen, 0)
+}
+
 func (s *testParserSuite) TestEscape(c *C) {
 	defer testleak.AfterTest(c)()
 	table := []testCase{
@@ -1055,6 +1233,45 @@ func (s *testParserSuite) TestEscape(c *C) {
 	s.RunTest(c, table)
 }
 
+func (s *testParserSuite) TestSQLMode(c *C) {
+	defer testleak.AfterTest(c)()
+	parser := New()
+	parser.SetSQLMode(mysql.ModeANSIQuotes)
+	st, err := parser.ParseOneStmt(`select "a" from t where b = 'c' 'd'`, "", "")
+	c.Assert(err, IsNil)
+	sel := st.(*ast.SelectStmt)
+	c.Assert(sel.Fields.Fields[0].Expr.(*ast.ColumnNameExpr).Name.Name.O, Equals, "a")
+	c.Assert(sel.Where, NotNil)
+
+	parser.SetSQLMode(mysql.ModeNoBackslashEscapes)
+	st, err = parser.ParseOneStmt(`select 'a\n'`, "", "")
+	c.Assert(err, IsNil)
+	c.Assert(st.(*ast.SelectStmt).Fields.Fields[0].Expr.GetDatum().GetString(), Equals, `a\n`)
+
+	parser.SetSQLMode(mysql.ModePipesAsConcat)
+	st, err = parser.ParseOneStmt(`select a || b || c from t`, "", "")
+	c.Assert(err, IsNil)
+	f, ok := st.(*ast.SelectStmt).Fields.Fields[0].Expr.(*ast.FuncCallExpr)
+	c.Assert(ok, IsTrue)
+	c.Assert(f.FnName.L, Equals, "concat")
+
+	parser.SetSQLMode(mysql.ModeHighNotPrecedence)
+	st, err = parser.ParseOneStmt(`select not 1 between -5 and 5`, "", "")
+	c.Assert(err, IsNil)
+	_, ok = st.(*ast.SelectStmt).Fields.Fields[0].Expr.(*ast.BetweenExpr)
+	c.Assert(ok, IsTrue)
+	_, err = parser.ParseOneStmt(`create table if not exists t (a int not null)`, "", "")
+	c.Assert(err, IsNil)
+	_, err = parser.ParseOneStmt(`select a not in (1, 2), a is not null, a not like 'b' from t`, "", "")
+	c.Assert(err, IsNil)
+
+	parser.SetSQLMode(mysql.ModeNone)
+	st, err = parser.ParseOneStmt(`select not 1 between -5 and 5`, "", "")
+	c.Assert(err, IsNil)
+	_, ok = st.(*ast.SelectStmt).Fields.Fields[0].Expr.(*ast.UnaryOperationExpr)
+	c.Assert(ok, IsTrue)
+}
+
 func (s *testParserSuite) TestInsertStatementMemoryAllocation(c *C) {
 	sql := "insert t values (1)" + strings.Repeat(",(1)", 1000)
 	var oldStats, newStats runtime.MemStats
@@ -0,0 +1,73 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+)
+
+// redactedString replaces the redacted string literals.
+const redactedString = "'***'"
+
+// RedactAuthStrings replaces the passwords and the authentication strings in a SQL statement with '***', so the
+// statement can be written to the logs. The string literals are redacted if they follow "IDENTIFIED [WITH plugin]
+// BY|AS [PASSWORD]", or follow "=" or "(" after PASSWORD, like in "SET PASSWORD FOR u = PASSWORD('pwd')".
+func RedactAuthStrings(sql string) string {
+	s := NewScanner(sql)
+	var (
+		b          bytes.Buffer
+		last       int
+		prev       string
+		identified bool
+		password   bool
+	)
+	for {
+		tok, pos, lit := s.scan()
+		if tok == 0 || (tok == unicode.ReplacementChar && s.r.eof()) {
+			break
+		}
+		var text string
+		switch tok {
+		case stringLit:
+			redact := (identified && (prev == "by" || prev == "as" || prev == "password")) ||
+				(password && (prev == "=" || prev == "("))
+			if redact {
+				b.WriteString(sql[last:pos.Offset])
+				b.WriteString(redactedString)
+				// The scanner may have read the spaces after the literal.
+				last = pos.Offset + len(strings.TrimRight(sql[pos.Offset:s.r.p.Offset], " \t\r\n"))
+			}
+		case identifier:
+			text = strings.ToLower(lit)
+		default:
+			text = strings.TrimSpace(s.r.s[pos.Offset:s.r.p.Offset])
+		}
+		switch text {
+		case "identified":
+			identified = true
+		case "password":
+			password = true
+		case ";":
+			identified, password = false, false
+		}
+		prev = text
+	}
+	if last == 0 {
+		return sql
+	}
+	b.WriteString(sql[last:])
+	return b.String()
+}
@@ -0,0 +1,48 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+var _ = Suite(&testRedactSuite{})
+
+type testRedactSuite struct {
+}
+
+func (s *testRedactSuite) TestRedactAuthStrings(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []struct {
+		sql      string
+		redacted string
+	}{
+		{"select * from t where a = 'x'", "select * from t where a = 'x'"},
+		{"CREATE USER 'u'@'%' IDENTIFIED BY 'pwd'", "CREATE USER 'u'@'%' IDENTIFIED BY '***'"},
+		{"create user 'u1' identified by \"p1\", 'u2'@'h' identified by 'p\\'2'",
+			"create user 'u1' identified by '***', 'u2'@'h' identified by '***'"},
+		{"alter user u identified with 'caching_sha2_password' as '$A$005$abc'",
+			"alter user u identified with 'caching_sha2_password' as '***'"},
+		{"ALTER USER u IDENTIFIED BY PASSWORD '*ABC'", "ALTER USER u IDENTIFIED BY PASSWORD '***'"},
+		{"CREATE USER u IDENTIFIED BY 'pwd' PASSWORD EXPIRE", "CREATE USER u IDENTIFIED BY '***' PASSWORD EXPIRE"},
+		{"GRANT ALL ON *.* TO 'u'@'h' IDENTIFIED BY 'pwd'", "GRANT ALL ON *.* TO 'u'@'h' IDENTIFIED BY '***'"},
+		{"SET PASSWORD FOR 'u'@'h' = 'pwd'", "SET PASSWORD FOR 'u'@'h' = '***'"},
+		{"set password = password('pwd')", "set password = password('***')"},
+		{"set password='pwd'; select 'x' = 'x'", "set password='***'; select 'x' = 'x'"},
+	}
+	for _, t := range table {
+		c.Assert(RedactAuthStrings(t.sql), Equals, t.redacted, Commentf("sql %s", t.sql))
+	}
+}
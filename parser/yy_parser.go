@@ -29,13 +29,23 @@ import (
 // Error instances.
 var (
 	ErrSyntax = terror.ClassParser.New(CodeSyntaxErr, "syntax error")
+	// ErrWarnOptimizerHint is the warning of an optimizer hint that is ignored.
+	ErrWarnOptimizerHint = terror.ClassParser.New(CodeWarnOptimizerHint, "Optimizer hint syntax error")
 )
 
 // Error codes.
 const (
-	CodeSyntaxErr terror.ErrCode = 1
+	CodeSyntaxErr         terror.ErrCode = 1
+	CodeWarnOptimizerHint terror.ErrCode = 2
 )
 
+func init() {
+	parserMySQLErrCodes := map[terror.ErrCode]uint16{
+		CodeWarnOptimizerHint: mysql.ErrParse,
+	}
+	terror.ErrClassToMySQLCodes[terror.ClassParser] = parserMySQLErrCodes
+}
+
 var (
 	specCodePattern = regexp.MustCompile(`\/\*!(M?[0-9]{5,6})?([^*]|\*+[^*/])*\*+\/`)
 	specCodeStart   = regexp.MustCompile(`^\/\*!(M?[0-9]{5,6} )?[ \t]*`)
@@ -116,6 +126,11 @@ func (parser *Parser) Parse(sql, charset, collation string) ([]ast.StmtNode, err
 	return parser.result, nil
 }
 
+// SetSQLMode sets the SQL mode for the parser, it decides how some tokens are scanned.
+func (parser *Parser) SetSQLMode(mode mysql.SQLMode) {
+	parser.lexer.sqlMode = mode
+}
+
 // ParseOneStmt parses a query and returns an ast.StmtNode.
 // The query must have one statement, otherwise ErrSyntax is returned.
 func (parser *Parser) ParseOneStmt(sql, charset, collation string) (ast.StmtNode, error) {
@@ -39,6 +39,9 @@ const (
 	// Maximum allowed number of elements in table events_xxx_history.
 	// TODO: make it configurable?
 	historyElemMax int64 = 1024
+	// Maximum allowed number of digests in table events_statements_summary_by_digest,
+	// the statements of the other digests are aggregated into a row with NULL digest.
+	digestElemMax = 1024
 )
 
 var setupActorsCols = []columnInfo{
@@ -197,6 +200,25 @@ var stagesCurrentCols = []columnInfo{
 	{mysql.TypeEnum, -1, 0, nil, []string{"TRANSACTION", "STATEMENT", "STAGE"}},
 }
 
+var stmtsSummaryByDigestCols = []columnInfo{
+	{mysql.TypeVarchar, 64, 0, nil, nil},
+	{mysql.TypeVarchar, 32, 0, nil, nil},
+	{mysql.TypeLongBlob, -1, 0, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeTimestamp, 19, mysql.NotNullFlag, nil, nil},
+	{mysql.TypeTimestamp, 19, mysql.NotNullFlag, nil, nil},
+}
+
 func createMemoryTable(meta *model.TableInfo, alloc autoid.Allocator) (table.Table, error) {
 	tbl, _ := tables.MemoryTableFromMeta(alloc, meta)
 	return tbl, nil
@@ -338,6 +360,7 @@ func (ps *perfSchema) initialize() (err error) {
 	ps.tables = make(map[string]*model.TableInfo)
 	ps.mTables = make(map[string]table.Table, len(ps.tables))
 	ps.stmtHandles = make([]int64, currentElemMax)
+	ps.digestSummaries = make(map[digestKey]*digestSummary)
 
 	allColDefs := [][]columnInfo{
 		setupActorsCols,
@@ -355,6 +378,7 @@ func (ps *perfSchema) initialize() (err error) {
 		stagesCurrentCols,
 		stagesCurrentCols, // same as above
 		stagesCurrentCols, // same as above
+		stmtsSummaryByDigestCols,
 	}
 
 	allColNames := [][]string{
@@ -373,6 +397,7 @@ func (ps *perfSchema) initialize() (err error) {
 		ColumnStagesCurrent,
 		ColumnStagesHistory,
 		ColumnStagesHistoryLong,
+		ColumnStmtsSummaryByDigest,
 	}
 
 	// initialize all table, column and result field definitions
@@ -30,6 +30,8 @@ type EnumCallerName int
 const (
 	// CallerNameSessionExecute is for session.go:Execute() method.
 	CallerNameSessionExecute EnumCallerName = iota + 1
+	// CallerNameSessionExecutePrepared is for session.go:ExecutePreparedStmt() method.
+	CallerNameSessionExecutePrepared
 )
 
 const (
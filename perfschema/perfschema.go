@@ -15,6 +15,7 @@ package perfschema
 
 import (
 	"reflect"
+	"sync"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/kv"
@@ -56,6 +57,10 @@ type perfSchema struct {
 	mTables     map[string]table.Table // Memory tables for perfSchema
 	stmtHandles []int64
 	stmtInfos   map[reflect.Type]*statementInfo
+
+	// digestMu protects digestSummaries.
+	digestMu        sync.Mutex
+	digestSummaries map[digestKey]*digestSummary
 }
 
 var (
@@ -162,6 +162,57 @@ func (p *testPerfSchemaSuit) TestInstrument(c *C) {
 	c.Assert(cnt, Greater, 0)
 }
 
+func (p *testPerfSchemaSuit) TestStatementDigest(c *C) {
+	defer testleak.AfterTest(c)()
+	testDB, err := sql.Open(tidb.DriverName, tidb.EngineGoLevelDBMemory+"/test/test")
+	c.Assert(err, IsNil)
+	defer testDB.Close()
+
+	mustExec(c, testDB, "create table digest_t (a int, b int)")
+	mustExec(c, testDB, "insert into digest_t values (1, 1), (2, 2), (3, 3)")
+	mustExec(c, testDB, "insert into digest_t values (4, 4)")
+	mustQuery(c, testDB, "select * from digest_t where a = 1")
+	mustQuery(c, testDB, "SELECT * FROM digest_t WHERE a = 2")
+	// The prepared statements are counted too.
+	stmt, err := testDB.Prepare("select * from digest_t where a = ?")
+	c.Assert(err, IsNil)
+	var a, b int
+	c.Assert(stmt.QueryRow(3).Scan(&a, &b), IsNil)
+	c.Assert(stmt.Close(), IsNil)
+	stmt, err = testDB.Prepare("insert into digest_t values (?, ?)")
+	c.Assert(err, IsNil)
+	_, err = stmt.Exec(5, 5)
+	c.Assert(err, IsNil)
+	c.Assert(stmt.Close(), IsNil)
+
+	tx := mustBegin(c, testDB)
+	var (
+		schema, digest     string
+		count, sent, exam  uint64
+		affected, sumError uint64
+	)
+	row := tx.QueryRow(`select schema_name, digest, count_star, sum_rows_sent, sum_rows_examined, sum_errors
+		from performance_schema.events_statements_summary_by_digest
+		where digest_text = "select * from digest_t where a = ?"`)
+	err = row.Scan(&schema, &digest, &count, &sent, &exam, &sumError)
+	c.Assert(err, IsNil)
+	c.Assert(schema, Equals, "test")
+	c.Assert(digest, HasLen, 32)
+	c.Assert(count, Equals, uint64(3))
+	c.Assert(sent, Equals, uint64(3))
+	// The filter is pushed down, only the matched rows are examined.
+	c.Assert(exam, Equals, uint64(3))
+	c.Assert(sumError, Equals, uint64(0))
+
+	row = tx.QueryRow(`select count_star, sum_rows_affected from performance_schema.events_statements_summary_by_digest
+		where digest_text = "insert into digest_t values (?, ?)"`)
+	err = row.Scan(&count, &affected)
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, uint64(3))
+	c.Assert(affected, Equals, uint64(5))
+	mustCommit(c, tx)
+}
+
 func (p *testPerfSchemaSuit) TestConcurrentStatement(c *C) {
 	defer testleak.AfterTest(c)()
 	testDB, err := sql.Open(tidb.DriverName, tidb.EngineGoLevelDBMemory+"/test/test")
@@ -23,6 +23,8 @@ import (
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -35,9 +37,7 @@ type statementInfo struct {
 }
 
 // StatementState provides temporary storage to a statement runtime statistics.
-// TODO:
-// 1. support statement digest.
-// 2. support prepared statement.
+// TODO: support prepared statement.
 type StatementState struct {
 	// Connection identifier
 	connID uint64
@@ -57,6 +57,10 @@ type StatementState struct {
 	lockTime int64
 	// SQL statement string
 	sqlText string
+	// Digest of the normalized statement
+	digest string
+	// Normalized statement string
+	digestText string
 	// Current schema name
 	schemaName string
 	// Number of errors
@@ -167,6 +171,7 @@ func (ps *perfSchema) StartStatement(sql string, connID uint64, callerName EnumC
 		callerLock.Unlock()
 	}
 
+	digestText, digest := parser.NormalizeDigest(sql)
 	return &StatementState{
 		connID:     connID,
 		info:       info,
@@ -175,6 +180,29 @@ func (ps *perfSchema) StartStatement(sql string, connID uint64, callerName EnumC
 		timerName:  timerName,
 		timerStart: timerStart,
 		sqlText:    sql,
+		digest:     digest,
+		digestText: digestText,
+	}
+}
+
+// SetSchemaName sets the current schema of the statement.
+func (state *StatementState) SetSchemaName(name string) {
+	if state != nil {
+		state.schemaName = name
+	}
+}
+
+// SetRows sets the number of rows affected, sent to the client and examined by the statement.
+func (state *StatementState) SetRows(affected, sent, examined uint64) {
+	if state != nil {
+		state.rowsAffected, state.rowsSent, state.rowsExamined = affected, sent, examined
+	}
+}
+
+// SetError records that the statement ends with an error.
+func (state *StatementState) SetError() {
+	if state != nil {
+		state.errNum++
 	}
 }
 
@@ -208,6 +236,10 @@ func (ps *perfSchema) EndStatement(state *StatementState) {
 	if err != nil {
 		log.Errorf("Unable to append to events_statements_history table %v", errors.ErrorStack(err))
 	}
+	err = ps.updateEventsStmtsSummaryByDigest(state)
+	if err != nil {
+		log.Errorf("Unable to update events_statements_summary_by_digest table %v", errors.ErrorStack(err))
+	}
 }
 
 func state2Record(state *StatementState) []types.Datum {
@@ -219,40 +251,40 @@ func state2Record(state *StatementState) []types.Datum {
 		state.source,             // SOURCE
 		uint64(state.timerStart), // TIMER_START
 		uint64(state.timerEnd),   // TIMER_END
-		nil, // TIMER_WAIT
-		uint64(state.lockTime),             // LOCK_TIME
-		state.sqlText,                      // SQL_TEXT
-		nil,                                // DIGEST
-		nil,                                // DIGEST_TEXT
-		state.schemaName,                   // CURRENT_SCHEMA
-		nil,                                // OBJECT_TYPE
-		nil,                                // OBJECT_SCHEMA
-		nil,                                // OBJECT_NAME
-		nil,                                // OBJECT_INSTANCE_BEGIN
-		nil,                                // MYSQL_ERRNO,
-		nil,                                // RETURNED_SQLSTATE
-		nil,                                // MESSAGE_TEXT
-		uint64(state.errNum),               // ERRORS
-		uint64(state.warnNum),              // WARNINGS
-		state.rowsAffected,                 // ROWS_AFFECTED
-		state.rowsSent,                     // ROWS_SENT
-		state.rowsExamined,                 // ROWS_EXAMINED
-		uint64(state.createdTmpDiskTables), // CREATED_TMP_DISK_TABLES
-		uint64(state.createdTmpTables),     // CREATED_TMP_TABLES
-		uint64(state.selectFullJoin),       // SELECT_FULL_JOIN
-		uint64(state.selectFullRangeJoin),  // SELECT_FULL_RANGE_JOIN
-		uint64(state.selectRange),          // SELECT_RANGE
-		uint64(state.selectRangeCheck),     // SELECT_RANGE_CHECK
-		uint64(state.selectScan),           // SELECT_SCAN
-		uint64(state.sortMergePasses),      // SORT_MERGE_PASSES
-		uint64(state.sortRange),            // SORT_RANGE
-		uint64(state.sortRows),             // SORT_ROWS
-		uint64(state.sortScan),             // SORT_SCAN
-		uint64(state.noIndexUsed),          // NO_INDEX_USED
-		uint64(state.noGoodIndexUsed),      // NO_GOOD_INDEX_USED
-		nil, // NESTING_EVENT_ID
-		nil, // NESTING_EVENT_TYPE
-		nil, // NESTING_EVENT_LEVEL
+		uint64(state.timerEnd-state.timerStart), // TIMER_WAIT
+		uint64(state.lockTime),                  // LOCK_TIME
+		state.sqlText,                           // SQL_TEXT
+		state.digest,                            // DIGEST
+		state.digestText,                        // DIGEST_TEXT
+		state.schemaName,                        // CURRENT_SCHEMA
+		nil,                                     // OBJECT_TYPE
+		nil,                                     // OBJECT_SCHEMA
+		nil,                                     // OBJECT_NAME
+		nil,                                     // OBJECT_INSTANCE_BEGIN
+		nil,                                     // MYSQL_ERRNO,
+		nil,                                     // RETURNED_SQLSTATE
+		nil,                                     // MESSAGE_TEXT
+		uint64(state.errNum),                    // ERRORS
+		uint64(state.warnNum),                   // WARNINGS
+		state.rowsAffected,                      // ROWS_AFFECTED
+		state.rowsSent,                          // ROWS_SENT
+		state.rowsExamined,                      // ROWS_EXAMINED
+		uint64(state.createdTmpDiskTables),      // CREATED_TMP_DISK_TABLES
+		uint64(state.createdTmpTables),          // CREATED_TMP_TABLES
+		uint64(state.selectFullJoin),            // SELECT_FULL_JOIN
+		uint64(state.selectFullRangeJoin),       // SELECT_FULL_RANGE_JOIN
+		uint64(state.selectRange),               // SELECT_RANGE
+		uint64(state.selectRangeCheck),          // SELECT_RANGE_CHECK
+		uint64(state.selectScan),                // SELECT_SCAN
+		uint64(state.sortMergePasses),           // SORT_MERGE_PASSES
+		uint64(state.sortRange),                 // SORT_RANGE
+		uint64(state.sortRows),                  // SORT_ROWS
+		uint64(state.sortScan),                  // SORT_SCAN
+		uint64(state.noIndexUsed),               // NO_INDEX_USED
+		uint64(state.noGoodIndexUsed),           // NO_GOOD_INDEX_USED
+		nil,                                     // NESTING_EVENT_ID
+		nil,                                     // NESTING_EVENT_TYPE
+		nil,                                     // NESTING_EVENT_LEVEL
 	)
 }
 
@@ -290,10 +322,121 @@ func (ps *perfSchema) appendEventsStmtsHistory(record []types.Datum) error {
 	return nil
 }
 
+// digestKey identifies a row of table events_statements_summary_by_digest.
+type digestKey struct {
+	schemaName string
+	digest     string
+}
+
+// digestSummary is the aggregated statistics of the statements with the same schema and digest.
+type digestSummary struct {
+	handle     int64
+	schemaName string
+	digest     string
+	digestText string
+
+	count           uint64
+	sumWait         uint64
+	minWait         uint64
+	maxWait         uint64
+	sumLockTime     uint64
+	sumErrors       uint64
+	sumWarnings     uint64
+	sumRowsAffected uint64
+	sumRowsSent     uint64
+	sumRowsExamined uint64
+	firstSeen       time.Time
+	lastSeen        time.Time
+}
+
+func (ds *digestSummary) add(state *StatementState, now time.Time) {
+	wait := uint64(state.timerEnd - state.timerStart)
+	if ds.count == 0 || wait < ds.minWait {
+		ds.minWait = wait
+	}
+	if wait > ds.maxWait {
+		ds.maxWait = wait
+	}
+	if ds.count == 0 {
+		ds.firstSeen = now
+	}
+	ds.count++
+	ds.sumWait += wait
+	ds.sumLockTime += uint64(state.lockTime)
+	ds.sumErrors += uint64(state.errNum)
+	ds.sumWarnings += uint64(state.warnNum)
+	ds.sumRowsAffected += state.rowsAffected
+	ds.sumRowsSent += state.rowsSent
+	ds.sumRowsExamined += state.rowsExamined
+	ds.lastSeen = now
+}
+
+func (ds *digestSummary) toRecord() []types.Datum {
+	record := make([]types.Datum, 0, len(ColumnStmtsSummaryByDigest))
+	if ds.digest == "" {
+		// The statements exceeding digestElemMax are aggregated into this row.
+		record = append(record, types.Datum{}, types.Datum{}, types.Datum{})
+	} else {
+		record = append(record, types.MakeDatums(ds.schemaName, ds.digest, ds.digestText)...)
+	}
+	record = append(record, types.MakeDatums(
+		ds.count,                  // COUNT_STAR
+		ds.sumWait,                // SUM_TIMER_WAIT
+		ds.minWait,                // MIN_TIMER_WAIT
+		ds.sumWait/ds.count,       // AVG_TIMER_WAIT
+		ds.maxWait,                // MAX_TIMER_WAIT
+		ds.sumLockTime,            // SUM_LOCK_TIME
+		ds.sumErrors,              // SUM_ERRORS
+		ds.sumWarnings,            // SUM_WARNINGS
+		ds.sumRowsAffected,        // SUM_ROWS_AFFECTED
+		ds.sumRowsSent,            // SUM_ROWS_SENT
+		ds.sumRowsExamined,        // SUM_ROWS_EXAMINED
+		timeToDatum(ds.firstSeen), // FIRST_SEEN
+		timeToDatum(ds.lastSeen),  // LAST_SEEN
+	)...)
+	return record
+}
+
+func timeToDatum(t time.Time) types.Datum {
+	return types.NewDatum(mysql.Time{Time: t, Type: mysql.TypeTimestamp, Fsp: mysql.DefaultFsp})
+}
+
+func (ps *perfSchema) updateEventsStmtsSummaryByDigest(state *StatementState) error {
+	tbl := ps.mTables[TableStmtsSummaryByDigest]
+	if tbl == nil {
+		return nil
+	}
+
+	ps.digestMu.Lock()
+	defer ps.digestMu.Unlock()
+	key := digestKey{schemaName: state.schemaName, digest: state.digest}
+	ds, ok := ps.digestSummaries[key]
+	if !ok && len(ps.digestSummaries) >= digestElemMax {
+		key = digestKey{}
+		ds, ok = ps.digestSummaries[key]
+	}
+	if !ok {
+		ds = &digestSummary{schemaName: key.schemaName, digest: key.digest, digestText: state.digestText}
+	}
+	ds.add(state, time.Now())
+	record := ds.toRecord()
+	if ok {
+		return errors.Trace(tbl.UpdateRecord(nil, ds.handle, nil, record, nil))
+	}
+	handle, err := tbl.AddRecord(nil, record)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ds.handle = handle
+	ps.digestSummaries[key] = ds
+	return nil
+}
+
 func (ps *perfSchema) registerStatements() {
 	ps.stmtInfos = make(map[reflect.Type]*statementInfo)
 	// Existing instrument names are the same as MySQL 5.7
 	ps.RegisterStatement("sql", "alter_table", (*ast.AlterTableStmt)(nil))
+	ps.RegisterStatement("sql", "alter_user", (*ast.AlterUserStmt)(nil))
 	ps.RegisterStatement("sql", "begin", (*ast.BeginStmt)(nil))
 	ps.RegisterStatement("sql", "commit", (*ast.CommitStmt)(nil))
 	ps.RegisterStatement("sql", "create_db", (*ast.CreateDatabaseStmt)(nil))
@@ -309,12 +452,16 @@ func (ps *perfSchema) registerStatements() {
 	ps.RegisterStatement("sql", "execute", (*ast.ExecuteStmt)(nil))
 	ps.RegisterStatement("sql", "explain", (*ast.ExplainStmt)(nil))
 	ps.RegisterStatement("sql", "grant", (*ast.GrantStmt)(nil))
+	ps.RegisterStatement("sql", "grant_role", (*ast.GrantRoleStmt)(nil))
 	ps.RegisterStatement("sql", "insert", (*ast.InsertStmt)(nil))
 	ps.RegisterStatement("sql", "prepare", (*ast.PrepareStmt)(nil))
+	ps.RegisterStatement("sql", "rename_user", (*ast.RenameUserStmt)(nil))
 	ps.RegisterStatement("sql", "rollback", (*ast.RollbackStmt)(nil))
 	ps.RegisterStatement("sql", "select", (*ast.SelectStmt)(nil))
 	ps.RegisterStatement("sql", "set", (*ast.SetStmt)(nil))
+	ps.RegisterStatement("sql", "set_default_role", (*ast.SetDefaultRoleStmt)(nil))
 	ps.RegisterStatement("sql", "set_password", (*ast.SetPwdStmt)(nil))
+	ps.RegisterStatement("sql", "set_role", (*ast.SetRoleStmt)(nil))
 	ps.RegisterStatement("sql", "show", (*ast.ShowStmt)(nil))
 	ps.RegisterStatement("sql", "truncate", (*ast.TruncateTableStmt)(nil))
 	ps.RegisterStatement("sql", "union", (*ast.UnionStmt)(nil))
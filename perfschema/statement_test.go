@@ -36,6 +36,8 @@ func (p *testStatementSuit) TestUninitPS(c *C) {
 	c.Assert(err, IsNil)
 	err = ps.appendEventsStmtsHistory([]types.Datum{})
 	c.Assert(err, IsNil)
+	err = ps.updateEventsStmtsSummaryByDigest(&StatementState{})
+	c.Assert(err, IsNil)
 }
 
 func (p *testStatementSuit) TestDisablePS(c *C) {
@@ -210,6 +210,18 @@ func (p *DataSource) PruneColumnsAndResolveIndices(parentUsedCols []*expression.
 	return nil, nil
 }
 
+// PruneColumnsAndResolveIndices implements LogicalPlan PruneColumnsAndResolveIndices interface.
+func (p *CTEScan) PruneColumnsAndResolveIndices(parentUsedCols []*expression.Column) ([]*expression.Column, error) {
+	used := makeUsedList(parentUsedCols, p.schema)
+	for i := len(used) - 1; i >= 0; i-- {
+		if !used[i] {
+			p.schema = append(p.schema[:i], p.schema[i+1:]...)
+		}
+	}
+	p.schema.InitIndices()
+	return nil, nil
+}
+
 // PruneColumnsAndResolveIndices implements LogicalPlan PruneColumnsAndResolveIndices interface.
 func (p *TableDual) PruneColumnsAndResolveIndices(parentUsedCols []*expression.Column) ([]*expression.Column, error) {
 	return nil, nil
@@ -0,0 +1,235 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/model"
+)
+
+const (
+	// TaskRoot means the operator is executed by tidb.
+	TaskRoot = "root"
+	// TaskCop means the operator is executed by the coprocessor of the storage.
+	TaskCop = "cop"
+)
+
+// OperatorInfo is the description of a plan operator shown by EXPLAIN.
+type OperatorInfo struct {
+	Operator     string
+	AccessObject string
+	Conditions   string
+	Task         string
+}
+
+// ExplainOperator returns the OperatorInfo of a plan.
+func ExplainOperator(p Plan) OperatorInfo {
+	info := OperatorInfo{Task: TaskRoot}
+	var conds []string
+	switch x := p.(type) {
+	case *PhysicalTableScan:
+		info.Operator = "TableScan"
+		info.AccessObject = "table:" + tableAsName(x.Table.Name.O, x.TableAsName)
+		if len(x.Ranges) > 0 {
+			ranges := make([]string, 0, len(x.Ranges))
+			for _, r := range x.Ranges {
+				ranges = append(ranges, fmt.Sprintf("[%d,%d]", r.LowVal, r.HighVal))
+			}
+			info.AccessObject += ", range:" + strings.Join(ranges, ",")
+		}
+		if x.KeepOrder {
+			info.AccessObject += ", keep order"
+		}
+		info.Task = TaskCop
+		conds = appendConditions(conds, "access", x.AccessCondition)
+		conds = x.physicalTableSource.explainPushed(conds)
+	case *PhysicalIndexScan:
+		info.Operator = "IndexScan"
+		info.AccessObject = fmt.Sprintf("table:%s, index:%s", tableAsName(x.Table.Name.O, x.TableAsName), x.Index.Name.O)
+		if len(x.Ranges) > 0 {
+			ranges := make([]string, 0, len(x.Ranges))
+			for _, r := range x.Ranges {
+				ranges = append(ranges, r.String())
+			}
+			info.AccessObject += ", range:" + strings.Join(ranges, ",")
+		}
+		if x.DoubleRead {
+			info.AccessObject += ", double read"
+		}
+		info.Task = TaskCop
+		conds = appendConditions(conds, "access", x.AccessCondition)
+		conds = x.physicalTableSource.explainPushed(conds)
+	case *PhysicalDummyScan:
+		info.Operator = "DummyScan"
+	case *PhysicalUnionScan:
+		info.Operator = "UnionScan"
+		if x.Condition != nil {
+			conds = append(conds, x.Condition.String())
+		}
+	case *CTEScan:
+		info.Operator = "CTEScan"
+		info.AccessObject = "cte:" + x.CTE.Name.O
+		if x.Working {
+			info.AccessObject += ", working"
+		} else if x.CTE.Recursive != nil {
+			info.AccessObject += ", recursive"
+		}
+	case *Selection:
+		info.Operator = "Selection"
+		conds = appendConditions(conds, "", x.Conditions)
+	case *Projection:
+		info.Operator = "Projection"
+	case *PhysicalHashJoin:
+		info.Operator = "HashJoin"
+		conds = append(conds, joinTypeString(x.JoinType))
+		conds = appendEqualConditions(conds, x.EqualConditions)
+		conds = appendConditions(conds, "left", x.LeftConditions)
+		conds = appendConditions(conds, "right", x.RightConditions)
+		conds = appendConditions(conds, "other", x.OtherConditions)
+	case *PhysicalMergeJoin:
+		info.Operator = "MergeJoin"
+		conds = append(conds, joinTypeString(x.JoinType))
+		conds = appendEqualConditions(conds, x.EqualConditions)
+		conds = appendConditions(conds, "left", x.LeftConditions)
+		conds = appendConditions(conds, "right", x.RightConditions)
+		conds = appendConditions(conds, "other", x.OtherConditions)
+	case *PhysicalIndexJoin:
+		info.Operator = "IndexJoin"
+		conds = append(conds, joinTypeString(x.JoinType), fmt.Sprintf("outer:%s", x.children[x.OuterIndex].GetID()))
+		conds = appendEqualConditions(conds, x.EqualConditions)
+		conds = appendConditions(conds, "left", x.LeftConditions)
+		conds = appendConditions(conds, "right", x.RightConditions)
+		conds = appendConditions(conds, "other", x.OtherConditions)
+	case *PhysicalHashSemiJoin:
+		info.Operator = "HashSemiJoin"
+		switch {
+		case x.WithAux:
+			conds = append(conds, "semi join with aux")
+		case x.Anti:
+			conds = append(conds, "anti semi join")
+		default:
+			conds = append(conds, "semi join")
+		}
+		conds = appendEqualConditions(conds, x.EqualConditions)
+		conds = appendConditions(conds, "left", x.LeftConditions)
+		conds = appendConditions(conds, "right", x.RightConditions)
+		conds = appendConditions(conds, "other", x.OtherConditions)
+	case *PhysicalApply:
+		info.Operator = "Apply"
+		if x.Checker != nil {
+			conds = append(conds, x.Checker.Condition.String())
+		}
+	case *PhysicalAggregation:
+		if x.AggType == StreamedAgg {
+			info.Operator = "StreamAgg"
+		} else {
+			info.Operator = "HashAgg"
+		}
+		for _, f := range x.AggFuncs {
+			conds = append(conds, f.String())
+		}
+		if len(x.GroupByItems) > 0 {
+			conds = appendConditions(conds, "group by", x.GroupByItems)
+		}
+	case *Sort:
+		info.Operator = "Sort"
+		if x.ExecLimit != nil {
+			info.Operator = "TopN"
+			conds = append(conds, fmt.Sprintf("offset:%d, count:%d", x.ExecLimit.Offset, x.ExecLimit.Count))
+		}
+		for _, item := range x.ByItems {
+			if item.Desc {
+				conds = append(conds, item.Expr.String()+" desc")
+			} else {
+				conds = append(conds, item.Expr.String())
+			}
+		}
+	case *Limit:
+		info.Operator = "Limit"
+		conds = append(conds, fmt.Sprintf("offset:%d, count:%d", x.Offset, x.Count))
+	default:
+		info.Operator = p.GetID()
+		if i := strings.LastIndex(info.Operator, "_"); i > 0 {
+			info.Operator = info.Operator[:i]
+		}
+	}
+	info.Conditions = strings.Join(conds, ", ")
+	return info
+}
+
+// explainPushed appends the descriptions of the operators that are pushed down to the scan.
+func (p *physicalTableSource) explainPushed(conds []string) []string {
+	conds = appendConditions(conds, "pushed", p.pushedConditions)
+	if p.Aggregated {
+		conds = append(conds, "pushed partial agg")
+	}
+	if len(p.SortItems) > 0 {
+		conds = append(conds, "pushed topN")
+	}
+	if p.LimitCount != nil {
+		conds = append(conds, fmt.Sprintf("pushed limit:%d", *p.LimitCount))
+	}
+	return conds
+}
+
+// tableAsName returns the table name with its alias, like "t1 as a".
+func tableAsName(name string, asName *model.CIStr) string {
+	if asName == nil || asName.L == "" || asName.O == name {
+		return name
+	}
+	return name + " as " + asName.O
+}
+
+func joinTypeString(tp JoinType) string {
+	switch tp {
+	case LeftOuterJoin:
+		return "left outer join"
+	case RightOuterJoin:
+		return "right outer join"
+	case SemiJoin:
+		return "semi join"
+	case SemiJoinWithAux:
+		return "semi join with aux"
+	default:
+		return "inner join"
+	}
+}
+
+func appendEqualConditions(conds []string, eqs []*expression.ScalarFunction) []string {
+	if len(eqs) == 0 {
+		return conds
+	}
+	strs := make([]string, 0, len(eqs))
+	for _, eq := range eqs {
+		strs = append(strs, eq.String())
+	}
+	return append(conds, "equal:["+strings.Join(strs, " ")+"]")
+}
+
+func appendConditions(conds []string, label string, exprs []expression.Expression) []string {
+	if len(exprs) == 0 {
+		return conds
+	}
+	strs := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		strs = append(strs, expr.String())
+	}
+	if label == "" {
+		return append(conds, strings.Join(strs, " "))
+	}
+	return append(conds, label+":["+strings.Join(strs, " ")+"]")
+}
@@ -0,0 +1,119 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/sessionctx/variable"
+)
+
+// Optimizer hints of join algorithms.
+const (
+	// HintHashJoin makes the hinted tables the build side of hash joins.
+	HintHashJoin = "hash_join"
+	// HintMergeJoin makes the joins of the hinted tables sort merge joins.
+	HintMergeJoin = "merge_join"
+	// HintINLJoin makes the hinted tables the inner side of index lookup joins.
+	HintINLJoin = "inl_join"
+)
+
+const (
+	preferHashJoinBuildLeft uint = 1 << iota
+	preferHashJoinBuildRight
+	preferMergeJoin
+	preferIndexJoinInnerLeft
+	preferIndexJoinInnerRight
+)
+
+// tableHintInfo stores the tables hinted by the optimizer hints of a select statement.
+type tableHintInfo struct {
+	hashJoinTables  []model.CIStr
+	mergeJoinTables []model.CIStr
+	indexJoinTables []model.CIStr
+}
+
+// pushTableHints pushes the optimizer hints of a select statement,
+// the hints ignored by the parser are reported as warnings like MySQL does.
+func (b *planBuilder) pushTableHints(sel *ast.SelectStmt) {
+	for _, warn := range sel.HintWarnings {
+		variable.GetSessionVars(b.ctx).AppendWarning(warn)
+	}
+	var info tableHintInfo
+	for _, hint := range sel.TableHints {
+		switch hint.HintName.L {
+		case HintHashJoin:
+			info.hashJoinTables = append(info.hashJoinTables, hint.Tables...)
+		case HintMergeJoin:
+			info.mergeJoinTables = append(info.mergeJoinTables, hint.Tables...)
+		case HintINLJoin:
+			info.indexJoinTables = append(info.indexJoinTables, hint.Tables...)
+		}
+	}
+	b.tableHintInfo = append(b.tableHintInfo, info)
+}
+
+func (b *planBuilder) popTableHints() {
+	b.tableHintInfo = b.tableHintInfo[:len(b.tableHintInfo)-1]
+}
+
+// setPreferredJoinType sets the join algorithm preferred by the hints of the current select statement.
+func (b *planBuilder) setPreferredJoinType(p *Join, lChild, rChild LogicalPlan) {
+	if len(b.tableHintInfo) == 0 {
+		return
+	}
+	info := b.tableHintInfo[len(b.tableHintInfo)-1]
+	lAlias, rAlias := extractTableAlias(lChild), extractTableAlias(rChild)
+	if containsTable(info.mergeJoinTables, lAlias) || containsTable(info.mergeJoinTables, rAlias) {
+		p.preferJoinType |= preferMergeJoin
+	}
+	if containsTable(info.indexJoinTables, lAlias) {
+		p.preferJoinType |= preferIndexJoinInnerLeft
+	}
+	if containsTable(info.indexJoinTables, rAlias) {
+		p.preferJoinType |= preferIndexJoinInnerRight
+	}
+	if containsTable(info.hashJoinTables, lAlias) {
+		p.preferJoinType |= preferHashJoinBuildLeft
+	}
+	if containsTable(info.hashJoinTables, rAlias) {
+		p.preferJoinType |= preferHashJoinBuildRight
+	}
+}
+
+// extractTableAlias returns the table name or alias of a plan if all its columns come from the same table.
+func extractTableAlias(p LogicalPlan) *model.CIStr {
+	schema := p.GetSchema()
+	if len(schema) == 0 || schema[0].TblName.L == "" {
+		return nil
+	}
+	for _, col := range schema[1:] {
+		if col.TblName.L != schema[0].TblName.L {
+			return nil
+		}
+	}
+	return &schema[0].TblName
+}
+
+func containsTable(tables []model.CIStr, name *model.CIStr) bool {
+	if name == nil {
+		return false
+	}
+	for _, t := range tables {
+		if t.L == name.L {
+			return true
+		}
+	}
+	return false
+}
@@ -23,7 +23,7 @@ import (
 
 // tryToGetJoinGroup tries to fetch a whole join group, which all joins is cartesian join.
 func tryToGetJoinGroup(j *Join) ([]LogicalPlan, bool) {
-	if j.reordered || !j.cartesianJoin {
+	if j.reordered || !j.cartesianJoin || j.preferJoinType > 0 {
 		return nil, false
 	}
 	lChild := j.GetChildByIndex(0).(LogicalPlan)
@@ -96,7 +96,11 @@ func (b *planBuilder) buildResultSetNode(node ast.ResultSetNode) LogicalPlan {
 		case *ast.UnionStmt:
 			p = b.buildUnion(v)
 		case *ast.TableName:
-			p = b.buildDataSource(v)
+			if v.CTE != nil {
+				p = b.buildCTE(v)
+			} else {
+				p = b.buildDataSource(v)
+			}
 		default:
 			b.err = ErrUnsupportedType.Gen("unsupported table source type %T", v)
 			return nil
@@ -220,6 +224,7 @@ func (b *planBuilder) buildJoin(join *ast.Join) LogicalPlan {
 	} else {
 		joinPlan.JoinType = InnerJoin
 	}
+	b.setPreferredJoinType(joinPlan, leftPlan, rightPlan)
 	addChild(joinPlan, leftPlan)
 	addChild(joinPlan, rightPlan)
 	return joinPlan
@@ -327,12 +332,38 @@ func (b *planBuilder) buildDistinct(src LogicalPlan) LogicalPlan {
 }
 
 func (b *planBuilder) buildUnion(union *ast.UnionStmt) LogicalPlan {
+	if union.With != nil {
+		b.collectCTERefs(union.With, union)
+	}
+	u := b.buildUnionAll(union.SelectList.Selects)
+	if b.err != nil {
+		return nil
+	}
+	var p LogicalPlan
+	p = u
+	if union.Distinct {
+		p = b.buildDistinct(u)
+	}
+	if union.OrderBy != nil {
+		p = b.buildSort(p, union.OrderBy.Items, nil)
+	}
+	if union.Limit != nil {
+		p = b.buildLimit(p, union.Limit)
+	}
+	return p
+}
+
+// buildUnionAll builds a Union plan that concatenates the results of the selects.
+func (b *planBuilder) buildUnionAll(selects []*ast.SelectStmt) *Union {
 	u := &Union{baseLogicalPlan: newBaseLogicalPlan(Un, b.allocator)}
 	u.self = u
 	u.initID()
-	u.children = make([]Plan, len(union.SelectList.Selects))
-	for i, sel := range union.SelectList.Selects {
+	u.children = make([]Plan, len(selects))
+	for i, sel := range selects {
 		u.children[i] = b.buildSelect(sel)
+		if b.err != nil {
+			return nil
+		}
 		u.correlated = u.correlated || u.children[i].IsCorrelated()
 	}
 	firstSchema := u.children[0].GetSchema().DeepCopy()
@@ -369,18 +400,7 @@ func (b *planBuilder) buildUnion(union *ast.UnionStmt) LogicalPlan {
 	}
 
 	u.SetSchema(firstSchema)
-	var p LogicalPlan
-	p = u
-	if union.Distinct {
-		p = b.buildDistinct(u)
-	}
-	if union.OrderBy != nil {
-		p = b.buildSort(p, union.OrderBy.Items, nil)
-	}
-	if union.Limit != nil {
-		p = b.buildLimit(p, union.Limit)
-	}
-	return p
+	return u
 }
 
 // ByItems wraps a "by" item.
@@ -748,6 +768,11 @@ func (b *planBuilder) unfoldWildStar(p LogicalPlan, selectFields []*ast.SelectFi
 }
 
 func (b *planBuilder) buildSelect(sel *ast.SelectStmt) LogicalPlan {
+	b.pushTableHints(sel)
+	defer b.popTableHints()
+	if sel.With != nil {
+		b.collectCTERefs(sel.With, sel)
+	}
 	hasAgg := b.detectSelectAgg(sel)
 	var (
 		p                             LogicalPlan
@@ -888,6 +913,206 @@ func (b *planBuilder) buildDataSource(tn *ast.TableName) LogicalPlan {
 	return p
 }
 
+// cteInfo is the build state of a common table expression.
+type cteInfo struct {
+	// refs is the number of references to the common table expression in the statement.
+	refs int
+	// def is the materialized definition, it is built on the first reference.
+	def *CTEDefinition
+	// working is true while the recursive query blocks are being built.
+	working bool
+}
+
+// cteRefCounter counts the references to the common table expressions.
+type cteRefCounter struct {
+	ctes map[*ast.CommonTableExpression]*cteInfo
+}
+
+// Enter implements ast.Visitor Enter interface.
+func (c *cteRefCounter) Enter(in ast.Node) (ast.Node, bool) {
+	if tn, ok := in.(*ast.TableName); ok && tn.CTE != nil {
+		if info, ok := c.ctes[tn.CTE]; ok {
+			info.refs++
+		}
+	}
+	return in, false
+}
+
+// Leave implements ast.Visitor Leave interface.
+func (c *cteRefCounter) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// collectCTERefs registers the common table expressions of the WITH clause
+// and counts how many times they are referenced in the statement.
+func (b *planBuilder) collectCTERefs(with *ast.WithClause, stmt ast.Node) {
+	if b.ctes == nil {
+		b.ctes = make(map[*ast.CommonTableExpression]*cteInfo)
+	}
+	counter := &cteRefCounter{ctes: make(map[*ast.CommonTableExpression]*cteInfo, len(with.CTEs))}
+	for _, cte := range with.CTEs {
+		info := &cteInfo{}
+		b.ctes[cte] = info
+		counter.ctes[cte] = info
+	}
+	stmt.Accept(counter)
+}
+
+// countCTERefs returns the number of references to cte in the node.
+func countCTERefs(node ast.Node, cte *ast.CommonTableExpression) int {
+	info := &cteInfo{}
+	node.Accept(&cteRefCounter{ctes: map[*ast.CommonTableExpression]*cteInfo{cte: info}})
+	return info.refs
+}
+
+// buildCTE builds the plan for a table name that refers to a common table expression.
+// A non-recursive common table expression that is referenced only once is inlined like a derived table,
+// otherwise it is materialized once and read by a CTEScan.
+func (b *planBuilder) buildCTE(tn *ast.TableName) LogicalPlan {
+	cte := tn.CTE
+	info, ok := b.ctes[cte]
+	if !ok {
+		b.err = errors.Errorf("unknown common table expression %s", cte.Name.O)
+		return nil
+	}
+	if info.working {
+		return b.buildCTEScan(tn, info.def, true)
+	}
+	if !cte.IsRecursive && info.refs <= 1 {
+		p := b.buildResultSetNode(cte.Query)
+		if b.err != nil {
+			return nil
+		}
+		rfs := tn.GetResultFields()
+		if len(rfs) != len(p.GetSchema()) {
+			b.err = errWrongCTEColumnCount()
+			return nil
+		}
+		for i, col := range p.GetSchema() {
+			col.ColName = rfs[i].ColumnAsName
+			col.TblName = tn.Name
+			col.DBName = model.NewCIStr("")
+		}
+		return p
+	}
+	if info.def == nil {
+		b.buildCTEDefinition(cte, info)
+		if b.err != nil {
+			return nil
+		}
+	}
+	return b.buildCTEScan(tn, info.def, false)
+}
+
+func (b *planBuilder) buildCTEScan(tn *ast.TableName, def *CTEDefinition, working bool) LogicalPlan {
+	p := &CTEScan{
+		baseLogicalPlan: newBaseLogicalPlan(CTE, b.allocator),
+		CTE:             def,
+		Working:         working,
+	}
+	p.self = p
+	p.initID()
+	rfs := tn.GetResultFields()
+	seedSchema := def.Seed.GetSchema()
+	if len(rfs) != len(seedSchema) {
+		b.err = errWrongCTEColumnCount()
+		return nil
+	}
+	schema := make([]*expression.Column, 0, len(seedSchema))
+	for i, col := range seedSchema {
+		schema = append(schema, &expression.Column{
+			FromID:   p.id,
+			ColName:  rfs[i].ColumnAsName,
+			TblName:  tn.Name,
+			RetType:  col.RetType,
+			Position: i})
+	}
+	p.SetSchema(schema)
+	return p
+}
+
+// buildCTEDefinition builds the plans that materialize the common table expression.
+// For a recursive one, the query blocks that don't refer to itself make up the seed,
+// the others make up the recursive part that runs on the rows of the last iteration.
+func (b *planBuilder) buildCTEDefinition(cte *ast.CommonTableExpression, info *cteInfo) {
+	// The definition is evaluated once, so it can't refer to the outer query.
+	outerSchemas := b.outerSchemas
+	b.outerSchemas = nil
+	defer func() {
+		b.outerSchemas = outerSchemas
+	}()
+	def := &CTEDefinition{Name: cte.Name}
+	info.def = def
+	if !cte.IsRecursive {
+		def.Seed = b.buildResultSetNode(cte.Query)
+		if b.err == nil {
+			b.cteDefs = append(b.cteDefs, def)
+		}
+		return
+	}
+	union := cte.Query.(*ast.UnionStmt)
+	if union.OrderBy != nil || union.Limit != nil {
+		b.err = ErrUnSupported.Gen("ORDER BY / LIMIT over UNION in recursive Common Table Expression")
+		return
+	}
+	if union.With != nil {
+		b.collectCTERefs(union.With, union)
+	}
+	var seeds, recursives []*ast.SelectStmt
+	for _, sel := range union.SelectList.Selects {
+		refs := countCTERefs(sel, cte)
+		if refs == 0 {
+			if len(recursives) > 0 {
+				b.err = ErrCTERecursiveRequiresNonrecursiveFirst.Gen("Recursive Common Table Expression '%s' should have one or more non-recursive query blocks followed by one or more recursive ones", cte.Name.O)
+				return
+			}
+			seeds = append(seeds, sel)
+			continue
+		}
+		if refs > 1 {
+			b.err = ErrCTERecursiveRequiresSingleReference.Gen("In recursive query block of Recursive Common Table Expression '%s', the recursive table must be referenced only once, and not in any subquery", cte.Name.O)
+			return
+		}
+		if b.detectSelectAgg(sel) {
+			b.err = ErrCTERecursiveForbidsAggregation.Gen("Recursive Common Table Expression '%s' can contain neither aggregation nor window functions in recursive query block", cte.Name.O)
+			return
+		}
+		if sel.Distinct || sel.OrderBy != nil || sel.Limit != nil {
+			b.err = ErrUnSupported.Gen("DISTINCT, ORDER BY or LIMIT in recursive query block of Common Table Expression")
+			return
+		}
+		recursives = append(recursives, sel)
+	}
+	def.Seed = b.buildCTEPart(seeds)
+	if b.err != nil {
+		return
+	}
+	info.working = true
+	def.Recursive = b.buildCTEPart(recursives)
+	info.working = false
+	if b.err != nil {
+		return
+	}
+	if len(def.Seed.GetSchema()) != len(def.Recursive.GetSchema()) {
+		b.err = errors.New("The used SELECT statements have a different number of columns")
+		return
+	}
+	def.Distinct = union.Distinct
+	b.cteDefs = append(b.cteDefs, def)
+}
+
+// buildCTEPart builds the query blocks of a recursive common table expression that are combined by UNION ALL.
+func (b *planBuilder) buildCTEPart(selects []*ast.SelectStmt) LogicalPlan {
+	if len(selects) == 1 {
+		return b.buildSelect(selects[0])
+	}
+	u := b.buildUnionAll(selects)
+	if b.err != nil {
+		return nil
+	}
+	return u
+}
+
 // ApplyConditionChecker checks whether all or any output of apply matches a condition.
 type ApplyConditionChecker struct {
 	Condition expression.Expression
@@ -46,6 +46,8 @@ type Join struct {
 	anti          bool
 	reordered     bool
 	cartesianJoin bool
+	// preferJoinType is the join algorithm preferred by the optimizer hints, see preferHashJoinBuildLeft etc.
+	preferJoinType uint
 
 	EqualConditions []*expression.ScalarFunction
 	LeftConditions  []expression.Expression
@@ -107,6 +109,29 @@ type TableDual struct {
 	baseLogicalPlan
 }
 
+// CTEDefinition is a common table expression that is materialized once and
+// shared by all of its references.
+type CTEDefinition struct {
+	Name model.CIStr
+	// Seed produces the initial rows of the common table expression.
+	Seed Plan
+	// Recursive is evaluated repeatedly on the rows produced by the last iteration,
+	// it is nil for a non-recursive common table expression.
+	Recursive Plan
+	// Distinct means the rows are combined by UNION DISTINCT.
+	Distinct bool
+}
+
+// CTEScan reads the rows of a materialized common table expression.
+// The Position of a schema column is its offset in the materialized row.
+type CTEScan struct {
+	baseLogicalPlan
+
+	CTE *CTEDefinition
+	// Working means the scan is the recursive reference that reads the rows produced by the last iteration.
+	Working bool
+}
+
 // DataSource represents a tablescan without condition push down.
 type DataSource struct {
 	baseLogicalPlan
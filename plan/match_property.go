@@ -204,6 +204,26 @@ func (p *PhysicalHashJoin) matchProperty(prop *requiredProperty, childPlanInfo .
 	return &physicalPlanInfo{p: &np, cost: cost, count: estimateJoinCount(lRes.count, rRes.count)}
 }
 
+// matchProperty implements PhysicalPlan matchProperty interface.
+func (p *PhysicalMergeJoin) matchProperty(_ *requiredProperty, childPlanInfo ...*physicalPlanInfo) *physicalPlanInfo {
+	lRes, rRes := childPlanInfo[0], childPlanInfo[1]
+	np := *p
+	np.SetChildren(lRes.p, rRes.p)
+	cost := lRes.cost + rRes.cost + float64(lRes.count+rRes.count)*cpuFactor
+	return &physicalPlanInfo{p: &np, cost: cost, count: estimateJoinCount(lRes.count, rRes.count)}
+}
+
+// matchProperty implements PhysicalPlan matchProperty interface.
+func (p *PhysicalIndexJoin) matchProperty(_ *requiredProperty, childPlanInfo ...*physicalPlanInfo) *physicalPlanInfo {
+	lRes, rRes := childPlanInfo[0], childPlanInfo[1]
+	np := *p
+	np.SetChildren(lRes.p, rRes.p)
+	// The inner child is only read for the join keys of the outer rows.
+	outer := childPlanInfo[p.OuterIndex]
+	cost := outer.cost + float64(outer.count)*netWorkFactor
+	return &physicalPlanInfo{p: &np, cost: cost, count: estimateJoinCount(lRes.count, rRes.count)}
+}
+
 // matchProperty implements PhysicalPlan matchProperty interface.
 func (p *Union) matchProperty(_ *requiredProperty, childPlanInfo ...*physicalPlanInfo) *physicalPlanInfo {
 	np := *p
@@ -283,6 +303,11 @@ func (p *Distinct) matchProperty(_ *requiredProperty, _ ...*physicalPlanInfo) *p
 	panic("You can't call this function!")
 }
 
+// matchProperty implements PhysicalPlan matchProperty interface.
+func (p *CTEScan) matchProperty(_ *requiredProperty, _ ...*physicalPlanInfo) *physicalPlanInfo {
+	panic("You can't call this function!")
+}
+
 // matchProperty implements PhysicalPlan matchProperty interface.
 func (p *TableDual) matchProperty(_ *requiredProperty, _ ...*physicalPlanInfo) *physicalPlanInfo {
 	panic("You can't call this function!")
@@ -40,27 +40,103 @@ func Optimize(ctx context.Context, node ast.Node, is infoschema.InfoSchema) (Pla
 		return nil, errors.Trace(builder.err)
 	}
 	if logic, ok := p.(LogicalPlan); ok {
-		var err error
-		_, logic, err = logic.PredicatePushDown(nil)
+		p, err := optimizeLogicalPlan(logic, builder.allocator)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		_, err = logic.PruneColumnsAndResolveIndices(p.GetSchema())
-		if err != nil {
-			return nil, errors.Trace(err)
+		// The materialized common table expressions are optimized separately,
+		// every column they produce is kept because the scans read them by position.
+		for _, def := range builder.cteDefs {
+			def.Seed, err = optimizeLogicalPlan(def.Seed.(LogicalPlan), builder.allocator)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if def.Recursive != nil {
+				def.Recursive, err = optimizeLogicalPlan(def.Recursive.(LogicalPlan), builder.allocator)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+			}
 		}
-		logic = EliminateProjection(logic)
-		info, err := logic.convert2PhysicalPlan(&requiredProperty{})
-		if err != nil {
-			return nil, errors.Trace(err)
-		}
-		p = info.p
 		log.Debugf("[PLAN] %s", ToString(p))
 		return p, nil
 	}
 	return p, nil
 }
 
+// optimizeLogicalPlan does the logical optimizations and converts the logical plan to a physical plan.
+func optimizeLogicalPlan(logic LogicalPlan, a *idAllocator) (Plan, error) {
+	schema := logic.GetSchema()
+	_, logic, err := logic.PredicatePushDown(nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	_, err = logic.PruneColumnsAndResolveIndices(schema)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	logic = EliminateProjection(logic)
+	info, err := logic.convert2PhysicalPlan(&requiredProperty{})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	assignPhysicalIDs(info.p, a)
+	return info.p, nil
+}
+
+// assignPhysicalIDs allocates IDs for the physical plans that are created without IDs,
+// so that every operator of the final plan can be identified.
+func assignPhysicalIDs(p Plan, a *idAllocator) {
+	if p.GetID() == "" {
+		if np, ok := p.(interface {
+			initIDWithType(tp string, a *idAllocator)
+		}); ok {
+			np.initIDWithType(physicalPlanType(p), a)
+		}
+	}
+	for _, child := range p.GetChildren() {
+		assignPhysicalIDs(child, a)
+	}
+	if apply, ok := p.(*PhysicalApply); ok {
+		assignPhysicalIDs(apply.InnerPlan, a)
+	}
+}
+
+func physicalPlanType(p Plan) string {
+	switch x := p.(type) {
+	case *PhysicalTableScan:
+		return Ts
+	case *PhysicalIndexScan:
+		return Idx
+	case *PhysicalDummyScan:
+		return "DummyScan"
+	case *PhysicalUnionScan:
+		return "UnionScan"
+	case *PhysicalHashJoin:
+		return "HashJoin"
+	case *PhysicalMergeJoin:
+		return "MergeJoin"
+	case *PhysicalIndexJoin:
+		return "IndexJoin"
+	case *PhysicalHashSemiJoin:
+		return "HashSemiJoin"
+	case *PhysicalApply:
+		return App
+	case *PhysicalAggregation:
+		if x.AggType == StreamedAgg {
+			return "StreamAgg"
+		}
+		return "HashAgg"
+	case *Sort:
+		return Srt
+	case *Limit:
+		return Lim
+	case *CTEScan:
+		return CTE
+	}
+	return "Plan"
+}
+
 // PrepareStmt prepares a raw statement parsed from parser.
 // The statement must be prepared before it can be passed to optimize function.
 // We pass InfoSchema instead of getting from Context in case it is changed after resolving name.
@@ -68,7 +144,7 @@ func PrepareStmt(is infoschema.InfoSchema, ctx context.Context, node ast.Node) e
 	if err := Preprocess(node, is, ctx); err != nil {
 		return errors.Trace(err)
 	}
-	if err := Validate(node, true); err != nil {
+	if err := Validate(ctx, node, true); err != nil {
 		return errors.Trace(err)
 	}
 	return nil
@@ -76,31 +152,67 @@ func PrepareStmt(is infoschema.InfoSchema, ctx context.Context, node ast.Node) e
 
 // Optimizer error codes.
 const (
-	CodeOneColumn           terror.ErrCode = 1
-	CodeSameColumns         terror.ErrCode = 2
-	CodeMultiWildCard       terror.ErrCode = 3
-	CodeUnsupported         terror.ErrCode = 4
-	CodeInvalidGroupFuncUse terror.ErrCode = 5
-	CodeIllegalReference    terror.ErrCode = 6
+	CodeOneColumn               terror.ErrCode = 1
+	CodeSameColumns             terror.ErrCode = 2
+	CodeMultiWildCard           terror.ErrCode = 3
+	CodeUnsupported             terror.ErrCode = 4
+	CodeInvalidGroupFuncUse     terror.ErrCode = 5
+	CodeIllegalReference        terror.ErrCode = 6
+	CodeKeyDoesNotExist         terror.ErrCode = 7
+	CodeWrongUsage              terror.ErrCode = 8
+	CodeCantUseOptionHere       terror.ErrCode = 9
+	CodeWrongFieldWithGroup     terror.ErrCode = 10
+	CodeMixOfGroupFuncAndFields terror.ErrCode = 11
+	CodeNonUniqTable            terror.ErrCode = 12
+	CodeViewWrongList           terror.ErrCode = 13
+
+	CodeCTERecursiveRequiresUnion             terror.ErrCode = 14
+	CodeCTERecursiveRequiresNonrecursiveFirst terror.ErrCode = 15
+	CodeCTERecursiveForbidsAggregation        terror.ErrCode = 16
+	CodeCTERecursiveRequiresSingleReference   terror.ErrCode = 17
 )
 
 // Optimizer base errors.
 var (
-	ErrOneColumn           = terror.ClassOptimizer.New(CodeOneColumn, "Operand should contain 1 column(s)")
-	ErrSameColumns         = terror.ClassOptimizer.New(CodeSameColumns, "Operands should contain same columns")
-	ErrMultiWildCard       = terror.ClassOptimizer.New(CodeMultiWildCard, "wildcard field exist more than once")
-	ErrUnSupported         = terror.ClassOptimizer.New(CodeUnsupported, "unsupported")
-	ErrInvalidGroupFuncUse = terror.ClassOptimizer.New(CodeInvalidGroupFuncUse, "Invalid use of group function")
-	ErrIllegalReference    = terror.ClassOptimizer.New(CodeIllegalReference, "Illegal reference")
+	ErrOneColumn               = terror.ClassOptimizer.New(CodeOneColumn, "Operand should contain 1 column(s)")
+	ErrSameColumns             = terror.ClassOptimizer.New(CodeSameColumns, "Operands should contain same columns")
+	ErrMultiWildCard           = terror.ClassOptimizer.New(CodeMultiWildCard, "wildcard field exist more than once")
+	ErrUnSupported             = terror.ClassOptimizer.New(CodeUnsupported, "unsupported")
+	ErrInvalidGroupFuncUse     = terror.ClassOptimizer.New(CodeInvalidGroupFuncUse, "Invalid use of group function")
+	ErrIllegalReference        = terror.ClassOptimizer.New(CodeIllegalReference, "Illegal reference")
+	ErrKeyDoesNotExist         = terror.ClassOptimizer.New(CodeKeyDoesNotExist, "Key does not exist")
+	ErrWrongUsage              = terror.ClassOptimizer.New(CodeWrongUsage, "Incorrect usage")
+	ErrCantUseOptionHere       = terror.ClassOptimizer.New(CodeCantUseOptionHere, "Incorrect usage/placement")
+	ErrWrongFieldWithGroup     = terror.ClassOptimizer.New(CodeWrongFieldWithGroup, "Expression is not in GROUP BY clause")
+	ErrMixOfGroupFuncAndFields = terror.ClassOptimizer.New(CodeMixOfGroupFuncAndFields, "Mixing of aggregated and nonaggregated columns without GROUP BY")
+	ErrNonUniqTable            = terror.ClassOptimizer.New(CodeNonUniqTable, "Not unique table/alias")
+	ErrViewWrongList           = terror.ClassOptimizer.New(CodeViewWrongList, "SELECT list and column names list have different column counts")
+
+	ErrCTERecursiveRequiresUnion             = terror.ClassOptimizer.New(CodeCTERecursiveRequiresUnion, "Recursive Common Table Expression should contain a UNION")
+	ErrCTERecursiveRequiresNonrecursiveFirst = terror.ClassOptimizer.New(CodeCTERecursiveRequiresNonrecursiveFirst, "Recursive Common Table Expression should have non-recursive query blocks first")
+	ErrCTERecursiveForbidsAggregation        = terror.ClassOptimizer.New(CodeCTERecursiveForbidsAggregation, "Recursive Common Table Expression can't contain aggregation in recursive query block")
+	ErrCTERecursiveRequiresSingleReference   = terror.ClassOptimizer.New(CodeCTERecursiveRequiresSingleReference, "Recursive table must be referenced only once")
 )
 
 func init() {
 	mySQLErrCodes := map[terror.ErrCode]uint16{
-		CodeOneColumn:           mysql.ErrOperandColumns,
-		CodeSameColumns:         mysql.ErrOperandColumns,
-		CodeMultiWildCard:       mysql.ErrParse,
-		CodeInvalidGroupFuncUse: mysql.ErrInvalidGroupFuncUse,
-		CodeIllegalReference:    mysql.ErrIllegalReference,
+		CodeOneColumn:               mysql.ErrOperandColumns,
+		CodeSameColumns:             mysql.ErrOperandColumns,
+		CodeMultiWildCard:           mysql.ErrParse,
+		CodeInvalidGroupFuncUse:     mysql.ErrInvalidGroupFuncUse,
+		CodeIllegalReference:        mysql.ErrIllegalReference,
+		CodeKeyDoesNotExist:         mysql.ErrKeyDoesNotExits,
+		CodeWrongUsage:              mysql.ErrWrongUsage,
+		CodeCantUseOptionHere:       mysql.ErrCantUseOptionHere,
+		CodeWrongFieldWithGroup:     mysql.ErrWrongFieldWithGroup,
+		CodeMixOfGroupFuncAndFields: mysql.ErrMixOfGroupFuncAndFields,
+		CodeNonUniqTable:            mysql.ErrNonuniqTable,
+		CodeViewWrongList:           mysql.ErrViewWrongList,
+
+		CodeCTERecursiveRequiresUnion:             mysql.ErrCTERecursiveRequiresUnion,
+		CodeCTERecursiveRequiresNonrecursiveFirst: mysql.ErrCTERecursiveRequiresNonrecursiveFirst,
+		CodeCTERecursiveForbidsAggregation:        mysql.ErrCTERecursiveForbidsAggregation,
+		CodeCTERecursiveRequiresSingleReference:   mysql.ErrCTERecursiveRequiresSingleReference,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassOptimizer] = mySQLErrCodes
 }
@@ -115,7 +115,7 @@ func (p *DataSource) convert2TableScan(prop *requiredProperty) (*physicalPlanInf
 				memDB = true
 			}
 			if !memDB && client.SupportRequestType(kv.ReqTypeSelect, 0) {
-				ts.ConditionPBExpr, newSel.Conditions = expressionsToPB(newSel.Conditions, client)
+				ts.ConditionPBExpr, ts.pushedConditions, newSel.Conditions = expressionsToPB(newSel.Conditions, client)
 			}
 		}
 		err := buildTableRange(ts)
@@ -212,7 +212,7 @@ func (p *DataSource) convert2IndexScan(prop *requiredProperty, index *model.Inde
 				memDB = true
 			}
 			if !memDB && client.SupportRequestType(kv.ReqTypeSelect, 0) {
-				is.ConditionPBExpr, newSel.Conditions = expressionsToPB(newSel.Conditions, client)
+				is.ConditionPBExpr, is.pushedConditions, newSel.Conditions = expressionsToPB(newSel.Conditions, client)
 			}
 		}
 		err := buildIndexRange(is)
@@ -287,7 +287,7 @@ func (p *DataSource) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlan
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		if indexInfo.cost < info.cost {
+		if info == nil || indexInfo.cost < info.cost {
 			info = indexInfo
 		}
 	}
@@ -320,6 +320,7 @@ func (p *DataSource) tryToConvert2DummyScan(prop *requiredProperty) (*physicalPl
 
 // addPlanToResponse creates a *physicalPlanInfo that adds p as the parent of info.
 func addPlanToResponse(parent PhysicalPlan, info *physicalPlanInfo) *physicalPlanInfo {
+	info.recordStatsCount()
 	np := parent.Copy()
 	np.SetChildren(info.p)
 	return &physicalPlanInfo{p: np, cost: info.cost, count: info.count}
@@ -355,6 +356,7 @@ func enforceProperty(prop *requiredProperty, info *physicalPlanInfo) *physicalPl
 	if prop.limit != nil && prop.limit.Count < info.count {
 		info.count = prop.limit.Count
 	}
+	info.recordStatsCount()
 	return info
 }
 
@@ -553,6 +555,208 @@ func (p *Join) convert2PhysicalPlanRight(prop *requiredProperty, innerJoin bool)
 	return resultInfo, nil
 }
 
+// convert2PhysicalPlanMerge converts the join to a sort merge join, it returns nil if the join keys of both sides
+// can not be compared in the order they are sorted.
+func (p *Join) convert2PhysicalPlanMerge(prop *requiredProperty) (*physicalPlanInfo, error) {
+	lChild := p.GetChildByIndex(0).(LogicalPlan)
+	rChild := p.GetChildByIndex(1).(LogicalPlan)
+	join := &PhysicalMergeJoin{
+		JoinType:        p.JoinType,
+		EqualConditions: p.EqualConditions,
+		LeftConditions:  p.LeftConditions,
+		RightConditions: p.RightConditions,
+		OtherConditions: p.OtherConditions,
+	}
+	for _, eqCond := range p.EqualConditions {
+		lCol, lOK := eqCond.Args[0].(*expression.Column)
+		rCol, rOK := eqCond.Args[1].(*expression.Column)
+		if !lOK || !rOK || !sameSortOrder(lCol.RetType, rCol.RetType) {
+			return nil, nil
+		}
+		join.LeftKeys = append(join.LeftKeys, lCol)
+		join.RightKeys = append(join.RightKeys, rCol)
+	}
+	join.SetSchema(p.schema)
+	lInfo, err := convert2SortedPhysicalPlan(lChild, join.LeftKeys)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	rInfo, err := convert2SortedPhysicalPlan(rChild, join.RightKeys)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return enforceProperty(prop, join.matchProperty(prop, lInfo, rInfo)), nil
+}
+
+// convert2SortedPhysicalPlan converts the plan to *physicalPlanInfo sorted by the columns in ascending order.
+// A sort is enforced if it is cheaper than keeping the order by the plan itself.
+func convert2SortedPhysicalPlan(p LogicalPlan, cols []*expression.Column) (*physicalPlanInfo, error) {
+	prop := &requiredProperty{props: make([]*columnProp, 0, len(cols)), sortKeyLen: len(cols)}
+	for _, col := range cols {
+		prop.props = append(prop.props, &columnProp{col: col})
+	}
+	info, err := p.convert2PhysicalPlan(prop)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	enforced, err := p.convert2PhysicalPlan(&requiredProperty{})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	enforced = enforceProperty(prop, enforced)
+	if enforced.cost < info.cost {
+		info = enforced
+	}
+	return info, nil
+}
+
+// sameSortOrder checks if the values of the two types are sorted in the same order when they are compared to each other.
+func sameSortOrder(a, b *types.FieldType) bool {
+	if a.Tp == b.Tp {
+		return true
+	}
+	isNumeric := func(tp byte) bool {
+		switch tp {
+		case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong, mysql.TypeYear,
+			mysql.TypeFloat, mysql.TypeDouble, mysql.TypeNewDecimal:
+			return true
+		}
+		return false
+	}
+	isString := func(tp byte) bool {
+		return types.IsTypeChar(tp) || types.IsTypeBlob(tp) || tp == mysql.TypeVarString
+	}
+	return (isNumeric(a.Tp) && isNumeric(b.Tp)) || (isString(a.Tp) && isString(b.Tp))
+}
+
+// convert2PhysicalPlanIndex converts the join to an index lookup join whose inner child is the child of innerIdx.
+// It returns nil if the inner child is not a table that can be read by the join keys.
+func (p *Join) convert2PhysicalPlanIndex(prop *requiredProperty, innerIdx int) (*physicalPlanInfo, error) {
+	outerIdx := 1 - innerIdx
+	innerChild := p.GetChildByIndex(innerIdx).(LogicalPlan)
+	outerChild := p.GetChildByIndex(outerIdx).(LogicalPlan)
+	if innerChild.IsCorrelated() {
+		return nil, nil
+	}
+	ds, ok := innerChild.(*DataSource)
+	if sel, isSel := innerChild.(*Selection); isSel {
+		ds, ok = sel.GetChildByIndex(0).(*DataSource)
+	}
+	if !ok {
+		return nil, nil
+	}
+	switch ds.DBName.L {
+	case "information_schema", "performance_schema":
+		return nil, nil
+	}
+	join := &PhysicalIndexJoin{
+		JoinType:        p.JoinType,
+		OuterIndex:      outerIdx,
+		EqualConditions: p.EqualConditions,
+		LeftConditions:  p.LeftConditions,
+		RightConditions: p.RightConditions,
+		OtherConditions: p.OtherConditions,
+		KeyOffset:       -1,
+	}
+	for _, eqCond := range p.EqualConditions {
+		outerCol, outerOK := eqCond.Args[outerIdx].(*expression.Column)
+		innerCol, innerOK := eqCond.Args[innerIdx].(*expression.Column)
+		if !outerOK || !innerOK {
+			return nil, nil
+		}
+		join.OuterJoinKeys = append(join.OuterJoinKeys, outerCol)
+		join.InnerJoinKeys = append(join.InnerJoinKeys, innerCol)
+	}
+	join.SetSchema(p.schema)
+	indices, includeTableScan := availableIndices(ds.table)
+	var innerInfo *physicalPlanInfo
+	var err error
+	for i, key := range join.InnerJoinKeys {
+		offset := ds.GetSchema().GetIndex(key)
+		if offset == -1 {
+			continue
+		}
+		colInfo := ds.Columns[offset]
+		if includeTableScan && ds.Table.PKIsHandle && mysql.HasPriKeyFlag(colInfo.Flag) {
+			innerInfo, err = ds.convert2TableScan(&requiredProperty{})
+		} else {
+			for _, idx := range indices {
+				if idx.Columns[0].Name.L == colInfo.Name.L && idx.Columns[0].Length == types.UnspecifiedLength {
+					innerInfo, err = ds.convert2IndexScan(&requiredProperty{}, idx)
+					break
+				}
+			}
+		}
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if innerInfo != nil {
+			join.KeyOffset = i
+			break
+		}
+	}
+	if innerInfo == nil {
+		return nil, nil
+	}
+	// The inner scan can not be read by ranges in a dirty transaction, because a union scan is required.
+	innerPlan := innerInfo.p
+	if sel, ok := innerPlan.(*Selection); ok {
+		innerPlan = sel.GetChildByIndex(0).(PhysicalPlan)
+	}
+	switch x := innerPlan.(type) {
+	case *PhysicalTableScan:
+		join.InnerConditions = x.AccessCondition
+	case *PhysicalIndexScan:
+		join.InnerConditions = x.AccessCondition
+	default:
+		return nil, nil
+	}
+	innerInfo.recordStatsCount()
+	outerInfo, err := outerChild.convert2PhysicalPlan(&requiredProperty{})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	infos := []*physicalPlanInfo{outerInfo, innerInfo}
+	if innerIdx == 0 {
+		infos[0], infos[1] = innerInfo, outerInfo
+	}
+	return enforceProperty(prop, join.matchProperty(prop, infos...)), nil
+}
+
+// convert2PhysicalPlanByHint converts the join to the *physicalPlanInfo preferred by the optimizer hints.
+// It returns nil if no hint can be applied to the join.
+func (p *Join) convert2PhysicalPlanByHint(prop *requiredProperty) (*physicalPlanInfo, error) {
+	if p.preferJoinType == 0 || p.JoinType == SemiJoin || p.JoinType == SemiJoinWithAux {
+		return nil, nil
+	}
+	if p.preferJoinType&preferMergeJoin > 0 && len(p.EqualConditions) > 0 {
+		info, err := p.convert2PhysicalPlanMerge(prop)
+		if info != nil || err != nil {
+			return info, errors.Trace(err)
+		}
+	}
+	if p.preferJoinType&preferIndexJoinInnerRight > 0 && p.JoinType != RightOuterJoin && len(p.EqualConditions) > 0 {
+		info, err := p.convert2PhysicalPlanIndex(prop, 1)
+		if info != nil || err != nil {
+			return info, errors.Trace(err)
+		}
+	}
+	if p.preferJoinType&preferIndexJoinInnerLeft > 0 && p.JoinType != LeftOuterJoin && len(p.EqualConditions) > 0 {
+		info, err := p.convert2PhysicalPlanIndex(prop, 0)
+		if info != nil || err != nil {
+			return info, errors.Trace(err)
+		}
+	}
+	// The hash join of an outer join can only build the inner side.
+	if p.preferJoinType&preferHashJoinBuildRight > 0 && p.JoinType != RightOuterJoin {
+		return p.convert2PhysicalPlanLeft(prop, p.JoinType == InnerJoin)
+	}
+	if p.preferJoinType&preferHashJoinBuildLeft > 0 && p.JoinType != LeftOuterJoin {
+		return p.convert2PhysicalPlanRight(prop, p.JoinType == InnerJoin)
+	}
+	return nil, nil
+}
+
 // convert2PhysicalPlan implements the LogicalPlan convert2PhysicalPlan interface.
 func (p *Join) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo, error) {
 	info, err := p.getPlanInfo(prop)
@@ -562,6 +766,14 @@ func (p *Join) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo,
 	if info != nil {
 		return info, nil
 	}
+	info, err = p.convert2PhysicalPlanByHint(prop)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if info != nil {
+		p.storePlanInfo(prop, info)
+		return info, nil
+	}
 	switch p.JoinType {
 	case SemiJoin, SemiJoinWithAux:
 		info, err = p.convert2PhysicalPlanSemi(prop)
@@ -76,6 +76,8 @@ type physicalTableSource struct {
 
 	// ConditionPBExpr is the pb structure of conditions that be pushed down.
 	ConditionPBExpr *tipb.Expr
+	// pushedConditions are the conditions of ConditionPBExpr, they are kept for EXPLAIN.
+	pushedConditions []expression.Expression
 
 	LimitCount *int64
 	SortItems  []*tipb.ByItem
@@ -226,6 +228,42 @@ type PhysicalHashJoin struct {
 	Concurrency     int
 }
 
+// PhysicalMergeJoin represents sort merge join plan, both children are sorted by the join keys.
+type PhysicalMergeJoin struct {
+	basePlan
+
+	JoinType JoinType
+
+	EqualConditions []*expression.ScalarFunction
+	LeftConditions  []expression.Expression
+	RightConditions []expression.Expression
+	OtherConditions []expression.Expression
+	LeftKeys        []*expression.Column
+	RightKeys       []*expression.Column
+}
+
+// PhysicalIndexJoin represents index lookup join plan. The rows of the outer child are fetched in batches, the inner
+// child is a scan whose ranges are built from the join keys of each batch.
+type PhysicalIndexJoin struct {
+	basePlan
+
+	JoinType JoinType
+	// OuterIndex is the index of the outer child in the children, the other child is the inner child.
+	OuterIndex int
+
+	EqualConditions []*expression.ScalarFunction
+	LeftConditions  []expression.Expression
+	RightConditions []expression.Expression
+	OtherConditions []expression.Expression
+	OuterJoinKeys   []*expression.Column
+	InnerJoinKeys   []*expression.Column
+	// KeyOffset is the offset of the join key used to build the ranges of the inner scan.
+	KeyOffset int
+	// InnerConditions are the access conditions of the inner scan. Its ranges are replaced by the join keys,
+	// so the conditions are evaluated on the inner rows instead.
+	InnerConditions []expression.Expression
+}
+
 // PhysicalHashSemiJoin represents hash join for semi join.
 type PhysicalHashSemiJoin struct {
 	basePlan
@@ -452,6 +490,74 @@ func (p *PhysicalHashJoin) MarshalJSON() ([]byte, error) {
 	return buffer.Bytes(), nil
 }
 
+// Copy implements the PhysicalPlan Copy interface.
+func (p *PhysicalMergeJoin) Copy() PhysicalPlan {
+	np := *p
+	return &np
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (p *PhysicalMergeJoin) MarshalJSON() ([]byte, error) {
+	return marshalJoin("MergeJoin", p.JoinType, p.children, p.EqualConditions, p.LeftConditions, p.RightConditions, p.OtherConditions)
+}
+
+// Copy implements the PhysicalPlan Copy interface.
+func (p *PhysicalIndexJoin) Copy() PhysicalPlan {
+	np := *p
+	return &np
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (p *PhysicalIndexJoin) MarshalJSON() ([]byte, error) {
+	return marshalJoin("IndexJoin", p.JoinType, p.children, p.EqualConditions, p.LeftConditions, p.RightConditions, p.OtherConditions)
+}
+
+func marshalJoin(algorithm string, joinType JoinType, children []Plan, eq []*expression.ScalarFunction, left, right, other []expression.Expression) ([]byte, error) {
+	leftChild, err := json.Marshal(children[0].(PhysicalPlan))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	rightChild, err := json.Marshal(children[1].(PhysicalPlan))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	tp := "InnerJoin"
+	if joinType == LeftOuterJoin {
+		tp = "LeftJoin"
+	} else if joinType == RightOuterJoin {
+		tp = "RightJoin"
+	}
+	eqConds, err := json.Marshal(eq)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	leftConds, err := json.Marshal(left)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	rightConds, err := json.Marshal(right)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	otherConds, err := json.Marshal(other)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	buffer := bytes.NewBufferString("{")
+	buffer.WriteString(fmt.Sprintf(
+		"\"type\": \"%s\",\n "+
+			"\"algorithm\": \"%s\",\n "+
+			"\"eqCond\": %s,\n "+
+			"\"leftCond\": %s,\n "+
+			"\"rightCond\": %s,\n "+
+			"\"otherCond\": %s,\n"+
+			"\"leftPlan\": %s,\n "+
+			"\"rightPlan\": %s"+
+			"}",
+		tp, algorithm, eqConds, leftConds, rightConds, otherConds, leftChild, rightChild))
+	return buffer.Bytes(), nil
+}
+
 // Copy implements the PhysicalPlan Copy interface.
 func (p *Distinct) Copy() PhysicalPlan {
 	np := *p
@@ -580,6 +686,12 @@ func (p *Sort) MarshalJSON() ([]byte, error) {
 	return buffer.Bytes(), nil
 }
 
+// Copy implements the PhysicalPlan Copy interface.
+func (p *CTEScan) Copy() PhysicalPlan {
+	np := *p
+	return &np
+}
+
 // Copy implements the PhysicalPlan Copy interface.
 func (p *TableDual) Copy() PhysicalPlan {
 	np := *p
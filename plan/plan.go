@@ -56,6 +56,8 @@ const (
 	Ext = "Exists"
 	// Dual is the type of TableDual.
 	Dual = "TableDual"
+	// CTE is the type of CTEScan.
+	CTE = "CTEScan"
 	// Lock is the type of SelectLock.
 	Lock = "SelectLock"
 	// Load is the type of LoadData.
@@ -138,6 +140,18 @@ type physicalPlanInfo struct {
 	count uint64
 }
 
+// recordStatsCount records the estimated row count on the physical plan.
+// The row count of a union scan is also the row count of the scan below it.
+func (info *physicalPlanInfo) recordStatsCount() {
+	if info.p == nil {
+		return
+	}
+	info.p.SetStatsCount(info.count)
+	if us, ok := info.p.(*PhysicalUnionScan); ok {
+		us.GetChildByIndex(0).(PhysicalPlan).SetStatsCount(info.count)
+	}
+}
+
 // LogicalPlan is a tree of logical operators.
 // We can do a lot of logical optimizations to it, like predicate pushdown and column pruning.
 type LogicalPlan interface {
@@ -179,6 +193,12 @@ type PhysicalPlan interface {
 
 	// Copy copies the current plan.
 	Copy() PhysicalPlan
+
+	// StatsCount returns the row count of the plan estimated by the optimizer.
+	StatsCount() uint64
+
+	// SetStatsCount sets the estimated row count of the plan.
+	SetStatsCount(count uint64)
 }
 
 type baseLogicalPlan struct {
@@ -220,6 +240,7 @@ func (p *baseLogicalPlan) storePlanInfo(prop *requiredProperty, info *physicalPl
 	if err != nil {
 		return errors.Trace(err)
 	}
+	info.recordStatsCount()
 	newInfo := *info // copy it
 	p.planMap[string(key)] = &newInfo
 	return nil
@@ -265,6 +286,13 @@ func (p *basePlan) initID() {
 	p.id = p.tp + p.allocator.allocID()
 }
 
+// initIDWithType sets the type and the ID of a plan which is created without them.
+func (p *basePlan) initIDWithType(tp string, a *idAllocator) {
+	p.tp = tp
+	p.allocator = a
+	p.initID()
+}
+
 // basePlan implements base Plan interface.
 // Should be used as embedded struct in Plan implementations.
 type basePlan struct {
@@ -278,6 +306,9 @@ type basePlan struct {
 	tp        string
 	id        string
 	allocator *idAllocator
+
+	// statsCount is the estimated row count, it is only set for physical plans.
+	statsCount uint64
 }
 
 // MarshalJSON implements json.Marshaler interface.
@@ -297,6 +328,16 @@ func (p *basePlan) IsCorrelated() bool {
 	return p.correlated
 }
 
+// StatsCount implements PhysicalPlan StatsCount interface.
+func (p *basePlan) StatsCount() uint64 {
+	return p.statsCount
+}
+
+// SetStatsCount implements PhysicalPlan SetStatsCount interface.
+func (p *basePlan) SetStatsCount(count uint64) {
+	p.statsCount = count
+}
+
 // GetID implements Plan GetID interface.
 func (p *basePlan) GetID() string {
 	return p.id
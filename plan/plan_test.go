@@ -726,6 +726,74 @@ func (s *testPlanSuite) TestCBO(c *C) {
 	}
 }
 
+func (s *testPlanSuite) TestJoinHints(c *C) {
+	defer testleak.AfterTest(c)()
+	cases := []struct {
+		sql  string
+		best string
+	}{
+		{
+			sql:  "select /*+ HASH_JOIN(t1) */ * from t t1, t t2 where t1.a = t2.b",
+			best: "RightHashJoin{Table(t)->Table(t)}(t1.a,t2.b)->Projection",
+		},
+		{
+			sql:  "select /*+ HASH_JOIN(t2) */ * from t t1, t t2 where t1.a = t2.b",
+			best: "LeftHashJoin{Table(t)->Table(t)}(t1.a,t2.b)->Projection",
+		},
+		{
+			sql:  "select /*+ MERGE_JOIN(t1, t2) */ * from t t1, t t2 where t1.a = t2.a",
+			best: "MergeJoin{Table(t)->Table(t)}(t1.a,t2.a)->Projection",
+		},
+		{
+			sql:  "select /*+ MERGE_JOIN(t1) */ * from t t1 left join t t2 on t1.a = t2.c",
+			best: "MergeJoin{Table(t)->Index(t.c_d_e)[[<nil>,+inf]]}(t1.a,t2.c)->Projection",
+		},
+		{
+			sql:  "select /*+ INL_JOIN(t2) */ * from t t1, t t2 where t1.b = t2.a",
+			best: "LeftIndexJoin{Table(t)->Table(t)}(t1.b,t2.a)->Projection",
+		},
+		{
+			sql:  "select /*+ INL_JOIN(t1) */ * from t t1 right join t t2 on t1.c = t2.b",
+			best: "RightIndexJoin{Index(t.c_d_e)[[<nil>,+inf]]->Table(t)}(t1.c,t2.b)->Projection",
+		},
+		{
+			// The inner side of a left outer join can not be the outer side of an index join.
+			sql:  "select /*+ INL_JOIN(t1) */ * from t t1 left join t t2 on t1.a = t2.b",
+			best: "LeftHashJoin{Table(t)->Table(t)}(t1.a,t2.b)->Projection",
+		},
+		{
+			// The join key has no index, the hint is ignored.
+			sql:  "select /*+ INL_JOIN(t2) */ * from t t1, t t2 where t1.a = t2.b",
+			best: "LeftHashJoin{Table(t)->Table(t)}(t1.a,t2.b)->Projection",
+		},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.sql)
+		stmt, err := s.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil, comment)
+
+		err = mockResolve(stmt)
+		c.Assert(err, IsNil)
+
+		builder := &planBuilder{
+			allocator: new(idAllocator),
+			ctx:       mock.NewContext(),
+			colMapper: make(map[*ast.ColumnNameExpr]int),
+		}
+		p := builder.build(stmt)
+		c.Assert(builder.err, IsNil)
+		lp := p.(LogicalPlan)
+
+		_, lp, err = lp.PredicatePushDown(nil)
+		c.Assert(err, IsNil)
+		_, err = lp.PruneColumnsAndResolveIndices(lp.GetSchema())
+		c.Assert(err, IsNil)
+		info, err := lp.convert2PhysicalPlan(&requiredProperty{})
+		c.Assert(err, IsNil)
+		c.Assert(ToString(info.p), Equals, ca.best, Commentf("for %s", ca.sql))
+	}
+}
+
 func (s *testPlanSuite) TestRefine(c *C) {
 	defer testleak.AfterTest(c)()
 	cases := []struct {
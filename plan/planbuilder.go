@@ -51,6 +51,12 @@ type planBuilder struct {
 	outerSchemas []expression.Schema
 	// colMapper stores the column that must be pre-resolved.
 	colMapper map[*ast.ColumnNameExpr]int
+	// tableHintInfo is the stack of the optimizer hints of the select statements being built.
+	tableHintInfo []tableHintInfo
+	// ctes stores the build state of the common table expressions.
+	ctes map[*ast.CommonTableExpression]*cteInfo
+	// cteDefs are the materialized common table expressions, they are optimized separately.
+	cteDefs []*CTEDefinition
 }
 
 func (b *planBuilder) build(node ast.Node) Plan {
@@ -86,6 +92,9 @@ func (b *planBuilder) build(node ast.Node) Plan {
 	case *ast.PrepareStmt:
 		return b.buildPrepare(x)
 	case *ast.SelectStmt:
+		if x.SelectIntoOpt != nil {
+			return b.buildSelectInto(x)
+		}
 		return b.buildSelect(x)
 	case *ast.UnionStmt:
 		return b.buildUnion(x)
@@ -94,7 +103,8 @@ func (b *planBuilder) build(node ast.Node) Plan {
 	case *ast.ShowStmt:
 		return b.buildShow(x)
 	case *ast.AnalyzeTableStmt, *ast.BinlogStmt, *ast.FlushTableStmt, *ast.UseStmt, *ast.SetStmt, *ast.DoStmt, *ast.BeginStmt,
-		*ast.CommitStmt, *ast.RollbackStmt, *ast.CreateUserStmt, *ast.SetPwdStmt, *ast.GrantStmt, *ast.DropUserStmt:
+		*ast.CommitStmt, *ast.RollbackStmt, *ast.CreateUserStmt, *ast.AlterUserStmt, *ast.RenameUserStmt, *ast.SetPwdStmt,
+		*ast.GrantStmt, *ast.GrantRoleStmt, *ast.SetRoleStmt, *ast.SetDefaultRoleStmt, *ast.DropUserStmt:
 		return b.buildSimple(node.(ast.StmtNode))
 	case *ast.TruncateTableStmt:
 		return b.buildDDL(x)
@@ -181,7 +191,8 @@ func (b *planBuilder) extractSelectAgg(sel *ast.SelectStmt) []*ast.AggregateFunc
 func availableIndices(table *ast.TableName) (indices []*model.IndexInfo, includeTableScan bool) {
 	var usableHints []*ast.IndexHint
 	for _, hint := range table.IndexHints {
-		if hint.HintScope == ast.HintForScan {
+		// The indices for join are also used to find rows, so they are treated as the indices for scan.
+		if hint.HintScope == ast.HintForScan || hint.HintScope == ast.HintForJoin {
 			usableHints = append(usableHints, hint)
 		}
 	}
@@ -288,6 +299,21 @@ func (b *planBuilder) buildAdmin(as *ast.AdminStmt) Plan {
 	case ast.AdminShowDDL:
 		p = &ShowDDL{}
 		p.SetFields(buildShowDDLFields())
+	case ast.AdminCheckIndex:
+		p = &CheckIndex{Table: as.Tables[0], IndexName: as.Index, HandleRanges: as.HandleRanges}
+		p.SetFields(buildCheckIndexFields())
+	case ast.AdminRecoverIndex:
+		p = &RecoverIndex{Table: as.Tables[0], IndexName: as.Index}
+		p.SetFields(buildRecoverIndexFields())
+	case ast.AdminCleanupIndex:
+		p = &CleanupIndex{Table: as.Tables[0], IndexName: as.Index}
+		p.SetFields(buildCleanupIndexFields())
+	case ast.AdminShowDDLJobs:
+		p = &ShowDDLJobs{}
+		p.SetFields(buildShowDDLJobsFields())
+	case ast.AdminCancelDDLJobs:
+		p = &CancelDDLJobs{JobIDs: as.JobIDs}
+		p.SetFields(buildCancelDDLJobsFields())
 	default:
 		b.err = ErrUnsupportedType.Gen("Unsupported type %T", as)
 	}
@@ -295,6 +321,54 @@ func (b *planBuilder) buildAdmin(as *ast.AdminStmt) Plan {
 	return p
 }
 
+func buildCheckIndexFields() []*ast.ResultField {
+	rfs := make([]*ast.ResultField, 0, 4)
+	rfs = append(rfs, buildResultField("", "INDEX_HANDLE", mysql.TypeLonglong, 4))
+	rfs = append(rfs, buildResultField("", "INDEX_VALUES", mysql.TypeVarchar, 256))
+	rfs = append(rfs, buildResultField("", "RECORD_HANDLE", mysql.TypeLonglong, 4))
+	rfs = append(rfs, buildResultField("", "RECORD_VALUES", mysql.TypeVarchar, 256))
+
+	return rfs
+}
+
+func buildRecoverIndexFields() []*ast.ResultField {
+	rfs := make([]*ast.ResultField, 0, 2)
+	rfs = append(rfs, buildResultField("", "ADDED_COUNT", mysql.TypeLonglong, 4))
+	rfs = append(rfs, buildResultField("", "SCAN_COUNT", mysql.TypeLonglong, 4))
+
+	return rfs
+}
+
+func buildCleanupIndexFields() []*ast.ResultField {
+	rfs := make([]*ast.ResultField, 0, 1)
+	rfs = append(rfs, buildResultField("", "REMOVED_COUNT", mysql.TypeLonglong, 4))
+
+	return rfs
+}
+
+func buildShowDDLJobsFields() []*ast.ResultField {
+	rfs := make([]*ast.ResultField, 0, 9)
+	rfs = append(rfs, buildResultField("", "JOB_ID", mysql.TypeLonglong, 4))
+	rfs = append(rfs, buildResultField("", "DB_NAME", mysql.TypeVarchar, 64))
+	rfs = append(rfs, buildResultField("", "TABLE_NAME", mysql.TypeVarchar, 64))
+	rfs = append(rfs, buildResultField("", "JOB_TYPE", mysql.TypeVarchar, 64))
+	rfs = append(rfs, buildResultField("", "SCHEMA_STATE", mysql.TypeVarchar, 64))
+	rfs = append(rfs, buildResultField("", "STATE", mysql.TypeVarchar, 64))
+	rfs = append(rfs, buildResultField("", "ROW_COUNT", mysql.TypeLonglong, 4))
+	rfs = append(rfs, buildResultField("", "START_TIME", mysql.TypeDatetime, 19))
+	rfs = append(rfs, buildResultField("", "END_TIME", mysql.TypeDatetime, 19))
+
+	return rfs
+}
+
+func buildCancelDDLJobsFields() []*ast.ResultField {
+	rfs := make([]*ast.ResultField, 0, 2)
+	rfs = append(rfs, buildResultField("", "JOB_ID", mysql.TypeLonglong, 4))
+	rfs = append(rfs, buildResultField("", "RESULT", mysql.TypeVarchar, 128))
+
+	return rfs
+}
+
 func buildShowDDLFields() []*ast.ResultField {
 	rfs := make([]*ast.ResultField, 0, 6)
 	rfs = append(rfs, buildResultField("", "SCHEMA_VER", mysql.TypeLonglong, 4))
@@ -424,11 +498,12 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 
 func (b *planBuilder) buildLoadData(ld *ast.LoadDataStmt) Plan {
 	p := &LoadData{
-		IsLocal:    ld.IsLocal,
-		Path:       ld.Path,
-		Table:      ld.Table,
-		FieldsInfo: ld.FieldsInfo,
-		LinesInfo:  ld.LinesInfo,
+		IsLocal:     ld.IsLocal,
+		Path:        ld.Path,
+		OnDuplicate: ld.OnDuplicate,
+		Table:       ld.Table,
+		FieldsInfo:  ld.FieldsInfo,
+		LinesInfo:   ld.LinesInfo,
 	}
 	return p
 }
@@ -437,6 +512,42 @@ func (b *planBuilder) buildDDL(node ast.DDLNode) Plan {
 	return &DDL{Statement: node}
 }
 
+// buildPhysicalTarget optimizes the plan wrapped by another plan, such as the statement of EXPLAIN,
+// the top level plan is optimized by Optimize.
+func (b *planBuilder) buildPhysicalTarget(p Plan) Plan {
+	logic, ok := p.(LogicalPlan)
+	if !ok {
+		return p
+	}
+	_, logic, err := logic.PredicatePushDown(nil)
+	if err != nil {
+		b.err = errors.Trace(err)
+		return nil
+	}
+	_, err = logic.PruneColumnsAndResolveIndices(logic.GetSchema())
+	if err != nil {
+		b.err = errors.Trace(err)
+		return nil
+	}
+	info, err := logic.convert2PhysicalPlan(&requiredProperty{})
+	if err != nil {
+		b.err = errors.Trace(err)
+		return nil
+	}
+	assignPhysicalIDs(info.p, b.allocator)
+	return info.p
+}
+
+func (b *planBuilder) buildSelectInto(sel *ast.SelectStmt) Plan {
+	targetPlan := b.buildPhysicalTarget(b.buildSelect(sel))
+	if b.err != nil {
+		return nil
+	}
+	p := &SelectInto{TargetPlan: targetPlan, IntoOpt: sel.SelectIntoOpt}
+	addChild(p, targetPlan)
+	return p
+}
+
 func (b *planBuilder) buildExplain(explain *ast.ExplainStmt) Plan {
 	if show, ok := explain.Stmt.(*ast.ShowStmt); ok {
 		return b.buildShow(show)
@@ -445,34 +556,52 @@ func (b *planBuilder) buildExplain(explain *ast.ExplainStmt) Plan {
 	if b.err != nil {
 		return nil
 	}
-	if logic, ok := targetPlan.(LogicalPlan); ok {
-		var err error
-		_, logic, err = logic.PredicatePushDown(nil)
-		if err != nil {
-			b.err = errors.Trace(err)
-			return nil
-		}
-		_, err = logic.PruneColumnsAndResolveIndices(logic.GetSchema())
-		if err != nil {
-			b.err = errors.Trace(err)
-			return nil
-		}
-		info, err := logic.convert2PhysicalPlan(&requiredProperty{})
-		if err != nil {
-			b.err = errors.Trace(err)
-			return nil
-		}
-		targetPlan = info.p
+	targetPlan = b.buildPhysicalTarget(targetPlan)
+	if b.err != nil {
+		return nil
+	}
+	format := explain.Format
+	if format == "" {
+		format = ast.ExplainFormatRow
+	}
+	if explain.Analyze && format != ast.ExplainFormatRow {
+		b.err = ErrUnSupported.Gen("EXPLAIN ANALYZE only supports the %s format", ast.ExplainFormatRow)
+		return nil
 	}
-	p := &Explain{StmtPlan: targetPlan}
+	p := &Explain{StmtPlan: targetPlan, Analyze: explain.Analyze, Format: format}
 	addChild(p, targetPlan)
-	col := &expression.Column{
-		RetType: types.NewFieldType(mysql.TypeString),
+	switch format {
+	case ast.ExplainFormatRow:
+		names := ExplainColumns
+		if explain.Analyze {
+			names = append(append([]string{}, ExplainColumns...), ExplainAnalyzeColumns...)
+		}
+		p.SetSchema(buildExplainSchema(names))
+	case ast.ExplainFormatJSON:
+		col := &expression.Column{
+			RetType: types.NewFieldType(mysql.TypeString),
+		}
+		p.SetSchema([]*expression.Column{col, col})
+	case ast.ExplainFormatDOT:
+		p.SetSchema(buildExplainSchema([]string{"dot contents"}))
+	default:
+		b.err = ErrUnSupported.Gen("unknown EXPLAIN format %s", format)
+		return nil
 	}
-	p.SetSchema([]*expression.Column{col, col})
 	return p
 }
 
+func buildExplainSchema(names []string) expression.Schema {
+	schema := make(expression.Schema, 0, len(names))
+	for _, name := range names {
+		schema = append(schema, &expression.Column{
+			ColName: model.NewCIStr(name),
+			RetType: types.NewFieldType(mysql.TypeString),
+		})
+	}
+	return schema
+}
+
 func buildShowProcedureFields() []*ast.ResultField {
 	tblName := "ROUTINES"
 	rfs := make([]*ast.ResultField, 0, 11)
@@ -32,6 +32,43 @@ type ShowDDL struct {
 	basePlan
 }
 
+// ShowDDLJobs is for showing the DDL jobs in the queue and the recent history.
+type ShowDDLJobs struct {
+	basePlan
+}
+
+// CancelDDLJobs is for cancelling the DDL jobs.
+type CancelDDLJobs struct {
+	basePlan
+
+	JobIDs []int64
+}
+
+// CheckIndex is for checking the index entries and the rows of a table in the handle ranges.
+type CheckIndex struct {
+	basePlan
+
+	Table        *ast.TableName
+	IndexName    string
+	HandleRanges []ast.HandleRange
+}
+
+// RecoverIndex is for adding the missing index entries of the rows.
+type RecoverIndex struct {
+	basePlan
+
+	Table     *ast.TableName
+	IndexName string
+}
+
+// CleanupIndex is for deleting the dangling index entries.
+type CleanupIndex struct {
+	basePlan
+
+	Table     *ast.TableName
+	IndexName string
+}
+
 // CheckTable is for checking table data.
 type CheckTable struct {
 	basePlan
@@ -192,11 +229,21 @@ type Insert struct {
 type LoadData struct {
 	basePlan
 
-	IsLocal    bool
-	Path       string
-	Table      *ast.TableName
-	FieldsInfo *ast.FieldsClause
-	LinesInfo  *ast.LinesClause
+	IsLocal     bool
+	Path        string
+	OnDuplicate ast.OnDuplicateKeyHandlingType
+	Table       *ast.TableName
+	FieldsInfo  *ast.FieldsClause
+	LinesInfo   *ast.LinesClause
+}
+
+// SelectInto represents a SELECT ... INTO OUTFILE/DUMPFILE plan, it writes the rows of
+// the target plan into a file.
+type SelectInto struct {
+	basePlan
+
+	TargetPlan Plan
+	IntoOpt    *ast.SelectIntoOption
 }
 
 // DDL represents a DDL statement plan.
@@ -206,9 +253,18 @@ type DDL struct {
 	Statement ast.DDLNode
 }
 
+// ExplainColumns are the result columns of EXPLAIN in the row format, one row for each operator.
+var ExplainColumns = []string{"id", "parent", "operator", "access object", "estRows", "conditions", "task"}
+
+// ExplainAnalyzeColumns are the result columns that EXPLAIN ANALYZE appends to ExplainColumns.
+var ExplainAnalyzeColumns = []string{"actRows", "execution info", "memory"}
+
 // Explain represents a explain plan.
 type Explain struct {
 	basePlan
 
 	StmtPlan Plan
+	Analyze  bool
+	// Format is one of ast.ExplainFormatRow, ast.ExplainFormatJSON and ast.ExplainFormatDOT.
+	Format string
 }
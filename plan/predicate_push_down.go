@@ -299,6 +299,11 @@ func (p *DataSource) PredicatePushDown(predicates []expression.Expression) ([]ex
 	return predicates, p, nil
 }
 
+// PredicatePushDown implements LogicalPlan PredicatePushDown interface.
+func (p *CTEScan) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
+	return predicates, p, nil
+}
+
 // PredicatePushDown implements LogicalPlan PredicatePushDown interface.
 func (p *TableDual) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
 	return predicates, p, nil
@@ -61,6 +61,10 @@ type nameResolver struct {
 	useOuterContext bool
 
 	contextStack []*resolverContext
+	// ctes are the common table expressions that can be referred to, the inner ones are at the end.
+	ctes []*ast.CommonTableExpression
+	// definingCTEs are the recursive common table expressions whose queries are being resolved.
+	definingCTEs []*ast.CommonTableExpression
 }
 
 // resolverContext stores information in a single level of select statement
@@ -225,6 +229,9 @@ func (nr *nameResolver) Enter(inNode ast.Node) (outNode ast.Node, skipChildren b
 		nr.pushContext()
 	case *ast.UpdateStmt:
 		nr.pushContext()
+	case *ast.WithClause:
+		nr.handleWithClause(v)
+		return inNode, true
 	}
 	return inNode, false
 }
@@ -295,6 +302,7 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 			nr.useOuterContext = true
 		}
 		nr.popContext()
+		nr.popCTEs(v.With)
 	case *ast.SetStmt:
 		nr.popContext()
 	case *ast.ShowStmt:
@@ -315,6 +323,7 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 			nr.useOuterContext = true
 		}
 		nr.popContext()
+		nr.popCTEs(v.With)
 	case *ast.UnionSelectList:
 		nr.handleUnionSelectList(v)
 	case *ast.InsertStmt:
@@ -329,12 +338,122 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 	return inNode, nr.Err == nil
 }
 
+// handleWithClause resolves the queries of the common table expressions in order, a common table expression
+// can refer to the ones defined before it, and to itself if the WITH clause is recursive.
+func (nr *nameResolver) handleWithClause(w *ast.WithClause) {
+	names := make(map[string]struct{}, len(w.CTEs))
+	for _, cte := range w.CTEs {
+		if _, ok := names[cte.Name.L]; ok {
+			nr.Err = ErrNonUniqTable.Gen("Not unique table/alias: '%s'", cte.Name.O)
+			return
+		}
+		names[cte.Name.L] = struct{}{}
+		cte.IsRecursive = false
+		if w.IsRecursive {
+			nr.ctes = append(nr.ctes, cte)
+			nr.definingCTEs = append(nr.definingCTEs, cte)
+		}
+		cte.Query.Accept(nr)
+		if w.IsRecursive {
+			nr.definingCTEs = nr.definingCTEs[:len(nr.definingCTEs)-1]
+		}
+		if nr.Err != nil {
+			return
+		}
+		if !w.IsRecursive {
+			nr.ctes = append(nr.ctes, cte)
+		}
+		if len(cte.ColNameList) > 0 && len(cte.ColNameList) != len(cte.Query.GetResultFields()) {
+			nr.Err = errWrongCTEColumnCount()
+			return
+		}
+	}
+}
+
+// popCTEs removes the common table expressions of the WITH clause when leaving the statement.
+func (nr *nameResolver) popCTEs(w *ast.WithClause) {
+	if w != nil {
+		nr.ctes = nr.ctes[:len(nr.ctes)-len(w.CTEs)]
+	}
+}
+
+func errWrongCTEColumnCount() error {
+	return ErrViewWrongList.Gen("In definition of view, derived table or common table expression, SELECT list and column names list have different column counts")
+}
+
+// findCTE finds the innermost common table expression with the name.
+func (nr *nameResolver) findCTE(name model.CIStr) *ast.CommonTableExpression {
+	for i := len(nr.ctes) - 1; i >= 0; i-- {
+		if nr.ctes[i].Name.L == name.L {
+			return nr.ctes[i]
+		}
+	}
+	return nil
+}
+
+func (nr *nameResolver) isDefiningCTE(cte *ast.CommonTableExpression) bool {
+	for _, v := range nr.definingCTEs {
+		if v == cte {
+			return true
+		}
+	}
+	return false
+}
+
+// handleCTEName sets the result fields for a table name that refers to a common table expression.
+func (nr *nameResolver) handleCTEName(tn *ast.TableName, cte *ast.CommonTableExpression) {
+	var rfs []*ast.ResultField
+	if nr.isDefiningCTE(cte) {
+		// It's a recursive reference, the columns are decided by the non-recursive query blocks before it.
+		union, ok := cte.Query.(*ast.UnionStmt)
+		if !ok {
+			nr.Err = ErrCTERecursiveRequiresUnion.Gen("Recursive Common Table Expression '%s' should contain a UNION", cte.Name.O)
+			return
+		}
+		rfs = union.SelectList.Selects[0].GetResultFields()
+		if rfs == nil {
+			nr.Err = ErrCTERecursiveRequiresNonrecursiveFirst.Gen("Recursive Common Table Expression '%s' should have one or more non-recursive query blocks followed by one or more recursive ones", cte.Name.O)
+			return
+		}
+		if len(cte.ColNameList) > 0 && len(cte.ColNameList) != len(rfs) {
+			nr.Err = errWrongCTEColumnCount()
+			return
+		}
+		cte.IsRecursive = true
+	} else {
+		rfs = cte.Query.GetResultFields()
+	}
+	tableInfo := &model.TableInfo{Name: tn.Name}
+	fields := make([]*ast.ResultField, 0, len(rfs))
+	for i, rf := range rfs {
+		nf := *rf
+		if len(cte.ColNameList) > 0 {
+			nf.ColumnAsName = cte.ColNameList[i]
+		} else if nf.ColumnAsName.L == "" {
+			nf.ColumnAsName = rf.Column.Name
+		}
+		nf.Table = tableInfo
+		nf.DBName = model.CIStr{}
+		nf.TableName = tn
+		nf.Referenced = false
+		fields = append(fields, &nf)
+	}
+	tn.CTE = cte
+	tn.SetResultFields(fields)
+}
+
 // handleTableName looks up and sets the schema information and result fields for table name.
 func (nr *nameResolver) handleTableName(tn *ast.TableName) {
+	ctx := nr.currentContext()
+	if tn.Schema.L == "" && !ctx.inCreateOrDropTable && !ctx.inDeleteTableList {
+		if cte := nr.findCTE(tn.Name); cte != nil {
+			nr.handleCTEName(tn, cte)
+			return
+		}
+	}
 	if tn.Schema.L == "" {
 		tn.Schema = nr.DefaultSchema
 	}
-	ctx := nr.currentContext()
 	if ctx.inCreateOrDropTable {
 		// The table may not exist in create table or drop table statement.
 		// Skip resolving the table to avoid error.
@@ -361,6 +480,17 @@ func (nr *nameResolver) handleTableName(tn *ast.TableName) {
 	tn.TableInfo = table.Meta()
 	dbInfo, _ := nr.Info.SchemaByName(tn.Schema)
 	tn.DBInfo = dbInfo
+	for _, hint := range tn.IndexHints {
+		for _, idxName := range hint.IndexNames {
+			if idxName.L == "primary" && tn.TableInfo.PKIsHandle {
+				continue
+			}
+			if findIndexByName(tn.TableInfo.Indices, idxName) == nil {
+				nr.Err = ErrKeyDoesNotExist.Gen("Key '%s' doesn't exist in table '%s'", idxName.O, tn.Name.O)
+				return
+			}
+		}
+	}
 
 	rfs := make([]*ast.ResultField, 0, len(tn.TableInfo.Columns))
 	tmp := make([]struct {
@@ -895,7 +1025,7 @@ func (nr *nameResolver) fillShowFields(s *ast.ShowStmt) {
 			mysql.TypeVarchar, mysql.TypeVarchar}
 	case ast.ShowColumns:
 		names = table.ColDescFieldNames(s.Full)
-	case ast.ShowWarnings:
+	case ast.ShowWarnings, ast.ShowErrors:
 		names = []string{"Level", "Code", "Message"}
 		ftypes = []byte{mysql.TypeVarchar, mysql.TypeLong, mysql.TypeVarchar}
 	case ast.ShowCharset:
@@ -26,7 +26,7 @@ func ToString(p Plan) string {
 
 func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 	switch in.(type) {
-	case *Join, *Union, *PhysicalHashJoin, *PhysicalHashSemiJoin:
+	case *Join, *Union, *PhysicalHashJoin, *PhysicalHashSemiJoin, *PhysicalMergeJoin, *PhysicalIndexJoin:
 		idxs = append(idxs, len(strs))
 	}
 
@@ -38,6 +38,12 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 	switch x := in.(type) {
 	case *CheckTable:
 		str = "CheckTable"
+	case *CheckIndex:
+		str = "CheckIndex"
+	case *RecoverIndex:
+		str = "RecoverIndex"
+	case *CleanupIndex:
+		str = "CleanupIndex"
 	case *PhysicalIndexScan:
 		str = fmt.Sprintf("Index(%s.%s)%v", x.Table.Name.L, x.Index.Name.L, x.Ranges)
 	case *PhysicalTableScan:
@@ -60,6 +66,30 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 			r := eq.Args[1].String()
 			str += fmt.Sprintf("(%s,%s)", l, r)
 		}
+	case *PhysicalMergeJoin:
+		last := len(idxs) - 1
+		idx := idxs[last]
+		children := strs[idx:]
+		strs = strs[:idx]
+		idxs = idxs[:last]
+		str = "MergeJoin{" + strings.Join(children, "->") + "}"
+		for _, eq := range x.EqualConditions {
+			str += fmt.Sprintf("(%s,%s)", eq.Args[0], eq.Args[1])
+		}
+	case *PhysicalIndexJoin:
+		last := len(idxs) - 1
+		idx := idxs[last]
+		children := strs[idx:]
+		strs = strs[:idx]
+		idxs = idxs[:last]
+		if x.OuterIndex == 1 {
+			str = "RightIndexJoin{" + strings.Join(children, "->") + "}"
+		} else {
+			str = "LeftIndexJoin{" + strings.Join(children, "->") + "}"
+		}
+		for _, eq := range x.EqualConditions {
+			str += fmt.Sprintf("(%s,%s)", eq.Args[0], eq.Args[1])
+		}
 	case *PhysicalHashSemiJoin:
 		last := len(idxs) - 1
 		idx := idxs[last]
@@ -85,6 +115,10 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 		str = "Lock"
 	case *ShowDDL:
 		str = "ShowDDL"
+	case *ShowDDLJobs:
+		str = "ShowDDLJobs"
+	case *CancelDDLJobs:
+		str = "CancelDDLJobs"
 	case *Filter:
 		str = "Filter"
 	case *Sort:
@@ -108,6 +142,8 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 		idxs = idxs[:last]
 	case *DataSource:
 		str = fmt.Sprintf("DataScan(%v)", x.Table.Name.L)
+	case *CTEScan:
+		str = fmt.Sprintf("CTEScan(%v)", x.CTE.Name.L)
 	case *Selection:
 		str = "Selection"
 	case *Projection:
@@ -19,15 +19,17 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/parser/opcode"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/types"
 )
 
 // Validate checkes whether the node is valid.
-func Validate(node ast.Node, inPrepare bool) error {
-	v := validator{inPrepare: inPrepare}
+func Validate(ctx context.Context, node ast.Node, inPrepare bool) error {
+	v := validator{inPrepare: inPrepare, root: node, sqlMode: variable.GetSessionVars(ctx).SQLMode}
 	node.Accept(&v)
 	return v.err
 }
@@ -39,10 +41,13 @@ type validator struct {
 	wildCardCount int
 	inPrepare     bool
 	inAggregate   bool
+	// root is the validated node, SELECT ... INTO is only allowed at the top level.
+	root    ast.Node
+	sqlMode mysql.SQLMode
 }
 
 func (v *validator) Enter(in ast.Node) (out ast.Node, skipChildren bool) {
-	switch in.(type) {
+	switch x := in.(type) {
 	case *ast.AggregateFuncExpr:
 		if v.inAggregate {
 			// Aggregate function can not contain aggregate function.
@@ -60,6 +65,18 @@ func (v *validator) Enter(in ast.Node) (out ast.Node, skipChildren bool) {
 		if v.err != nil {
 			return in, true
 		}
+	case *ast.UnionStmt:
+		for _, sel := range x.SelectList.Selects {
+			if sel.SelectIntoOpt != nil {
+				v.err = ErrWrongUsage.Gen("Incorrect usage of UNION and INTO")
+				return in, true
+			}
+		}
+	case *ast.SelectStmt:
+		if x.SelectIntoOpt != nil && in != v.root {
+			v.err = ErrCantUseOptionHere.Gen("Incorrect usage/placement of 'INTO'")
+			return in, true
+		}
 	}
 	return in, false
 }
@@ -86,6 +103,10 @@ func (v *validator) Leave(in ast.Node) (out ast.Node, ok bool) {
 		v.checkAllOneColumn(x.Expr)
 	case *ast.IsTruthExpr:
 		v.checkAllOneColumn(x.Expr)
+	case *ast.SelectStmt:
+		if v.sqlMode.HasOnlyFullGroupBy() {
+			v.checkOnlyFullGroupBy(x)
+		}
 	case *ast.ParamMarkerExpr:
 		if !v.inPrepare {
 			v.err = parser.ErrSyntax.Gen("syntax error, unexpected '?'")
@@ -306,3 +327,117 @@ func (v *validator) checkCreateIndexGrammar(stmt *ast.CreateIndexStmt) {
 		}
 	}
 }
+
+// checkOnlyFullGroupBy checks the select list for the ONLY_FULL_GROUP_BY sql mode, every column
+// out of the aggregate functions must be in the GROUP BY clause, and an aggregated query without
+// GROUP BY can't contain such columns.
+func (v *validator) checkOnlyFullGroupBy(sel *ast.SelectStmt) {
+	if sel.Fields == nil {
+		return
+	}
+	if sel.GroupBy == nil {
+		if !selectHasAgg(sel) {
+			return
+		}
+		for i, field := range sel.Fields.Fields {
+			if cols := nonAggColumns(field.Expr); len(cols) > 0 {
+				v.err = ErrMixOfGroupFuncAndFields.Gen("In aggregated query without GROUP BY, expression #%d of SELECT list contains nonaggregated column '%s'; this is incompatible with sql_mode=only_full_group_by",
+					i+1, columnFullName(cols[0]))
+				return
+			}
+		}
+		return
+	}
+	groupByCols := make(map[string]struct{})
+	groupByExprs := make(map[ast.ExprNode]struct{})
+	for _, item := range sel.GroupBy.Items {
+		switch x := item.Expr.(type) {
+		case *ast.ColumnNameExpr:
+			if x.Refer == nil {
+				continue
+			}
+			groupByCols[columnKey(x.Refer)] = struct{}{}
+			groupByExprs[x.Refer.Expr] = struct{}{}
+		case *ast.PositionExpr:
+			if x.N < 1 || x.N > len(sel.Fields.Fields) {
+				continue
+			}
+			expr := sel.Fields.Fields[x.N-1].Expr
+			groupByExprs[expr] = struct{}{}
+			if col, ok := expr.(*ast.ColumnNameExpr); ok && col.Refer != nil {
+				groupByCols[columnKey(col.Refer)] = struct{}{}
+			}
+		}
+	}
+	for i, field := range sel.Fields.Fields {
+		if _, ok := groupByExprs[field.Expr]; ok {
+			continue
+		}
+		for _, col := range nonAggColumns(field.Expr) {
+			if _, ok := groupByCols[columnKey(col.Refer)]; !ok {
+				v.err = ErrWrongFieldWithGroup.Gen("Expression #%d of SELECT list is not in GROUP BY clause and contains nonaggregated column '%s' which is not functionally dependent on columns in GROUP BY clause; this is incompatible with sql_mode=only_full_group_by",
+					i+1, columnFullName(col))
+				return
+			}
+		}
+	}
+}
+
+func selectHasAgg(sel *ast.SelectStmt) bool {
+	for _, field := range sel.Fields.Fields {
+		if field.Expr != nil && ast.HasAggFlag(field.Expr) {
+			return true
+		}
+	}
+	return sel.Having != nil && ast.HasAggFlag(sel.Having.Expr)
+}
+
+// nonAggColumns collects the columns of the expression which are not in aggregate functions or subqueries.
+func nonAggColumns(expr ast.ExprNode) []*ast.ColumnNameExpr {
+	if expr == nil {
+		return nil
+	}
+	c := &columnCollector{}
+	expr.Accept(c)
+	return c.cols
+}
+
+type columnCollector struct {
+	cols []*ast.ColumnNameExpr
+}
+
+func (c *columnCollector) Enter(in ast.Node) (ast.Node, bool) {
+	switch x := in.(type) {
+	case *ast.AggregateFuncExpr, *ast.SubqueryExpr:
+		return in, true
+	case *ast.ColumnNameExpr:
+		if x.Refer != nil {
+			c.cols = append(c.cols, x)
+		}
+	}
+	return in, false
+}
+
+func (c *columnCollector) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// columnKey identifies the column a result field refers to.
+func columnKey(rf *ast.ResultField) string {
+	table := rf.TableAsName.L
+	if table == "" && rf.Table != nil {
+		table = rf.Table.Name.L
+	}
+	if rf.Column == nil {
+		return table + "."
+	}
+	return table + "." + rf.Column.Name.L
+}
+
+func columnFullName(col *ast.ColumnNameExpr) string {
+	rf := col.Refer
+	if rf.Table == nil || rf.Column == nil {
+		return col.Name.Name.O
+	}
+	return rf.DBName.O + "." + rf.Table.Name.O + "." + rf.Column.Name.O
+}
@@ -55,6 +55,9 @@ func (s *testValidatorSuite) TestValidator(c *C) {
 		{"create table t(a int primary key, b int, c varchar(10), d char(256));", true, errors.New("Column length too big for column 'd' (max = 255); use BLOB or TEXT instead")},
 		{"create index ib on t(b,a,b);", true, errors.New("Duplicate column name 'b'")},
 		{"create table t(c1 int not null primary key, c2 int not null primary key)", true, errors.New("Multiple primary key defined")},
+		{"select 1 into outfile '/tmp/t.txt'", true, nil},
+		{"select 1 from (select 1 into outfile '/tmp/t.txt') t", true, plan.ErrCantUseOptionHere},
+		{"select 1 union select 2 into outfile '/tmp/t.txt'", true, plan.ErrWrongUsage},
 	}
 	store, err := tidb.NewStore(tidb.EngineGoLevelDBMemory)
 	c.Assert(err, IsNil)
@@ -66,7 +69,7 @@ func (s *testValidatorSuite) TestValidator(c *C) {
 		c.Assert(err1, IsNil)
 		c.Assert(stmts, HasLen, 1)
 		stmt := stmts[0]
-		err = plan.Validate(stmt, ca.inPrepare)
+		err = plan.Validate(se.(context.Context), stmt, ca.inPrepare)
 		c.Assert(terror.ErrorEqual(err, ca.err), IsTrue)
 	}
 }
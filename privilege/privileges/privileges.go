@@ -62,6 +62,12 @@ func (ps *privileges) add(p mysql.PrivilegeType) {
 	ps.privs[p] = true
 }
 
+func (ps *privileges) merge(other *privileges) {
+	for p := range other.privs {
+		ps.add(p)
+	}
+}
+
 func (ps *privileges) String() string {
 	switch ps.Level {
 	case ast.GrantLevelGlobal:
@@ -136,6 +142,28 @@ type userPrivileges struct {
 	TablePrivs map[string]map[string]*privileges
 }
 
+// merge merges the privileges of a role into the privileges of the user.
+func (ps *userPrivileges) merge(other *userPrivileges) {
+	ps.GlobalPrivs.merge(other.GlobalPrivs)
+	for d, p := range other.DBPrivs {
+		if _, ok := ps.DBPrivs[d]; !ok {
+			ps.DBPrivs[d] = &privileges{Level: ast.GrantLevelDB}
+		}
+		ps.DBPrivs[d].merge(p)
+	}
+	for d, dps := range other.TablePrivs {
+		if _, ok := ps.TablePrivs[d]; !ok {
+			ps.TablePrivs[d] = make(map[string]*privileges)
+		}
+		for t, p := range dps {
+			if _, ok := ps.TablePrivs[d][t]; !ok {
+				ps.TablePrivs[d][t] = &privileges{Level: ast.GrantLevelTable}
+			}
+			ps.TablePrivs[d][t].merge(p)
+		}
+	}
+}
+
 func (ps *userPrivileges) ShowGrants() []string {
 	gs := []string{}
 	// Show global grants
@@ -170,11 +198,15 @@ func (ps *userPrivileges) ShowGrants() []string {
 type UserPrivileges struct {
 	User  string
 	privs *userPrivileges
+	// roles is the active roles of the session when privs is loaded, the privileges of the roles are merged
+	// into privs, and privs is reloaded once the active roles are changed.
+	roles []string
 }
 
 // Check implements Checker.Check interface.
 func (p *UserPrivileges) Check(ctx context.Context, db *model.DBInfo, tbl *model.TableInfo, privilege mysql.PrivilegeType) (bool, error) {
-	if p.privs == nil {
+	roles := variable.GetSessionVars(ctx).ActiveRoles
+	if p.privs == nil || !equalRoles(p.roles, roles) {
 		// Lazy load
 		if len(p.User) == 0 {
 			// User current user
@@ -185,7 +217,7 @@ func (p *UserPrivileges) Check(ctx context.Context, db *model.DBInfo, tbl *model
 				return true, nil
 			}
 		}
-		err := p.loadPrivileges(ctx)
+		err := p.loadPrivileges(ctx, roles)
 		if err != nil {
 			return false, errors.Trace(err)
 		}
@@ -195,6 +227,9 @@ func (p *UserPrivileges) Check(ctx context.Context, db *model.DBInfo, tbl *model
 	if ok {
 		return true, nil
 	}
+	if db == nil {
+		return false, nil
+	}
 	// Check db scope privileges.
 	dbp, ok := p.privs.DBPrivs[db.Name.O]
 	if ok {
@@ -218,7 +253,20 @@ func (p *UserPrivileges) Check(ctx context.Context, db *model.DBInfo, tbl *model
 	return tblp.contain(privilege), nil
 }
 
-func (p *UserPrivileges) loadPrivileges(ctx context.Context) error {
+func equalRoles(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// loadPrivileges loads the privileges of the user, and merges the privileges of the roles into them.
+func (p *UserPrivileges) loadPrivileges(ctx context.Context, roles []string) error {
 	strs := strings.Split(p.User, "@")
 	if len(strs) != 2 {
 		return errInvalidUserNameFormat.Gen("Wrong username format: %s", p.User)
@@ -242,6 +290,14 @@ func (p *UserPrivileges) loadPrivileges(ctx context.Context) error {
 		return errors.Trace(err)
 	}
 	// TODO: consider column scope privilege latter.
+	for _, role := range roles {
+		rp := &UserPrivileges{User: role}
+		if err = rp.loadPrivileges(ctx, nil); err != nil {
+			return errors.Trace(err)
+		}
+		p.privs.merge(rp.privs)
+	}
+	p.roles = roles
 	return nil
 }
 
@@ -272,6 +328,11 @@ func (p *UserPrivileges) loadGlobalPrivileges(ctx context.Context) error {
 			break
 		}
 		for i := userTablePrivColumnStartIndex; i < len(fs); i++ {
+			// The authentication columns follow the privilege columns.
+			p, ok := mysql.Col2PrivType[fs[i].ColumnAsName.O]
+			if !ok {
+				continue
+			}
 			d := row.Data[i]
 			if d.Kind() != types.KindMysqlEnum {
 				return errInvalidPrivilegeType.Gen("Privilege should be mysql.Enum: %v(%T)", d, d)
@@ -280,11 +341,6 @@ func (p *UserPrivileges) loadGlobalPrivileges(ctx context.Context) error {
 			if ed.String() != "Y" {
 				continue
 			}
-			f := fs[i]
-			p, ok := mysql.Col2PrivType[f.ColumnAsName.O]
-			if !ok {
-				return errInvalidPrivilegeType.Gen("Unknown Privilege Type!")
-			}
 			ps.add(p)
 		}
 	}
@@ -379,15 +435,48 @@ func (p *UserPrivileges) loadTableScopePrivileges(ctx context.Context) error {
 }
 
 // ShowGrants implements privilege.Checker ShowGrants interface.
+// The privileges of the active roles are shown for the current user, and the roles granted to the user
+// are shown as GRANT role TO user.
 func (p *UserPrivileges) ShowGrants(ctx context.Context, user string) ([]string, error) {
-	// If user is current user
-	if user == p.User {
-		return p.privs.ShowGrants(), nil
+	var roles []string
+	if user == variable.GetSessionVars(ctx).User {
+		roles = variable.GetSessionVars(ctx).ActiveRoles
 	}
 	userp := &UserPrivileges{User: user}
-	err := userp.loadPrivileges(ctx)
+	err := userp.loadPrivileges(ctx, roles)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	return userp.privs.ShowGrants(), nil
+	gs := userp.privs.ShowGrants()
+	granted, err := userp.loadGrantedRoles(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, role := range granted {
+		gs = append(gs, fmt.Sprintf(`GRANT %s TO '%s'@'%s'`, role, userp.privs.User, userp.privs.Host))
+	}
+	return gs, nil
+}
+
+// loadGrantedRoles loads the roles granted to the user in the format of 'name'@'host'.
+func (p *UserPrivileges) loadGrantedRoles(ctx context.Context) ([]string, error) {
+	sql := fmt.Sprintf(`SELECT FROM_USER, FROM_HOST FROM %s.%s WHERE TO_USER="%s" AND TO_HOST="%s";`,
+		mysql.SystemDB, mysql.RoleEdgeTable, p.privs.User, p.privs.Host)
+	rs, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer rs.Close()
+	var roles []string
+	for {
+		row, err := rs.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		roles = append(roles, fmt.Sprintf(`'%s'@'%s'`, row.Data[0].GetString(), row.Data[1].GetString()))
+	}
+	return roles, nil
 }
@@ -15,7 +15,9 @@ package privileges_test
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/ngaut/log"
@@ -266,6 +268,124 @@ func (s *testPrivilegeSuite) TestDropTablePriv(c *C) {
 	mustExec(c, se1, `DROP TABLE todrop;`)
 }
 
+func (s *testPrivilegeSuite) TestFilePriv(c *C) {
+	defer testleak.AfterTest(c)()
+	se := newSession(c, s.store, s.dbName)
+	ctx, _ := se.(context.Context)
+	mustExec(c, se, `CREATE USER 'file'@'localhost' identified by '123';`)
+	mustExec(c, se, `GRANT ALL ON test.* TO 'file'@'localhost';`)
+	variable.GetSessionVars(ctx).User = "file@localhost"
+	pc := &privileges.UserPrivileges{}
+	r, err := pc.Check(ctx, nil, nil, mysql.FilePriv)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsFalse)
+	dir, err := ioutil.TempDir("", "file_priv")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	sysVar := variable.SysVars[variable.SecureFilePriv]
+	sysVar.Value = dir
+	defer func() { sysVar.Value = "NULL" }()
+	_, err = se.Execute(fmt.Sprintf("SELECT * FROM test INTO OUTFILE '%s'", filepath.Join(dir, "t1.txt")))
+	c.Assert(err, NotNil)
+
+	variable.GetSessionVars(ctx).User = "root@localhost"
+	mustExec(c, se, `GRANT FILE ON *.* TO 'file'@'localhost';`)
+	variable.GetSessionVars(ctx).User = "file@localhost"
+	pc = &privileges.UserPrivileges{}
+	r, err = pc.Check(ctx, nil, nil, mysql.FilePriv)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsTrue)
+	gs, err := pc.ShowGrants(ctx, "file@localhost")
+	c.Assert(err, IsNil)
+	c.Assert(gs, HasLen, 2)
+	c.Assert(gs[0], Equals, "GRANT File ON *.* TO 'file'@'localhost'")
+
+	se1 := newSession(c, s.store, s.dbName)
+	ctx1, _ := se1.(context.Context)
+	variable.GetSessionVars(ctx1).User = "file@localhost"
+	mustExec(c, se1, fmt.Sprintf("SELECT * FROM test INTO OUTFILE '%s'", filepath.Join(dir, "t2.txt")))
+}
+
+func (s *testPrivilegeSuite) TestRoles(c *C) {
+	defer testleak.AfterTest(c)()
+	se := newSession(c, s.store, s.dbName)
+	mustExec(c, se, `CREATE ROLE 'reader', 'writer'@'localhost'`)
+	mustExec(c, se, `CREATE USER 'engineer'@'localhost'`)
+	mustExec(c, se, `GRANT SELECT ON test.* TO 'reader'@'%'`)
+	mustExec(c, se, `GRANT UPDATE ON test.test TO 'writer'@'localhost'`)
+	mustExec(c, se, `GRANT 'reader', 'writer'@'localhost' TO 'engineer'@'localhost'`)
+	// The roles granted to the same user name on another host are not granted to the user.
+	mustExec(c, se, `CREATE ROLE 'auditor'`)
+	mustExec(c, se, `CREATE USER 'engineer'@'%'`)
+	mustExec(c, se, `GRANT 'auditor' TO 'engineer'@'%'`)
+	// Nobody can log in as a role.
+	c.Assert(se.AuthWithoutVerification("reader@localhost"), IsFalse)
+
+	db := &model.DBInfo{Name: model.NewCIStr("test")}
+	tbl := &model.TableInfo{Name: model.NewCIStr("test")}
+	se1 := newSession(c, s.store, s.dbName)
+	ctx, _ := se1.(context.Context)
+	c.Assert(se1.AuthWithoutVerification("engineer@localhost"), IsTrue)
+	pc := &privileges.UserPrivileges{}
+	// The granted roles are not active without the default roles.
+	r, err := pc.Check(ctx, db, nil, mysql.SelectPriv)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsFalse)
+	gs, err := pc.ShowGrants(ctx, "engineer@localhost")
+	c.Assert(err, IsNil)
+	expected := []string{`GRANT 'reader'@'%' TO 'engineer'@'localhost'`, `GRANT 'writer'@'localhost' TO 'engineer'@'localhost'`}
+	c.Assert(testutil.CompareUnorderedStringSlice(gs, expected), IsTrue, Commentf("%v", gs))
+
+	mustExec(c, se1, `SET ROLE 'reader'`)
+	r, err = pc.Check(ctx, db, nil, mysql.SelectPriv)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsTrue)
+	r, err = pc.Check(ctx, db, tbl, mysql.UpdatePriv)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsFalse)
+	gs, err = pc.ShowGrants(ctx, "engineer@localhost")
+	c.Assert(err, IsNil)
+	c.Assert(testutil.CompareUnorderedStringSlice(gs, append(expected, `GRANT Select ON test.* TO 'engineer'@'localhost'`)), IsTrue, Commentf("%v", gs))
+
+	mustExec(c, se1, `SET ROLE ALL EXCEPT 'reader'`)
+	r, err = pc.Check(ctx, db, nil, mysql.SelectPriv)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsFalse)
+	r, err = pc.Check(ctx, db, tbl, mysql.UpdatePriv)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsTrue)
+	mustExec(c, se1, `SET ROLE NONE`)
+	r, err = pc.Check(ctx, db, tbl, mysql.UpdatePriv)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsFalse)
+
+	// The default roles are activated when the user logs in.
+	mustExec(c, se, `SET DEFAULT ROLE ALL TO 'engineer'@'localhost'`)
+	se2 := newSession(c, s.store, s.dbName)
+	ctx2, _ := se2.(context.Context)
+	c.Assert(se2.AuthWithoutVerification("engineer@localhost"), IsTrue)
+	pc = &privileges.UserPrivileges{}
+	r, err = pc.Check(ctx2, db, nil, mysql.SelectPriv)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsTrue)
+	r, err = pc.Check(ctx2, db, tbl, mysql.UpdatePriv)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsTrue)
+
+	// The privileges of a dropped role are revoked.
+	mustExec(c, se, `DROP ROLE 'writer'@'localhost'`)
+	se3 := newSession(c, s.store, s.dbName)
+	ctx3, _ := se3.(context.Context)
+	c.Assert(se3.AuthWithoutVerification("engineer@localhost"), IsTrue)
+	c.Assert(variable.GetSessionVars(ctx3).ActiveRoles, DeepEquals, []string{"reader@%"})
+	pc = &privileges.UserPrivileges{}
+	r, err = pc.Check(ctx3, db, tbl, mysql.UpdatePriv)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsFalse)
+	mustExec(c, se, `DROP USER 'engineer'@'localhost', 'engineer'@'%'`)
+	mustExec(c, se, `DROP ROLE 'reader', 'auditor'`)
+}
+
 func mustExec(c *C, se tidb.Session, sql string) {
 	_, err := se.Execute(sql)
 	c.Assert(err, IsNil)
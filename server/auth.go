@@ -0,0 +1,280 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util"
+)
+
+// Headers and status of the packets in the authentication phase.
+// See https://dev.mysql.com/doc/internals/en/connection-phase-packets.html
+const (
+	authSwitchRequest byte = 0xfe
+	authMoreData      byte = 0x01
+
+	cachingSha2RequestPublicKey byte = 0x02
+	cachingSha2FastAuthSuccess  byte = 0x03
+	cachingSha2PerformFullAuth  byte = 0x04
+)
+
+const rsaKeyBits = 2048
+
+// authenticate verifies the user of the connection with the authentication plugin of the user.
+// The client is asked to switch to the plugin of the user if it authenticates with another one.
+func (cc *clientConn) authenticate(ctx IContext, auth []byte, authPlugin string) error {
+	host, err := cc.clientHost()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	user := fmt.Sprintf("%s@%s", cc.user, host)
+	errAccessDenied := mysql.NewErr(mysql.ErrAccessDenied, cc.user, host, "Yes")
+	plugin, credential, err := ctx.GetAuthInfo(user)
+	if err != nil {
+		if terror.ExecResultIsEmpty.Equal(err) {
+			log.Errorf("User [%s] not exist %v", user, err)
+		} else {
+			log.Errorf("Get the authentication info of [%s] error %v", user, err)
+		}
+		return errors.Trace(errAccessDenied)
+	}
+	if authPlugin == "" {
+		authPlugin = mysql.AuthNativePassword
+	}
+
+	var ok bool
+	switch plugin {
+	case mysql.AuthNativePassword, mysql.AuthCachingSha2Password:
+		if authPlugin != plugin {
+			auth, err = cc.switchAuthPlugin(plugin)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if plugin == mysql.AuthNativePassword {
+			// The current user of the session is set by Auth.
+			if !ctx.Auth(user, auth, cc.salt) {
+				return errors.Trace(errAccessDenied)
+			}
+			return nil
+		}
+		ok, err = cc.authCachingSha2Password(user, credential, auth)
+	case mysql.AuthSocket:
+		ok, err = cc.authSocket(credential)
+	default:
+		err = mysql.NewErr(mysql.ErrPluginIsNotLoaded, plugin)
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !ok || !ctx.AuthWithoutVerification(user) {
+		return errors.Trace(errAccessDenied)
+	}
+	return nil
+}
+
+// switchAuthPlugin asks the client to authenticate with the plugin, and returns the auth data of the plugin.
+// See https://dev.mysql.com/doc/internals/en/connection-phase-packets.html#packet-Protocol::AuthSwitchRequest
+func (cc *clientConn) switchAuthPlugin(plugin string) ([]byte, error) {
+	if cc.capability&mysql.ClientPluginAuth == 0 {
+		return nil, errors.Trace(mysql.NewErr(mysql.ErrNotSupportedAuthMode))
+	}
+	data := make([]byte, 4, 4+len(plugin)+len(cc.salt)+3)
+	data = append(data, authSwitchRequest)
+	data = append(data, plugin...)
+	data = append(data, 0)
+	data = append(data, cc.salt...)
+	data = append(data, 0)
+	if err := cc.writePacket(data); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := cc.flush(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	auth, err := cc.readPacket()
+	return auth, errors.Trace(err)
+}
+
+// writeAuthMoreData sends the extra data of the authentication plugin to the client.
+func (cc *clientConn) writeAuthMoreData(extra []byte) error {
+	data := make([]byte, 4, 5+len(extra))
+	data = append(data, authMoreData)
+	data = append(data, extra...)
+	if err := cc.writePacket(data); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(cc.flush())
+}
+
+// authCachingSha2Password verifies the scramble of caching_sha2_password with the cached digest of the password,
+// or asks the client to send the password if the user is not cached.
+// See https://dev.mysql.com/doc/dev/mysql-server/latest/page_caching_sha2_authentication_exchanges.html
+func (cc *clientConn) authCachingSha2Password(user, credential string, auth []byte) (bool, error) {
+	if len(auth) == 0 || (len(auth) == 1 && auth[0] == 0) {
+		// The client sends nothing for the empty password.
+		return credential == "", nil
+	}
+	if credential == "" {
+		return false, nil
+	}
+	if digest, ok := cc.server.sha2Cache.get(user, credential); ok {
+		if !util.CheckSha2Scramble(auth, cc.salt, digest) {
+			return false, nil
+		}
+		return true, errors.Trace(cc.writeAuthMoreData([]byte{cachingSha2FastAuthSuccess}))
+	}
+
+	if err := cc.writeAuthMoreData([]byte{cachingSha2PerformFullAuth}); err != nil {
+		return false, errors.Trace(err)
+	}
+	pwd, err := cc.readSha2Password()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	if pwd == nil || !util.CheckSha2Password(credential, pwd) {
+		return false, nil
+	}
+	cc.server.sha2Cache.set(user, credential, util.Sha256Hash(util.Sha256Hash(pwd)))
+	return true, nil
+}
+
+// readSha2Password reads the password in the full authentication of caching_sha2_password.
+// The password is sent in plain text on secure connections, otherwise it is encrypted by the RSA public key
+// of the server, the client may request the public key first.
+// It returns nil if the password can't be decrypted.
+func (cc *clientConn) readSha2Password() ([]byte, error) {
+	data, err := cc.readPacket()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if cc.isSecureTransport() {
+		return trimNullTerminator(data), nil
+	}
+
+	key, err := cc.server.getRSAKey()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(data) == 1 && data[0] == cachingSha2RequestPublicKey {
+		pub, err1 := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		if err1 != nil {
+			return nil, errors.Trace(err1)
+		}
+		err1 = cc.writeAuthMoreData(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))
+		if err1 != nil {
+			return nil, errors.Trace(err1)
+		}
+		if data, err1 = cc.readPacket(); err1 != nil {
+			return nil, errors.Trace(err1)
+		}
+	}
+	plain, err := rsa.DecryptOAEP(sha1.New(), rand.Reader, key, data, nil)
+	if err != nil {
+		log.Warnf("Decrypt the password of [%s] error %v", cc.user, err)
+		return nil, nil
+	}
+	// The password is XORed with the salt before encryption.
+	for i := range plain {
+		plain[i] ^= cc.salt[i%len(cc.salt)]
+	}
+	return trimNullTerminator(plain), nil
+}
+
+// isSecureTransport checks whether the password can be sent in plain text on the connection.
+func (cc *clientConn) isSecureTransport() bool {
+	switch cc.conn.(type) {
+	case *net.UnixConn, *tls.Conn:
+		return true
+	}
+	return false
+}
+
+func trimNullTerminator(data []byte) []byte {
+	if n := len(data); n > 0 && data[n-1] == 0 {
+		return data[:n-1]
+	}
+	return data
+}
+
+// authSocket verifies that the operating system user of the client connected by the Unix socket
+// is the user of the connection, or the user named in the authentication string.
+func (cc *clientConn) authSocket(credential string) (bool, error) {
+	conn, ok := cc.conn.(*net.UnixConn)
+	if !ok {
+		return false, nil
+	}
+	osUser, err := peerUser(conn)
+	if err != nil {
+		log.Warnf("Get the peer user of [%s] error %v", cc.user, err)
+		return false, nil
+	}
+	if credential == "" {
+		credential = cc.user
+	}
+	return osUser == credential, nil
+}
+
+// getRSAKey returns the RSA key of the server, the key is generated at the first call.
+func (s *Server) getRSAKey() (*rsa.PrivateKey, error) {
+	s.rsaKeyOnce.Do(func() {
+		s.rsaKey, s.rsaKeyErr = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	})
+	return s.rsaKey, errors.Trace(s.rsaKeyErr)
+}
+
+// sha2Cache caches SHA256(SHA256(password)) of the users authenticated by caching_sha2_password,
+// so their scrambles can be verified without the full authentication.
+type sha2Cache struct {
+	mu      sync.RWMutex
+	entries map[string]sha2CacheEntry
+}
+
+type sha2CacheEntry struct {
+	// credential is the authentication string when the entry is cached,
+	// the entry is stale once the password of the user is changed.
+	credential string
+	digest     []byte
+}
+
+func (c *sha2Cache) get(user, credential string) ([]byte, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[user]
+	if !ok || e.credential != credential {
+		return nil, false
+	}
+	return e.digest, true
+}
+
+func (c *sha2Cache) set(user, credential string, digest []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]sha2CacheEntry)
+	}
+	c.entries[user] = sha2CacheEntry{credential: credential, digest: digest}
+}
@@ -0,0 +1,46 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package server
+
+import (
+	"net"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"github.com/juju/errors"
+)
+
+// peerUser gets the name of the operating system user of the process on the other side of the Unix socket.
+func peerUser(conn *net.UnixConn) (string, error) {
+	f, err := conn.File()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	defer f.Close()
+	fd := int(f.Fd())
+	// Fd puts the duplicated descriptor into the blocking mode, which is shared with the connection.
+	defer syscall.SetNonblock(fd, true)
+	cred, err := syscall.GetsockoptUcred(fd, syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	u, err := user.LookupId(strconv.Itoa(int(cred.Uid)))
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return u.Username, nil
+}
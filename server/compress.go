@@ -0,0 +1,162 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"io"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/mysql"
+)
+
+// The compressed protocol wraps the stream of plain packets in compressed packets.
+// A compressed packet has a 7 bytes header: 3 bytes of the payload length, 1 byte of the compressed sequence
+// and 3 bytes of the payload length before compression, which is 0 if the payload is not compressed.
+// See https://dev.mysql.com/doc/internals/en/compressed-packet-header.html
+const compressedHeaderLen = 7
+
+// minCompressLength is the minimum length of a payload to compress, shorter payloads are sent uncompressed.
+const minCompressLength = 50
+
+// compressedReader reads the compressed packets and returns the decompressed plain packets.
+type compressedReader struct {
+	p  *packetIO
+	rb *bufio.Reader
+	// buf is the decompressed data that has not been read.
+	buf []byte
+}
+
+// Read implements io.Reader interface.
+func (r *compressedReader) Read(data []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if err := r.readCompressedPacket(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(data, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *compressedReader) readCompressedPacket() error {
+	var header [compressedHeaderLen]byte
+	if _, err := io.ReadFull(r.rb, header[:]); err != nil {
+		return err
+	}
+
+	sequence := header[3]
+	if sequence != r.p.compressedSequence {
+		return errInvalidSequence.Gen("invalid compressed sequence %d != %d", sequence, r.p.compressedSequence)
+	}
+	r.p.compressedSequence++
+
+	length := int(uint32(header[0]) | uint32(header[1])<<8 | uint32(header[2])<<16)
+	uncompressedLength := int(uint32(header[4]) | uint32(header[5])<<8 | uint32(header[6])<<16)
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r.rb, payload); err != nil {
+		return err
+	}
+	if uncompressedLength == 0 {
+		r.buf = payload
+		return nil
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	r.buf = make([]byte, uncompressedLength)
+	if _, err = io.ReadFull(zr, r.buf); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(zr.Close())
+}
+
+// compressedWriter buffers the written plain packets, they are compressed and written when it is flushed,
+// or when MaxPayloadLen bytes are buffered, so a large result set is not held in memory.
+type compressedWriter struct {
+	p  *packetIO
+	wb *bufio.Writer
+	// buf is the data that has not been compressed.
+	buf bytes.Buffer
+	// compressed is the buffer of the compressed payload.
+	compressed bytes.Buffer
+	zw         *zlib.Writer
+}
+
+// Write implements io.Writer interface.
+func (w *compressedWriter) Write(data []byte) (int, error) {
+	n, _ := w.buf.Write(data)
+	for w.buf.Len() >= mysql.MaxPayloadLen {
+		if err := w.writeCompressedPacket(w.buf.Next(mysql.MaxPayloadLen)); err != nil {
+			return 0, errors.Trace(err)
+		}
+	}
+	return n, nil
+}
+
+// flush writes the buffered data in compressed packets, every compressed packet holds at most MaxPayloadLen bytes
+// of the uncompressed data.
+func (w *compressedWriter) flush() error {
+	for w.buf.Len() > 0 {
+		n := w.buf.Len()
+		if n > mysql.MaxPayloadLen {
+			n = mysql.MaxPayloadLen
+		}
+		if err := w.writeCompressedPacket(w.buf.Next(n)); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	w.buf.Reset()
+	return errors.Trace(w.wb.Flush())
+}
+
+func (w *compressedWriter) writeCompressedPacket(data []byte) error {
+	payload, uncompressedLength := data, 0
+	if len(data) >= minCompressLength {
+		w.compressed.Reset()
+		if w.zw == nil {
+			w.zw = zlib.NewWriter(&w.compressed)
+		} else {
+			w.zw.Reset(&w.compressed)
+		}
+		if _, err := w.zw.Write(data); err != nil {
+			return errors.Trace(err)
+		}
+		if err := w.zw.Close(); err != nil {
+			return errors.Trace(err)
+		}
+		// The data is sent uncompressed if it can not be compressed.
+		if w.compressed.Len() < len(data) {
+			payload, uncompressedLength = w.compressed.Bytes(), len(data)
+		}
+	}
+
+	header := [compressedHeaderLen]byte{
+		byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16),
+		w.p.compressedSequence,
+		byte(uncompressedLength), byte(uncompressedLength >> 8), byte(uncompressedLength >> 16),
+	}
+	w.p.compressedSequence++
+	if _, err := w.wb.Write(header[:]); err != nil {
+		return errors.Trace(mysql.ErrBadConn)
+	}
+	if _, err := w.wb.Write(payload); err != nil {
+		return errors.Trace(mysql.ErrBadConn)
+	}
+	return nil
+}
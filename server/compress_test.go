@@ -0,0 +1,84 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/mysql"
+)
+
+type CompressTestSuite struct{}
+
+var _ = Suite(CompressTestSuite{})
+
+// newCompressedPacketIO creates a packetIO with the compressed protocol that reads and writes buf.
+func newCompressedPacketIO(buf *bytes.Buffer) *packetIO {
+	p := &packetIO{rb: bufio.NewReader(buf), wb: bufio.NewWriter(buf)}
+	p.setCompressed()
+	return p
+}
+
+func (ts CompressTestSuite) TestCompressedPacket(c *C) {
+	var buf bytes.Buffer
+	p := newCompressedPacketIO(&buf)
+
+	// A short packet is not compressed.
+	c.Assert(p.writePacket(append(make([]byte, 4), "select 1"...)), IsNil)
+	c.Assert(p.flush(), IsNil)
+	c.Assert(buf.Len(), Equals, compressedHeaderLen+4+len("select 1"))
+	c.Assert(buf.Bytes()[3], Equals, uint8(0))
+	c.Assert(buf.Bytes()[4:7], DeepEquals, []byte{0, 0, 0})
+	data, err := newCompressedPacketIO(&buf).readPacket()
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "select 1")
+
+	// The packets written before a flush are compressed together.
+	buf.Reset()
+	p.resetSequence()
+	large := bytes.Repeat([]byte("tidb"), 10000)
+	c.Assert(p.writePacket(append(make([]byte, 4), large...)), IsNil)
+	c.Assert(p.writePacket(append(make([]byte, 4), "ok"...)), IsNil)
+	c.Assert(p.flush(), IsNil)
+	c.Assert(buf.Len() < len(large)/10, IsTrue)
+	r := newCompressedPacketIO(&buf)
+	data, err = r.readPacket()
+	c.Assert(err, IsNil)
+	c.Assert(data, DeepEquals, large)
+	data, err = r.readPacket()
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "ok")
+	c.Assert(r.compressedSequence, Equals, uint8(1))
+
+	// The data is compressed and written before the flush once MaxPayloadLen bytes are buffered.
+	buf.Reset()
+	p.resetSequence()
+	huge := bytes.Repeat([]byte("tidb"), mysql.MaxPayloadLen/4+1)
+	c.Assert(p.writePacket(append(make([]byte, 4), huge...)), IsNil)
+	c.Assert(p.compressedSequence, Equals, uint8(1))
+	c.Assert(p.cw.buf.Len() < mysql.MaxPayloadLen, IsTrue)
+	c.Assert(p.flush(), IsNil)
+	data, err = newCompressedPacketIO(&buf).readPacket()
+	c.Assert(err, IsNil)
+	c.Assert(data, DeepEquals, huge)
+
+	// The compressed sequence is checked.
+	buf.Reset()
+	c.Assert(p.writePacket(append(make([]byte, 4), "select 1"...)), IsNil)
+	c.Assert(p.flush(), IsNil)
+	_, err = newCompressedPacketIO(&buf).readPacket()
+	c.Assert(err, NotNil)
+}
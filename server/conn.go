@@ -41,6 +41,7 @@ import (
 	"io"
 	"net"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -48,8 +49,10 @@ import (
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/arena"
+	"github.com/pingcap/tidb/util/audit"
 	"github.com/pingcap/tidb/util/hack"
 )
 
@@ -57,7 +60,7 @@ var defaultCapability = mysql.ClientLongPassword | mysql.ClientLongFlag |
 	mysql.ClientConnectWithDB | mysql.ClientProtocol41 |
 	mysql.ClientTransactions | mysql.ClientSecureConnection | mysql.ClientFoundRows |
 	mysql.ClientMultiStatements | mysql.ClientMultiResults | mysql.ClientLocalFiles |
-	mysql.ClientConnectAtts
+	mysql.ClientConnectAtts | mysql.ClientCompress | mysql.ClientPluginAuth
 
 // clientConn represents a connection between server and client, it maintains connection specific state,
 // handles client query.
@@ -104,12 +107,19 @@ func (cc *clientConn) handshake() error {
 	}
 
 	err := cc.writePacket(data)
-	cc.pkt.sequence = 0
+	cc.pkt.resetSequence()
 	if err != nil {
 		return errors.Trace(err)
 	}
 
-	return errors.Trace(cc.flush())
+	if err = cc.flush(); err != nil {
+		return errors.Trace(err)
+	}
+	// The packets after the OK packet are compressed if the client asks for it.
+	if cc.capability&mysql.ClientCompress > 0 {
+		cc.pkt.setCompressed()
+	}
+	return nil
 }
 
 func (cc *clientConn) Close() error {
@@ -120,11 +130,39 @@ func (cc *clientConn) Close() error {
 	connGauge.Set(float64(connections))
 	cc.conn.Close()
 	if cc.ctx != nil {
+		cc.auditConnection(audit.EventDisconnect, nil)
 		return cc.ctx.Close()
 	}
 	return nil
 }
 
+// auditConnection writes the connection event of the user to the audit log,
+// connErr is the error that the event fails with, like an authentication failure.
+func (cc *clientConn) auditConnection(event string, connErr error) {
+	if !audit.Enabled() {
+		return
+	}
+	host, err := cc.clientHost()
+	if err != nil {
+		host = cc.conn.RemoteAddr().String()
+	}
+	r := &audit.Record{
+		Event:     event,
+		ConnID:    uint64(cc.connectionID),
+		User:      cc.user,
+		Host:      host,
+		DB:        cc.dbname,
+		Class:     audit.ClassConnection,
+		ErrorCode: audit.ErrorCode(connErr),
+	}
+	if cc.ctx != nil && connErr == nil {
+		r.DB = cc.ctx.CurrentDB()
+	}
+	if err = audit.Write(r); err != nil {
+		log.Errorf("[audit] write error: %v, %s", errors.ErrorStack(err), cc)
+	}
+}
+
 // writeInitialHandshake sends server version, connection ID, server capability, collation, server status
 // and auth salt to the client.
 func (cc *clientConn) writeInitialHandshake() error {
@@ -158,6 +196,9 @@ func (cc *clientConn) writeInitialHandshake() error {
 	data = append(data, cc.salt[8:]...)
 	// filler [00]
 	data = append(data, 0)
+	// auth-plugin name
+	data = append(data, mysql.AuthNativePassword...)
+	data = append(data, 0)
 	err := cc.writePacket(data)
 	if err != nil {
 		return errors.Trace(err)
@@ -179,6 +220,7 @@ type handshakeResponse41 struct {
 	User       string
 	DBName     string
 	Auth       []byte
+	AuthPlugin string
 	Attrs      map[string]string
 }
 
@@ -228,10 +270,12 @@ func handshakeResponseFromData(packet *handshakeResponse41, data []byte) error {
 		}
 	}
 
-	if capability&mysql.ClientPluginAuth > 0 {
-		// TODO: Support mysql.ClientPluginAuth, skip it now
-		idx := bytes.IndexByte(data[pos:], 0)
-		pos = pos + idx + 1
+	if capability&mysql.ClientPluginAuth > 0 && pos < len(data) {
+		// The plugin name may be absent even if the client sets the capability, see TestIssue1768.
+		if idx := bytes.IndexByte(data[pos:], 0); idx >= 0 {
+			packet.AuthPlugin = string(data[pos : pos+idx])
+			pos = pos + idx + 1
+		}
 	}
 
 	if capability&mysql.ClientConnectAtts > 0 {
@@ -285,27 +329,213 @@ func (cc *clientConn) readHandshakeResponse() error {
 	cc.collation = p.Collation
 	cc.attrs = p.Attrs
 
-	// Open session and do auth
-	cc.ctx, err = cc.server.driver.OpenCtx(uint64(cc.connectionID), cc.capability, uint8(cc.collation), cc.dbname)
+	// Open session, reserve the connection slot and do auth
+	ctx, err := cc.openSession()
+	if err == nil {
+		if err = cc.reserveConnection(ctx); err != nil {
+			ctx.Close()
+		} else if err = cc.doAuth(ctx, p.Auth, p.AuthPlugin); err == nil {
+			cc.ctx = ctx
+		}
+	}
+	cc.auditConnection(audit.EventConnect, err)
+	return errors.Trace(err)
+}
+
+// reserveConnection registers the connection in the server before the authentication,
+// it fails if the connections of the server would exceed max_connections.
+// The connection is unregistered when it is closed.
+func (cc *clientConn) reserveConnection(ctx IContext) error {
+	value, err := ctx.GetGlobalSysVar(variable.MaxConnections)
 	if err != nil {
-		cc.Close()
 		return errors.Trace(err)
 	}
-	if !cc.server.skipAuth() {
-		// Do Auth
-		addr := cc.conn.RemoteAddr().String()
-		host, _, err1 := net.SplitHostPort(addr)
-		if err1 != nil {
-			return errors.Trace(mysql.NewErr(mysql.ErrAccessDenied, cc.user, addr, "Yes"))
+	maxConnections, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !cc.server.addClient(cc, maxConnections) {
+		return mysql.NewErr(mysql.ErrConCount)
+	}
+	return nil
+}
+
+// openSessionAndDoAuth opens a new session for the user, the collation and the database of the connection,
+// and authenticates the user with the auth data sent by the client authentication plugin.
+// The session is closed if the authentication fails.
+func (cc *clientConn) openSessionAndDoAuth(auth []byte, authPlugin string) (IContext, error) {
+	ctx, err := cc.openSession()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = cc.doAuth(ctx, auth, authPlugin); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return ctx, nil
+}
+
+// openSession opens a new session for the user, the collation and the database of the connection.
+func (cc *clientConn) openSession() (IContext, error) {
+	ctx, err := cc.server.driver.OpenCtx(uint64(cc.connectionID), cc.capability, uint8(cc.collation), cc.dbname)
+	return ctx, errors.Trace(err)
+}
+
+// doAuth authenticates the user of the session with the auth data sent by the client authentication plugin,
+// and counts the connection for the resource limits of the user. The session is closed if it fails.
+func (cc *clientConn) doAuth(ctx IContext, auth []byte, authPlugin string) error {
+	if cc.server.skipAuth() {
+		return nil
+	}
+	if err := cc.authenticate(ctx, auth, authPlugin); err != nil {
+		ctx.Close()
+		return errors.Trace(err)
+	}
+	if err := ctx.AcquireUserConnection(false); err != nil {
+		ctx.Close()
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// clientHost returns the host of the client, it is used to authenticate the user.
+// The clients connected by the Unix socket are from localhost.
+func (cc *clientConn) clientHost() (string, error) {
+	if _, ok := cc.conn.(*net.UnixConn); ok {
+		return "localhost", nil
+	}
+	addr := cc.conn.RemoteAddr().String()
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", errors.Trace(mysql.NewErr(mysql.ErrAccessDenied, cc.user, addr, "Yes"))
+	}
+	return host, nil
+}
+
+// changeUserFromData parses the payload of COM_CHANGE_USER, the capability is negotiated in the handshake.
+// See https://dev.mysql.com/doc/internals/en/com-change-user.html
+func changeUserFromData(packet *handshakeResponse41, capability uint32, data []byte) error {
+	pos := bytes.IndexByte(data, 0)
+	if pos < 0 {
+		return mysql.ErrMalformPacket
+	}
+	packet.User = string(data[:pos])
+	pos++
+
+	if capability&mysql.ClientSecureConnection > 0 {
+		if pos >= len(data) {
+			return mysql.ErrMalformPacket
+		}
+		authLen := int(data[pos])
+		pos++
+		if pos+authLen > len(data) {
+			return mysql.ErrMalformPacket
+		}
+		packet.Auth = data[pos : pos+authLen]
+		pos += authLen
+	} else {
+		idx := bytes.IndexByte(data[pos:], 0)
+		if idx < 0 {
+			return mysql.ErrMalformPacket
 		}
-		user := fmt.Sprintf("%s@%s", cc.user, host)
-		if !cc.ctx.Auth(user, p.Auth, cc.salt) {
-			return errors.Trace(mysql.NewErr(mysql.ErrAccessDenied, cc.user, host, "Yes"))
+		packet.Auth = data[pos : pos+idx]
+		pos += idx + 1
+	}
+
+	idx := bytes.IndexByte(data[pos:], 0)
+	if idx < 0 {
+		return mysql.ErrMalformPacket
+	}
+	packet.DBName = string(data[pos : pos+idx])
+	pos += idx + 1
+
+	// The character set and the rest are optional.
+	if pos+2 <= len(data) {
+		packet.Collation = data[pos]
+		pos += 2
+	}
+
+	if capability&mysql.ClientPluginAuth > 0 && pos < len(data) {
+		idx = bytes.IndexByte(data[pos:], 0)
+		if idx < 0 {
+			return mysql.ErrMalformPacket
+		}
+		packet.AuthPlugin = string(data[pos : pos+idx])
+		pos += idx + 1
+	}
+
+	if capability&mysql.ClientConnectAtts > 0 && pos < len(data) {
+		if num, null, off := parseLengthEncodedInt(data[pos:]); !null {
+			pos += off
+			if pos+int(num) > len(data) {
+				return mysql.ErrMalformPacket
+			}
+			attrs, err := parseAttrs(data[pos : pos+int(num)])
+			if err != nil {
+				return errors.Trace(err)
+			}
+			packet.Attrs = attrs
 		}
 	}
 	return nil
 }
 
+// handleChangeUser authenticates the user in COM_CHANGE_USER and replaces the session of the connection with a new one,
+// so the session variables, the prepared statements and the transaction of the previous user are dropped.
+// The previous session is kept if the authentication fails.
+func (cc *clientConn) handleChangeUser(data []byte) error {
+	p := handshakeResponse41{Collation: cc.collation}
+	if err := changeUserFromData(&p, cc.capability, data); err != nil {
+		return errors.Trace(err)
+	}
+	user, dbname, collation, attrs := cc.user, cc.dbname, cc.collation, cc.attrs
+	cc.user, cc.dbname, cc.collation, cc.attrs = p.User, p.DBName, p.Collation, p.Attrs
+	ctx, err := cc.openSessionAndDoAuth(p.Auth, p.AuthPlugin)
+	if err != nil {
+		cc.auditConnection(audit.EventChangeUser, err)
+		cc.user, cc.dbname, cc.collation, cc.attrs = user, dbname, collation, attrs
+		return errors.Trace(err)
+	}
+	cc.replaceSession(ctx)
+	cc.auditConnection(audit.EventChangeUser, nil)
+	return errors.Trace(cc.writeOK())
+}
+
+// handleResetConnection replaces the session of the connection with a new one for the same user and
+// the current database without authenticating the user again.
+// See https://dev.mysql.com/doc/internals/en/com-reset-connection.html
+func (cc *clientConn) handleResetConnection() error {
+	cc.dbname = cc.ctx.CurrentDB()
+	ctx, err := cc.server.driver.OpenCtx(uint64(cc.connectionID), cc.capability, uint8(cc.collation), cc.dbname)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !cc.server.skipAuth() {
+		host, err1 := cc.clientHost()
+		if err1 == nil && !ctx.AuthWithoutVerification(fmt.Sprintf("%s@%s", cc.user, host)) {
+			err1 = mysql.NewErr(mysql.ErrAccessDenied, cc.user, host, "Yes")
+		}
+		if err1 == nil {
+			// The connection is moved to the new session, it is released when the old session is closed.
+			err1 = ctx.AcquireUserConnection(true)
+		}
+		if err1 != nil {
+			ctx.Close()
+			return errors.Trace(err1)
+		}
+	}
+	cc.replaceSession(ctx)
+	return errors.Trace(cc.writeOK())
+}
+
+// replaceSession closes the session of the connection and uses ctx instead,
+// the uncommitted transaction of the closed session is rolled back.
+func (cc *clientConn) replaceSession(ctx IContext) {
+	if err := cc.ctx.Close(); err != nil {
+		log.Errorf("close session error %s, %s", errors.ErrorStack(err), cc)
+	}
+	cc.ctx = ctx
+}
+
 // Run reads client query and writes query result to client in for loop, if there is a panic during query handling,
 // it will be recovered and log the panic error.
 // This function returns and the connection is closed if there is an IO error or there is a panic.
@@ -323,9 +553,18 @@ func (cc *clientConn) Run() {
 
 	for {
 		cc.alloc.Reset()
+		timeout := cc.idleTimeout()
+		if timeout > 0 {
+			cc.conn.SetReadDeadline(time.Now().Add(timeout))
+		}
 		data, err := cc.readPacket()
+		if timeout > 0 {
+			cc.conn.SetReadDeadline(time.Time{})
+		}
 		if err != nil {
-			if terror.ErrorNotEqual(err, io.EOF) {
+			if netErr, ok := errors.Cause(err).(net.Error); ok && netErr.Timeout() {
+				log.Infof("[%d] close the connection idle for more than %v", cc.connectionID, timeout)
+			} else if terror.ErrorNotEqual(err, io.EOF) {
 				log.Error(errors.ErrorStack(err))
 			}
 			return
@@ -340,10 +579,36 @@ func (cc *clientConn) Run() {
 			cc.writeError(err)
 		}
 
-		cc.pkt.sequence = 0
+		cc.pkt.resetSequence()
 	}
 }
 
+// idleTimeout returns how long the connection can wait for the next command, 0 means no limit.
+// It is wait_timeout, or tidb_idle_transaction_timeout if the connection is in a transaction and it is set.
+// The connection is closed when the limit is exceeded, its transaction is rolled back.
+func (cc *clientConn) idleTimeout() time.Duration {
+	if cc.ctx.Status()&mysql.ServerStatusInTrans > 0 {
+		if timeout := cc.sessionSysVarSeconds(variable.TiDBIdleTransactionTimeout); timeout > 0 {
+			return timeout
+		}
+	}
+	return cc.sessionSysVarSeconds(variable.WaitTimeout)
+}
+
+// sessionSysVarSeconds gets the session system variable in seconds, it is 0 if the variable is invalid.
+func (cc *clientConn) sessionSysVarSeconds(name string) time.Duration {
+	value, err := cc.ctx.GetSessionSysVar(name)
+	if err != nil {
+		log.Warnf("[%d] get %s error: %v", cc.connectionID, name, err)
+		return 0
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
 // dispatch handles client request based on command which is the first byte of the data.
 // It also gets a token from server which is used to limit the concurrently handling clients.
 // The most frequently used command is ComQuery.
@@ -384,6 +649,8 @@ func (cc *clientConn) dispatch(data []byte) error {
 		return cc.handleStmtPrepare(hack.String(data))
 	case mysql.ComStmtExecute:
 		return cc.handleStmtExecute(data)
+	case mysql.ComStmtFetch:
+		return cc.handleStmtFetch(data)
 	case mysql.ComStmtClose:
 		return cc.handleStmtClose(data)
 	case mysql.ComStmtSendLongData:
@@ -392,6 +659,10 @@ func (cc *clientConn) dispatch(data []byte) error {
 		return cc.handleStmtReset(data)
 	case mysql.ComSetOption:
 		return cc.handleSetOption(data)
+	case mysql.ComChangeUser:
+		return cc.handleChangeUser(data)
+	case mysql.ComResetConnection:
+		return cc.handleResetConnection()
 	default:
 		return mysql.NewErrf(mysql.ErrUnknown, "command %d not supported now", cmd)
 	}
@@ -437,7 +708,7 @@ func (cc *clientConn) writeError(e error) error {
 	originErr := errors.Cause(e)
 	if te, ok = originErr.(*terror.Error); ok {
 		m = te.ToSQLError()
-	} else {
+	} else if m, ok = originErr.(*mysql.SQLError); !ok {
 		m = mysql.NewErrf(mysql.ErrUnknown, e.Error())
 	}
 
@@ -480,6 +751,17 @@ func (cc *clientConn) writeEOF(more bool) error {
 	return errors.Trace(err)
 }
 
+// writeEOFWithStatus writes an EOF packet with the status flags.
+func (cc *clientConn) writeEOFWithStatus(status uint16) error {
+	data := cc.alloc.AllocWithLen(4, 9)
+	data = append(data, mysql.EOFHeader)
+	if cc.capability&mysql.ClientProtocol41 > 0 {
+		data = append(data, dumpUint16(cc.ctx.WarningCount())...)
+		data = append(data, dumpUint16(status)...)
+	}
+	return errors.Trace(cc.writePacket(data))
+}
+
 func (cc *clientConn) writeReq(filePath string) error {
 	data := cc.alloc.AllocWithLen(4, 5+len(filePath))
 	data = append(data, mysql.LocalInFileHeader)
@@ -639,25 +921,11 @@ func (cc *clientConn) writeResultset(rs ResultSet, binary bool, more bool) error
 	if err != nil {
 		return errors.Trace(err)
 	}
-	columnLen := dumpLengthEncodedInt(uint64(len(columns)))
-	data := cc.alloc.AllocWithLen(4, 1024)
-	data = append(data, columnLen...)
-	if err = cc.writePacket(data); err != nil {
-		return errors.Trace(err)
-	}
-
-	for _, v := range columns {
-		data = data[0:4]
-		data = append(data, v.Dump(cc.alloc)...)
-		if err = cc.writePacket(data); err != nil {
-			return errors.Trace(err)
-		}
-	}
-
-	if err = cc.writeEOF(false); err != nil {
+	if err = cc.writeColumnInfo(columns, 0); err != nil {
 		return errors.Trace(err)
 	}
 
+	data := cc.alloc.AllocWithLen(4, 1024)
 	for {
 		if err != nil {
 			return errors.Trace(err)
@@ -702,6 +970,24 @@ func (cc *clientConn) writeResultset(rs ResultSet, binary bool, more bool) error
 	return errors.Trace(cc.flush())
 }
 
+// writeColumnInfo writes the column count and the column definitions, followed by an EOF packet
+// whose status is the session status with the serverStatus flags added.
+func (cc *clientConn) writeColumnInfo(columns []*ColumnInfo, serverStatus uint16) error {
+	data := cc.alloc.AllocWithLen(4, 1024)
+	data = append(data, dumpLengthEncodedInt(uint64(len(columns)))...)
+	if err := cc.writePacket(data); err != nil {
+		return errors.Trace(err)
+	}
+	for _, v := range columns {
+		data = data[0:4]
+		data = append(data, v.Dump(cc.alloc)...)
+		if err := cc.writePacket(data); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(cc.writeEOFWithStatus(cc.ctx.Status() | serverStatus))
+}
+
 func (cc *clientConn) writeMultiResultset(rss []ResultSet, binary bool) error {
 	for _, rs := range rss {
 		if err := cc.writeResultset(rs, binary, true); err != nil {
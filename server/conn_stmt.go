@@ -42,6 +42,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/util/hack"
+	"github.com/pingcap/tidb/util/types"
 )
 
 func (cc *clientConn) handleStmtPrepare(sql string) error {
@@ -118,8 +119,8 @@ func (cc *clientConn) handleStmtExecute(data []byte) (err error) {
 
 	flag := data[pos]
 	pos++
-	//now we only support CURSOR_TYPE_NO_CURSOR flag
-	if flag != 0 {
+	//now we only support CURSOR_TYPE_NO_CURSOR and CURSOR_TYPE_READ_ONLY flags
+	if flag != mysql.CursorTypeNoCursor && flag != mysql.CursorTypeReadOnly {
 		return mysql.NewErrf(mysql.ErrUnknown, "unsupported flag %d", flag)
 	}
 
@@ -165,10 +166,122 @@ func (cc *clientConn) handleStmtExecute(data []byte) (err error) {
 	if rs == nil {
 		return errors.Trace(cc.writeOK())
 	}
+	if flag == mysql.CursorTypeReadOnly {
+		return errors.Trace(cc.openCursor(stmt, rs))
+	}
 
 	return errors.Trace(cc.writeResultset(rs, true, false))
 }
 
+// cursorResultSet is the result set of an open cursor, its rows are sent by COM_STMT_FETCH.
+type cursorResultSet struct {
+	ResultSet
+	columns []*ColumnInfo
+	// row is read before the columns are got, it is the first row to fetch.
+	row []types.Datum
+}
+
+// Columns implements ResultSet Columns method.
+func (rs *cursorResultSet) Columns() ([]*ColumnInfo, error) {
+	return rs.columns, nil
+}
+
+// Next implements ResultSet Next method.
+func (rs *cursorResultSet) Next() ([]types.Datum, error) {
+	if row := rs.row; row != nil {
+		rs.row = nil
+		return row, nil
+	}
+	return rs.ResultSet.Next()
+}
+
+// openCursor keeps the result set open on the statement and only writes the column definitions,
+// the rows are fetched by COM_STMT_FETCH.
+// See https://dev.mysql.com/doc/internals/en/com-stmt-execute-response.html
+func (cc *clientConn) openCursor(stmt IStatement, rs ResultSet) error {
+	// We need to call Next before we get columns.
+	row, err := rs.Next()
+	if err != nil {
+		rs.Close()
+		return errors.Trace(err)
+	}
+	columns, err := rs.Columns()
+	if err != nil {
+		rs.Close()
+		return errors.Trace(err)
+	}
+	stmt.StoreResultSet(&cursorResultSet{ResultSet: rs, columns: columns, row: row})
+	if err = cc.writeColumnInfo(columns, mysql.ServerStatusCursorExists); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(cc.flush())
+}
+
+// handleStmtFetch sends at most the requested number of rows of the open cursor.
+// See https://dev.mysql.com/doc/internals/en/com-stmt-fetch.html
+func (cc *clientConn) handleStmtFetch(data []byte) (err error) {
+	if len(data) < 8 {
+		return mysql.ErrMalformPacket
+	}
+
+	stmtID := binary.LittleEndian.Uint32(data[0:4])
+	numRows := binary.LittleEndian.Uint32(data[4:8])
+
+	stmt := cc.ctx.GetStatement(int(stmtID))
+	if stmt == nil {
+		return mysql.NewErr(mysql.ErrUnknownStmtHandler,
+			strconv.FormatUint(uint64(stmtID), 10), "stmt_fetch")
+	}
+	rs := stmt.GetResultSet()
+	if rs == nil {
+		return mysql.NewErrf(mysql.ErrStmtHasNoOpenCursor, "The statement (%d) has no open cursor.", stmtID)
+	}
+	defer func() {
+		// The rows of a failed fetch are lost, so the cursor is closed instead of being fetched from after them.
+		if err != nil {
+			stmt.StoreResultSet(nil)
+		}
+	}()
+	columns, err := rs.Columns()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	data = cc.alloc.AllocWithLen(4, 1024)
+	var exhausted bool
+	for i := uint32(0); i < numRows; i++ {
+		var row []types.Datum
+		row, err = rs.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			exhausted = true
+			break
+		}
+		var rowData []byte
+		rowData, err = dumpRowValuesBinary(cc.alloc, columns, row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		data = append(data[0:4], rowData...)
+		if err = cc.writePacket(data); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	status := cc.ctx.Status() | mysql.ServerStatusCursorExists
+	if exhausted {
+		// The cursor is exhausted, it is closed after the last row is sent.
+		status |= mysql.ServerStatusLastRowSend
+		stmt.StoreResultSet(nil)
+	}
+	if err = cc.writeEOFWithStatus(status); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(cc.flush())
+}
+
 func parseStmtArgs(args []interface{}, boundParams [][]byte, nullBitmap, paramTypes, paramValues []byte) (err error) {
 	pos := 0
 	var v []byte
@@ -69,6 +69,7 @@ func (ts ConnTestSuite) TestHandshakeResponseFromData(c *C) {
 	c.Assert(p.Capability&capability, Equals, capability)
 	c.Assert(p.User, Equals, "pam")
 	c.Assert(p.DBName, Equals, "test")
+	c.Assert(p.AuthPlugin, Equals, mysql.AuthNativePassword)
 }
 
 func (ts ConnTestSuite) TestIssue1768(c *C) {
@@ -95,6 +96,28 @@ func (ts ConnTestSuite) TestIssue1768(c *C) {
 	c.Assert(len(p.Auth) > 0, IsTrue)
 }
 
+func (ts ConnTestSuite) TestChangeUserFromData(c *C) {
+	capability := mysql.ClientProtocol41 | mysql.ClientSecureConnection | mysql.ClientPluginAuth
+	data := []byte("pam\x00\x03abctest\x00\x21\x00mysql_native_password\x00")
+	var p handshakeResponse41
+	c.Assert(changeUserFromData(&p, capability, data), IsNil)
+	c.Assert(p.User, Equals, "pam")
+	c.Assert(p.Auth, DeepEquals, []byte("abc"))
+	c.Assert(p.DBName, Equals, "test")
+	c.Assert(p.Collation, Equals, uint8(mysql.DefaultCollationID))
+	c.Assert(p.AuthPlugin, Equals, mysql.AuthNativePassword)
+
+	// The character set is optional.
+	p = handshakeResponse41{}
+	c.Assert(changeUserFromData(&p, capability, []byte("pam\x00\x00\x00")), IsNil)
+	c.Assert(p.User, Equals, "pam")
+	c.Assert(p.Auth, HasLen, 0)
+	c.Assert(p.DBName, Equals, "")
+
+	c.Assert(changeUserFromData(&p, capability, []byte("pam\x00\x03ab")), Equals, mysql.ErrMalformPacket)
+	c.Assert(changeUserFromData(&p, capability, []byte("pam")), Equals, mysql.ErrMalformPacket)
+}
+
 func mapIdentical(m1, m2 map[string]string) bool {
 	return mapBelong(m1, m2) && mapBelong(m2, m1)
 }
@@ -72,8 +72,23 @@ type IContext interface {
 	// Close closes the IContext.
 	Close() error
 
+	// GetAuthInfo gets the authentication plugin and the credential of the user.
+	GetAuthInfo(user string) (plugin, credential string, err error)
+
 	// Auth verifies user's authentication.
 	Auth(user string, auth []byte, salt []byte) bool
+
+	// AuthWithoutVerification sets the user of an authenticated connection without verifying the password.
+	AuthWithoutVerification(user string) bool
+
+	// AcquireUserConnection counts the connection of the authenticated user for the resource limits of the user.
+	AcquireUserConnection(moved bool) error
+
+	// GetGlobalSysVar gets the value of the global system variable.
+	GetGlobalSysVar(name string) (string, error)
+
+	// GetSessionSysVar gets the session value of the TiDB system variable.
+	GetSessionSysVar(name string) (string, error)
 }
 
 // IStatement is the interface to use a prepared statement.
@@ -93,9 +108,15 @@ type IStatement interface {
 	// BoundParams returns bound parameters.
 	BoundParams() [][]byte
 
-	// Reset removes all bound parameters.
+	// Reset removes all bound parameters and closes the open cursor.
 	Reset()
 
+	// StoreResultSet stores the result set of the open cursor, the previous one is closed.
+	StoreResultSet(rs ResultSet)
+
+	// GetResultSet gets the result set of the open cursor, it returns nil if there is no open cursor.
+	GetResultSet() ResultSet
+
 	// Close closes the statement.
 	Close() error
 }
@@ -19,8 +19,11 @@ import (
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/db"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -39,10 +42,9 @@ func NewTiDBDriver(store kv.Storage) *TiDBDriver {
 
 // TiDBContext implements IContext.
 type TiDBContext struct {
-	session      tidb.Session
-	currentDB    string
-	warningCount uint16
-	stmts        map[int]*TiDBStatement
+	session   tidb.Session
+	currentDB string
+	stmts     map[int]*TiDBStatement
 }
 
 // TiDBStatement implements IStatement.
@@ -51,6 +53,8 @@ type TiDBStatement struct {
 	numParams   int
 	boundParams [][]byte
 	ctx         *TiDBContext
+	// rs is the result set of the open cursor.
+	rs ResultSet
 }
 
 // ID implements IStatement ID method.
@@ -97,11 +101,26 @@ func (ts *TiDBStatement) Reset() {
 	for i := range ts.boundParams {
 		ts.boundParams[i] = nil
 	}
+	ts.StoreResultSet(nil)
+}
+
+// StoreResultSet implements IStatement StoreResultSet method.
+func (ts *TiDBStatement) StoreResultSet(rs ResultSet) {
+	if ts.rs != nil {
+		ts.rs.Close()
+	}
+	ts.rs = rs
+}
+
+// GetResultSet implements IStatement GetResultSet method.
+func (ts *TiDBStatement) GetResultSet() ResultSet {
+	return ts.rs
 }
 
 // Close implements IStatement Close method.
 func (ts *TiDBStatement) Close() error {
 	//TODO close at tidb level
+	ts.StoreResultSet(nil)
 	err := ts.ctx.session.DropPreparedStmt(ts.id)
 	if err != nil {
 		return errors.Trace(err)
@@ -168,13 +187,17 @@ func (tc *TiDBContext) AffectedRows() uint64 {
 }
 
 // CurrentDB implements IContext CurrentDB method.
+// The database may be changed by USE statements, so it is read from the session.
 func (tc *TiDBContext) CurrentDB() string {
+	if ctx, ok := tc.session.(context.Context); ok {
+		return db.GetCurrentSchema(ctx)
+	}
 	return tc.currentDB
 }
 
 // WarningCount implements IContext WarningCount method.
 func (tc *TiDBContext) WarningCount() uint16 {
-	return tc.warningCount
+	return tc.session.WarningCount()
 }
 
 // Execute implements IContext Execute method.
@@ -202,14 +225,50 @@ func (tc *TiDBContext) SetClientCapability(flags uint32) {
 
 // Close implements IContext Close method.
 func (tc *TiDBContext) Close() (err error) {
+	for _, stmt := range tc.stmts {
+		stmt.StoreResultSet(nil)
+	}
 	return tc.session.Close()
 }
 
+// GetAuthInfo implements IContext GetAuthInfo method.
+func (tc *TiDBContext) GetAuthInfo(user string) (plugin, credential string, err error) {
+	return tc.session.GetAuthInfo(user)
+}
+
 // Auth implements IContext Auth method.
 func (tc *TiDBContext) Auth(user string, auth []byte, salt []byte) bool {
 	return tc.session.Auth(user, auth, salt)
 }
 
+// AuthWithoutVerification implements IContext AuthWithoutVerification method.
+func (tc *TiDBContext) AuthWithoutVerification(user string) bool {
+	return tc.session.AuthWithoutVerification(user)
+}
+
+// AcquireUserConnection implements IContext AcquireUserConnection method.
+func (tc *TiDBContext) AcquireUserConnection(moved bool) error {
+	return tc.session.AcquireUserConnection(moved)
+}
+
+// GetGlobalSysVar implements IContext GetGlobalSysVar method.
+func (tc *TiDBContext) GetGlobalSysVar(name string) (string, error) {
+	ctx, ok := tc.session.(context.Context)
+	if !ok {
+		return "", errors.Errorf("unknown system variable %s", name)
+	}
+	return variable.GetGlobalVarAccessor(ctx).GetGlobalSysVar(ctx, name)
+}
+
+// GetSessionSysVar implements IContext GetSessionSysVar method.
+func (tc *TiDBContext) GetSessionSysVar(name string) (string, error) {
+	ctx, ok := tc.session.(context.Context)
+	if !ok {
+		return "", errors.Errorf("unknown system variable %s", name)
+	}
+	return variable.GetSessionVars(ctx).GetTiDBSystemVar(ctx, name)
+}
+
 // FieldList implements IContext FieldList method.
 func (tc *TiDBContext) FieldList(table string) (colums []*ColumnInfo, err error) {
 	rs, err := tc.Execute("SELECT * FROM " + table + " LIMIT 0")
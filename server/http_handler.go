@@ -0,0 +1,292 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/tablecodec"
+)
+
+// The paths of the status HTTP API that inspect the storage.
+// The schema path is /schema[/db[/table]], the regions path is /tables/{db}/{table}/regions,
+// the MVCC path is /mvcc/key/{db}/{table}/{handle}, the DDL history path is /ddl/history[?limit=N].
+const (
+	pathSchema     = "/schema"
+	pathTables     = "/tables/"
+	pathMvccKey    = "/mvcc/key/"
+	pathDDLHistory = "/ddl/history"
+)
+
+// defaultDDLHistoryLimit is the number of the DDL jobs returned by /ddl/history if no limit is given.
+const defaultDDLHistoryLimit = 100
+
+// regionsMaxBackoff is the max sleep time in milliseconds of locating the Regions of a table.
+const regionsMaxBackoff = 20000
+
+// storeHandler serves the JSON endpoints that inspect the schema, the Regions and the MVCC data of the storage.
+type storeHandler struct {
+	store kv.Storage
+}
+
+// registerStoreHandlers registers the handlers that inspect the storage on the mux.
+func registerStoreHandlers(mux *http.ServeMux, store kv.Storage) {
+	h := &storeHandler{store: store}
+	mux.HandleFunc(pathSchema, h.handleSchema)
+	mux.HandleFunc(pathSchema+"/", h.handleSchema)
+	mux.HandleFunc(pathTables, h.handleTableRegions)
+	mux.HandleFunc(pathMvccKey, h.handleMvccKey)
+	mux.HandleFunc(pathDDLHistory, h.handleDDLHistory)
+}
+
+// splitPath splits the path after the prefix into its segments.
+func splitPath(path, prefix string) []string {
+	path = strings.Trim(strings.TrimPrefix(path, prefix), "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.WriteHeader(code)
+	_, err = w.Write([]byte(err.Error()))
+	if err != nil {
+		log.Error(errors.ErrorStack(err))
+	}
+}
+
+func writeData(w http.ResponseWriter, data interface{}) {
+	js, err := json.MarshalIndent(data, "", " ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(js)
+	if err != nil {
+		log.Error(errors.ErrorStack(err))
+	}
+}
+
+func (h *storeHandler) infoSchema() (infoschema.InfoSchema, error) {
+	dom, err := tidb.GetDomain(h.store)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return dom.InfoSchema(), nil
+}
+
+// getTable finds the table by the database name and the table name, it writes the error if the table is not found.
+func (h *storeHandler) getTable(w http.ResponseWriter, dbName, tableName string) (table.Table, bool) {
+	is, err := h.infoSchema()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	tbl, err := is.TableByName(model.NewCIStr(dbName), model.NewCIStr(tableName))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return nil, false
+	}
+	return tbl, true
+}
+
+// handleSchema serves /schema, /schema/{db} and /schema/{db}/{table}.
+// They return all the databases, the tables of a database and a table respectively.
+func (h *storeHandler) handleSchema(w http.ResponseWriter, req *http.Request) {
+	is, err := h.infoSchema()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	params := splitPath(req.URL.Path, pathSchema)
+	switch len(params) {
+	case 0:
+		writeData(w, is.AllSchemas())
+	case 1:
+		dbName := model.NewCIStr(params[0])
+		if !is.SchemaExists(dbName) {
+			writeError(w, http.StatusNotFound, infoschema.ErrDatabaseNotExists.Gen("Unknown database '%s'", params[0]))
+			return
+		}
+		tables := is.SchemaTables(dbName)
+		tableInfos := make([]*model.TableInfo, 0, len(tables))
+		for _, tbl := range tables {
+			tableInfos = append(tableInfos, tbl.Meta())
+		}
+		writeData(w, tableInfos)
+	case 2:
+		tbl, ok := h.getTable(w, params[0], params[1])
+		if ok {
+			writeData(w, tbl.Meta())
+		}
+	default:
+		writeError(w, http.StatusBadRequest, errors.Errorf("invalid path %s", req.URL.Path))
+	}
+}
+
+// regionMeta is the meta of a Region in the response of the regions API.
+type regionMeta struct {
+	ID          uint64              `json:"region_id"`
+	Leader      *metapb.Peer        `json:"leader"`
+	Peers       []*metapb.Peer      `json:"peers"`
+	RegionEpoch *metapb.RegionEpoch `json:"region_epoch"`
+}
+
+// indexRegions are the Regions of an index.
+type indexRegions struct {
+	Name    string       `json:"name"`
+	ID      int64        `json:"id"`
+	Regions []regionMeta `json:"regions"`
+}
+
+// tableRegions are the Regions of the records and the indices of a table.
+type tableRegions struct {
+	Name          string         `json:"name"`
+	ID            int64          `json:"id"`
+	RecordRegions []regionMeta   `json:"record_regions"`
+	Indices       []indexRegions `json:"indices"`
+}
+
+// handleTableRegions serves /tables/{db}/{table}/regions.
+func (h *storeHandler) handleTableRegions(w http.ResponseWriter, req *http.Request) {
+	params := splitPath(req.URL.Path, pathTables)
+	if len(params) != 3 || params[2] != "regions" {
+		writeError(w, http.StatusBadRequest, errors.Errorf("invalid path %s", req.URL.Path))
+		return
+	}
+	store, ok := h.store.(tikv.Storage)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, errors.New("only TiKV storage has Regions"))
+		return
+	}
+	tbl, ok := h.getTable(w, params[0], params[1])
+	if !ok {
+		return
+	}
+	tableInfo := tbl.Meta()
+	cache := store.GetRegionCache()
+	bo := tikv.NewBackoffer(regionsMaxBackoff)
+	result := &tableRegions{Name: tableInfo.Name.O, ID: tableInfo.ID}
+	recordPrefix := tablecodec.GenTableRecordPrefix(tableInfo.ID)
+	regions, err := cache.ListRegionsInKeyRange(bo, recordPrefix, recordPrefix.PrefixNext())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	result.RecordRegions = newRegionMetas(regions)
+	for _, index := range tableInfo.Indices {
+		indexPrefix := tablecodec.EncodeTableIndexPrefix(tableInfo.ID, index.ID)
+		regions, err = cache.ListRegionsInKeyRange(bo, indexPrefix, indexPrefix.PrefixNext())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		result.Indices = append(result.Indices, indexRegions{
+			Name:    index.Name.O,
+			ID:      index.ID,
+			Regions: newRegionMetas(regions),
+		})
+	}
+	writeData(w, result)
+}
+
+func newRegionMetas(regions []*tikv.Region) []regionMeta {
+	metas := make([]regionMeta, 0, len(regions))
+	for _, region := range regions {
+		meta := region.GetMeta()
+		metas = append(metas, regionMeta{
+			ID:          region.GetID(),
+			Leader:      region.GetLeader(),
+			Peers:       meta.GetPeers(),
+			RegionEpoch: meta.GetRegionEpoch(),
+		})
+	}
+	return metas
+}
+
+// handleMvccKey serves /mvcc/key/{db}/{table}/{handle}, it returns the lock and the versions of a row.
+// On TiKV, only the latest committed value is returned without its timestamps, "complete" is false
+// and "note" states what is missing. All the versions are returned only by mock-tikv.
+func (h *storeHandler) handleMvccKey(w http.ResponseWriter, req *http.Request) {
+	params := splitPath(req.URL.Path, pathMvccKey)
+	if len(params) != 3 {
+		writeError(w, http.StatusBadRequest, errors.Errorf("invalid path %s", req.URL.Path))
+		return
+	}
+	store, ok := h.store.(tikv.Storage)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, errors.New("only TiKV storage supports MVCC inspection"))
+		return
+	}
+	handle, err := strconv.ParseInt(params[2], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.Errorf("invalid handle %s", params[2]))
+		return
+	}
+	tbl, ok := h.getTable(w, params[0], params[1])
+	if !ok {
+		return
+	}
+	info, err := store.MvccGetByKey(tablecodec.EncodeRowKeyWithHandle(tbl.Meta().ID, handle))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeData(w, info)
+}
+
+// handleDDLHistory serves /ddl/history[?limit=N], it returns the last N finished DDL jobs, the newest is the first.
+// N is defaultDDLHistoryLimit by default.
+func (h *storeHandler) handleDDLHistory(w http.ResponseWriter, req *http.Request) {
+	limit := defaultDDLHistoryLimit
+	if s := req.FormValue("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, errors.Errorf("invalid limit %s", s))
+			return
+		}
+		limit = n
+	}
+	txn, err := h.store.Begin()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	jobs, err := meta.NewMeta(txn).GetLastNHistoryDDLJobs(limit)
+	if err != nil {
+		txn.Rollback()
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err = txn.Rollback(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeData(w, jobs)
+}
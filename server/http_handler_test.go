@@ -0,0 +1,147 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/store/tikv"
+)
+
+type HTTPHandlerTestSuite struct {
+	store  kv.Storage
+	server *httptest.Server
+}
+
+var _ = Suite(new(HTTPHandlerTestSuite))
+
+func (ts *HTTPHandlerTestSuite) SetUpSuite(c *C) {
+	store, err := tikv.NewMockTikvStore()
+	c.Assert(err, IsNil)
+	ts.store = store
+	tidb.SetSchemaLease(0)
+	se, err := tidb.CreateSession(store)
+	c.Assert(err, IsNil)
+	for _, sql := range []string{
+		"create database http_test",
+		"create table http_test.t (a int primary key, b int, index idx_b (b))",
+		"insert http_test.t values (1, 10), (2, 20)",
+		"update http_test.t set b = 11 where a = 1",
+	} {
+		_, err = se.Execute(sql)
+		c.Assert(err, IsNil, Commentf("sql %s", sql))
+	}
+	mux := http.NewServeMux()
+	registerStoreHandlers(mux, store)
+	ts.server = httptest.NewServer(mux)
+}
+
+func (ts *HTTPHandlerTestSuite) TearDownSuite(c *C) {
+	ts.server.Close()
+	ts.store.Close()
+}
+
+// get requests the path and decodes the JSON response into data, it returns the status code.
+func (ts *HTTPHandlerTestSuite) get(c *C, path string, data interface{}) int {
+	resp, err := http.Get(ts.server.URL + path)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		c.Assert(json.NewDecoder(resp.Body).Decode(data), IsNil)
+	}
+	return resp.StatusCode
+}
+
+func (ts *HTTPHandlerTestSuite) TestSchema(c *C) {
+	var dbs []*model.DBInfo
+	c.Assert(ts.get(c, "/schema", &dbs), Equals, http.StatusOK)
+	names := make(map[string]bool)
+	for _, db := range dbs {
+		names[db.Name.L] = true
+	}
+	c.Assert(names["http_test"], IsTrue)
+	c.Assert(names["mysql"], IsTrue)
+
+	var tables []*model.TableInfo
+	c.Assert(ts.get(c, "/schema/http_test", &tables), Equals, http.StatusOK)
+	c.Assert(tables, HasLen, 1)
+	c.Assert(tables[0].Name.L, Equals, "t")
+
+	var table model.TableInfo
+	c.Assert(ts.get(c, "/schema/http_test/t", &table), Equals, http.StatusOK)
+	c.Assert(table.Columns, HasLen, 2)
+	c.Assert(table.Indices, HasLen, 1)
+
+	c.Assert(ts.get(c, "/schema/no_such_db", nil), Equals, http.StatusNotFound)
+	c.Assert(ts.get(c, "/schema/http_test/no_such_table", nil), Equals, http.StatusNotFound)
+	c.Assert(ts.get(c, "/schema/http_test/t/x", nil), Equals, http.StatusBadRequest)
+}
+
+func (ts *HTTPHandlerTestSuite) TestRegions(c *C) {
+	var regions tableRegions
+	c.Assert(ts.get(c, "/tables/http_test/t/regions", &regions), Equals, http.StatusOK)
+	c.Assert(regions.Name, Equals, "t")
+	c.Assert(regions.RecordRegions, HasLen, 1)
+	c.Assert(regions.RecordRegions[0].Leader, NotNil)
+	c.Assert(regions.RecordRegions[0].Peers, HasLen, 1)
+	c.Assert(regions.Indices, HasLen, 1)
+	c.Assert(regions.Indices[0].Name, Equals, "idx_b")
+	c.Assert(regions.Indices[0].Regions, HasLen, 1)
+
+	c.Assert(ts.get(c, "/tables/http_test/t", nil), Equals, http.StatusBadRequest)
+	c.Assert(ts.get(c, "/tables/http_test/no_such_table/regions", nil), Equals, http.StatusNotFound)
+}
+
+func (ts *HTTPHandlerTestSuite) TestMvccKey(c *C) {
+	var info tikv.MvccKeyInfo
+	c.Assert(ts.get(c, "/mvcc/key/http_test/t/1", &info), Equals, http.StatusOK)
+	c.Assert(info.Complete, IsTrue)
+	c.Assert(info.Note, Equals, "")
+	c.Assert(info.Lock, IsNil)
+	c.Assert(info.Values, HasLen, 2)
+	c.Assert(info.Values[0].Type, Equals, "put")
+	c.Assert(info.Values[0].CommitTS > info.Values[1].CommitTS, IsTrue)
+
+	c.Assert(ts.get(c, "/mvcc/key/http_test/t/3", &info), Equals, http.StatusOK)
+	c.Assert(info.Values, HasLen, 0)
+
+	c.Assert(ts.get(c, "/mvcc/key/http_test/t/x", nil), Equals, http.StatusBadRequest)
+}
+
+func (ts *HTTPHandlerTestSuite) TestDDLHistory(c *C) {
+	var jobs []*model.Job
+	c.Assert(ts.get(c, "/ddl/history", &jobs), Equals, http.StatusOK)
+	var found bool
+	for _, job := range jobs {
+		c.Assert(job.IsFinished(), IsTrue)
+		if job.Type == model.ActionCreateTable {
+			found = true
+		}
+	}
+	c.Assert(found, IsTrue)
+
+	var last []*model.Job
+	c.Assert(ts.get(c, "/ddl/history?limit=1", &last), Equals, http.StatusOK)
+	c.Assert(last, HasLen, 1)
+	c.Assert(last[0].ID, Equals, jobs[0].ID)
+
+	c.Assert(ts.get(c, "/ddl/history?limit=x", nil), Equals, http.StatusBadRequest)
+	c.Assert(ts.get(c, "/ddl/history?limit=0", nil), Equals, http.StatusBadRequest)
+}
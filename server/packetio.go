@@ -54,6 +54,11 @@ type packetIO struct {
 	wb *bufio.Writer
 
 	sequence uint8
+
+	// compressedSequence is the sequence of the compressed packets, it is used after the compressed protocol is enabled.
+	compressedSequence uint8
+	// cw compresses the written packets, it is nil if the compressed protocol is not enabled.
+	cw *compressedWriter
 }
 
 func newPacketIO(conn net.Conn) *packetIO {
@@ -73,6 +78,10 @@ func (p *packetIO) readOnePacket() ([]byte, error) {
 	}
 
 	sequence := uint8(header[3])
+	// The sequence in a compressed packet is not checked, the client may sync it with the compressed sequence.
+	if p.cw != nil {
+		p.sequence = sequence
+	}
 	if sequence != p.sequence {
 		return nil, errInvalidSequence.Gen("invalid sequence %d != %d", sequence, p.sequence)
 	}
@@ -153,5 +162,24 @@ func (p *packetIO) writePacket(data []byte) error {
 }
 
 func (p *packetIO) flush() error {
-	return p.wb.Flush()
+	if err := p.wb.Flush(); err != nil {
+		return errors.Trace(err)
+	}
+	if p.cw != nil {
+		return errors.Trace(p.cw.flush())
+	}
+	return nil
+}
+
+// resetSequence resets the sequences at the beginning of a command.
+func (p *packetIO) resetSequence() {
+	p.sequence = 0
+	p.compressedSequence = 0
+}
+
+// setCompressed enables the compressed protocol, the packets are wrapped in compressed packets after it is called.
+func (p *packetIO) setCompressed() {
+	p.rb = bufio.NewReaderSize(&compressedReader{p: p, rb: p.rb}, defaultReaderSize)
+	p.cw = &compressedWriter{p: p, wb: p.wb}
+	p.wb = bufio.NewWriterSize(p.cw, defaultWriterSize)
 }
@@ -29,6 +29,7 @@
 package server
 
 import (
+	"crypto/rsa"
 	"encoding/json"
 	"math/rand"
 	"net"
@@ -69,6 +70,14 @@ type Server struct {
 	rwlock            *sync.RWMutex
 	concurrentLimiter *TokenLimiter
 	clients           map[uint32]*clientConn
+
+	// sha2Cache caches the users authenticated by caching_sha2_password for the fast authentication.
+	sha2Cache sha2Cache
+	// rsaKey is used to exchange the password of caching_sha2_password on insecure connections,
+	// it is generated at the first use.
+	rsaKeyOnce sync.Once
+	rsaKey     *rsa.PrivateKey
+	rsaKeyErr  error
 }
 
 // ConnectionCount gets current connection count.
@@ -80,6 +89,21 @@ func (s *Server) ConnectionCount() int {
 	return cnt
 }
 
+// addClient registers the client in the server if the connections don't exceed maxConnections,
+// a non-positive maxConnections means no limit. It returns false if the server is full.
+func (s *Server) addClient(cc *clientConn, maxConnections int64) bool {
+	s.rwlock.Lock()
+	if maxConnections > 0 && int64(len(s.clients)) >= maxConnections {
+		s.rwlock.Unlock()
+		return false
+	}
+	s.clients[cc.connectionID] = cc
+	connections := len(s.clients)
+	s.rwlock.Unlock()
+	connGauge.Set(float64(connections))
+	return true
+}
+
 func (s *Server) getToken() *Token {
 	return s.concurrentLimiter.Get()
 }
@@ -133,7 +157,6 @@ func NewServer(cfg *Config, driver IDriver) (*Server, error) {
 
 	var err error
 	if cfg.Socket != "" {
-		cfg.SkipAuth = true
 		s.listener, err = net.Listen("unix", cfg.Socket)
 	} else {
 		s.listener, err = net.Listen("tcp", s.cfg.Addr)
@@ -190,19 +213,14 @@ func (s *Server) onConn(c net.Conn) {
 		// Some keep alive services will send request to TiDB and disconnect immediately.
 		// So we use info log level.
 		log.Infof("handshake error %s", errors.ErrorStack(err))
-		c.Close()
+		// The connection slot and the session opened in the handshake are released.
+		conn.Close()
 		return
 	}
 	defer func() {
 		log.Infof("close %s", conn)
 	}()
 
-	s.rwlock.Lock()
-	s.clients[conn.connectionID] = conn
-	connections := len(s.clients)
-	s.rwlock.Unlock()
-	connGauge.Set(float64(connections))
-
 	conn.Run()
 }
 
@@ -231,6 +249,9 @@ func (s *Server) startStatusHTTP() {
 			})
 			// HTTP path for prometheus.
 			http.Handle("/metrics", prometheus.Handler())
+			if d, ok := s.driver.(*TiDBDriver); ok {
+				registerStoreHandlers(http.DefaultServeMux, d.store)
+			}
 			addr := s.cfg.StatusAddr
 			if len(addr) == 0 {
 				addr = defaultStatusAddr
@@ -224,8 +224,9 @@ xxx row4_col1	- 		900
 xxx row5_col1	- 	row5_col3`)
 	c.Assert(err, IsNil)
 
-	// support ClientLocalFiles capability
-	runTests(c, dsn+"&allowAllFiles=true", func(dbt *DBTest) {
+	// support ClientLocalFiles capability, the rows with duplicate keys are skipped with warnings,
+	// so the driver must not turn the warnings into errors.
+	runTests(c, strings.Replace(dsn, "strict=true", "strict=false", 1)+"&allowAllFiles=true", func(dbt *DBTest) {
 		dbt.mustExec("create table test (a varchar(255), b varchar(255) default 'default value', c int not null auto_increment, primary key(c))")
 		rs, err := dbt.db.Exec("load data local infile '/tmp/load_data_test.csv' into table test")
 		dbt.Assert(err, IsNil)
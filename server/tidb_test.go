@@ -15,11 +15,30 @@
 package server
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/arena"
+	"github.com/pingcap/tidb/util/audit"
 )
 
 type TidbTestSuite struct {
@@ -123,3 +142,419 @@ func (ts *TidbTestSuite) TestSocket(c *C) {
 	dsn = tcpDsn
 	server.Close()
 }
+
+// readPackets splits the written data into the payloads of the packets.
+func readPackets(c *C, data []byte) [][]byte {
+	var packets [][]byte
+	for len(data) > 0 {
+		c.Assert(len(data) >= 4, IsTrue)
+		length := int(uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16)
+		packets = append(packets, data[4:4+length])
+		data = data[4+length:]
+	}
+	return packets
+}
+
+// eofStatus checks the packet is an EOF packet and returns its status.
+func eofStatus(c *C, packet []byte) uint16 {
+	c.Assert(packet[0], Equals, mysql.EOFHeader)
+	return binary.LittleEndian.Uint16(packet[3:5])
+}
+
+func (ts *TidbTestSuite) TestCursorFetch(c *C) {
+	ctx, err := ts.tidbdrv.OpenCtx(0, mysql.ClientProtocol41, mysql.DefaultCollationID, "test")
+	c.Assert(err, IsNil)
+	defer ctx.Close()
+	_, err = ctx.Execute("create table cursor_fetch (a int, b int)")
+	c.Assert(err, IsNil)
+	_, err = ctx.Execute("insert cursor_fetch values (1, 1), (2, 2), (3, 3)")
+	c.Assert(err, IsNil)
+
+	var buf bytes.Buffer
+	cc := &clientConn{
+		pkt:        &packetIO{wb: bufio.NewWriter(&buf)},
+		server:     ts.server,
+		capability: mysql.ClientProtocol41,
+		alloc:      arena.NewAllocator(1024),
+		ctx:        ctx,
+	}
+	stmt, _, _, err := ctx.Prepare("select a, b from cursor_fetch order by a")
+	c.Assert(err, IsNil)
+	stmtID := make([]byte, 4)
+	binary.LittleEndian.PutUint32(stmtID, uint32(stmt.ID()))
+	fetch := func(numRows uint32) [][]byte {
+		buf.Reset()
+		data := append([]byte{}, stmtID...)
+		data = append(data, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(data[4:], numRows)
+		c.Assert(cc.handleStmtFetch(data), IsNil)
+		return readPackets(c, buf.Bytes())
+	}
+
+	// No rows are sent when the cursor is opened.
+	execute := append(append([]byte{}, stmtID...), mysql.CursorTypeReadOnly, 1, 0, 0, 0)
+	c.Assert(cc.handleStmtExecute(execute), IsNil)
+	packets := readPackets(c, buf.Bytes())
+	c.Assert(packets, HasLen, 4)
+	c.Assert(eofStatus(c, packets[3])&mysql.ServerStatusCursorExists, Equals, mysql.ServerStatusCursorExists)
+	c.Assert(stmt.GetResultSet(), NotNil)
+
+	packets = fetch(2)
+	c.Assert(packets, HasLen, 3)
+	status := eofStatus(c, packets[2])
+	c.Assert(status&mysql.ServerStatusCursorExists, Equals, mysql.ServerStatusCursorExists)
+	c.Assert(status&mysql.ServerStatusLastRowSend, Equals, uint16(0))
+
+	packets = fetch(2)
+	c.Assert(packets, HasLen, 2)
+	c.Assert(eofStatus(c, packets[1])&mysql.ServerStatusLastRowSend, Equals, mysql.ServerStatusLastRowSend)
+	c.Assert(stmt.GetResultSet(), IsNil)
+
+	data := append(append([]byte{}, stmtID...), 1, 0, 0, 0)
+	err = cc.handleStmtFetch(data)
+	c.Assert(err.(*mysql.SQLError).Code, Equals, uint16(mysql.ErrStmtHasNoOpenCursor))
+
+	// Reset closes the open cursor.
+	c.Assert(cc.handleStmtExecute(execute), IsNil)
+	c.Assert(stmt.GetResultSet(), NotNil)
+	stmt.Reset()
+	c.Assert(stmt.GetResultSet(), IsNil)
+
+	// The other statements don't close the open cursor, it is fetched between them.
+	dispatchExecute := append([]byte{mysql.ComStmtExecute}, execute...)
+	c.Assert(cc.dispatch(dispatchExecute), IsNil)
+	packets = fetch(1)
+	c.Assert(packets, HasLen, 2)
+	c.Assert(cc.dispatch(append([]byte{mysql.ComQuery}, "select 1"...)), IsNil)
+	c.Assert(stmt.GetResultSet(), NotNil)
+	packets = fetch(3)
+	c.Assert(packets, HasLen, 3)
+	c.Assert(eofStatus(c, packets[2])&mysql.ServerStatusLastRowSend, Equals, mysql.ServerStatusLastRowSend)
+
+	// A failed fetch closes the open cursor.
+	c.Assert(cc.dispatch(dispatchExecute), IsNil)
+	cc.pkt = &packetIO{wb: bufio.NewWriterSize(errorWriter{}, 16)}
+	c.Assert(cc.handleStmtFetch(data), NotNil)
+	c.Assert(stmt.GetResultSet(), IsNil)
+	cc.pkt = &packetIO{wb: bufio.NewWriter(&buf)}
+
+	// Closing the statement closes its cursor.
+	c.Assert(cc.dispatch(dispatchExecute), IsNil)
+	c.Assert(cc.dispatch(append([]byte{mysql.ComStmtClose}, stmtID...)), IsNil)
+	c.Assert(stmt.GetResultSet(), IsNil)
+}
+
+// errorWriter is an io.Writer that always fails.
+type errorWriter struct{}
+
+func (errorWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write error")
+}
+
+// queryUserVar returns the value of the user variable @a in the session.
+func queryUserVar(c *C, ctx IContext) interface{} {
+	rss, err := ctx.Execute("select @a")
+	c.Assert(err, IsNil)
+	row, err := rss[0].Next()
+	c.Assert(err, IsNil)
+	c.Assert(rss[0].Close(), IsNil)
+	return row[0].GetValue()
+}
+
+func (ts *TidbTestSuite) TestChangeUserAndResetConnection(c *C) {
+	ctx, err := ts.tidbdrv.OpenCtx(0, mysql.ClientProtocol41, mysql.DefaultCollationID, "test")
+	c.Assert(err, IsNil)
+	var buf bytes.Buffer
+	cc := &clientConn{
+		pkt:        &packetIO{wb: bufio.NewWriter(&buf)},
+		server:     &Server{cfg: &Config{SkipAuth: true}, driver: ts.tidbdrv},
+		capability: mysql.ClientProtocol41 | mysql.ClientSecureConnection,
+		user:       "root",
+		dbname:     "test",
+		collation:  mysql.DefaultCollationID,
+		alloc:      arena.NewAllocator(1024),
+		ctx:        ctx,
+	}
+	defer func() {
+		c.Assert(cc.ctx.Close(), IsNil)
+	}()
+
+	// The session variables, the prepared statements and the current database are kept by the old session.
+	_, err = cc.ctx.Execute("set @a = 1")
+	c.Assert(err, IsNil)
+	_, err = cc.ctx.Execute("use mysql")
+	c.Assert(err, IsNil)
+	stmt, _, _, err := cc.ctx.Prepare("select 1")
+	c.Assert(err, IsNil)
+	c.Assert(queryUserVar(c, cc.ctx), Equals, "1")
+
+	c.Assert(cc.handleResetConnection(), IsNil)
+	c.Assert(buf.Bytes()[4], Equals, mysql.OKHeader)
+	c.Assert(queryUserVar(c, cc.ctx), IsNil)
+	c.Assert(cc.ctx.GetStatement(stmt.ID()), IsNil)
+	c.Assert(cc.ctx.CurrentDB(), Equals, "mysql")
+
+	_, err = cc.ctx.Execute("set @a = 1")
+	c.Assert(err, IsNil)
+	buf.Reset()
+	// user "root", empty auth, database "test", collation utf8_general_ci.
+	data := append([]byte("root"), 0, 0)
+	data = append(data, "test"...)
+	data = append(data, 0, mysql.DefaultCollationID, 0)
+	c.Assert(cc.handleChangeUser(data), IsNil)
+	c.Assert(buf.Bytes()[4], Equals, mysql.OKHeader)
+	c.Assert(queryUserVar(c, cc.ctx), IsNil)
+	c.Assert(cc.ctx.CurrentDB(), Equals, "test")
+
+	c.Assert(errors.Cause(cc.handleChangeUser([]byte("root"))), Equals, mysql.ErrMalformPacket)
+}
+
+func (ts *TidbTestSuite) TestIdleTimeout(c *C) {
+	newClient := func(sqls ...string) *authClient {
+		cli := newAuthClient(c)
+		cli.writeHandshakeResponse("root", nil, mysql.AuthNativePassword)
+		c.Assert(cli.read()[0], Equals, mysql.OKHeader)
+		for _, sql := range sqls {
+			cli.pkt.resetSequence()
+			cli.write(append([]byte{mysql.ComQuery}, sql...))
+			c.Assert(cli.read()[0], Equals, mysql.OKHeader, Commentf("sql %s", sql))
+		}
+		return cli
+	}
+	// checkClosed checks the server closes the idle connection after about timeout.
+	checkClosed := func(cli *authClient, timeout time.Duration) {
+		defer cli.conn.Close()
+		start := time.Now()
+		c.Assert(cli.conn.SetReadDeadline(start.Add(timeout+3*time.Second)), IsNil)
+		_, err := cli.pkt.readPacket()
+		c.Assert(errors.Cause(err), Equals, io.EOF)
+		c.Assert(time.Since(start), Greater, timeout/2)
+	}
+
+	checkClosed(newClient("set @@wait_timeout = 1"), time.Second)
+	// The idle transaction timeout overrides wait_timeout in a transaction.
+	checkClosed(newClient("set @@wait_timeout = 100", "set @@tidb_idle_transaction_timeout = 1", "begin"), time.Second)
+}
+
+// authClient is a minimal client to test the authentication plugins, which are not supported by the driver.
+type authClient struct {
+	c    *C
+	conn net.Conn
+	pkt  *packetIO
+	salt []byte
+}
+
+func newAuthClient(c *C) *authClient {
+	conn, err := net.Dial("tcp", "127.0.0.1:4001")
+	c.Assert(err, IsNil)
+	cli := &authClient{c: c, conn: conn, pkt: newPacketIO(conn)}
+	data := cli.read()
+	// protocol version, server version, connection id.
+	pos := 1 + bytes.IndexByte(data[1:], 0) + 1 + 4
+	cli.salt = append(cli.salt, data[pos:pos+8]...)
+	// filler, capability, charset, status, capability, auth data length, reserved.
+	pos += 8 + 1 + 2 + 1 + 2 + 2 + 1 + 10
+	cli.salt = append(cli.salt, data[pos:pos+12]...)
+	pos += 13
+	c.Assert(string(data[pos:pos+bytes.IndexByte(data[pos:], 0)]), Equals, mysql.AuthNativePassword)
+	return cli
+}
+
+func (cli *authClient) read() []byte {
+	data, err := cli.pkt.readPacket()
+	cli.c.Assert(err, IsNil)
+	return data
+}
+
+func (cli *authClient) write(data []byte) {
+	cli.c.Assert(cli.pkt.writePacket(append(make([]byte, 4), data...)), IsNil)
+	cli.c.Assert(cli.pkt.flush(), IsNil)
+}
+
+func (cli *authClient) writeHandshakeResponse(user string, auth []byte, plugin string) {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, mysql.ClientProtocol41|mysql.ClientSecureConnection|mysql.ClientPluginAuth)
+	data = append(data, 0, 0, 0, 0, mysql.DefaultCollationID)
+	data = append(data, make([]byte, 23)...)
+	data = append(data, user...)
+	data = append(data, 0, byte(len(auth)))
+	data = append(data, auth...)
+	data = append(data, plugin...)
+	data = append(data, 0)
+	cli.write(data)
+}
+
+func sha2Scramble(pwd string, nonce []byte) []byte {
+	stage1 := util.Sha256Hash([]byte(pwd))
+	scramble := util.Sha256Hash(append(util.Sha256Hash(stage1), nonce...))
+	for i := range scramble {
+		scramble[i] ^= stage1[i]
+	}
+	return scramble
+}
+
+func (ts *TidbTestSuite) TestAuthPlugins(c *C) {
+	ctx, err := ts.tidbdrv.OpenCtx(0, mysql.ClientProtocol41, mysql.DefaultCollationID, "test")
+	c.Assert(err, IsNil)
+	defer ctx.Close()
+	_, err = ctx.Execute(`CREATE USER 'sha2user'@'%' IDENTIFIED WITH 'caching_sha2_password' BY 'sha2pwd'`)
+	c.Assert(err, IsNil)
+	_, err = ctx.Execute(`CREATE USER 'nativeuser'@'%' IDENTIFIED BY 'nativepwd'`)
+	c.Assert(err, IsNil)
+
+	// The first authentication is the full authentication, the password is encrypted by the public key.
+	cli := newAuthClient(c)
+	cli.writeHandshakeResponse("sha2user", sha2Scramble("sha2pwd", cli.salt), mysql.AuthCachingSha2Password)
+	c.Assert(cli.read(), DeepEquals, []byte{authMoreData, cachingSha2PerformFullAuth})
+	cli.write([]byte{cachingSha2RequestPublicKey})
+	data := cli.read()
+	c.Assert(data[0], Equals, authMoreData)
+	block, _ := pem.Decode(data[1:])
+	c.Assert(block, NotNil)
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	c.Assert(err, IsNil)
+	plain := []byte("sha2pwd\x00")
+	for i := range plain {
+		plain[i] ^= cli.salt[i%len(cli.salt)]
+	}
+	enc, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, pub.(*rsa.PublicKey), plain, nil)
+	c.Assert(err, IsNil)
+	cli.write(enc)
+	c.Assert(cli.read()[0], Equals, mysql.OKHeader)
+	cli.conn.Close()
+
+	// The user is cached, the scramble is verified in the fast authentication.
+	cli = newAuthClient(c)
+	cli.writeHandshakeResponse("sha2user", sha2Scramble("sha2pwd", cli.salt), mysql.AuthCachingSha2Password)
+	c.Assert(cli.read(), DeepEquals, []byte{authMoreData, cachingSha2FastAuthSuccess})
+	c.Assert(cli.read()[0], Equals, mysql.OKHeader)
+	cli.conn.Close()
+
+	cli = newAuthClient(c)
+	cli.writeHandshakeResponse("sha2user", sha2Scramble("wrongpwd", cli.salt), mysql.AuthCachingSha2Password)
+	c.Assert(cli.read()[0], Equals, mysql.ErrHeader)
+	cli.conn.Close()
+
+	// The client is asked to switch to the plugin of the user.
+	cli = newAuthClient(c)
+	cli.writeHandshakeResponse("nativeuser", sha2Scramble("nativepwd", cli.salt), mysql.AuthCachingSha2Password)
+	data = cli.read()
+	c.Assert(data[0], Equals, authSwitchRequest)
+	expect := append([]byte(mysql.AuthNativePassword), 0)
+	expect = append(expect, cli.salt...)
+	c.Assert(data[1:], DeepEquals, append(expect, 0))
+	cli.write(util.CalcPassword(cli.salt, util.Sha1Hash([]byte("nativepwd"))))
+	c.Assert(cli.read()[0], Equals, mysql.OKHeader)
+	cli.conn.Close()
+
+	// The unix socket peer credential is required by auth_socket.
+	_, err = ctx.Execute(`CREATE USER 'socketuser'@'%' IDENTIFIED WITH 'auth_socket'`)
+	c.Assert(err, IsNil)
+	cli = newAuthClient(c)
+	cli.writeHandshakeResponse("socketuser", nil, mysql.AuthNativePassword)
+	c.Assert(cli.read()[0], Equals, mysql.ErrHeader)
+	cli.conn.Close()
+}
+
+// errCode checks the packet is an error packet and returns its error code.
+func errCode(c *C, packet []byte) uint16 {
+	c.Assert(packet[0], Equals, mysql.ErrHeader)
+	return binary.LittleEndian.Uint16(packet[1:3])
+}
+
+func (ts *TidbTestSuite) TestConnectionLimits(c *C) {
+	ctx, err := ts.tidbdrv.OpenCtx(0, mysql.ClientProtocol41, mysql.DefaultCollationID, "test")
+	c.Assert(err, IsNil)
+	defer ctx.Close()
+	_, err = ctx.Execute(`CREATE USER 'connlimit'@'%' WITH MAX_USER_CONNECTIONS 1`)
+	c.Assert(err, IsNil)
+
+	cli := newAuthClient(c)
+	defer cli.conn.Close()
+	cli.writeHandshakeResponse("connlimit", nil, mysql.AuthNativePassword)
+	c.Assert(cli.read()[0], Equals, mysql.OKHeader)
+	// The connection is registered before it handles the commands.
+	cli.pkt.resetSequence()
+	cli.write([]byte{mysql.ComPing})
+	c.Assert(cli.read()[0], Equals, mysql.OKHeader)
+
+	cli2 := newAuthClient(c)
+	cli2.writeHandshakeResponse("connlimit", nil, mysql.AuthNativePassword)
+	c.Assert(errCode(c, cli2.read()), Equals, uint16(mysql.ErrTooManyUserConnections))
+	cli2.conn.Close()
+
+	_, err = ctx.Execute(`SET GLOBAL max_connections = 1`)
+	c.Assert(err, IsNil)
+	defer ctx.Execute(`SET GLOBAL max_connections = 151`)
+	cli2 = newAuthClient(c)
+	cli2.writeHandshakeResponse("root", nil, mysql.AuthNativePassword)
+	c.Assert(errCode(c, cli2.read()), Equals, uint16(mysql.ErrConCount))
+	cli2.conn.Close()
+
+	// The slot reserved before the authentication is released when the handshake fails.
+	_, err = ctx.Execute(`SET GLOBAL max_connections = 2`)
+	c.Assert(err, IsNil)
+	cli2 = newAuthClient(c)
+	cli2.writeHandshakeResponse("connlimit", nil, mysql.AuthNativePassword)
+	c.Assert(errCode(c, cli2.read()), Equals, uint16(mysql.ErrTooManyUserConnections))
+	cli2.conn.Close()
+	for i := 0; i < 50 && ts.server.ConnectionCount() > 1; i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	cli2 = newAuthClient(c)
+	cli2.writeHandshakeResponse("root", nil, mysql.AuthNativePassword)
+	c.Assert(cli2.read()[0], Equals, mysql.OKHeader)
+	cli2.conn.Close()
+}
+
+func (ts *TidbTestSuite) TestAuditConnection(c *C) {
+	ctx, err := ts.tidbdrv.OpenCtx(0, mysql.ClientProtocol41, mysql.DefaultCollationID, "test")
+	c.Assert(err, IsNil)
+	defer ctx.Close()
+	_, err = ctx.Execute(`CREATE USER 'audituser'@'%' IDENTIFIED BY 'auditpwd'`)
+	c.Assert(err, IsNil)
+	dir, err := ioutil.TempDir("", "audit")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "audit.log")
+	c.Assert(audit.SetFile(name, 0, 0), IsNil)
+	defer audit.SetFile("", 0, 0)
+	audit.SetFilter(&audit.Filter{Users: []string{"audituser"}})
+	defer audit.SetFilter(nil)
+
+	cli := newAuthClient(c)
+	cli.writeHandshakeResponse("audituser", util.CalcPassword(cli.salt, util.Sha1Hash([]byte("wrongpwd"))), mysql.AuthNativePassword)
+	c.Assert(errCode(c, cli.read()), Equals, uint16(mysql.ErrAccessDenied))
+	cli.conn.Close()
+	cli = newAuthClient(c)
+	cli.writeHandshakeResponse("audituser", util.CalcPassword(cli.salt, util.Sha1Hash([]byte("auditpwd"))), mysql.AuthNativePassword)
+	c.Assert(cli.read()[0], Equals, mysql.OKHeader)
+	cli.conn.Close()
+
+	// The connection is closed by the server after it reads EOF.
+	var records []*audit.Record
+	for i := 0; i < 50 && len(records) < 3; i++ {
+		time.Sleep(20 * time.Millisecond)
+		data, err := ioutil.ReadFile(name)
+		c.Assert(err, IsNil)
+		records = records[:0]
+		for _, line := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+			if len(line) == 0 {
+				continue
+			}
+			r := &audit.Record{}
+			c.Assert(json.Unmarshal(line, r), IsNil)
+			records = append(records, r)
+		}
+	}
+	c.Assert(records, HasLen, 3)
+	for i, event := range []string{audit.EventConnect, audit.EventConnect, audit.EventDisconnect} {
+		c.Assert(records[i].Event, Equals, event)
+		c.Assert(records[i].Class, Equals, audit.ClassConnection)
+		c.Assert(records[i].User, Equals, "audituser")
+		c.Assert(records[i].Host, Equals, "127.0.0.1")
+	}
+	c.Assert(records[0].ErrorCode, Equals, uint16(mysql.ErrAccessDenied))
+	c.Assert(records[1].ErrorCode, Equals, uint16(0))
+	c.Assert(records[1].ConnID, Equals, records[2].ConnID)
+}
@@ -21,6 +21,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -48,6 +50,8 @@ import (
 	"github.com/pingcap/tidb/store/localstore"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/audit"
+	"github.com/pingcap/tidb/util/slowlog"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -56,6 +60,7 @@ type Session interface {
 	Status() uint16                               // Flag of current status, such as autocommit.
 	LastInsertID() uint64                         // Last inserted auto_increment id.
 	AffectedRows() uint64                         // Affected rows by latest executed stmt.
+	WarningCount() uint16                         // Warnings, including errors and notes, of latest executed stmt.
 	SetValue(key fmt.Stringer, value interface{}) // SetValue saves a value associated with this session for key.
 	Value(key fmt.Stringer) interface{}           // Value returns the value associated with this session for key.
 	Execute(sql string) ([]ast.RecordSet, error)  // Execute a sql statement.
@@ -72,6 +77,15 @@ type Session interface {
 	Close() error
 	Retry() error
 	Auth(user string, auth []byte, salt []byte) bool
+	// AuthWithoutVerification sets the user of an authenticated connection without checking the password.
+	AuthWithoutVerification(user string) bool
+	// GetAuthInfo gets the authentication plugin and the credential of the user.
+	GetAuthInfo(user string) (plugin, credential string, err error)
+	// AcquireUserConnection counts the connection of the authenticated user for its resource limits,
+	// it fails if the connection exceeds the limits. The connection moved from another session of the same client,
+	// like the session reset by COM_RESET_CONNECTION, is counted without checking the limits.
+	// The connection is released when the session is closed.
+	AcquireUserConnection(moved bool) error
 }
 
 var (
@@ -127,6 +141,19 @@ type session struct {
 	// For performance_schema only.
 	stmtState *perfschema.StatementState
 	parser    *parser.Parser
+	// restrictedParser parses the restricted SQL with the default SQL mode, the SQL mode of the session
+	// like ANSI_QUOTES doesn't apply to the internal SQL.
+	restrictedParser *parser.Parser
+	// The start ts of the last transaction, for the slow log.
+	lastTxnStartTS uint64
+
+	// account is the user@host in mysql.user of the connection counted by AcquireUserConnection,
+	// the statements of the session are limited by the resources of the account.
+	account   string
+	resources userResources
+	// passwordExpired is set if the password of the authenticated user has expired,
+	// the session can only run SET PASSWORD until the password is changed.
+	passwordExpired bool
 }
 
 func (s *session) cleanRetryInfo() {
@@ -166,6 +193,14 @@ func (s *session) AffectedRows() uint64 {
 	return variable.GetSessionVars(s).AffectedRows
 }
 
+func (s *session) WarningCount() uint16 {
+	count := variable.GetSessionVars(s).WarningCount()
+	if count > math.MaxUint16 {
+		return math.MaxUint16
+	}
+	return uint16(count)
+}
+
 func (s *session) resetHistory() {
 	s.ClearValue(forupdate.ForUpdateKey)
 	s.history.reset()
@@ -180,6 +215,9 @@ func (s *session) SetConnectionID(connectionID uint64) {
 }
 
 func (s *session) finishTxn(rollback bool) error {
+	// The rows of LOAD DATA in the transaction can't be inserted again by a retry.
+	noRetry := s.Value(executor.LoadDataNoRetryKey) != nil
+	s.ClearValue(executor.LoadDataNoRetryKey)
 	// transaction has already been committed or rolled back
 	if s.txn == nil {
 		return nil
@@ -208,7 +246,7 @@ func (s *session) finishTxn(rollback bool) error {
 	}
 	err := s.txn.Commit()
 	if err != nil {
-		if !variable.GetSessionVars(s).RetryInfo.Retrying && kv.IsRetryableError(err) {
+		if !variable.GetSessionVars(s).RetryInfo.Retrying && !noRetry && kv.IsRetryableError(err) {
 			err = s.Retry()
 		}
 		if err != nil {
@@ -319,7 +357,7 @@ func (s *session) ExecRestrictedSQL(ctx context.Context, sql string) (ast.Record
 		return nil, errors.Trace(err)
 	}
 	charset, collation := getCtxCharsetInfo(s)
-	rawStmts, err := s.ParseSQL(sql, charset, collation)
+	rawStmts, err := s.restrictedParser.Parse(sql, charset, collation)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -346,29 +384,39 @@ func (s *session) ExecRestrictedSQL(ctx context.Context, sql string) (ast.Record
 // getExecRet executes restricted sql and the result is one column.
 // It returns a string value.
 func (s *session) getExecRet(ctx context.Context, sql string) (string, error) {
-	cleanTxn := s.txn == nil
-	rs, err := s.ExecRestrictedSQL(ctx, sql)
+	row, err := s.getExecRow(ctx, sql)
 	if err != nil {
 		return "", errors.Trace(err)
 	}
-	defer rs.Close()
-	row, err := rs.Next()
+	value, err := types.ToString(row[0].GetValue())
 	if err != nil {
 		return "", errors.Trace(err)
 	}
-	if row == nil {
-		return "", terror.ExecResultIsEmpty
+	return value, nil
+}
+
+// getExecRow executes the restricted sql and returns the first row, terror.ExecResultIsEmpty is returned if there is no row.
+func (s *session) getExecRow(ctx context.Context, sql string) ([]types.Datum, error) {
+	if s.txn == nil {
+		// This function has some side effect. Run select may create new txn.
+		// We should make environment unchanged, even if the result is empty.
+		defer func() {
+			s.txn = nil
+		}()
 	}
-	value, err := types.ToString(row.Data[0].GetValue())
+	rs, err := s.ExecRestrictedSQL(ctx, sql)
 	if err != nil {
-		return "", errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
-	if cleanTxn {
-		// This function has some side effect. Run select may create new txn.
-		// We should make environment unchanged.
-		s.txn = nil
+	defer rs.Close()
+	row, err := rs.Next()
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
-	return value, nil
+	if row == nil {
+		return nil, terror.ExecResultIsEmpty
+	}
+	return row.Data, nil
 }
 
 // GetGlobalSysVar implements GlobalVarAccessor.GetGlobalSysVar interface.
@@ -391,10 +439,31 @@ func (s *session) GetGlobalSysVar(ctx context.Context, name string) (string, err
 
 // SetGlobalSysVar implements GlobalVarAccessor.SetGlobalSysVar interface.
 func (s *session) SetGlobalSysVar(ctx context.Context, name string, value string) error {
+	name = strings.ToLower(name)
+	var ddlReorgVal int64
+	switch name {
+	case variable.TiDBDDLReorgWorkerCount, variable.TiDBDDLReorgBatchSize:
+		var err error
+		ddlReorgVal, err = strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return variable.ErrWrongTypeForVar.Gen("Incorrect argument type to variable '%s'", name)
+		}
+	}
 	sql := fmt.Sprintf(`UPDATE  %s.%s SET VARIABLE_VALUE="%s" WHERE VARIABLE_NAME="%s";`,
-		mysql.SystemDB, mysql.GlobalVariablesTable, value, strings.ToLower(name))
+		mysql.SystemDB, mysql.GlobalVariablesTable, value, name)
 	_, err := s.ExecRestrictedSQL(ctx, sql)
-	return errors.Trace(err)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// The DDL worker loads the global variables from the storage when a reorganization job starts or resumes,
+	// the values of this server are used if they can't be loaded.
+	switch name {
+	case variable.TiDBDDLReorgWorkerCount:
+		variable.SetDDLReorgWorkerCount(int32(ddlReorgVal))
+	case variable.TiDBDDLReorgBatchSize:
+		variable.SetDDLReorgBatchSize(int32(ddlReorgVal))
+	}
+	return nil
 }
 
 // IsAutocommit checks if it is in the auto-commit mode.
@@ -438,6 +507,7 @@ func (s *session) ShouldAutocommit(ctx context.Context) (bool, error) {
 }
 
 func (s *session) ParseSQL(sql, charset, collation string) ([]ast.StmtNode, error) {
+	s.parser.SetSQLMode(variable.GetSessionVars(s).SQLMode)
 	return s.parser.Parse(sql, charset, collation)
 }
 
@@ -447,35 +517,84 @@ func (s *session) Execute(sql string) ([]ast.RecordSet, error) {
 	}
 	startTS := time.Now()
 	charset, collation := getCtxCharsetInfo(s)
+	vars := variable.GetSessionVars(s)
 	rawStmts, err := s.ParseSQL(sql, charset, collation)
 	if err != nil {
 		log.Warnf("compiling %s, error: %v", sql, err)
+		s.auditStmt(sql, nil, 0, err)
+		vars.ResetWarnings()
+		vars.AppendError(err)
 		return nil, errors.Trace(err)
 	}
-	sessionExecuteParseDuration.Observe(time.Since(startTS).Seconds())
+	parseTime := time.Since(startTS)
+	sessionExecuteParseDuration.Observe(parseTime.Seconds())
 
 	var rs []ast.RecordSet
 	ph := sessionctx.GetDomain(s).PerfSchema()
 	for i, rst := range rawStmts {
 		startTS := time.Now()
+		vars.StmtDeadline = time.Time{}
+		if !isDiagnosticStmt(rst) {
+			vars.ResetWarnings()
+		}
+		stmtText := rst.Text()
+		if stmtText == "" {
+			stmtText = sql
+		}
+		if err1 := s.checkPasswordExpired(rst); err1 != nil {
+			s.auditStmt(stmtText, rst, 0, err1)
+			vars.AppendError(err1)
+			return nil, errors.Trace(err1)
+		}
+		if err1 := s.countStmt(rst); err1 != nil {
+			s.auditStmt(stmtText, rst, 0, err1)
+			vars.AppendError(err1)
+			return nil, errors.Trace(err1)
+		}
 		st, err1 := Compile(s, rst)
 		if err1 != nil {
 			log.Errorf("Syntax error: %s", sql)
 			log.Errorf("Error occurs at %s.", err1)
+			s.auditStmt(stmtText, rst, 0, err1)
+			vars.AppendError(err1)
 			return nil, errors.Trace(err1)
 		}
-		sessionExecuteCompileDuration.Observe(time.Since(startTS).Seconds())
-		id := variable.GetSessionVars(s).ConnectionID
-		s.stmtState = ph.StartStatement(sql, id, perfschema.CallerNameSessionExecute, rawStmts[i])
+		compileTime := time.Since(startTS)
+		sessionExecuteCompileDuration.Observe(compileTime.Seconds())
+		s.stmtState = ph.StartStatement(stmtText, vars.ConnectionID, perfschema.CallerNameSessionExecute, rawStmts[i])
+		s.stmtState.SetSchemaName(db.GetCurrentSchema(s))
 		s.SetValue(context.QueryString, sql)
+		info := &stmtExecInfo{
+			sql:           stmtText,
+			stmt:          rst,
+			startTime:     startTS.Add(-parseTime),
+			parseTime:     parseTime,
+			compileTime:   compileTime,
+			perfHandle:    ph,
+			perfState:     s.stmtState,
+			slowThreshold: s.slowLogThreshold(),
+		}
 
 		startTS = time.Now()
+		vars.StmtDeadline = s.stmtDeadline(info.startTime, rst)
 		r, err := runStmt(s, st)
-		ph.EndStatement(s.stmtState)
+		if r != nil && err == nil {
+			// The statement ends when the record set is drained and closed.
+			r = &stmtRecordSet{RecordSet: r, se: s, info: info}
+		} else {
+			s.finishStmt(info, vars.AffectedRows, 0, err)
+		}
 		if err != nil {
 			log.Warnf("session:%v, err:%v", s, err)
+			vars.AppendError(err)
 			return nil, errors.Trace(err)
 		}
+		if _, ok := rst.(*ast.SetPwdStmt); ok && s.passwordExpired {
+			if err = s.refreshPasswordExpired(); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		resourceTracker.forgetAccounts(rst)
 		sessionExecuteRunDuration.Observe(time.Since(startTS).Seconds())
 		if r != nil {
 			rs = append(rs, r)
@@ -494,6 +613,9 @@ func (s *session) PrepareStmt(sql string) (stmtID uint32, paramCount int, fields
 	if err := s.checkSchemaValidOrRollback(); err != nil {
 		return 0, 0, nil, errors.Trace(err)
 	}
+	if s.passwordExpired {
+		return 0, 0, nil, errors.Trace(mysql.NewErr(mysql.ErrMustChangePassword))
+	}
 	prepareExec := &executor.PrepareExec{
 		IS:      sessionctx.GetDomain(s).InfoSchema(),
 		Ctx:     s,
@@ -549,6 +671,155 @@ func checkArgs(args ...interface{}) error {
 	return nil
 }
 
+// stmtExecInfo is the runtime information of a statement, it is used to end the statement
+// in perfschema and write the slow log.
+type stmtExecInfo struct {
+	sql           string
+	stmt          ast.StmtNode
+	startTime     time.Time
+	parseTime     time.Duration
+	compileTime   time.Duration
+	perfHandle    perfschema.PerfSchema
+	perfState     *perfschema.StatementState
+	slowThreshold time.Duration
+}
+
+// slowLogThreshold returns the threshold of the slow log for the session, a negative value disables it.
+func (s *session) slowLogThreshold() time.Duration {
+	val, err := variable.GetSessionVars(s).GetTiDBSystemVar(s, variable.TiDBSlowLogThreshold)
+	if err != nil {
+		log.Debugf("[slowlog] get %s error: %v", variable.TiDBSlowLogThreshold, err)
+		return variable.DefSlowLogThreshold * time.Millisecond
+	}
+	threshold, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		log.Warnf("[slowlog] invalid %s %q", variable.TiDBSlowLogThreshold, val)
+		return variable.DefSlowLogThreshold * time.Millisecond
+	}
+	return time.Duration(threshold) * time.Millisecond
+}
+
+// stmtDeadline returns the time that the statement must finish before, it is zero if the statement has no limit.
+// Only the SELECT statements are limited, the MAX_EXECUTION_TIME hint overrides the max_execution_time variable.
+func (s *session) stmtDeadline(startTime time.Time, node ast.StmtNode) time.Time {
+	sel, ok := node.(*ast.SelectStmt)
+	if !ok {
+		return time.Time{}
+	}
+	for _, hint := range sel.TableHints {
+		if hint.HintName.L == "max_execution_time" {
+			if hint.MaxExecutionTime == 0 {
+				return time.Time{}
+			}
+			return startTime.Add(time.Duration(hint.MaxExecutionTime) * time.Millisecond)
+		}
+	}
+	val, err := variable.GetSessionVars(s).GetTiDBSystemVar(s, variable.MaxExecutionTime)
+	if err != nil {
+		log.Debugf("get %s error: %v", variable.MaxExecutionTime, err)
+		return time.Time{}
+	}
+	limit, err := strconv.ParseUint(val, 10, 64)
+	if err != nil || limit == 0 {
+		return time.Time{}
+	}
+	return startTime.Add(time.Duration(limit) * time.Millisecond)
+}
+
+// finishStmt ends the statement in perfschema, writes it to the audit log,
+// and writes it to the slow log if it runs longer than the threshold.
+func (s *session) finishStmt(info *stmtExecInfo, affected, sent uint64, stmtErr error) {
+	vars := variable.GetSessionVars(s)
+	vars.StmtDeadline = time.Time{}
+	if stmtErr != nil {
+		info.perfState.SetError()
+	}
+	info.perfState.SetRows(affected, sent, vars.ExaminedRows)
+	info.perfHandle.EndStatement(info.perfState)
+	s.auditStmt(info.sql, info.stmt, affected, stmtErr)
+
+	queryTime := time.Since(info.startTime)
+	if info.slowThreshold < 0 || queryTime <= info.slowThreshold {
+		return
+	}
+	txnStartTS := s.lastTxnStartTS
+	if s.txn != nil {
+		txnStartTS = s.txn.StartTS()
+	}
+	_, digest := parser.NormalizeDigest(info.sql)
+	err := slowlog.Write(&slowlog.Entry{
+		StartTime:   info.startTime,
+		TxnStartTS:  txnStartTS,
+		ConnID:      vars.ConnectionID,
+		User:        vars.User,
+		DB:          db.GetCurrentSchema(s),
+		QueryTime:   queryTime,
+		ParseTime:   info.parseTime,
+		CompileTime: info.compileTime,
+		CopTime:     vars.GetCopTime(),
+		Rows:        affected + sent,
+		Digest:      digest,
+		SQL:         parser.RedactAuthStrings(info.sql),
+	})
+	if err != nil {
+		log.Errorf("[slowlog] write error: %v", errors.ErrorStack(err))
+	}
+}
+
+// auditStmt writes the statement to the audit log, stmtErr is the error that the statement fails with.
+func (s *session) auditStmt(sql string, node ast.StmtNode, affected uint64, stmtErr error) {
+	if !audit.Enabled() {
+		return
+	}
+	vars := variable.GetSessionVars(s)
+	_, digest := parser.NormalizeDigest(sql)
+	r := &audit.Record{
+		Event:        audit.EventQuery,
+		ConnID:       vars.ConnectionID,
+		DB:           db.GetCurrentSchema(s),
+		Class:        audit.StmtClass(node),
+		Digest:       digest,
+		SQL:          parser.RedactAuthStrings(sql),
+		AffectedRows: affected,
+		ErrorCode:    audit.ErrorCode(stmtErr),
+	}
+	r.SetUser(vars.User)
+	if err := audit.Write(r); err != nil {
+		log.Errorf("[audit] write error: %v", errors.ErrorStack(err))
+	}
+}
+
+// stmtRecordSet wraps the record set of a statement, the statement ends when the record set is closed.
+type stmtRecordSet struct {
+	ast.RecordSet
+	se       *session
+	info     *stmtExecInfo
+	rowsSent uint64
+	err      error
+	ended    bool
+}
+
+// Next implements ast.RecordSet Next interface.
+func (rs *stmtRecordSet) Next() (*ast.Row, error) {
+	row, err := rs.RecordSet.Next()
+	if err != nil {
+		rs.err = err
+	} else if row != nil {
+		rs.rowsSent++
+	}
+	return row, errors.Trace(err)
+}
+
+// Close implements ast.RecordSet Close interface.
+func (rs *stmtRecordSet) Close() error {
+	err := rs.RecordSet.Close()
+	if !rs.ended {
+		rs.ended = true
+		rs.se.finishStmt(rs.info, 0, rs.rowsSent, rs.err)
+	}
+	return errors.Trace(err)
+}
+
 // ExecutePreparedStmt executes a prepared statement.
 func (s *session) ExecutePreparedStmt(stmtID uint32, args ...interface{}) (ast.RecordSet, error) {
 	if err := s.checkSchemaValidOrRollback(); err != nil {
@@ -558,11 +829,82 @@ func (s *session) ExecutePreparedStmt(stmtID uint32, args ...interface{}) (ast.R
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	startTS := time.Now()
 	st := executor.CompileExecutePreparedStmt(s, stmtID, args...)
+	vars := variable.GetSessionVars(s)
+	vars.ResetWarnings()
+	vars.StmtDeadline = time.Time{}
+	prepared, ok := vars.PreparedStmts[stmtID].(*executor.Prepared)
+	if !ok {
+		// The statement is not found, running it returns the error.
+		r, err := runStmt(s, st, args...)
+		if err != nil {
+			vars.AppendError(err)
+		}
+		return r, errors.Trace(err)
+	}
+	stmtText := prepared.Stmt.Text()
+	if err = s.checkPasswordExpired(prepared.Stmt); err != nil {
+		s.auditStmt(stmtText, prepared.Stmt, 0, err)
+		vars.AppendError(err)
+		return nil, errors.Trace(err)
+	}
+	if err = s.countStmt(prepared.Stmt); err != nil {
+		s.auditStmt(stmtText, prepared.Stmt, 0, err)
+		vars.AppendError(err)
+		return nil, errors.Trace(err)
+	}
+	ph := sessionctx.GetDomain(s).PerfSchema()
+	s.stmtState = ph.StartStatement(stmtText, vars.ConnectionID, perfschema.CallerNameSessionExecutePrepared, prepared.Stmt)
+	s.stmtState.SetSchemaName(db.GetCurrentSchema(s))
+	info := &stmtExecInfo{
+		sql:           stmtText,
+		stmt:          prepared.Stmt,
+		startTime:     startTS,
+		perfHandle:    ph,
+		perfState:     s.stmtState,
+		slowThreshold: s.slowLogThreshold(),
+	}
+
+	vars.StmtDeadline = s.stmtDeadline(info.startTime, prepared.Stmt)
 	r, err := runStmt(s, st, args...)
+	if r != nil && err == nil {
+		// The statement ends when the record set is drained and closed.
+		r = &stmtRecordSet{RecordSet: r, se: s, info: info}
+	} else {
+		s.finishStmt(info, vars.AffectedRows, 0, err)
+	}
+	if err != nil {
+		vars.AppendError(err)
+	}
 	return r, errors.Trace(err)
 }
 
+// isDiagnosticStmt checks whether the statement reads the warnings of the previous statement,
+// like SHOW WARNINGS and SELECT @@warning_count, the warnings are not cleared before it runs.
+func isDiagnosticStmt(node ast.StmtNode) bool {
+	switch x := node.(type) {
+	case *ast.ShowStmt:
+		return x.Tp == ast.ShowWarnings || x.Tp == ast.ShowErrors
+	case *ast.SelectStmt:
+		if x.From != nil || x.Fields == nil {
+			return false
+		}
+		for _, field := range x.Fields.Fields {
+			v, ok := field.Expr.(*ast.VariableExpr)
+			if !ok || !v.IsSystem {
+				return false
+			}
+			name := strings.ToLower(v.Name)
+			if name != variable.WarningCount && name != variable.ErrorCount {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
 func (s *session) DropPreparedStmt(stmtID uint32) error {
 	if err := s.checkSchemaValidOrRollback(); err != nil {
 		return errors.Trace(err)
@@ -619,6 +961,7 @@ func (s *session) GetTxn(forceNew bool) (kv.Transaction, error) {
 	if retryInfo.Retrying {
 		s.txn.SetOption(kv.RetryAttempts, retryInfo.Attempts)
 	}
+	s.lastTxnStartTS = s.txn.StartTS()
 	return s.txn, nil
 }
 
@@ -637,25 +980,56 @@ func (s *session) ClearValue(key fmt.Stringer) {
 
 // Close function does some clean work when session end.
 func (s *session) Close() error {
+	if s.account != "" {
+		resourceTracker.disconnect(s.account)
+		s.account = ""
+	}
 	log.Info("RollbackTxn for session close.")
 	return s.RollbackTxn()
 }
 
-func (s *session) getPassword(name, host string) (string, error) {
-	// Get password for name and host.
-	authSQL := fmt.Sprintf("SELECT Password FROM %s.%s WHERE User='%s' and Host='%s';", mysql.SystemDB, mysql.UserTable, name, host)
-	pwd, err := s.getExecRet(s, authSQL)
-	if err == nil {
-		return pwd, nil
-	} else if !terror.ExecResultIsEmpty.Equal(err) {
-		return "", errors.Trace(err)
+// getUserRow gets the columns of the user from mysql.user, the user for any host(%) is used if there is
+// no user for the host. It returns the host of the user in mysql.user.
+func (s *session) getUserRow(name, host, columns string) ([]types.Datum, string, error) {
+	sql := fmt.Sprintf("SELECT %s FROM %s.%s WHERE User='%s' and Host='%s';", columns, mysql.SystemDB, mysql.UserTable, name, host)
+	row, err := s.getExecRow(s, sql)
+	if terror.ExecResultIsEmpty.Equal(err) {
+		//Try to get the user with any host(%).
+		host = "%"
+		sql = fmt.Sprintf("SELECT %s FROM %s.%s WHERE User='%s' and Host='%%';", columns, mysql.SystemDB, mysql.UserTable, name)
+		row, err = s.getExecRow(s, sql)
+	}
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	return row, host, nil
+}
+
+// getAuthInfo gets the authentication plugin and the credential of the user. The credential is the password
+// for mysql_native_password, otherwise it is the authentication string.
+func (s *session) getAuthInfo(name, host string) (plugin, credential string, err error) {
+	row, _, err := s.getUserRow(name, host, "plugin, Password, authentication_string")
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+	plugin = row[0].GetString()
+	if plugin == "" || plugin == mysql.AuthNativePassword {
+		return mysql.AuthNativePassword, row[1].GetString(), nil
+	}
+	return plugin, row[2].GetString(), nil
+}
+
+// GetAuthInfo implements Session GetAuthInfo interface.
+func (s *session) GetAuthInfo(user string) (plugin, credential string, err error) {
+	strs := strings.Split(user, "@")
+	if len(strs) != 2 {
+		return "", "", errors.Errorf("invalid format for user: %s", user)
 	}
-	//Try to get user password for name with any host(%).
-	authSQL = fmt.Sprintf("SELECT Password FROM %s.%s WHERE User='%s' and Host='%%';", mysql.SystemDB, mysql.UserTable, name)
-	pwd, err = s.getExecRet(s, authSQL)
-	return pwd, errors.Trace(err)
+	plugin, credential, err = s.getAuthInfo(strs[0], strs[1])
+	return plugin, credential, errors.Trace(err)
 }
 
+// Auth implements Session Auth interface, it verifies the scramble of mysql_native_password.
 func (s *session) Auth(user string, auth []byte, salt []byte) bool {
 	strs := strings.Split(user, "@")
 	if len(strs) != 2 {
@@ -665,7 +1039,7 @@ func (s *session) Auth(user string, auth []byte, salt []byte) bool {
 	// Get user password.
 	name := strs[0]
 	host := strs[1]
-	pwd, err := s.getPassword(name, host)
+	plugin, pwd, err := s.getAuthInfo(name, host)
 	if err != nil {
 		if terror.ExecResultIsEmpty.Equal(err) {
 			log.Errorf("User [%s] not exist %v", name, err)
@@ -674,6 +1048,10 @@ func (s *session) Auth(user string, auth []byte, salt []byte) bool {
 		}
 		return false
 	}
+	if plugin != mysql.AuthNativePassword {
+		log.Errorf("User [%s] is identified with %s", name, plugin)
+		return false
+	}
 	if len(pwd) != 0 && len(pwd) != 40 {
 		log.Errorf("User [%s] password from SystemDB not like a sha1sum", name)
 		return false
@@ -687,10 +1065,169 @@ func (s *session) Auth(user string, auth []byte, salt []byte) bool {
 	if !bytes.Equal(auth, checkAuth) {
 		return false
 	}
-	variable.GetSessionVars(s).SetCurrentUser(user)
+	return s.setAuthenticatedUser(user, name, host)
+}
+
+// AuthWithoutVerification implements Session AuthWithoutVerification interface.
+// It is used to set the user verified by the authentication plugin, or restore the user when the session of
+// an authenticated connection is reset, the user must still exist.
+func (s *session) AuthWithoutVerification(user string) bool {
+	strs := strings.Split(user, "@")
+	if len(strs) != 2 {
+		log.Warnf("Invalid format for user: %s", user)
+		return false
+	}
+	if _, _, err := s.getAuthInfo(strs[0], strs[1]); err != nil {
+		log.Errorf("Get User [%s] password from SystemDB error %v", strs[0], err)
+		return false
+	}
+	return s.setAuthenticatedUser(user, strs[0], strs[1])
+}
+
+// setAuthenticatedUser sets the current user of the session if the account of the user is not locked,
+// the default roles of the account are activated.
+func (s *session) setAuthenticatedUser(user, name, host string) bool {
+	accountHost, locked, expired, err := s.getAccountStatus(name, host)
+	if err != nil {
+		log.Errorf("Get the account status of [%s] error %v", user, err)
+		return false
+	}
+	if locked {
+		log.Warnf("User [%s] is locked", user)
+		return false
+	}
+	if s.txn == nil {
+		// Like getExecRow, the transaction created by loading the roles is not kept.
+		defer func() {
+			s.txn = nil
+		}()
+	}
+	roles, err := executor.LoadDefaultRoles(s, name, accountHost)
+	if err != nil {
+		log.Errorf("Get the default roles of [%s] error %v", user, err)
+		return false
+	}
+	s.passwordExpired = expired
+	vars := variable.GetSessionVars(s)
+	vars.SetCurrentUser(user)
+	vars.ActiveRoles = roles
 	return true
 }
 
+// getAccountStatus checks whether the account of the user is locked and whether its password has expired,
+// it returns the host of the account in mysql.user.
+// The password expires if it is marked as expired, or it is older than the password lifetime of the account,
+// the lifetime is default_password_lifetime if the account doesn't set it, and zero means never expiring.
+func (s *session) getAccountStatus(name, host string) (accountHost string, locked, expired bool, err error) {
+	row, accountHost, err := s.getUserRow(name, host, "account_locked, password_expired, password_last_changed, password_lifetime")
+	if err != nil {
+		return "", false, false, errors.Trace(err)
+	}
+	lockedStr, err := row[0].ToString()
+	if err != nil {
+		return "", false, false, errors.Trace(err)
+	}
+	expiredStr, err := row[1].ToString()
+	if err != nil {
+		return "", false, false, errors.Trace(err)
+	}
+	if lockedStr == "Y" || expiredStr == "Y" || row[2].IsNull() {
+		return accountHost, lockedStr == "Y", expiredStr == "Y", nil
+	}
+	var lifetime int64
+	if row[3].IsNull() {
+		value, err1 := s.GetGlobalSysVar(s, variable.DefaultPasswordLifetime)
+		if err1 != nil {
+			return "", false, false, errors.Trace(err1)
+		}
+		if value != "" {
+			if lifetime, err1 = strconv.ParseInt(value, 10, 64); err1 != nil {
+				return "", false, false, errors.Trace(err1)
+			}
+		}
+	} else if lifetime, err = row[3].ToInt64(); err != nil {
+		return "", false, false, errors.Trace(err)
+	}
+	if lifetime <= 0 {
+		return accountHost, false, false, nil
+	}
+	lastChanged := row[2].GetMysqlTime().Time
+	return accountHost, false, time.Since(lastChanged) >= time.Duration(lifetime)*24*time.Hour, nil
+}
+
+// refreshPasswordExpired rechecks the password of the current user after it may be changed.
+func (s *session) refreshPasswordExpired() error {
+	strs := strings.Split(variable.GetSessionVars(s).User, "@")
+	if len(strs) != 2 {
+		return nil
+	}
+	_, _, expired, err := s.getAccountStatus(strs[0], strs[1])
+	if err != nil {
+		return errors.Trace(err)
+	}
+	s.passwordExpired = expired
+	return nil
+}
+
+// checkPasswordExpired checks whether the statement can run, only SET PASSWORD is allowed if the password
+// of the current user has expired.
+func (s *session) checkPasswordExpired(node ast.StmtNode) error {
+	if !s.passwordExpired {
+		return nil
+	}
+	if _, ok := node.(*ast.SetPwdStmt); ok {
+		return nil
+	}
+	return mysql.NewErr(mysql.ErrMustChangePassword)
+}
+
+// AcquireUserConnection implements Session AcquireUserConnection interface.
+func (s *session) AcquireUserConnection(moved bool) error {
+	user := variable.GetSessionVars(s).User
+	strs := strings.Split(user, "@")
+	if len(strs) != 2 {
+		return errors.Errorf("invalid format for user: %s", user)
+	}
+	row, host, err := s.getUserRow(strs[0], strs[1], "max_questions, max_updates, max_connections, max_user_connections")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	res := userResources{
+		maxQuestions:       row[0].GetInt64(),
+		maxUpdates:         row[1].GetInt64(),
+		maxConnections:     row[2].GetInt64(),
+		maxUserConnections: row[3].GetInt64(),
+	}
+	// The global max_user_connections is used if the account doesn't limit its connections.
+	maxUserConnections := res.maxUserConnections
+	if maxUserConnections == 0 {
+		value, err1 := s.GetGlobalSysVar(s, variable.MaxUserConnections)
+		if err1 != nil {
+			return errors.Trace(err1)
+		}
+		if maxUserConnections, err1 = strconv.ParseInt(value, 10, 64); err1 != nil {
+			return errors.Trace(err1)
+		}
+	}
+	account := fmt.Sprintf("%s@%s", strs[0], host)
+	if err = resourceTracker.connect(account, res, maxUserConnections, moved); err != nil {
+		return errors.Trace(err)
+	}
+	if s.account != "" {
+		resourceTracker.disconnect(s.account)
+	}
+	s.account, s.resources = account, res
+	return nil
+}
+
+// countStmt counts the statement for the resource limits of the account of the session.
+func (s *session) countStmt(node ast.StmtNode) error {
+	if s.account == "" {
+		return nil
+	}
+	return errors.Trace(resourceTracker.countStmt(s.account, s.resources, isUpdateStmt(node)))
+}
+
 // Some vars name for debug.
 const (
 	retryEmptyHistoryList = "RetryEmptyHistoryList"
@@ -712,6 +1249,8 @@ func CreateSession(store kv.Storage) (Session, error) {
 		debugInfos:  make(map[string]interface{}),
 		maxRetryCnt: 10,
 		parser:      parser.New(),
+
+		restrictedParser: parser.New(),
 	}
 	domain, err := domap.Get(store)
 	if err != nil {
@@ -762,7 +1301,7 @@ func CreateSession(store kv.Storage) (Session, error) {
 
 const (
 	notBootstrapped         = 0
-	currentBootstrapVersion = 2
+	currentBootstrapVersion = 14
 )
 
 func getStoreBootstrapVersion(store kv.Storage) int64 {
@@ -14,7 +14,11 @@
 package tidb
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
@@ -23,13 +27,19 @@ import (
 
 	"github.com/juju/errors"
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/audit"
+	"github.com/pingcap/tidb/util/slowlog"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -401,6 +411,43 @@ func (s *testSessionSuite) TestRowLock(c *C) {
 	c.Assert(err, IsNil)
 }
 
+// The rows that LOAD DATA has committed in batches are not dropped or inserted again by a retry.
+func (s *testSessionSuite) TestLoadDataRetry(c *C) {
+	defer testleak.AfterTest(c)()
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	se1 := newSession(c, store, s.dbName)
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, "create table t (c1 int primary key, c2 int)")
+
+	ctx := se.(context.Context)
+	tbl, err := sessionctx.GetDomain(ctx).InfoSchema().TableByName(model.NewCIStr(s.dbName), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	ld := executor.NewLoadDataInfo(make([]types.Datum, 2), ctx, tbl)
+	ld.FieldsInfo = &ast.FieldsClause{Terminated: "\t"}
+	ld.LinesInfo = &ast.LinesClause{Terminated: "\n"}
+	ld.BatchSize = 2
+
+	// The first row of a batch is inserted, then se1 inserts the second row, so the batch conflicts.
+	_, err = ld.InsertData(nil, []byte("1\t1\n"))
+	c.Assert(err, IsNil)
+	mustExecSQL(c, se1, "insert t values (2, 20)")
+	_, err = ld.InsertData(nil, []byte("2\t2\n"))
+	c.Assert(kv.IsRetryableError(err), IsTrue)
+	mustExecMatch(c, se, "select * from t", [][]interface{}{{2, 20}})
+
+	// The transactions without LOAD DATA are still retried.
+	mustExecSQL(c, se, "begin")
+	mustExecSQL(c, se, "update t set c2 = c2 + 1 where c1 = 2")
+	mustExecSQL(c, se1, "update t set c2 = c2 + 10 where c1 = 2")
+	mustExecSQL(c, se, "commit")
+	mustExecMatch(c, se, "select * from t", [][]interface{}{{2, 31}})
+
+	mustExecSQL(c, se, s.dropDBSQL)
+	err = store.Close()
+	c.Assert(err, IsNil)
+}
+
 func (s *testSessionSuite) TestIssue1118(c *C) {
 	defer testleak.AfterTest(c)()
 	store := newStore(c, s.dbName)
@@ -2448,3 +2495,388 @@ func (s *testSessionSuite) TestSelectHaving(c *C) {
 	mustExecMultiSQL(c, se, "select * from select_having_test group by id having null is not null;")
 	mustExecMultiSQL(c, se, "drop table select_having_test")
 }
+
+func (s *testSessionSuite) TestSetDDLReorgVars(c *C) {
+	defer testleak.AfterTest(c)()
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	defer func() {
+		variable.SetDDLReorgWorkerCount(variable.DefDDLReorgWorkerCount)
+		variable.SetDDLReorgBatchSize(variable.DefDDLReorgBatchSize)
+	}()
+
+	mustExecMatch(c, se, "select @@global.tidb_ddl_reorg_worker_cnt, @@global.tidb_ddl_reorg_batch_size", [][]interface{}{{"4", "1024"}})
+	mustExecSQL(c, se, "set global tidb_ddl_reorg_worker_cnt = 8")
+	mustExecSQL(c, se, "set @@global.tidb_ddl_reorg_batch_size = 100")
+	c.Assert(variable.GetDDLReorgWorkerCount(), Equals, int32(8))
+	c.Assert(variable.GetDDLReorgBatchSize(), Equals, int32(100))
+	mustExecMatch(c, se, "select @@global.tidb_ddl_reorg_worker_cnt, @@global.tidb_ddl_reorg_batch_size", [][]interface{}{{"8", "100"}})
+
+	_, err := exec(se, "set global tidb_ddl_reorg_worker_cnt = 'abc'")
+	c.Assert(terror.ErrorEqual(err, variable.ErrWrongTypeForVar), IsTrue)
+	c.Assert(variable.GetDDLReorgWorkerCount(), Equals, int32(8))
+	_, err = exec(se, "set tidb_ddl_reorg_batch_size = 10")
+	c.Assert(err, NotNil)
+
+	err = store.Close()
+	c.Assert(err, IsNil)
+}
+
+func (s *testSessionSuite) TestSlowLog(c *C) {
+	defer testleak.AfterTest(c)()
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	dir, err := ioutil.TempDir("", "slowlog")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	err = slowlog.SetFile(filepath.Join(dir, "slow.log"))
+	c.Assert(err, IsNil)
+	defer slowlog.SetFile("")
+
+	mustExecMatch(c, se, "select @@tidb_slow_log_threshold", [][]interface{}{{"300"}})
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, "create table t (a int)")
+	mustExecSQL(c, se, "insert t values (1), (2)")
+	mustExecMatch(c, se, "select count(*) from information_schema.slow_query", [][]interface{}{{"0"}})
+
+	mustExecSQL(c, se, "set tidb_slow_log_threshold = -1")
+	mustExecSQL(c, se, "insert t values (3)")
+	mustExecMatch(c, se, "select count(*) from information_schema.slow_query", [][]interface{}{{"0"}})
+	mustExecSQL(c, se, "set tidb_slow_log_threshold = 0")
+	mustExecSQL(c, se, "insert t values (4), (5)")
+	mustExecMatch(c, se, "select * from t where a > 3", [][]interface{}{{"4"}, {"5"}})
+	_, digest := parser.NormalizeDigest("insert t values (4), (5)")
+	mustExecMatch(c, se, "select conn_id, user, db, `rows`, digest, query from information_schema.slow_query where query like 'insert%'",
+		[][]interface{}{{"0", "root@%", s.dbName, "2", digest, "insert t values (4), (5);"}})
+	r := mustExecSQL(c, se, "select txn_start_ts, query_time >= parse_time + compile_time, `rows` from information_schema.slow_query where query = 'select * from t where a > 3;'")
+	row, err := r.Next()
+	c.Assert(err, IsNil)
+	c.Assert(row, NotNil)
+	c.Assert(row.Data[0].GetUint64(), Greater, uint64(0))
+	c.Assert(row.Data[1].GetInt64(), Equals, int64(1))
+	c.Assert(row.Data[2].GetUint64(), Equals, uint64(2))
+	r.Close()
+
+	// The prepared statements are logged too.
+	id, _, _, err := se.PrepareStmt("select * from t where a = ?")
+	c.Assert(err, IsNil)
+	r, err = se.ExecutePreparedStmt(id, 5)
+	c.Assert(err, IsNil)
+	_, err = GetRows(r)
+	c.Assert(err, IsNil)
+	mustExecMatch(c, se, "select `rows` from information_schema.slow_query where query = 'select * from t where a = ?;'",
+		[][]interface{}{{"1"}})
+
+	// The passwords are redacted.
+	mustExecSQL(c, se, "create user 'slow'@'localhost' identified by 'secret'")
+	mustExecMatch(c, se, "select query from information_schema.slow_query where query like 'create user%'",
+		[][]interface{}{{"create user 'slow'@'localhost' identified by '***';"}})
+
+	// The users without the PROCESS privilege only see their own statements, the hosts must match too.
+	mustExecSQL(c, se, "create user 'slow'@'otherhost'")
+	se1 := newSession(c, store, s.dbName)
+	variable.GetSessionVars(se1.(context.Context)).User = "slow@localhost"
+	mustExecSQL(c, se1, "set tidb_slow_log_threshold = 0")
+	mustExecMatch(c, se1, "select 1", [][]interface{}{{"1"}})
+	mustExecMatch(c, se1, "select user, query from information_schema.slow_query",
+		[][]interface{}{{"slow@localhost", "select 1;"}})
+	se2 := newSession(c, store, s.dbName)
+	variable.GetSessionVars(se2.(context.Context)).User = "slow@otherhost"
+	mustExecMatch(c, se2, "select count(*) from information_schema.slow_query", [][]interface{}{{"0"}})
+	mustExecSQL(c, se, "grant process on *.* to 'slow'@'otherhost'")
+	se3 := newSession(c, store, s.dbName)
+	variable.GetSessionVars(se3.(context.Context)).User = "slow@otherhost"
+	mustExecMatch(c, se3, "select count(*) > 2 from information_schema.slow_query where user = 'root@%'", [][]interface{}{{"1"}})
+
+	err = store.Close()
+	c.Assert(err, IsNil)
+}
+
+func (s *testSessionSuite) TestAuditLog(c *C) {
+	defer testleak.AfterTest(c)()
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	dir, err := ioutil.TempDir("", "audit")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "audit.log")
+	err = audit.SetFile(name, 0, 0)
+	c.Assert(err, IsNil)
+	defer audit.SetFile("", 0, 0)
+
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, "create table t (a int)")
+	mustExecSQL(c, se, "insert t values (1), (2)")
+	mustExecSQL(c, se, "select * from t")
+	mustExecSQL(c, se, "create user 'audit'@'%' identified by 'audit_pwd1'; create table t1 (a int)")
+	mustExecSQL(c, se, "set password for 'audit'@'%' = 'audit_pwd2'")
+	_, err = exec(se, "create table t (a int)")
+	c.Assert(err, NotNil)
+	_, err = exec(se, "create user 'audit2'@'%' identified by 'audit_pwd3' xx")
+	c.Assert(err, NotNil)
+
+	data, err := ioutil.ReadFile(name)
+	c.Assert(err, IsNil)
+	// The passwords are not written to the audit log.
+	c.Assert(strings.Contains(string(data), "audit_pwd"), IsFalse)
+	var records []*audit.Record
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		r := &audit.Record{}
+		c.Assert(json.Unmarshal([]byte(line), r), IsNil)
+		if r.Event == audit.EventQuery || r.Event == audit.EventDDLJob {
+			records = append(records, r)
+		}
+	}
+	type result struct {
+		event, class, sql string
+		errCode           uint16
+	}
+	var results []result
+	for _, r := range records {
+		c.Assert(r.User, Equals, "root")
+		c.Assert(r.Host, Equals, "%")
+		c.Assert(r.DB, Equals, s.dbName)
+		results = append(results, result{r.Event, r.Class, r.SQL, r.ErrorCode})
+	}
+	c.Assert(results, DeepEquals, []result{
+		{audit.EventQuery, audit.ClassDDL, "drop table if exists t", 0},
+		{audit.EventDDLJob, audit.ClassDDL, "create table t (a int)", 0},
+		{audit.EventQuery, audit.ClassDDL, "create table t (a int)", 0},
+		{audit.EventQuery, audit.ClassDCL, "create user 'audit'@'%' identified by '***';", 0},
+		{audit.EventDDLJob, audit.ClassDDL, "create user 'audit'@'%' identified by '***'; create table t1 (a int)", 0},
+		{audit.EventQuery, audit.ClassDDL, " create table t1 (a int)", 0},
+		{audit.EventQuery, audit.ClassDCL, "set password for 'audit'@'%' = '***'", 0},
+		{audit.EventQuery, audit.ClassDDL, "create table t (a int)", mysql.ErrTableExists},
+		{audit.EventQuery, audit.ClassInvalid, "create user 'audit2'@'%' identified by '***' xx", mysql.ErrUnknown},
+	})
+	_, digest := parser.NormalizeDigest("create table t (a int)")
+	c.Assert(records[1].Digest, Equals, digest)
+	c.Assert(records[1].JobID, Greater, int64(0))
+	c.Assert(records[1].JobType, Equals, "create table")
+
+	err = store.Close()
+	c.Assert(err, IsNil)
+}
+
+func (s *testSessionSuite) TestAccountLockAndPasswordExpire(c *C) {
+	defer testleak.AfterTest(c)()
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	defer se.Close()
+	mustExecSQL(c, se, `CREATE USER 'locked'@'%' ACCOUNT LOCK`)
+	mustExecSQL(c, se, `CREATE USER 'expired'@'%' PASSWORD EXPIRE`)
+	mustExecSQL(c, se, `CREATE USER 'rotated'@'%' PASSWORD EXPIRE INTERVAL 90 DAY`)
+
+	se1 := newSession(c, store, s.dbName)
+	defer se1.Close()
+	c.Assert(se1.Auth("locked@localhost", nil, nil), IsFalse)
+	c.Assert(se1.AuthWithoutVerification("locked@localhost"), IsFalse)
+	mustExecSQL(c, se, `ALTER USER 'locked'@'%' ACCOUNT UNLOCK`)
+	c.Assert(se1.AuthWithoutVerification("locked@localhost"), IsTrue)
+
+	// Only SET PASSWORD is allowed with the expired password.
+	c.Assert(se1.AuthWithoutVerification("expired@localhost"), IsTrue)
+	_, err := se1.Execute("select 1")
+	c.Assert(terror.ErrorEqual(err, mysql.NewErr(mysql.ErrMustChangePassword)), IsTrue, Commentf("%v", err))
+	_, _, _, err = se1.PrepareStmt("select 1")
+	c.Assert(terror.ErrorEqual(err, mysql.NewErr(mysql.ErrMustChangePassword)), IsTrue, Commentf("%v", err))
+	mustExecSQL(c, se1, "SET PASSWORD = 'new'")
+	mustExecSQL(c, se1, "select 1")
+
+	// The password expires after the lifetime.
+	c.Assert(se1.AuthWithoutVerification("rotated@localhost"), IsTrue)
+	mustExecSQL(c, se1, "select 1")
+	mustExecSQL(c, se, `UPDATE mysql.user SET password_last_changed = DATE_SUB(NOW(), INTERVAL 91 DAY) WHERE User = 'rotated'`)
+	c.Assert(se1.AuthWithoutVerification("rotated@localhost"), IsTrue)
+	_, err = se1.Execute("select 1")
+	c.Assert(err, NotNil)
+	// The lifetime of the account overrides default_password_lifetime.
+	mustExecSQL(c, se, `ALTER USER 'rotated'@'%' PASSWORD EXPIRE DEFAULT`)
+	c.Assert(se1.AuthWithoutVerification("rotated@localhost"), IsTrue)
+	mustExecSQL(c, se1, "select 1")
+	mustExecSQL(c, se, "SET GLOBAL default_password_lifetime = 90")
+	c.Assert(se1.AuthWithoutVerification("rotated@localhost"), IsTrue)
+	_, err = se1.Execute("select 1")
+	c.Assert(err, NotNil)
+	mustExecSQL(c, se, "SET GLOBAL default_password_lifetime = 0")
+
+	mustExecSQL(c, se, `DROP USER 'locked'@'%', 'expired'@'%', 'rotated'@'%'`)
+	err = store.Close()
+	c.Assert(err, IsNil)
+}
+
+func (s *testSessionSuite) TestUserResources(c *C) {
+	defer testleak.AfterTest(c)()
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	defer se.Close()
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, "create table t (a int)")
+	mustExecSQL(c, se, `CREATE USER 'limited'@'%' WITH MAX_QUERIES_PER_HOUR 3 MAX_UPDATES_PER_HOUR 1
+		MAX_CONNECTIONS_PER_HOUR 2 MAX_USER_CONNECTIONS 1`)
+
+	now := time.Now()
+	resourceTracker.now = func() time.Time { return now }
+	defer func() {
+		resourceTracker.now = time.Now
+	}()
+	connect := func(moved bool) (Session, error) {
+		se1, err := CreateSession(store)
+		c.Assert(err, IsNil)
+		c.Assert(se1.AuthWithoutVerification("limited@localhost"), IsTrue)
+		err = se1.AcquireUserConnection(moved)
+		if err != nil {
+			se1.Close()
+			return nil, err
+		}
+		return se1, nil
+	}
+	sqlErrCode := func(err error) uint16 {
+		c.Assert(err, NotNil)
+		e, ok := errors.Cause(err).(*mysql.SQLError)
+		c.Assert(ok, IsTrue, Commentf("%v", err))
+		return e.Code
+	}
+
+	se1, err := connect(false)
+	c.Assert(err, IsNil)
+	_, err = connect(false)
+	c.Assert(sqlErrCode(err), Equals, uint16(mysql.ErrTooManyUserConnections))
+	// The connection moved from another session is not limited.
+	se2, err := connect(true)
+	c.Assert(err, IsNil)
+	c.Assert(se2.Close(), IsNil)
+
+	mustExecSQL(c, se1, "use "+s.dbName)
+	_, err = se1.Execute("insert t values (1)")
+	c.Assert(err, IsNil)
+	_, err = se1.Execute("insert t values (2)")
+	c.Assert(sqlErrCode(err), Equals, uint16(mysql.ErrUserLimitReached))
+	c.Assert(err.Error(), Matches, ".*'max_updates' resource.*")
+	_, err = se1.Execute("select * from t")
+	c.Assert(err, IsNil)
+	_, err = se1.Execute("select * from t")
+	c.Assert(sqlErrCode(err), Equals, uint16(mysql.ErrUserLimitReached))
+	c.Assert(err.Error(), Matches, ".*'max_questions' resource.*")
+	// The sessions without an account are not limited.
+	mustExecSQL(c, se, "select * from t")
+
+	c.Assert(se1.Close(), IsNil)
+	se1, err = connect(false)
+	c.Assert(err, IsNil)
+	c.Assert(se1.Close(), IsNil)
+	_, err = connect(false)
+	c.Assert(sqlErrCode(err), Equals, uint16(mysql.ErrUserLimitReached))
+	c.Assert(err.Error(), Matches, ".*'max_connections_per_hour' resource.*")
+
+	// The hourly limits are reset after an hour.
+	now = now.Add(time.Hour)
+	se1, err = connect(false)
+	c.Assert(err, IsNil)
+	mustExecSQL(c, se1, "select 1")
+	c.Assert(se1.Close(), IsNil)
+
+	// The usage of the dropped account is removed.
+	hasUsage := func(account string) bool {
+		resourceTracker.mu.Lock()
+		defer resourceTracker.mu.Unlock()
+		_, ok := resourceTracker.usages[account]
+		return ok
+	}
+	c.Assert(hasUsage("limited@%"), IsTrue)
+	mustExecSQL(c, se, "drop user 'limited'@'%'")
+	c.Assert(hasUsage("limited@%"), IsFalse)
+	err = store.Close()
+	c.Assert(err, IsNil)
+}
+
+func (s *testSessionSuite) TestUserResourceTrackerPrune(c *C) {
+	defer testleak.AfterTest(c)()
+	t := newUserResourceTracker()
+	now := time.Now()
+	t.now = func() time.Time { return now }
+	res := userResources{}
+
+	c.Assert(t.connect("idle@%", res, 0, false), IsNil)
+	t.disconnect("idle@%")
+	c.Assert(t.connect("active@%", res, 0, false), IsNil)
+	c.Assert(t.connect("closing@%", res, 0, false), IsNil)
+	// The usage is kept within its hour, so the hourly limits still apply after the account disconnects.
+	c.Assert(t.usages, HasLen, 3)
+
+	// The usages are removed after their hour has passed if the accounts have no connections.
+	now = now.Add(time.Hour)
+	c.Assert(t.countStmt("active@%", userResources{maxQuestions: 10}, false), IsNil)
+	c.Assert(t.usages, HasLen, 2)
+	c.Assert(t.usages["active@%"], NotNil)
+	c.Assert(t.usages["closing@%"], NotNil)
+	now = now.Add(time.Hour)
+	t.disconnect("closing@%")
+	c.Assert(t.usages, HasLen, 1)
+	c.Assert(t.usages["active@%"].active, Equals, int64(1))
+
+	// The usages of the dropped and the renamed accounts are removed.
+	c.Assert(t.connect("renamed@%", res, 0, false), IsNil)
+	t.forgetAccounts(&ast.RenameUserStmt{UserToUsers: []*ast.UserToUser{{OldUser: "renamed@%", NewUser: "new@%"}}})
+	t.forgetAccounts(&ast.DropUserStmt{UserList: []string{"active@%"}})
+	c.Assert(t.usages, HasLen, 0)
+	// The connection of the dropped account is released without its usage.
+	t.disconnect("active@%")
+	c.Assert(t.usages, HasLen, 0)
+}
+
+func (s *testSessionSuite) TestMaxExecutionTime(c *C) {
+	defer testleak.AfterTest(c)()
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	defer se.Close()
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, "create table t (a int)")
+	mustExecSQL(c, se, "insert t values (1), (2)")
+
+	// The first row takes 100ms, so the statement is interrupted before the second row.
+	checkTimeout := func(sql string, timeout bool) {
+		rs, err := se.Execute(sql)
+		c.Assert(err, IsNil)
+		_, err = rs[0].Next()
+		c.Assert(err, IsNil)
+		_, err = rs[0].Next()
+		if timeout {
+			c.Assert(terror.ErrorEqual(err, kv.ErrQueryTimeout), IsTrue, Commentf("sql %s, err %v", sql, err))
+			c.Assert(audit.ErrorCode(err), Equals, uint16(mysql.ErrQueryTimeout))
+		} else {
+			c.Assert(err, IsNil, Commentf("sql %s", sql))
+		}
+		c.Assert(rs[0].Close(), IsNil)
+	}
+	checkTimeout("select /*+ MAX_EXECUTION_TIME(50) */ sleep(0.1), a from t", true)
+	checkTimeout("select sleep(0.1), a from t", false)
+	// The deadline is got when the statement starts, the statements after it don't change it.
+	checkTimeout("select /*+ MAX_EXECUTION_TIME(50) */ sleep(0.1), a from t; set @a = 1", true)
+	id, _, _, err := se.PrepareStmt("select /*+ MAX_EXECUTION_TIME(50) */ sleep(0.1), a from t")
+	c.Assert(err, IsNil)
+	rs, err := se.ExecutePreparedStmt(id)
+	c.Assert(err, IsNil)
+	_, err = rs.Next()
+	c.Assert(err, IsNil)
+	mustExecSQL(c, se, "set @a = 1")
+	_, err = rs.Next()
+	c.Assert(terror.ErrorEqual(err, kv.ErrQueryTimeout), IsTrue, Commentf("err %v", err))
+	c.Assert(rs.Close(), IsNil)
+	mustExecSQL(c, se, "set @@max_execution_time = 50")
+	checkTimeout("select sleep(0.1), a from t", true)
+	// The hint overrides the variable.
+	checkTimeout("select /*+ MAX_EXECUTION_TIME(0) */ sleep(0.1), a from t", false)
+	// Only the SELECT statements are limited.
+	mustExecSQL(c, se, "insert t select sleep(0.1) from t")
+	mustExecSQL(c, se, "set @@max_execution_time = 0")
+	checkTimeout("select sleep(0.1), a from t", false)
+
+	_, err = exec(se, "set @@max_execution_time = -1")
+	c.Assert(err, NotNil)
+	_, err = exec(se, "set @@wait_timeout = 'abc'")
+	c.Assert(err, NotNil)
+
+	err = store.Close()
+	c.Assert(err, IsNil)
+}
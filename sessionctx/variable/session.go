@@ -14,13 +14,17 @@
 package variable
 
 import (
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -102,15 +106,49 @@ type SessionVars struct {
 	// Found rows
 	FoundRows uint64
 
+	// Rows examined by the current statement
+	ExaminedRows uint64
+
+	// Time in nanoseconds waiting for the coprocessor responses of the current statement, accessed atomically.
+	copTime int64
+
+	// StmtDeadline is the time that the current statement must finish before, zero means no limit.
+	// It is set by max_execution_time or the MAX_EXECUTION_TIME hint of a SELECT statement, the executors
+	// get it when the statement starts.
+	StmtDeadline time.Time
+
+	// warnMu protects the warnings and their counts, the executors running in goroutines like the join workers
+	// append warnings concurrently.
+	warnMu sync.Mutex
+	// warnings are the warnings of the current statement, they are kept for SHOW WARNINGS.
+	warnings []SQLWarn
+	// warningCount and errorCount count all the warnings and errors of the current statement,
+	// including those not kept because of MaxErrorCount.
+	warningCount int
+	errorCount   int
+
+	// MaxErrorCount is the maximum number of warnings kept for SHOW WARNINGS, it is the max_error_count variable.
+	MaxErrorCount int
+
 	// Current user
 	User string
 
+	// ActiveRoles is the roles activated by SET ROLE or the default roles of the current user, in the format of
+	// name@host, the privileges of the active roles are granted to the current user.
+	ActiveRoles []string
+
 	// Strict SQL mode
 	StrictSQLMode bool
 
+	// SQLMode is the flag set parsed from the sql_mode variable.
+	SQLMode mysql.SQLMode
+
 	// InUpdateStmt indicates if the session is handling update stmt.
 	InUpdateStmt bool
 
+	// InInsertStmt indicates if the session is handling insert or replace stmt.
+	InInsertStmt bool
+
 	// InRestrictedSQL indicates if the session is handling restricted SQL execution.
 	InRestrictedSQL bool
 
@@ -120,6 +158,9 @@ type SessionVars struct {
 	// SnapshotInfoschema is used with SnapshotTS, when the schema version at snapshotTS less than current schema
 	// version, we load an old version schema for query.
 	SnapshotInfoschema interface{}
+
+	// MemTracker tracks the memory usage of the session, statement trackers are attached to it.
+	MemTracker *memory.Tracker
 }
 
 // sessionVarsKeyType is a dummy type to avoid naming collision in context.
@@ -141,6 +182,9 @@ func BindSessionVars(ctx context.Context) {
 		PreparedStmtNameToID: make(map[string]uint32),
 		RetryInfo:            &RetryInfo{},
 		StrictSQLMode:        true,
+		MaxErrorCount:        DefMaxErrorCount,
+		SQLMode:              mysql.ModeStrictTransTables | mysql.ModeNoEngineSubstitution,
+		MemTracker:           memory.NewTracker("session", -1),
 	}
 	ctx.SetValue(sessionVarsKey, v)
 }
@@ -202,6 +246,97 @@ func (s *SessionVars) AddFoundRows(rows uint64) {
 	s.FoundRows += rows
 }
 
+// AddExaminedRows adds examined rows with the argument rows.
+func (s *SessionVars) AddExaminedRows(rows uint64) {
+	s.ExaminedRows += rows
+}
+
+// AddCopTime adds the time waiting for the coprocessor responses of the current statement.
+func (s *SessionVars) AddCopTime(d time.Duration) {
+	atomic.AddInt64(&s.copTime, int64(d))
+}
+
+// GetCopTime gets the time waiting for the coprocessor responses of the current statement.
+func (s *SessionVars) GetCopTime() time.Duration {
+	return time.Duration(atomic.LoadInt64(&s.copTime))
+}
+
+// ResetStmtExecStats resets the execution statistics of the current statement.
+func (s *SessionVars) ResetStmtExecStats() {
+	s.ExaminedRows = 0
+	atomic.StoreInt64(&s.copTime, 0)
+}
+
+// Levels of the statement warnings.
+const (
+	WarnLevelError   = "Error"
+	WarnLevelWarning = "Warning"
+	WarnLevelNote    = "Note"
+)
+
+// SQLWarn relates a warning of the statement and its level.
+type SQLWarn struct {
+	Level string
+	Err   error
+}
+
+// AppendWarning appends a warning to the current statement.
+func (s *SessionVars) AppendWarning(warn error) {
+	s.appendWarn(WarnLevelWarning, warn)
+}
+
+// AppendNote appends a note to the current statement.
+func (s *SessionVars) AppendNote(note error) {
+	s.appendWarn(WarnLevelNote, note)
+}
+
+// AppendError appends the error of the current statement, SHOW ERRORS returns it.
+func (s *SessionVars) AppendError(err error) {
+	s.appendWarn(WarnLevelError, err)
+}
+
+func (s *SessionVars) appendWarn(level string, err error) {
+	s.warnMu.Lock()
+	defer s.warnMu.Unlock()
+	if level == WarnLevelError {
+		s.errorCount++
+	}
+	s.warningCount++
+	if len(s.warnings) < s.MaxErrorCount {
+		s.warnings = append(s.warnings, SQLWarn{Level: level, Err: err})
+	}
+}
+
+// GetWarnings returns the warnings of the current statement.
+func (s *SessionVars) GetWarnings() []SQLWarn {
+	s.warnMu.Lock()
+	defer s.warnMu.Unlock()
+	return append([]SQLWarn(nil), s.warnings...)
+}
+
+// WarningCount returns the number of the errors, warnings and notes of the current statement.
+func (s *SessionVars) WarningCount() int {
+	s.warnMu.Lock()
+	defer s.warnMu.Unlock()
+	return s.warningCount
+}
+
+// ErrorCount returns the number of the errors of the current statement.
+func (s *SessionVars) ErrorCount() int {
+	s.warnMu.Lock()
+	defer s.warnMu.Unlock()
+	return s.errorCount
+}
+
+// ResetWarnings clears the warnings, it is called before a statement other than SHOW WARNINGS runs.
+func (s *SessionVars) ResetWarnings() {
+	s.warnMu.Lock()
+	defer s.warnMu.Unlock()
+	s.warnings = nil
+	s.warningCount = 0
+	s.errorCount = 0
+}
+
 // SetStatusFlag sets the session server status variable.
 // If on is ture sets the flag in session status,
 // otherwise removes the flag.
@@ -246,10 +381,23 @@ func (s *SessionVars) SetSystemVar(key string, value types.Datum) error {
 	}
 	if key == sqlMode {
 		sVal = strings.ToUpper(sVal)
-		if strings.Contains(sVal, "STRICT_TRANS_TABLES") || strings.Contains(sVal, "STRICT_ALL_TABLES") {
-			s.StrictSQLMode = true
-		} else {
-			s.StrictSQLMode = false
+		mode, err := mysql.GetSQLMode(sVal)
+		if err != nil {
+			return ErrWrongValueForVar.Gen("Variable '%s' can't be set to the value of '%s'", key, sVal)
+		}
+		s.SQLMode = mode
+		s.StrictSQLMode = mode.HasStrictMode()
+	} else if key == WarningCount || key == ErrorCount {
+		return errors.Errorf("Variable '%s' is a read only variable", key)
+	} else if key == MaxErrorCount {
+		n, err1 := strconv.Atoi(sVal)
+		if err1 != nil || n < 0 {
+			return ErrWrongTypeForVar.Gen("Incorrect argument type to variable '%s'", key)
+		}
+		s.MaxErrorCount = n
+	} else if key == MaxExecutionTime || key == WaitTimeout || key == TiDBIdleTransactionTimeout {
+		if n, err1 := strconv.ParseInt(sVal, 10, 64); err1 != nil || n < 0 {
+			return ErrWrongTypeForVar.Gen("Incorrect argument type to variable '%s'", key)
 		}
 	} else if key == TiDBSnapshot {
 		err = s.setSnapshotTS(sVal)
@@ -282,6 +430,14 @@ func (s *SessionVars) setSnapshotTS(sVal string) error {
 func (s *SessionVars) GetSystemVar(key string) types.Datum {
 	var d types.Datum
 	key = strings.ToLower(key)
+	switch key {
+	case WarningCount:
+		d.SetInt64(int64(s.warningCount))
+		return d
+	case ErrorCount:
+		d.SetInt64(int64(s.errorCount))
+		return d
+	}
 	sVal, ok := s.systems[key]
 	if ok {
 		d.SetString(sVal)
@@ -14,8 +14,12 @@
 package variable_test
 
 import (
+	"errors"
+	"sync"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -60,6 +64,17 @@ func (*testSessionSuite) TestSession(c *C) {
 	c.Assert(v.StrictSQLMode, IsTrue)
 	v.SetSystemVar("sql_mode", types.NewStringDatum(""))
 	c.Assert(v.StrictSQLMode, IsFalse)
+	c.Assert(v.SetSystemVar("sql_mode", types.NewStringDatum("ansi,no_zero_date")), IsNil)
+	c.Assert(v.SQLMode.HasANSIQuotesMode(), IsTrue)
+	c.Assert(v.SQLMode.HasOnlyFullGroupBy(), IsTrue)
+	c.Assert(v.SQLMode.HasNoZeroDateMode(), IsTrue)
+	c.Assert(v.StrictSQLMode, IsFalse)
+	c.Assert(v.SetSystemVar("sql_mode", types.NewStringDatum("TRADITIONAL")), IsNil)
+	c.Assert(v.StrictSQLMode, IsTrue)
+	c.Assert(v.SQLMode.HasErrorForDivisionByZeroMode(), IsTrue)
+	err := v.SetSystemVar("sql_mode", types.NewStringDatum("NO_SUCH_MODE"))
+	c.Assert(terror.ErrorEqual(err, variable.ErrWrongValueForVar), IsTrue)
+	c.Assert(v.SQLMode.HasErrorForDivisionByZeroMode(), IsTrue)
 
 	v.SetSystemVar("character_set_connection", types.NewStringDatum("utf8"))
 	v.SetSystemVar("collation_connection", types.NewStringDatum("utf8_general_ci"))
@@ -69,3 +84,30 @@ func (*testSessionSuite) TestSession(c *C) {
 
 	c.Assert(v.SetSystemVar("character_set_results", types.Datum{}), IsNil)
 }
+
+func (*testSessionSuite) TestConcurrentWarnings(c *C) {
+	ctx := mock.NewContext()
+	variable.BindSessionVars(ctx)
+	v := variable.GetSessionVars(ctx)
+
+	// The executors running in goroutines append warnings concurrently.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				v.AppendWarning(errors.New("warning"))
+				v.AppendError(errors.New("error"))
+				v.GetWarnings()
+			}
+		}()
+	}
+	wg.Wait()
+	c.Assert(v.WarningCount(), Equals, 200)
+	c.Assert(v.ErrorCount(), Equals, 100)
+	c.Assert(v.GetWarnings(), HasLen, variable.DefMaxErrorCount)
+	v.ResetWarnings()
+	c.Assert(v.WarningCount(), Equals, 0)
+	c.Assert(v.GetWarnings(), HasLen, 0)
+}
@@ -14,7 +14,9 @@
 package variable
 
 import (
+	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/mysql"
@@ -58,14 +60,19 @@ func GetSysVar(name string) *SysVar {
 const (
 	CodeUnknownStatusVar terror.ErrCode = 1
 	CodeUnknownSystemVar terror.ErrCode = 1193
+	CodeWrongTypeForVar  terror.ErrCode = 1232
+	CodeWrongValueForVar terror.ErrCode = 1231
 )
 
 var tidbSysVars map[string]bool
 
 // Variable errors
 var (
-	UnknownStatusVar = terror.ClassVariable.New(CodeUnknownStatusVar, "unknown status variable")
-	UnknownSystemVar = terror.ClassVariable.New(CodeUnknownSystemVar, "unknown system variable")
+	UnknownStatusVar   = terror.ClassVariable.New(CodeUnknownStatusVar, "unknown status variable")
+	UnknownSystemVar   = terror.ClassVariable.New(CodeUnknownSystemVar, "unknown system variable")
+	ErrWrongTypeForVar = terror.ClassVariable.New(CodeWrongTypeForVar, "Incorrect argument type to variable")
+	// ErrWrongValueForVar is returned when a variable is set to a value it doesn't accept.
+	ErrWrongValueForVar = terror.ClassVariable.New(CodeWrongValueForVar, "Variable can't be set to the value")
 )
 
 func init() {
@@ -77,6 +84,8 @@ func init() {
 	// Register terror to mysql error map.
 	mySQLErrCodes := map[terror.ErrCode]uint16{
 		CodeUnknownSystemVar: mysql.ErrUnknownSystemVariable,
+		CodeWrongTypeForVar:  mysql.ErrWrongTypeForVar,
+		CodeWrongValueForVar: mysql.ErrWrongValueForVar,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassVariable] = mySQLErrCodes
 
@@ -84,6 +93,18 @@ func init() {
 	tidbSysVars[DistSQLScanConcurrencyVar] = true
 	tidbSysVars[DistSQLJoinConcurrencyVar] = true
 	tidbSysVars[TiDBSnapshot] = true
+	tidbSysVars[TiDBMemQuotaSession] = true
+	tidbSysVars[TiDBMemQuotaQuery] = true
+	tidbSysVars[TiDBMemOOMAction] = true
+	tidbSysVars[TiDBDDLReorgWorkerCount] = true
+	tidbSysVars[TiDBDDLReorgBatchSize] = true
+	tidbSysVars[TiDBSlowLogThreshold] = true
+	tidbSysVars[TiDBLoadDataBatchSize] = true
+	tidbSysVars[TiDBIdleTransactionTimeout] = true
+	// The timeouts are read for every statement or command, so they are read like the TiDB variables,
+	// the session values are initialized from the global values when they are read the first time.
+	tidbSysVars[MaxExecutionTime] = true
+	tidbSysVars[WaitTimeout] = true
 }
 
 // we only support MySQL now
@@ -98,7 +119,7 @@ var defaultSysVars = []*SysVar{
 	{ScopeGlobal | ScopeSession, "ndb_index_stat_option", ""},
 	{ScopeGlobal | ScopeSession, "old_passwords", "0"},
 	{ScopeNone, "innodb_version", "5.6.25"},
-	{ScopeGlobal, "max_connections", "151"},
+	{ScopeGlobal, MaxConnections, "151"},
 	{ScopeGlobal | ScopeSession, "big_tables", "OFF"},
 	{ScopeNone, "skip_external_locking", "ON"},
 	{ScopeGlobal, "slave_pending_jobs_size_max", "16777216"},
@@ -234,7 +255,7 @@ var defaultSysVars = []*SysVar{
 	{ScopeNone, "thread_concurrency", "10"},
 	{ScopeGlobal | ScopeSession, "query_prealloc_size", "8192"},
 	{ScopeNone, "relay_log_space_limit", "0"},
-	{ScopeGlobal | ScopeSession, "max_user_connections", "0"},
+	{ScopeGlobal | ScopeSession, MaxUserConnections, "0"},
 	{ScopeNone, "performance_schema_max_thread_classes", "50"},
 	{ScopeGlobal, "innodb_api_trx_level", "0"},
 	{ScopeNone, "disconnect_on_expired_password", "ON"},
@@ -242,12 +263,14 @@ var defaultSysVars = []*SysVar{
 	{ScopeGlobal, "expire_logs_days", "0"},
 	{ScopeGlobal | ScopeSession, "binlog_rows_query_log_events", "OFF"},
 	{ScopeGlobal, "validate_password_policy", ""},
-	{ScopeGlobal, "default_password_lifetime", ""},
+	{ScopeGlobal, DefaultPasswordLifetime, "0"},
 	{ScopeNone, "pid_file", "/usr/local/mysql/data/localhost.pid"},
 	{ScopeNone, "innodb_undo_tablespaces", "0"},
 	{ScopeGlobal, "innodb_status_output_locks", "OFF"},
 	{ScopeNone, "performance_schema_accounts_size", "100"},
-	{ScopeGlobal | ScopeSession, "max_error_count", "64"},
+	{ScopeGlobal | ScopeSession, MaxErrorCount, strconv.Itoa(DefMaxErrorCount)},
+	{ScopeSession, WarningCount, "0"},
+	{ScopeSession, ErrorCount, "0"},
 	{ScopeGlobal, "max_write_lock_count", "18446744073709551615"},
 	{ScopeNone, "performance_schema_max_socket_instances", "322"},
 	{ScopeNone, "performance_schema_max_table_instances", "12500"},
@@ -506,7 +529,7 @@ var defaultSysVars = []*SysVar{
 	{ScopeGlobal, "innodb_buffer_pool_size", "134217728"},
 	{ScopeGlobal, "innodb_adaptive_flushing", "ON"},
 	{ScopeNone, "datadir", "/usr/local/mysql/data/"},
-	{ScopeGlobal | ScopeSession, "wait_timeout", "28800"},
+	{ScopeGlobal | ScopeSession, WaitTimeout, strconv.Itoa(DefWaitTimeout)},
 	{ScopeGlobal, "innodb_monitor_enable", ""},
 	{ScopeNone, "date_format", "%Y-%m-%d"},
 	{ScopeGlobal, "innodb_buffer_pool_filename", "ib_buffer_pool"},
@@ -590,13 +613,151 @@ var defaultSysVars = []*SysVar{
 	{ScopeSession, TiDBSnapshot, ""},
 	{ScopeGlobal | ScopeSession, DistSQLScanConcurrencyVar, "10"},
 	{ScopeGlobal | ScopeSession, DistSQLJoinConcurrencyVar, "5"},
+	{ScopeGlobal | ScopeSession, TiDBMemQuotaSession, "34359738368"},
+	{ScopeGlobal | ScopeSession, TiDBMemQuotaQuery, "34359738368"},
+	{ScopeGlobal | ScopeSession, TiDBMemOOMAction, OOMActionLog},
+	{ScopeGlobal, TiDBDDLReorgWorkerCount, strconv.Itoa(DefDDLReorgWorkerCount)},
+	{ScopeGlobal, TiDBDDLReorgBatchSize, strconv.Itoa(DefDDLReorgBatchSize)},
+	{ScopeGlobal | ScopeSession, TiDBSlowLogThreshold, strconv.Itoa(DefSlowLogThreshold)},
+	{ScopeGlobal | ScopeSession, TiDBLoadDataBatchSize, strconv.Itoa(DefLoadDataBatchSize)},
+	{ScopeNone, SecureFilePriv, "NULL"},
+	{ScopeGlobal | ScopeSession, CTEMaxRecursionDepth, strconv.Itoa(DefCTEMaxRecursionDepth)},
+	{ScopeGlobal | ScopeSession, MaxExecutionTime, "0"},
+	{ScopeGlobal | ScopeSession, TiDBIdleTransactionTimeout, "0"},
 }
 
+// SecureFilePriv is the directory that the files read and written by statements are restricted to,
+// it can only be set when the server starts. An empty value means no restriction, and "NULL", the default,
+// disables the file operations.
+const SecureFilePriv = "secure_file_priv"
+
+// Diagnostics variables, warning_count and error_count are computed from the warnings of the last statement.
+const (
+	MaxErrorCount = "max_error_count"
+	WarningCount  = "warning_count"
+	ErrorCount    = "error_count"
+)
+
+// DefMaxErrorCount is the default value of MaxErrorCount.
+const DefMaxErrorCount = 64
+
+// Connection limits, max_connections limits the connections of the server,
+// max_user_connections limits the connections of an account, zero means no limit.
+const (
+	MaxConnections     = "max_connections"
+	MaxUserConnections = "max_user_connections"
+)
+
+// DefaultPasswordLifetime is the number of days after which the passwords of the accounts expire
+// if the accounts don't set their own lifetime, zero means the passwords never expire.
+const DefaultPasswordLifetime = "default_password_lifetime"
+
+// CTEMaxRecursionDepth is the maximum number of iterations of a recursive common table expression.
+const CTEMaxRecursionDepth = "cte_max_recursion_depth"
+
+// DefCTEMaxRecursionDepth is the default value of CTEMaxRecursionDepth.
+const DefCTEMaxRecursionDepth = 1000
+
+// Timeouts, max_execution_time is the time limit in milliseconds of a SELECT statement, wait_timeout is
+// the number of seconds that the server waits for a command of an idle connection before closing it.
+// Zero means no limit.
+const (
+	MaxExecutionTime = "max_execution_time"
+	WaitTimeout      = "wait_timeout"
+)
+
+// DefWaitTimeout is the default value of WaitTimeout.
+const DefWaitTimeout = 28800
+
 // TiDB system variables
 const (
 	TiDBSnapshot              = "tidb_snapshot"
 	DistSQLScanConcurrencyVar = "tidb_distsql_scan_concurrency"
 	DistSQLJoinConcurrencyVar = "tidb_distsql_join_concurrency"
+	// TiDBMemQuotaSession is the memory quota in bytes for the rows buffered by all the executors of a session.
+	// Sort spills rows to temporary files above it, hash join and hash aggregation return an error.
+	// Zero means no limit.
+	TiDBMemQuotaSession = "tidb_mem_quota_session"
+	// TiDBMemQuotaQuery is the memory quota in bytes for a statement, including all its executors
+	// and the growth of the transaction buffer. Sort spills rows before the statement exceeds it,
+	// so TiDBMemOOMAction is only taken for the memory that can't be spilled. Zero means no limit.
+	TiDBMemQuotaQuery = "tidb_mem_quota_query"
+	// TiDBMemOOMAction is the action taken when a statement exceeds TiDBMemQuotaQuery,
+	// OOMActionLog or OOMActionCancel.
+	TiDBMemOOMAction = "tidb_mem_oom_action"
+	// TiDBDDLReorgWorkerCount is the number of workers that backfill the index of an ADD INDEX job concurrently.
+	TiDBDDLReorgWorkerCount = "tidb_ddl_reorg_worker_cnt"
+	// TiDBDDLReorgBatchSize is the number of rows that a backfill worker handles in a transaction.
+	TiDBDDLReorgBatchSize = "tidb_ddl_reorg_batch_size"
+	// TiDBSlowLogThreshold is the threshold in milliseconds, the statements running longer than it
+	// are written to the slow log.
+	TiDBSlowLogThreshold = "tidb_slow_log_threshold"
+	// TiDBLoadDataBatchSize is the number of rows that LOAD DATA commits in a transaction,
+	// zero means all the rows are committed in one transaction.
+	TiDBLoadDataBatchSize = "tidb_load_data_batch_size"
+	// TiDBIdleTransactionTimeout is the number of seconds that the server waits for a command of a connection
+	// which is idle in a transaction, the connection is closed and the transaction is rolled back after it.
+	// Zero means wait_timeout applies.
+	TiDBIdleTransactionTimeout = "tidb_idle_transaction_timeout"
+)
+
+// DefSlowLogThreshold is the default value of TiDBSlowLogThreshold.
+const DefSlowLogThreshold = 300
+
+// DefLoadDataBatchSize is the default value of TiDBLoadDataBatchSize.
+const DefLoadDataBatchSize = 20000
+
+// Default values and limits of the DDL reorganization variables.
+const (
+	DefDDLReorgWorkerCount = 4
+	MaxDDLReorgWorkerCount = 128
+	DefDDLReorgBatchSize   = 1024
+	MaxDDLReorgBatchSize   = 10240
+)
+
+// The DDL reorganization variables are used by the DDL worker which has no session,
+// so the values set globally on this server are kept here.
+var (
+	ddlReorgWorkerCount int32 = DefDDLReorgWorkerCount
+	ddlReorgBatchSize   int32 = DefDDLReorgBatchSize
+)
+
+// SetDDLReorgWorkerCount sets ddlReorgWorkerCount, the value is clamped to [1, MaxDDLReorgWorkerCount].
+func SetDDLReorgWorkerCount(cnt int32) {
+	atomic.StoreInt32(&ddlReorgWorkerCount, clampInt32(cnt, 1, MaxDDLReorgWorkerCount))
+}
+
+// GetDDLReorgWorkerCount gets ddlReorgWorkerCount.
+func GetDDLReorgWorkerCount() int32 {
+	return atomic.LoadInt32(&ddlReorgWorkerCount)
+}
+
+// SetDDLReorgBatchSize sets ddlReorgBatchSize, the value is clamped to [1, MaxDDLReorgBatchSize].
+func SetDDLReorgBatchSize(size int32) {
+	atomic.StoreInt32(&ddlReorgBatchSize, clampInt32(size, 1, MaxDDLReorgBatchSize))
+}
+
+// GetDDLReorgBatchSize gets ddlReorgBatchSize.
+func GetDDLReorgBatchSize() int32 {
+	return atomic.LoadInt32(&ddlReorgBatchSize)
+}
+
+func clampInt32(v, min, max int32) int32 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// Values of TiDBMemOOMAction.
+const (
+	// OOMActionLog logs the memory usage of the statement.
+	OOMActionLog = "log"
+	// OOMActionCancel cancels the statement with an error.
+	OOMActionCancel = "cancel"
 )
 
 // SetNamesVariables is the system variable names related to set names statements.
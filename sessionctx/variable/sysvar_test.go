@@ -35,3 +35,23 @@ func (*testSysVarSuite) TestSysVar(c *C) {
 	f = GetSysVar("wrong-var-name")
 	c.Assert(f, IsNil)
 }
+
+func (*testSysVarSuite) TestDDLReorgVars(c *C) {
+	defer func() {
+		SetDDLReorgWorkerCount(DefDDLReorgWorkerCount)
+		SetDDLReorgBatchSize(DefDDLReorgBatchSize)
+	}()
+	c.Assert(GetDDLReorgWorkerCount(), Equals, int32(DefDDLReorgWorkerCount))
+	SetDDLReorgWorkerCount(8)
+	c.Assert(GetDDLReorgWorkerCount(), Equals, int32(8))
+	SetDDLReorgWorkerCount(0)
+	c.Assert(GetDDLReorgWorkerCount(), Equals, int32(1))
+	SetDDLReorgWorkerCount(MaxDDLReorgWorkerCount + 1)
+	c.Assert(GetDDLReorgWorkerCount(), Equals, int32(MaxDDLReorgWorkerCount))
+
+	c.Assert(GetDDLReorgBatchSize(), Equals, int32(DefDDLReorgBatchSize))
+	SetDDLReorgBatchSize(-1)
+	c.Assert(GetDDLReorgBatchSize(), Equals, int32(1))
+	SetDDLReorgBatchSize(MaxDDLReorgBatchSize * 2)
+	c.Assert(GetDDLReorgBatchSize(), Equals, int32(MaxDDLReorgBatchSize))
+}
@@ -65,6 +65,10 @@ func (txn *dbTxn) String() string {
 	return fmt.Sprintf("%d", txn.tid)
 }
 
+func (txn *dbTxn) Size() int {
+	return txn.us.Size()
+}
+
 func (txn *dbTxn) Seek(k kv.Key) (kv.Iterator, error) {
 	log.Debugf("[kv] seek key:% x, txn:%d", k, txn.tid)
 	return txn.us.Seek(k)
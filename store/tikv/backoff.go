@@ -20,6 +20,7 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/kv"
 )
 
 const (
@@ -112,6 +113,7 @@ const (
 	copBuildTaskMaxBackoff  = 5000
 	tsoMaxBackoff           = 5000
 	scannerNextMaxBackoff   = 5000
+	splitKeysMaxBackoff     = 5000
 	batchGetMaxBackoff      = 10000
 	copNextMaxBackoff       = 10000
 	getMaxBackoff           = 10000
@@ -128,6 +130,8 @@ type Backoffer struct {
 	maxSleep   int
 	totalSleep int
 	errors     []error
+	// deadline is the deadline of the statement that the Backoffer retries for, zero means no limit.
+	deadline time.Time
 }
 
 // NewBackoffer creates a Backoffer with maximum sleep time(in ms).
@@ -137,6 +141,15 @@ func NewBackoffer(maxSleep int) *Backoffer {
 	}
 }
 
+// NewBackofferWithDeadline creates a Backoffer with maximum sleep time(in ms), the backoff fails with
+// kv.ErrQueryTimeout instead of retrying after the deadline. A zero deadline means no limit.
+func NewBackofferWithDeadline(maxSleep int, deadline time.Time) *Backoffer {
+	return &Backoffer{
+		maxSleep: maxSleep,
+		deadline: deadline,
+	}
+}
+
 // Backoff sleeps a while base on the backoffType and records the error message.
 // It returns a retryable error if total sleep time exceeds maxSleep.
 func (b *Backoffer) Backoff(typ backoffType, err error) error {
@@ -152,11 +165,17 @@ func (b *Backoffer) Backoff(typ backoffType, err error) error {
 		f = typ.createFn()
 		b.fn[typ] = f
 	}
+	if b.deadlineExceeded() {
+		return errors.Trace(kv.ErrQueryTimeout)
+	}
 
 	b.totalSleep += f()
 
 	log.Warnf("%v, retry later(totalSleep %dms, maxSleep %dms)", err, b.totalSleep, b.maxSleep)
 	b.errors = append(b.errors, err)
+	if b.deadlineExceeded() {
+		return errors.Trace(kv.ErrQueryTimeout)
+	}
 	if b.totalSleep >= b.maxSleep {
 		e := errors.Errorf("backoffer.maxSleep %dms is exceeded, errors: %v", b.maxSleep, b.errors)
 		return errors.Annotate(e, txnRetryableMark)
@@ -164,11 +183,16 @@ func (b *Backoffer) Backoff(typ backoffType, err error) error {
 	return nil
 }
 
+func (b *Backoffer) deadlineExceeded() bool {
+	return !b.deadline.IsZero() && time.Now().After(b.deadline)
+}
+
 // Fork creates a new Backoffer which keeps current Backoffer's sleep time and errors.
 func (b *Backoffer) Fork() *Backoffer {
 	return &Backoffer{
 		maxSleep:   b.maxSleep,
 		totalSleep: b.totalSleep,
 		errors:     b.errors,
+		deadline:   b.deadline,
 	}
 }
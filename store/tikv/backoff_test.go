@@ -0,0 +1,51 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/terror"
+)
+
+type testBackoffSuite struct {
+}
+
+var _ = Suite(&testBackoffSuite{})
+
+func (s *testBackoffSuite) TestBackoffDeadline(c *C) {
+	regionErr := errors.New("region miss")
+
+	// No deadline, the backoff retries until maxSleep is exceeded.
+	bo := NewBackofferWithDeadline(150, time.Time{})
+	c.Assert(bo.Backoff(boRegionMiss, regionErr), IsNil)
+	err := bo.Backoff(boRegionMiss, regionErr)
+	c.Assert(err, NotNil)
+	c.Assert(terror.ErrorEqual(err, kv.ErrQueryTimeout), IsFalse)
+
+	// The deadline is exceeded before the backoff, it doesn't sleep.
+	bo = NewBackofferWithDeadline(copNextMaxBackoff, time.Now().Add(-time.Second))
+	start := time.Now()
+	err = bo.Backoff(boRegionMiss, regionErr)
+	c.Assert(terror.ErrorEqual(err, kv.ErrQueryTimeout), IsTrue)
+	c.Assert(time.Since(start), Less, 100*time.Millisecond)
+
+	// The deadline is exceeded during the sleep, the forked Backoffer keeps the deadline.
+	bo = NewBackofferWithDeadline(copNextMaxBackoff, time.Now().Add(50*time.Millisecond)).Fork()
+	err = bo.Backoff(boRegionMiss, regionErr)
+	c.Assert(terror.ErrorEqual(err, kv.ErrQueryTimeout), IsTrue)
+}
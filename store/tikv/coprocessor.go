@@ -73,7 +73,7 @@ func supportExpr(exprType tipb.ExprType) bool {
 func (c *CopClient) Send(req *kv.Request) kv.Response {
 	coprocessorCounter.WithLabelValues("send").Inc()
 
-	bo := NewBackoffer(copBuildTaskMaxBackoff)
+	bo := NewBackofferWithDeadline(copBuildTaskMaxBackoff, req.Deadline)
 	tasks, err := buildCopTasks(bo, c.store.regionCache, &copRanges{mid: req.KeyRanges}, req.Desc)
 	if err != nil {
 		return copErrorResponse{err}
@@ -322,7 +322,7 @@ func (it *copIterator) work() {
 		}
 		task.status = taskRunning
 		it.mu.Unlock()
-		bo := NewBackoffer(copNextMaxBackoff)
+		bo := NewBackofferWithDeadline(copNextMaxBackoff, it.req.Deadline)
 		resp, err := it.handleTask(bo, task)
 		if err != nil {
 			it.errChan <- err
@@ -424,7 +424,18 @@ func (it *copIterator) handleTask(bo *Backoffer, task *copTask) (*coprocessor.Re
 			Data:    it.req.Data,
 			Ranges:  task.ranges.toPBRanges(),
 		}
-		resp, err := it.store.client.SendCopReq(task.region.GetAddress(), req, readTimeoutMedium)
+		// The request doesn't wait longer than the deadline of the statement.
+		timeout := readTimeoutMedium
+		if !it.req.Deadline.IsZero() {
+			left := it.req.Deadline.Sub(time.Now())
+			if left <= 0 {
+				return nil, errors.Trace(kv.ErrQueryTimeout)
+			}
+			if left < timeout {
+				timeout = left
+			}
+		}
+		resp, err := it.store.client.SendCopReq(task.region.GetAddress(), req, timeout)
 		if err != nil {
 			it.store.regionCache.NextPeer(task.region.VerID())
 			err = bo.Backoff(boTiKVRPC, err)
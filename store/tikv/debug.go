@@ -0,0 +1,159 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	"github.com/juju/errors"
+	pb "github.com/pingcap/kvproto/pkg/kvrpcpb"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/store/tikv/mock-tikv"
+)
+
+// Storage represents a storage that connects TiKV.
+// It exposes the Regions and the MVCC data for the status HTTP API.
+type Storage interface {
+	kv.Storage
+
+	// GetRegionCache gets the RegionCache of the storage.
+	GetRegionCache() *RegionCache
+
+	// MvccGetByKey gets the lock and the versions of a key. On TiKV, only the latest committed value is
+	// returned without its timestamps, see MvccKeyInfo.Complete.
+	MvccGetByKey(key kv.Key) (*MvccKeyInfo, error)
+}
+
+var _ Storage = &tikvStore{}
+
+// MvccKeyInfo is the MVCC information of a key.
+type MvccKeyInfo struct {
+	Key  kv.Key    `json:"key"`
+	Lock *MvccLock `json:"lock,omitempty"`
+	// Values are the versions of the key, the newest is the first.
+	Values []*MvccValue `json:"values"`
+	// Complete is false if only the latest committed value is reported,
+	// TiKV has no request to read all the versions of a key.
+	Complete bool `json:"complete"`
+	// Note explains what is missing when the information is not complete.
+	Note string `json:"note,omitempty"`
+}
+
+// mvccIncompleteNote is the note of the MVCC information read from TiKV.
+const mvccIncompleteNote = "TiKV only reports the latest committed value, its timestamps and the older versions are not available"
+
+// MvccLock is the lock of a key left by a transaction that is not committed.
+type MvccLock struct {
+	Primary []byte `json:"primary"`
+	StartTS uint64 `json:"start_ts"`
+}
+
+// MvccValue is a version of a key.
+type MvccValue struct {
+	// Type is "put", "delete" or "rollback".
+	Type     string `json:"type"`
+	StartTS  uint64 `json:"start_ts"`
+	CommitTS uint64 `json:"commit_ts"`
+	Value    []byte `json:"value,omitempty"`
+}
+
+// mvccDebugger is implemented by the client of mock-tikv, which reads all the versions of a key directly.
+type mvccDebugger interface {
+	MvccGetByKey(key []byte) (*pb.LockInfo, []mocktikv.MvccValue)
+}
+
+func (s *tikvStore) GetRegionCache() *RegionCache {
+	return s.regionCache
+}
+
+func (s *tikvStore) MvccGetByKey(key kv.Key) (*MvccKeyInfo, error) {
+	info := &MvccKeyInfo{Key: key}
+	if c, ok := s.client.(mvccDebugger); ok {
+		lock, values := c.MvccGetByKey(key)
+		if lock != nil {
+			info.Lock = &MvccLock{Primary: lock.GetPrimaryLock(), StartTS: lock.GetLockVersion()}
+		}
+		for _, v := range values {
+			info.Values = append(info.Values, &MvccValue{
+				Type:     v.Type,
+				StartTS:  v.StartTS,
+				CommitTS: v.CommitTS,
+				Value:    v.Value,
+			})
+		}
+		info.Complete = true
+		return info, nil
+	}
+
+	// TiKV has no request to read all the versions of a key, so only the latest committed value is read.
+	info.Note = mvccIncompleteNote
+	ver, err := s.CurrentVersion()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	bo := NewBackoffer(getMaxBackoff)
+	val, lock, err := s.getWithoutResolve(bo, key, ver.Ver)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if lock != nil {
+		info.Lock = &MvccLock{Primary: lock.GetPrimaryLock(), StartTS: lock.GetLockVersion()}
+		// Read the latest value committed before the lock.
+		val, _, err = s.getWithoutResolve(bo, key, lock.GetLockVersion()-1)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if val != nil {
+		info.Values = []*MvccValue{{Type: "put", Value: val}}
+	}
+	return info, nil
+}
+
+// getWithoutResolve reads a key at the version, the lock on the key is returned instead of being resolved.
+func (s *tikvStore) getWithoutResolve(bo *Backoffer, key kv.Key, version uint64) ([]byte, *pb.LockInfo, error) {
+	req := &pb.Request{
+		Type: pb.MessageType_CmdGet,
+		CmdGetReq: &pb.CmdGetRequest{
+			Key:     key,
+			Version: version,
+		},
+	}
+	for {
+		region, err := s.regionCache.GetRegion(bo, key)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		resp, err := s.SendKVReq(bo, req, region.VerID(), readTimeoutShort)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		if regionErr := resp.GetRegionError(); regionErr != nil {
+			err = bo.Backoff(boRegionMiss, errors.New(regionErr.String()))
+			if err != nil {
+				return nil, nil, errors.Trace(err)
+			}
+			continue
+		}
+		cmdGetResp := resp.GetCmdGetResp()
+		if cmdGetResp == nil {
+			return nil, nil, errors.Trace(errBodyMissing)
+		}
+		if keyErr := cmdGetResp.GetError(); keyErr != nil {
+			if locked := keyErr.GetLocked(); locked != nil {
+				return nil, locked, nil
+			}
+			return nil, nil, errors.Errorf("unexpected key error: %s", keyErr)
+		}
+		return cmdGetResp.GetValue(), nil, nil
+	}
+}
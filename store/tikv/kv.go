@@ -14,6 +14,7 @@
 package tikv
 
 import (
+	"bytes"
 	"fmt"
 	"math/rand"
 	"net/url"
@@ -110,6 +111,11 @@ func newTikvStore(uuid string, pdClient pd.Client, client Client, enableGC bool)
 func NewMockTikvStore() (kv.Storage, error) {
 	cluster := mocktikv.NewCluster()
 	mocktikv.BootstrapWithSingleStore(cluster)
+	return NewMockTikvStoreWithCluster(cluster)
+}
+
+// NewMockTikvStoreWithCluster creates a mocked tikv store on the cluster, so tests can split the Regions of the cluster.
+func NewMockTikvStoreWithCluster(cluster *mocktikv.Cluster) (kv.Storage, error) {
 	mvccStore := mocktikv.NewMvccStore()
 	client := mocktikv.NewRPCClient(cluster, mvccStore)
 	uuid := fmt.Sprintf("mock-tikv-store-:%v", time.Now().Unix())
@@ -179,6 +185,24 @@ func (s *tikvStore) GetClient() kv.Client {
 	}
 }
 
+var _ kv.SplittableStorage = &tikvStore{}
+
+// GetSplitKeys gets the start keys of the Regions in the key range (startKey, endKey).
+func (s *tikvStore) GetSplitKeys(startKey, endKey kv.Key) ([]kv.Key, error) {
+	bo := NewBackoffer(splitKeysMaxBackoff)
+	regions, err := s.regionCache.ListRegionsInKeyRange(bo, startKey, endKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var keys []kv.Key
+	for _, r := range regions {
+		if bytes.Compare(r.StartKey(), startKey) > 0 && bytes.Compare(r.StartKey(), endKey) < 0 {
+			keys = append(keys, r.StartKey())
+		}
+	}
+	return keys, nil
+}
+
 // sendKVReq sends req to tikv server. It will retry internally to find the right
 // region leader if i) fails to establish a connection to server or ii) server
 // returns `NotLeader`.
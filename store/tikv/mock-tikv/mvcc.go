@@ -399,6 +399,52 @@ func (s *MvccStore) ScanLock(startKey, endKey []byte, maxTS uint64) ([]*kvrpcpb.
 	return locks, nil
 }
 
+// MvccValue is a version of a key, it is used for debugging.
+type MvccValue struct {
+	// Type is "put", "delete" or "rollback".
+	Type     string
+	StartTS  uint64
+	CommitTS uint64
+	Value    []byte
+}
+
+var mvccValueTypeNames = map[mvccValueType]string{
+	typePut:      "put",
+	typeDelete:   "delete",
+	typeRollback: "rollback",
+}
+
+// MvccGetByKey returns the lock and all the versions of a key, the newest version is the first.
+// It is used for debugging.
+func (s *MvccStore) MvccGetByKey(key []byte) (*kvrpcpb.LockInfo, []MvccValue) {
+	s.RLock()
+	defer s.RUnlock()
+
+	item := s.tree.Get(newEntry(key))
+	if item == nil {
+		return nil, nil
+	}
+	entry := item.(*mvccEntry).Clone()
+	var lock *kvrpcpb.LockInfo
+	if entry.lock != nil {
+		lock = &kvrpcpb.LockInfo{
+			PrimaryLock: entry.lock.primary,
+			LockVersion: entry.lock.startTS,
+			Key:         entry.key,
+		}
+	}
+	values := make([]MvccValue, 0, len(entry.values))
+	for _, v := range entry.values {
+		values = append(values, MvccValue{
+			Type:     mvccValueTypeNames[v.valueType],
+			StartTS:  v.startTS,
+			CommitTS: v.commitTS,
+			Value:    v.value,
+		})
+	}
+	return lock, values
+}
+
 // ResolveLock resolves all orphan locks belong to a transaction.
 func (s *MvccStore) ResolveLock(startKey, endKey []byte, startTS, commitTS uint64) error {
 	s.Lock()
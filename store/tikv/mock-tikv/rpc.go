@@ -327,6 +327,11 @@ func (c *RPCClient) SendCopReq(addr string, req *coprocessor.Request, timeout ti
 	return handler.handleCopRequest(req)
 }
 
+// MvccGetByKey returns the lock and all the versions of a key, it is used for debugging.
+func (c *RPCClient) MvccGetByKey(key []byte) (*kvrpcpb.LockInfo, []MvccValue) {
+	return c.mvccStore.MvccGetByKey(key)
+}
+
 // Close closes the client.
 func (c *RPCClient) Close() error {
 	return nil
@@ -74,6 +74,22 @@ func (c *RegionCache) GetRegion(bo *Backoffer, key []byte) (*Region, error) {
 	return c.insertRegionToCache(r), nil
 }
 
+// ListRegionsInKeyRange lists the Regions that overlap with the key range [startKey, endKey).
+func (c *RegionCache) ListRegionsInKeyRange(bo *Backoffer, startKey, endKey []byte) ([]*Region, error) {
+	var regions []*Region
+	for {
+		region, err := c.GetRegion(bo, startKey)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		regions = append(regions, region)
+		if len(region.EndKey()) == 0 || bytes.Compare(region.EndKey(), endKey) >= 0 {
+			return regions, nil
+		}
+		startKey = region.EndKey()
+	}
+}
+
 // GroupKeysByRegion separates keys into groups by their belonging Regions.
 // Specially it also returns the first key's region which may be used as the
 // 'PrimaryLockKey' and should be committed ahead of others.
@@ -346,6 +362,16 @@ func (r *Region) EndKey() []byte {
 	return r.meta.EndKey
 }
 
+// GetMeta returns the meta of the Region, it must not be modified.
+func (r *Region) GetMeta() *metapb.Region {
+	return r.meta
+}
+
+// GetLeader returns the peer that requests are sent to.
+func (r *Region) GetLeader() *metapb.Peer {
+	return r.peer
+}
+
 // GetAddress returns address.
 func (r *Region) GetAddress() string {
 	return r.addr
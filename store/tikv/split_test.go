@@ -98,3 +98,25 @@ func (s *testSplitSuite) TestStaleEpoch(c *C) {
 	_, err = txn.Get([]byte("c"))
 	c.Assert(err, IsNil)
 }
+
+func (s *testSplitSuite) TestGetSplitKeys(c *C) {
+	keys, err := s.store.GetSplitKeys([]byte("a"), []byte("z"))
+	c.Assert(err, IsNil)
+	c.Assert(keys, HasLen, 0)
+
+	for _, key := range []string{"c", "m", "x"} {
+		region, _ := s.cluster.GetRegionByKey([]byte(key))
+		s.split(c, region.GetId(), []byte(key))
+	}
+	// Drop the Region cached before the splits, so the split Regions are loaded.
+	region, err := s.store.regionCache.GetRegion(s.bo, []byte("a"))
+	c.Assert(err, IsNil)
+	s.store.regionCache.DropRegion(region.VerID())
+
+	keys, err = s.store.GetSplitKeys([]byte("a"), []byte("x"))
+	c.Assert(err, IsNil)
+	c.Assert(keys, DeepEquals, []kv.Key{kv.Key("c"), kv.Key("m")})
+	keys, err = s.store.GetSplitKeys([]byte("c"), []byte("z"))
+	c.Assert(err, IsNil)
+	c.Assert(keys, DeepEquals, []kv.Key{kv.Key("m"), kv.Key("x")})
+}
@@ -79,6 +79,10 @@ func (txn *tikvTxn) String() string {
 	return fmt.Sprintf("%d", txn.StartTS())
 }
 
+func (txn *tikvTxn) Size() int {
+	return txn.us.Size()
+}
+
 func (txn *tikvTxn) Seek(k kv.Key) (kv.Iterator, error) {
 	log.Debugf("Seek key[%q] txn[%d]", k, txn.StartTS())
 	txnCmdCounter.WithLabelValues("seek").Inc()
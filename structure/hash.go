@@ -208,6 +208,66 @@ func (t *TxStructure) HGetAll(key []byte) ([]HashPair, error) {
 	return res, errors.Trace(err)
 }
 
+// HGetLastN gets at most n fields and values with the largest fields in a hash, the largest field is the first.
+// The hash is scanned backwards from its last field, if the store doesn't support the reverse scan,
+// it is scanned forwards and only the last n fields are kept.
+func (t *TxStructure) HGetLastN(key []byte, n int) ([]HashPair, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	dataPrefix := t.hashDataKeyPrefix(key)
+	it, err := t.reader.SeekReverse(dataPrefix.PrefixNext())
+	if terror.ErrorEqual(err, kv.ErrNotImplemented) {
+		return t.hGetLastNByForwardScan(key, n)
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer it.Close()
+
+	var res []HashPair
+	for it.Valid() && len(res) < n {
+		if !it.Key().HasPrefix(dataPrefix) {
+			break
+		}
+		_, field, err := t.decodeHashDataKey(it.Key())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		pair := HashPair{
+			Field: append([]byte{}, field...),
+			Value: append([]byte{}, it.Value()...),
+		}
+		res = append(res, pair)
+		if err = it.Next(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return res, nil
+}
+
+func (t *TxStructure) hGetLastNByForwardScan(key []byte, n int) ([]HashPair, error) {
+	var res []HashPair
+	err := t.iterateHash(key, func(field []byte, value []byte) error {
+		pair := HashPair{
+			Field: append([]byte{}, field...),
+			Value: append([]byte{}, value...),
+		}
+		res = append(res, pair)
+		if len(res) > n {
+			res = res[1:]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res, nil
+}
+
 // HClear removes the hash value of the key.
 func (t *TxStructure) HClear(key []byte) error {
 	metaKey := t.encodeHashMetaKey(key)
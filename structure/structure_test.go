@@ -48,6 +48,15 @@ func (s *testTxStructureSuite) TearDownSuite(c *C) {
 	c.Assert(err, IsNil)
 }
 
+// noReverseRetriever is a kv.Retriever that doesn't support the reverse scan, like the TiKV snapshot.
+type noReverseRetriever struct {
+	kv.Retriever
+}
+
+func (r noReverseRetriever) SeekReverse(k kv.Key) (kv.Iterator, error) {
+	return nil, kv.ErrNotImplemented
+}
+
 func (s *testTxStructureSuite) TestString(c *C) {
 	defer testleak.AfterTest(c)()
 	txn, err := s.store.Begin()
@@ -209,6 +218,25 @@ func (s *testTxStructureSuite) TestHash(c *C) {
 		{[]byte("1"), []byte("1")},
 		{[]byte("2"), []byte("2")}})
 
+	res, err = tx.HGetLastN(key, 1)
+	c.Assert(err, IsNil)
+	c.Assert(res, DeepEquals, []HashPair{{[]byte("2"), []byte("2")}})
+	res, err = tx.HGetLastN(key, 3)
+	c.Assert(err, IsNil)
+	c.Assert(res, DeepEquals, []HashPair{
+		{[]byte("2"), []byte("2")},
+		{[]byte("1"), []byte("1")}})
+	// The hash is scanned forwards if the store doesn't support the reverse scan.
+	fwd := NewStructure(noReverseRetriever{txn}, txn, []byte{0x00})
+	res, err = fwd.HGetLastN(key, 1)
+	c.Assert(err, IsNil)
+	c.Assert(res, DeepEquals, []HashPair{{[]byte("2"), []byte("2")}})
+	res, err = fwd.HGetLastN(key, 3)
+	c.Assert(err, IsNil)
+	c.Assert(res, DeepEquals, []HashPair{
+		{[]byte("2"), []byte("2")},
+		{[]byte("1"), []byte("1")}})
+
 	err = tx.HDel(key, []byte("1"))
 	c.Assert(err, IsNil)
 
@@ -21,7 +21,6 @@ import (
 	"strings"
 
 	"github.com/juju/errors"
-	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/evaluator"
 	"github.com/pingcap/tidb/model"
@@ -102,7 +101,7 @@ func CastValues(ctx context.Context, rec []types.Datum, cols []*Column, ignoreEr
 		converted, err = CastValue(ctx, rec[c.Offset], c.ToInfo())
 		if err != nil {
 			if ignoreErr {
-				log.Warnf("cast values failed:%v", err)
+				variable.GetSessionVars(ctx).AppendWarning(err)
 				continue
 			} else {
 				return errors.Trace(err)
@@ -116,12 +115,15 @@ func CastValues(ctx context.Context, rec []types.Datum, cols []*Column, ignoreEr
 // CastValue casts a value based on column type.
 func CastValue(ctx context.Context, val types.Datum, col *model.ColumnInfo) (casted types.Datum, err error) {
 	casted, err = val.ConvertTo(&col.FieldType)
+	if err == nil && casted.Kind() == types.KindMysqlTime {
+		err = types.CheckZeroDate(casted, variable.GetSessionVars(ctx).SQLMode)
+	}
 	if err != nil {
-		if variable.GetSessionVars(ctx).StrictSQLMode {
+		sessVars := variable.GetSessionVars(ctx)
+		if sessVars.StrictSQLMode {
 			return casted, errors.Trace(err)
 		}
-		// TODO: add warnings.
-		log.Warnf("cast value error %v", err)
+		sessVars.AppendWarning(err)
 	}
 	return casted, nil
 }
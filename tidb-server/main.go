@@ -34,9 +34,12 @@ import (
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/server"
 	"github.com/pingcap/tidb/sessionctx/binloginfo"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/store/localstore/boltdb"
 	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/util/audit"
 	"github.com/pingcap/tidb/util/printer"
+	"github.com/pingcap/tidb/util/slowlog"
 	"github.com/pingcap/tipb/go-binlog"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/push"
@@ -55,10 +58,16 @@ var (
 	enablePS        = flag.Bool("perfschema", false, "If enable performance schema.")
 	reportStatus    = flag.Bool("report-status", true, "If enable status report HTTP service.")
 	logFile         = flag.String("log-file", "", "log file path")
+	slowLogFile     = flag.String("slow-log-file", "", "slow query log file path, the slow queries are written to the log file if it is empty")
+	auditLogFile    = flag.String("audit-log-file", "", "audit log file path, the audit log is disabled if it is empty")
+	auditLogSize    = flag.Int("audit-log-max-size", 100, "the size in MB that the audit log file is rotated at, set \"0\" to disable rotation")
+	auditLogBackups = flag.Int("audit-log-max-backups", 10, "the number of rotated audit log files to keep, it must be at least 1 with rotation")
+	auditFilter     = flag.String("audit-filter", "", "JSON file of the audit filter by users, dbs and classes, the connections, DDL, DCL and unparsable statements of all the users are audited if it is empty")
 	joinCon         = flag.Int("join-concurrency", 5, "the number of goroutines that participate joining.")
 	metricsAddr     = flag.String("metrics-addr", "", "prometheus pushgateway address, leaves it empty will disable prometheus push.")
 	metricsInterval = flag.Int("metrics-interval", 15, "prometheus client push interval in second, set \"0\" to disable prometheus push.")
 	binlogSocket    = flag.String("binlog-socket", "", "socket file to write binlog")
+	secureFilePriv  = flag.String("secure-file-priv", "NULL", "the directory that SELECT ... INTO OUTFILE and LOAD DATA INFILE can access, no restriction if it is empty, \"NULL\" disables them")
 )
 
 func main() {
@@ -88,6 +97,27 @@ func main() {
 		}
 		log.SetRotateByDay()
 	}
+	if len(*slowLogFile) > 0 {
+		err := slowlog.SetFile(*slowLogFile)
+		if err != nil {
+			log.Fatal(errors.ErrorStack(err))
+		}
+	}
+	if len(*auditFilter) > 0 {
+		filter, err := audit.LoadFilter(*auditFilter)
+		if err != nil {
+			log.Fatal(errors.ErrorStack(err))
+		}
+		audit.SetFilter(filter)
+	}
+	if len(*auditLogFile) > 0 {
+		err := audit.SetFile(*auditLogFile, int64(*auditLogSize)<<20, *auditLogBackups)
+		if err != nil {
+			log.Fatal(errors.ErrorStack(err))
+		}
+	}
+
+	variable.SysVars[variable.SecureFilePriv].Value = *secureFilePriv
 
 	if joinCon != nil && *joinCon > 0 {
 		plan.JoinConcurrency = *joinCon
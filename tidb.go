@@ -93,6 +93,11 @@ var (
 	schemaLease = 1 * time.Second
 )
 
+// GetDomain gets the domain of the storage, the domain is created if it doesn't exist.
+func GetDomain(store kv.Storage) (*domain.Domain, error) {
+	return domap.Get(store)
+}
+
 // SetSchemaLease changes the default schema lease time for DDL.
 // This function is very dangerous, don't use it if you really know what you do.
 // SetSchemaLease only affects not local storage after bootstrapped.
@@ -119,7 +124,11 @@ func getCtxCharsetInfo(ctx context.Context) (string, string) {
 func Parse(ctx context.Context, src string) ([]ast.StmtNode, error) {
 	log.Debug("compiling", src)
 	charset, collation := getCtxCharsetInfo(ctx)
-	stmts, err := parser.New().Parse(src, charset, collation)
+	p := parser.New()
+	if vars := variable.GetSessionVars(ctx); vars != nil {
+		p.SetSQLMode(vars.SQLMode)
+	}
+	stmts, err := p.Parse(src, charset, collation)
 	if err != nil {
 		log.Warnf("compiling %s, error: %v", src, err)
 		return nil, errors.Trace(err)
@@ -142,6 +151,7 @@ func runStmt(ctx context.Context, s ast.Statement, args ...interface{}) (ast.Rec
 	var rs ast.RecordSet
 	// before every execution, we must clear affectedrows.
 	variable.GetSessionVars(ctx).SetAffectedRows(0)
+	variable.GetSessionVars(ctx).ResetStmtExecStats()
 	if s.IsDDL() {
 		err = ctx.CommitTxn()
 		if err != nil {
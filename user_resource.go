@@ -0,0 +1,205 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tidb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
+)
+
+// The names of the resources in ErrUserLimitReached.
+const (
+	resourceMaxQuestions          = "max_questions"
+	resourceMaxUpdates            = "max_updates"
+	resourceMaxConnectionsPerHour = "max_connections_per_hour"
+)
+
+// userResources is the resource limits of an account in mysql.user, zero means no limit.
+// See https://dev.mysql.com/doc/refman/5.7/en/user-resources.html
+type userResources struct {
+	maxQuestions       int64
+	maxUpdates         int64
+	maxConnections     int64
+	maxUserConnections int64
+}
+
+// userResourceUsage is the resources used by an account.
+type userResourceUsage struct {
+	// The counters are reset when an hour has passed since hourStart.
+	hourStart   time.Time
+	questions   int64
+	updates     int64
+	connections int64
+	// active is the number of the current connections of the account.
+	active int64
+}
+
+// expired returns whether an hour has passed since the hourly counters are reset.
+func (u *userResourceUsage) expired(now time.Time) bool {
+	return now.Sub(u.hourStart) >= time.Hour
+}
+
+// userResourceTracker tracks the resource usage of the accounts in memory, so the usage is reset when
+// the server restarts, and every TiDB server limits the accounts separately.
+type userResourceTracker struct {
+	mu     sync.Mutex
+	usages map[string]*userResourceUsage
+	// pruneTime is the time when the expired usages are removed next.
+	pruneTime time.Time
+	// now is replaced in tests.
+	now func() time.Time
+}
+
+var resourceTracker = newUserResourceTracker()
+
+func newUserResourceTracker() *userResourceTracker {
+	return &userResourceTracker{
+		usages: make(map[string]*userResourceUsage),
+		now:    time.Now,
+	}
+}
+
+// usage gets the resource usage of the account, the hourly counters are reset if the hour has passed.
+// It must be called with the lock held.
+func (t *userResourceTracker) usage(account string) *userResourceUsage {
+	now := t.now()
+	t.prune(now)
+	u, ok := t.usages[account]
+	if !ok {
+		u = &userResourceUsage{hourStart: now}
+		t.usages[account] = u
+	}
+	if u.expired(now) {
+		u.hourStart = now
+		u.questions, u.updates, u.connections = 0, 0, 0
+	}
+	return u
+}
+
+// prune removes the usages of the accounts that have no connections after their hour has passed, their counters
+// would be reset anyway. It runs at most once an hour, so such a usage is kept for at most two hours.
+// It must be called with the lock held.
+func (t *userResourceTracker) prune(now time.Time) {
+	if now.Before(t.pruneTime) {
+		return
+	}
+	for account, u := range t.usages {
+		if u.active == 0 && u.expired(now) {
+			delete(t.usages, account)
+		}
+	}
+	t.pruneTime = now.Add(time.Hour)
+}
+
+// connect counts a new connection of the account. The connection moved from another session of the same
+// client connection, like the session reset by COM_RESET_CONNECTION, is counted without checking the limits.
+func (t *userResourceTracker) connect(account string, res userResources, maxUserConnections int64, moved bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	u := t.usage(account)
+	if !moved {
+		if maxUserConnections > 0 && u.active >= maxUserConnections {
+			return mysql.NewErr(mysql.ErrTooManyUserConnections, account)
+		}
+		if res.maxConnections > 0 && u.connections >= res.maxConnections {
+			return newUserLimitReachedErr(account, resourceMaxConnectionsPerHour, res.maxConnections)
+		}
+		u.connections++
+	}
+	u.active++
+	return nil
+}
+
+// disconnect releases a connection of the account.
+func (t *userResourceTracker) disconnect(account string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	t.prune(now)
+	u, ok := t.usages[account]
+	if !ok {
+		return
+	}
+	if u.active > 0 {
+		u.active--
+	}
+	if u.active == 0 && u.expired(now) {
+		delete(t.usages, account)
+	}
+}
+
+// forgetAccounts removes the usages of the accounts dropped or renamed by the statement. Like MySQL,
+// the usage of a renamed account isn't moved to its new name.
+func (t *userResourceTracker) forgetAccounts(node ast.StmtNode) {
+	var accounts []string
+	switch x := node.(type) {
+	case *ast.DropUserStmt:
+		accounts = x.UserList
+	case *ast.RenameUserStmt:
+		for _, userToUser := range x.UserToUsers {
+			accounts = append(accounts, userToUser.OldUser)
+		}
+	default:
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, account := range accounts {
+		delete(t.usages, account)
+	}
+}
+
+// countStmt counts a statement of the account, it fails if the statement exceeds the hourly limits.
+func (t *userResourceTracker) countStmt(account string, res userResources, isUpdate bool) error {
+	if res.maxQuestions == 0 && res.maxUpdates == 0 {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	u := t.usage(account)
+	if res.maxQuestions > 0 && u.questions >= res.maxQuestions {
+		return newUserLimitReachedErr(account, resourceMaxQuestions, res.maxQuestions)
+	}
+	if isUpdate && res.maxUpdates > 0 && u.updates >= res.maxUpdates {
+		return newUserLimitReachedErr(account, resourceMaxUpdates, res.maxUpdates)
+	}
+	u.questions++
+	if isUpdate {
+		u.updates++
+	}
+	return nil
+}
+
+func newUserLimitReachedErr(account, resource string, limit int64) error {
+	return mysql.NewErrf(mysql.ErrUserLimitReached, "User '%-.64s' has exceeded the '%s' resource (current value: %d)",
+		account, resource, limit)
+}
+
+// isUpdateStmt checks whether the statement modifies tables, databases or accounts,
+// such statements are limited by MAX_UPDATES_PER_HOUR.
+func isUpdateStmt(node ast.StmtNode) bool {
+	switch node.(type) {
+	case *ast.SelectStmt, *ast.UnionStmt, *ast.ShowStmt:
+		return false
+	case ast.DMLNode, ast.DDLNode:
+		return true
+	case *ast.GrantStmt, *ast.GrantRoleStmt, *ast.SetDefaultRoleStmt, *ast.CreateUserStmt, *ast.AlterUserStmt,
+		*ast.RenameUserStmt, *ast.DropUserStmt, *ast.SetPwdStmt:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,335 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit writes the audit log, it records the connections, the DDL jobs and the statements
+// that pass the filter as JSON lines, a record looks like:
+//
+//	{"time":"2017-05-11T10:19:10.123456789+08:00","event":"query","conn_id":1,"user":"root",
+//	"host":"127.0.0.1","db":"test","class":"ddl","digest":"42a1c8aae6f133e934d4bf0147491709",
+//	"sql":"create table t (a int)","affected_rows":0,"error_code":0}
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
+)
+
+// The events of the audit records.
+const (
+	EventConnect    = "connect"
+	EventChangeUser = "change_user"
+	EventDisconnect = "disconnect"
+	EventQuery      = "query"
+	EventDDLJob     = "ddl_job"
+)
+
+// The statement classes of the audit records, they are used to filter the records.
+const (
+	ClassConnection = "connection"
+	ClassDDL        = "ddl"
+	ClassDCL        = "dcl"
+	ClassDML        = "dml"
+	ClassQuery      = "query"
+	ClassOther      = "other"
+	// ClassInvalid is the class of the statements that fail to parse.
+	ClassInvalid = "invalid"
+)
+
+// DefaultClasses are the classes that are audited if the filter has no classes,
+// they are the connections, the privileged statements and the statements that fail to parse.
+var DefaultClasses = []string{ClassConnection, ClassDDL, ClassDCL, ClassInvalid}
+
+// Record is a record of the audit log.
+type Record struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+	// ConnID is the connection ID, it is 0 for the DDL jobs run by internal sessions.
+	ConnID uint64 `json:"conn_id"`
+	User   string `json:"user"`
+	Host   string `json:"host"`
+	DB     string `json:"db"`
+	Class  string `json:"class"`
+	Digest string `json:"digest,omitempty"`
+	SQL    string `json:"sql,omitempty"`
+	// JobID and JobType are only set for the DDL job records.
+	JobID        int64  `json:"job_id,omitempty"`
+	JobType      string `json:"job_type,omitempty"`
+	AffectedRows uint64 `json:"affected_rows"`
+	// ErrorCode is the MySQL error code of the event, 0 means it succeeds.
+	ErrorCode uint16 `json:"error_code"`
+}
+
+// SetUser sets the user and the host of the record from an account in the form of "user@host".
+func (r *Record) SetUser(account string) {
+	if i := strings.LastIndex(account, "@"); i >= 0 {
+		r.User, r.Host = account[:i], account[i+1:]
+		return
+	}
+	r.User, r.Host = account, ""
+}
+
+// Filter decides which records are written to the audit log. An empty list matches everything,
+// except that Classes falls back to DefaultClasses.
+type Filter struct {
+	// Users are the users to audit, an item is either a user name or an account in the form of "user@host".
+	Users []string `json:"users"`
+	// DBs are the current databases to audit, they don't apply to the connection records.
+	DBs     []string `json:"dbs"`
+	Classes []string `json:"classes"`
+}
+
+// LoadFilter reads the filter from a JSON file.
+func LoadFilter(name string) (*Filter, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	f := &Filter{}
+	if err = json.Unmarshal(data, f); err != nil {
+		return nil, errors.Annotatef(err, "invalid audit filter %s", name)
+	}
+	for _, class := range f.Classes {
+		if !isValidClass(class) {
+			return nil, errors.Errorf("invalid audit filter %s: unknown class %q", name, class)
+		}
+	}
+	return f, nil
+}
+
+func isValidClass(class string) bool {
+	switch class {
+	case ClassConnection, ClassDDL, ClassDCL, ClassDML, ClassQuery, ClassOther, ClassInvalid:
+		return true
+	}
+	return false
+}
+
+// Match returns whether the record passes the filter.
+func (f *Filter) Match(r *Record) bool {
+	classes := f.Classes
+	if len(classes) == 0 {
+		classes = DefaultClasses
+	}
+	if !containsString(classes, r.Class) {
+		return false
+	}
+	if len(f.Users) > 0 && !containsString(f.Users, r.User) && !containsString(f.Users, r.User+"@"+r.Host) {
+		return false
+	}
+	if len(f.DBs) > 0 && r.Class != ClassConnection {
+		found := false
+		for _, db := range f.DBs {
+			if strings.EqualFold(db, r.DB) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// StmtClass returns the audit class of the statement, it is ClassInvalid if node is nil,
+// which means the statement fails to parse.
+func StmtClass(node ast.StmtNode) string {
+	if node == nil {
+		return ClassInvalid
+	}
+	switch node.(type) {
+	case ast.DDLNode:
+		return ClassDDL
+	case *ast.CreateUserStmt, *ast.AlterUserStmt, *ast.DropUserStmt, *ast.RenameUserStmt,
+		*ast.GrantStmt, *ast.GrantRoleStmt, *ast.SetPwdStmt,
+		*ast.SetRoleStmt, *ast.SetDefaultRoleStmt:
+		return ClassDCL
+	case *ast.InsertStmt, *ast.UpdateStmt, *ast.DeleteStmt, *ast.LoadDataStmt:
+		return ClassDML
+	case *ast.SelectStmt, *ast.UnionStmt, *ast.ShowStmt, *ast.ExplainStmt:
+		return ClassQuery
+	}
+	return ClassOther
+}
+
+// ErrorCode returns the MySQL error code of err, it is 0 if err is nil.
+func ErrorCode(err error) uint16 {
+	if err == nil {
+		return 0
+	}
+	switch y := errors.Cause(err).(type) {
+	case *terror.Error:
+		return y.ToSQLError().Code
+	case *mysql.SQLError:
+		return y.Code
+	}
+	return mysql.ErrUnknown
+}
+
+var (
+	mu     sync.Mutex
+	file   *rotatingFile
+	filter = &Filter{}
+)
+
+// SetFile sets the file that the audit log is appended to. The file is rotated when it grows larger than
+// maxSize bytes, at most maxBackups rotated files named name.1 to name.N are kept, so maxBackups must be
+// at least 1 with the rotation. A non-positive maxSize disables the rotation. An empty name closes the current
+// file and disables the audit log.
+func SetFile(name string, maxSize int64, maxBackups int) error {
+	if name != "" && maxSize > 0 && maxBackups < 1 {
+		return errors.Errorf("invalid audit log max backups %d, it must be at least 1", maxBackups)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if file != nil {
+		if err := file.close(); err != nil {
+			log.Warnf("[audit] close %s error: %v", file.name, err)
+		}
+		file = nil
+	}
+	if name == "" {
+		return nil
+	}
+	f := &rotatingFile{name: name, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return errors.Trace(err)
+	}
+	file = f
+	return nil
+}
+
+// SetFilter sets the filter of the audit log, a nil filter audits the DefaultClasses of all the users.
+func SetFilter(f *Filter) {
+	if f == nil {
+		f = &Filter{}
+	}
+	mu.Lock()
+	filter = f
+	mu.Unlock()
+}
+
+// Enabled returns whether the audit log is enabled, the callers may skip building the records if it isn't.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return file != nil
+}
+
+// Write appends the record to the audit log if it passes the filter.
+func Write(r *Record) error {
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if file == nil || !filter.Match(r) {
+		return nil
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(file.write(append(data, '\n')))
+}
+
+// rotatingFile is an append only file that is rotated by size.
+type rotatingFile struct {
+	name       string
+	maxSize    int64
+	maxBackups int
+	f          *os.File
+	size       int64
+}
+
+func (rf *rotatingFile) open() error {
+	f, err := os.OpenFile(rf.name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.Trace(err)
+	}
+	rf.f, rf.size = f, info.Size()
+	return nil
+}
+
+func (rf *rotatingFile) close() error {
+	if rf.f == nil {
+		return nil
+	}
+	err := rf.f.Close()
+	rf.f = nil
+	return errors.Trace(err)
+}
+
+func (rf *rotatingFile) write(data []byte) error {
+	if rf.maxSize > 0 && rf.size > 0 && rf.size+int64(len(data)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if rf.f == nil {
+		if err := rf.open(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	n, err := rf.f.Write(data)
+	rf.size += int64(n)
+	return errors.Trace(err)
+}
+
+// rotate renames name.i to name.i+1 from the oldest backup, the oldest one is removed if there are
+// maxBackups of them, then renames the current file to name.1 and reopens it.
+func (rf *rotatingFile) rotate() error {
+	if err := rf.close(); err != nil {
+		return errors.Trace(err)
+	}
+	backup := func(i int) string {
+		return fmt.Sprintf("%s.%d", rf.name, i)
+	}
+	if err := os.Remove(backup(rf.maxBackups)); err != nil && !os.IsNotExist(err) {
+		return errors.Trace(err)
+	}
+	for i := rf.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(backup(i), backup(i+1)); err != nil && !os.IsNotExist(err) {
+			return errors.Trace(err)
+		}
+	}
+	if err := os.Rename(rf.name, backup(1)); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(rf.open())
+}
@@ -0,0 +1,183 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/juju/errors"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testAuditSuite{})
+
+type testAuditSuite struct{}
+
+func (s *testAuditSuite) TestFilter(c *C) {
+	defer testleak.AfterTest(c)()
+	newRecord := func(account, db, class string) *Record {
+		r := &Record{DB: db, Class: class}
+		r.SetUser(account)
+		return r
+	}
+	r := newRecord("root@127.0.0.1", "test", ClassDDL)
+	c.Assert(r.User, Equals, "root")
+	c.Assert(r.Host, Equals, "127.0.0.1")
+
+	f := &Filter{}
+	c.Assert(f.Match(r), IsTrue)
+	c.Assert(f.Match(newRecord("root@127.0.0.1", "test", ClassDCL)), IsTrue)
+	c.Assert(f.Match(newRecord("root@127.0.0.1", "", ClassConnection)), IsTrue)
+	c.Assert(f.Match(newRecord("root@127.0.0.1", "test", ClassInvalid)), IsTrue)
+	c.Assert(f.Match(newRecord("root@127.0.0.1", "test", ClassDML)), IsFalse)
+	c.Assert(f.Match(newRecord("root@127.0.0.1", "test", ClassQuery)), IsFalse)
+
+	f = &Filter{Users: []string{"u1", "u2@localhost"}, DBs: []string{"Test"}, Classes: []string{ClassDML, ClassConnection}}
+	c.Assert(f.Match(newRecord("u1@127.0.0.1", "test", ClassDML)), IsTrue)
+	c.Assert(f.Match(newRecord("u2@localhost", "test", ClassDML)), IsTrue)
+	c.Assert(f.Match(newRecord("u2@127.0.0.1", "test", ClassDML)), IsFalse)
+	c.Assert(f.Match(newRecord("u1@127.0.0.1", "mysql", ClassDML)), IsFalse)
+	c.Assert(f.Match(newRecord("u1@127.0.0.1", "test", ClassDDL)), IsFalse)
+	// The databases don't apply to the connections.
+	c.Assert(f.Match(newRecord("u1@127.0.0.1", "", ClassConnection)), IsTrue)
+}
+
+func (s *testAuditSuite) TestLoadFilter(c *C) {
+	defer testleak.AfterTest(c)()
+	dir, err := ioutil.TempDir("", "audit")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "filter.json")
+
+	err = ioutil.WriteFile(name, []byte(`{"users": ["root"], "classes": ["dml", "ddl"]}`), 0644)
+	c.Assert(err, IsNil)
+	f, err := LoadFilter(name)
+	c.Assert(err, IsNil)
+	c.Assert(f, DeepEquals, &Filter{Users: []string{"root"}, Classes: []string{ClassDML, ClassDDL}})
+
+	err = ioutil.WriteFile(name, []byte(`{"classes": ["select"]}`), 0644)
+	c.Assert(err, IsNil)
+	_, err = LoadFilter(name)
+	c.Assert(err, NotNil)
+	err = ioutil.WriteFile(name, []byte(`{"users": "root"}`), 0644)
+	c.Assert(err, IsNil)
+	_, err = LoadFilter(name)
+	c.Assert(err, NotNil)
+	_, err = LoadFilter(filepath.Join(dir, "not_exists.json"))
+	c.Assert(err, NotNil)
+}
+
+func (s *testAuditSuite) TestStmtClass(c *C) {
+	defer testleak.AfterTest(c)()
+	tbl := []struct {
+		sql   string
+		class string
+	}{
+		{"create table t (a int)", ClassDDL},
+		{"drop database db", ClassDDL},
+		{"create user 'u'@'%'", ClassDCL},
+		{"grant select on *.* to 'u'@'%'", ClassDCL},
+		{"set password = 'pwd'", ClassDCL},
+		{"set role all", ClassDCL},
+		{"insert t values (1)", ClassDML},
+		{"delete from t", ClassDML},
+		{"select * from t", ClassQuery},
+		{"show tables", ClassQuery},
+		{"begin", ClassOther},
+		{"set @a = 1", ClassOther},
+	}
+	p := parser.New()
+	for _, t := range tbl {
+		stmt, err := p.ParseOneStmt(t.sql, "", "")
+		c.Assert(err, IsNil, Commentf("sql %s", t.sql))
+		c.Assert(StmtClass(stmt), Equals, t.class, Commentf("sql %s", t.sql))
+	}
+	c.Assert(StmtClass(nil), Equals, ClassInvalid)
+}
+
+func (s *testAuditSuite) TestErrorCode(c *C) {
+	defer testleak.AfterTest(c)()
+	c.Assert(ErrorCode(nil), Equals, uint16(0))
+	c.Assert(ErrorCode(errors.Trace(mysql.NewErr(mysql.ErrAccessDenied, "u", "h", "YES"))), Equals, uint16(mysql.ErrAccessDenied))
+	c.Assert(ErrorCode(errors.New("unknown")), Equals, uint16(mysql.ErrUnknown))
+}
+
+func (s *testAuditSuite) TestFile(c *C) {
+	defer testleak.AfterTest(c)()
+	c.Assert(Enabled(), IsFalse)
+	c.Assert(Write(&Record{Class: ClassDDL}), IsNil)
+
+	dir, err := ioutil.TempDir("", "audit")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "audit.log")
+	// The rotation needs at least one backup.
+	c.Assert(SetFile(name, 100, 0), NotNil)
+	c.Assert(Enabled(), IsFalse)
+	// Each record is longer than 100 bytes, so every record is rotated to a backup.
+	err = SetFile(name, 100, 2)
+	c.Assert(err, IsNil)
+	defer SetFile("", 0, 0)
+	c.Assert(Enabled(), IsTrue)
+
+	for _, sql := range []string{"create table t1 (a int)", "create table t2 (a int)", "select 1", "create table t3 (a int)", "create table t4 (a int)"} {
+		r := &Record{Event: EventQuery, ConnID: 1, DB: "test", Class: ClassDDL, SQL: sql}
+		if sql == "select 1" {
+			r.Class = ClassQuery
+		}
+		r.SetUser("root@127.0.0.1")
+		c.Assert(Write(r), IsNil)
+	}
+	readRecords := func(name string) []*Record {
+		f, err1 := os.Open(name)
+		c.Assert(err1, IsNil)
+		defer f.Close()
+		var records []*Record
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			r := &Record{}
+			c.Assert(json.Unmarshal(scanner.Bytes(), r), IsNil)
+			records = append(records, r)
+		}
+		c.Assert(scanner.Err(), IsNil)
+		return records
+	}
+	records := readRecords(name)
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].SQL, Equals, "create table t4 (a int)")
+	c.Assert(records[0].User, Equals, "root")
+	c.Assert(records[0].Host, Equals, "127.0.0.1")
+	c.Assert(records[0].Time.IsZero(), IsFalse)
+	c.Assert(readRecords(name + ".1")[0].SQL, Equals, "create table t3 (a int)")
+	c.Assert(readRecords(name + ".2")[0].SQL, Equals, "create table t2 (a int)")
+	_, err = os.Stat(name + ".3")
+	c.Assert(os.IsNotExist(err), IsTrue)
+
+	SetFilter(&Filter{Classes: []string{ClassQuery}})
+	defer SetFilter(nil)
+	c.Assert(Write(&Record{Class: ClassDDL, SQL: "create table t5 (a int)"}), IsNil)
+	c.Assert(readRecords(name)[0].SQL, Equals, "create table t4 (a int)")
+}
@@ -0,0 +1,188 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+)
+
+// The password of caching_sha2_password is stored in the format of MySQL:
+// "$A$" + 3 digits of the iterations in thousands + "$" + 20 bytes of salt + 43 bytes of the SHA256-crypt digest.
+// See https://dev.mysql.com/doc/refman/8.0/en/caching-sha2-pluggable-authentication.html
+const (
+	sha2SaltLen      = 20
+	sha2DigestLen    = 43
+	sha2Iterations   = 5
+	sha2IterationMul = 1000
+	sha2Prefix       = "$A$"
+)
+
+// cryptAlphabet is the alphabet of the base64 encoding in SHA-crypt.
+const cryptAlphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// EncodeSha2Password hashes the plaintext password with a random salt for caching_sha2_password.
+func EncodeSha2Password(pwd string) string {
+	if len(pwd) == 0 {
+		return ""
+	}
+	salt := make([]byte, sha2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		panic(err)
+	}
+	// The salt must not contain '$' or NUL, it is mapped into the printable characters.
+	for i := range salt {
+		salt[i] = cryptAlphabet[salt[i]&0x3f]
+	}
+	return encodeSha2Password(pwd, salt, sha2Iterations)
+}
+
+func encodeSha2Password(pwd string, salt []byte, iterations int) string {
+	digest := sha256Crypt([]byte(pwd), salt, iterations*sha2IterationMul)
+	return fmt.Sprintf("%s%03d$%s%s", sha2Prefix, iterations, salt, digest)
+}
+
+// CheckSha2Password checks whether the plaintext password matches the hashed password of caching_sha2_password.
+func CheckSha2Password(hashed string, pwd []byte) bool {
+	if len(hashed) == 0 {
+		return len(pwd) == 0
+	}
+	// "$A$005$" is followed by the salt and the digest.
+	prefixLen := len(sha2Prefix) + 4
+	if len(hashed) != prefixLen+sha2SaltLen+sha2DigestLen || hashed[:len(sha2Prefix)] != sha2Prefix || hashed[prefixLen-1] != '$' {
+		return false
+	}
+	iterations, err := strconv.Atoi(hashed[len(sha2Prefix) : prefixLen-1])
+	if err != nil {
+		return false
+	}
+	salt := []byte(hashed[prefixLen : prefixLen+sha2SaltLen])
+	return encodeSha2Password(string(pwd), salt, iterations) == hashed
+}
+
+// Sha256Hash is an util function to calculate sha256 hash.
+func Sha256Hash(bs []byte) []byte {
+	sum := sha256.Sum256(bs)
+	return sum[:]
+}
+
+// CheckSha2Scramble checks the scramble of caching_sha2_password with the cached SHA256(SHA256(password)).
+// The scramble is SHA256(password) XOR SHA256(SHA256(SHA256(password)) <concat> nonce), the nonce is the salt.
+func CheckSha2Scramble(scramble, nonce, doubleSha256 []byte) bool {
+	if len(scramble) != sha256.Size {
+		return false
+	}
+	h := sha256.New()
+	h.Write(doubleSha256)
+	h.Write(nonce)
+	stage1 := h.Sum(nil)
+	for i := range stage1 {
+		stage1[i] ^= scramble[i]
+	}
+	return bytes.Equal(Sha256Hash(stage1), doubleSha256)
+}
+
+// sha256Crypt computes the digest of SHA-crypt with SHA256.
+// See https://www.akkadia.org/drepper/SHA-crypt.txt
+func sha256Crypt(key, salt []byte, rounds int) string {
+	b := sha256.New()
+	b.Write(key)
+	b.Write(salt)
+	b.Write(key)
+	sumB := b.Sum(nil)
+
+	a := sha256.New()
+	a.Write(key)
+	a.Write(salt)
+	for i := len(key); i > 0; i -= sha256.Size {
+		if i > sha256.Size {
+			a.Write(sumB)
+		} else {
+			a.Write(sumB[:i])
+		}
+	}
+	for i := len(key); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			a.Write(sumB)
+		} else {
+			a.Write(key)
+		}
+	}
+	sumA := a.Sum(nil)
+
+	dp := sha256.New()
+	for range key {
+		dp.Write(key)
+	}
+	p := repeatDigest(dp.Sum(nil), len(key))
+
+	ds := sha256.New()
+	for i := 0; i < 16+int(sumA[0]); i++ {
+		ds.Write(salt)
+	}
+	s := repeatDigest(ds.Sum(nil), len(salt))
+
+	sumC := sumA
+	for i := 0; i < rounds; i++ {
+		c := sha256.New()
+		if i&1 != 0 {
+			c.Write(p)
+		} else {
+			c.Write(sumC)
+		}
+		if i%3 != 0 {
+			c.Write(s)
+		}
+		if i%7 != 0 {
+			c.Write(p)
+		}
+		if i&1 != 0 {
+			c.Write(sumC)
+		} else {
+			c.Write(p)
+		}
+		sumC = c.Sum(nil)
+	}
+
+	buf := make([]byte, 0, sha2DigestLen)
+	groups := [][3]int{
+		{0, 10, 20}, {21, 1, 11}, {12, 22, 2}, {3, 13, 23}, {24, 4, 14},
+		{15, 25, 5}, {6, 16, 26}, {27, 7, 17}, {18, 28, 8}, {9, 19, 29},
+	}
+	for _, g := range groups {
+		buf = appendCrypt64(buf, uint(sumC[g[0]])<<16|uint(sumC[g[1]])<<8|uint(sumC[g[2]]), 4)
+	}
+	buf = appendCrypt64(buf, uint(sumC[31])<<8|uint(sumC[30]), 3)
+	return string(buf)
+}
+
+// repeatDigest repeats the digest to the length.
+func repeatDigest(digest []byte, length int) []byte {
+	result := make([]byte, 0, length)
+	for len(result)+len(digest) <= length {
+		result = append(result, digest...)
+	}
+	return append(result, digest[:length-len(result)]...)
+}
+
+func appendCrypt64(buf []byte, w uint, n int) []byte {
+	for i := 0; i < n; i++ {
+		buf = append(buf, cryptAlphabet[w&0x3f])
+		w >>= 6
+	}
+	return buf
+}
@@ -0,0 +1,60 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto/sha256"
+
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testCachingSha2Suite{})
+
+type testCachingSha2Suite struct{}
+
+func (s *testCachingSha2Suite) TestSha256Crypt(c *C) {
+	// The test vector of SHA-crypt with the default 5000 rounds.
+	c.Assert(sha256Crypt([]byte("Hello world!"), []byte("saltstring"), 5000), Equals, "5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5")
+}
+
+func (s *testCachingSha2Suite) TestSha2Password(c *C) {
+	hashed := EncodeSha2Password("abc")
+	c.Assert(hashed, HasLen, 70)
+	c.Assert(hashed[:7], Equals, "$A$005$")
+	c.Assert(CheckSha2Password(hashed, []byte("abc")), IsTrue)
+	c.Assert(CheckSha2Password(hashed, []byte("abd")), IsFalse)
+	c.Assert(CheckSha2Password(hashed[:60], []byte("abc")), IsFalse)
+	c.Assert(EncodeSha2Password("abc"), Not(Equals), hashed)
+
+	c.Assert(EncodeSha2Password(""), Equals, "")
+	c.Assert(CheckSha2Password("", nil), IsTrue)
+	c.Assert(CheckSha2Password("", []byte("abc")), IsFalse)
+}
+
+func (s *testCachingSha2Suite) TestSha2Scramble(c *C) {
+	pwd := []byte("abc")
+	nonce := []byte("01234567890123456789")
+	stage1 := Sha256Hash(pwd)
+	doubleSha256 := Sha256Hash(stage1)
+	h := sha256.New()
+	h.Write(doubleSha256)
+	h.Write(nonce)
+	scramble := h.Sum(nil)
+	for i := range scramble {
+		scramble[i] ^= stage1[i]
+	}
+	c.Assert(CheckSha2Scramble(scramble, nonce, doubleSha256), IsTrue)
+	c.Assert(CheckSha2Scramble(scramble, []byte("98765432109876543210"), doubleSha256), IsFalse)
+	c.Assert(CheckSha2Scramble(scramble[:20], nonce, doubleSha256), IsFalse)
+}
@@ -0,0 +1,45 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"sync"
+
+	"github.com/ngaut/log"
+)
+
+// ActionOnExceed is the action taken when the memory usage of a Tracker exceeds its limit.
+type ActionOnExceed interface {
+	// Action is called with the exceeded tracker. If it returns an error,
+	// the consumer should stop and return the error.
+	Action(t *Tracker) error
+}
+
+// LogOnExceed logs a warning only once when the memory usage exceeds the limit.
+type LogOnExceed struct {
+	mu    sync.Mutex
+	acted bool
+}
+
+// Action implements the ActionOnExceed interface.
+func (a *LogOnExceed) Action(t *Tracker) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.acted {
+		a.acted = true
+		log.Warnf("[memory] %s consumes %s, exceeds the quota %s:%s", t.Label(),
+			FormatBytes(t.BytesConsumed()), FormatBytes(t.BytesLimit()), t)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Tracker is used to track the memory usage during query execution.
+// Trackers are arranged into a tree, for example session -> statement -> executor,
+// the bytes consumed by a tracker are also consumed by all its ancestors.
+// A tracker can have a limit, when its consumption exceeds the limit, its ActionOnExceed is triggered.
+type Tracker struct {
+	label          string
+	bytesLimit     int64
+	actionOnExceed ActionOnExceed
+
+	// bytesConsumed and maxConsumed are accessed atomically.
+	bytesConsumed int64
+	maxConsumed   int64
+
+	mu struct {
+		sync.Mutex
+		children []*Tracker
+	}
+	parent *Tracker
+}
+
+// NewTracker creates a memory tracker.
+// bytesLimit <= 0 means no limit.
+func NewTracker(label string, bytesLimit int64) *Tracker {
+	return &Tracker{
+		label:      label,
+		bytesLimit: bytesLimit,
+	}
+}
+
+// Label gets the label of the tracker.
+func (t *Tracker) Label() string {
+	return t.label
+}
+
+// BytesLimit gets the limit of the tracker.
+func (t *Tracker) BytesLimit() int64 {
+	return t.bytesLimit
+}
+
+// SetActionOnExceed sets the action taken when the consumption exceeds the limit.
+func (t *Tracker) SetActionOnExceed(a ActionOnExceed) {
+	t.actionOnExceed = a
+}
+
+// AttachTo attaches the tracker as a child of parent, the current consumption is added to parent.
+// If the tracker already has a parent, it is detached first.
+func (t *Tracker) AttachTo(parent *Tracker) {
+	if t.parent != nil {
+		t.Detach()
+	}
+	parent.mu.Lock()
+	parent.mu.children = append(parent.mu.children, t)
+	parent.mu.Unlock()
+	t.parent = parent
+	// The action of the parent is not triggered here, it will be triggered by the next consumption.
+	parent.consume(t.BytesConsumed())
+}
+
+// Detach detaches the tracker from its parent, the consumption is removed from the ancestors.
+func (t *Tracker) Detach() {
+	parent := t.parent
+	if parent == nil {
+		return
+	}
+	parent.mu.Lock()
+	for i, child := range parent.mu.children {
+		if child == t {
+			parent.mu.children = append(parent.mu.children[:i], parent.mu.children[i+1:]...)
+			break
+		}
+	}
+	parent.mu.Unlock()
+	parent.consume(-t.BytesConsumed())
+	t.parent = nil
+}
+
+// consume adds bytes to the tracker and all its ancestors, it returns the farthest ancestor
+// whose consumption exceeds its limit.
+func (t *Tracker) consume(bytes int64) *Tracker {
+	var exceeded *Tracker
+	for tracker := t; tracker != nil; tracker = tracker.parent {
+		consumed := atomic.AddInt64(&tracker.bytesConsumed, bytes)
+		for {
+			maxConsumed := atomic.LoadInt64(&tracker.maxConsumed)
+			if consumed <= maxConsumed || atomic.CompareAndSwapInt64(&tracker.maxConsumed, maxConsumed, consumed) {
+				break
+			}
+		}
+		if bytes > 0 && tracker.bytesLimit > 0 && consumed > tracker.bytesLimit {
+			exceeded = tracker
+		}
+	}
+	return exceeded
+}
+
+// Consume adds bytes to the tracker, negative bytes means releasing memory.
+// If the consumption of the tracker or one of its ancestors exceeds the limit,
+// the action of the farthest one is taken and its error is returned.
+func (t *Tracker) Consume(bytes int64) error {
+	exceeded := t.consume(bytes)
+	if exceeded == nil || exceeded.actionOnExceed == nil {
+		return nil
+	}
+	return exceeded.actionOnExceed.Action(exceeded)
+}
+
+// WouldExceed returns whether consuming bytes makes the tracker or one of its ancestors exceed the limit.
+// The consumers that can release memory by themselves, like spilling executors, use it to release memory
+// before the action of the limit is taken.
+func (t *Tracker) WouldExceed(bytes int64) bool {
+	for tracker := t; tracker != nil; tracker = tracker.parent {
+		if tracker.bytesLimit > 0 && tracker.BytesConsumed()+bytes > tracker.bytesLimit {
+			return true
+		}
+	}
+	return false
+}
+
+// Release releases bytes consumed by the tracker, it never triggers the action.
+func (t *Tracker) Release(bytes int64) {
+	t.consume(-bytes)
+}
+
+// ReplaceBytes sets the consumption of the tracker to bytes.
+func (t *Tracker) ReplaceBytes(bytes int64) error {
+	return t.Consume(bytes - t.BytesConsumed())
+}
+
+// BytesConsumed gets the bytes consumed by the tracker and its children.
+func (t *Tracker) BytesConsumed() int64 {
+	return atomic.LoadInt64(&t.bytesConsumed)
+}
+
+// MaxConsumed gets the peak consumption of the tracker.
+func (t *Tracker) MaxConsumed() int64 {
+	return atomic.LoadInt64(&t.maxConsumed)
+}
+
+// String returns the tracker tree with the consumption of every tracker.
+func (t *Tracker) String() string {
+	buf := bytes.NewBufferString("\n")
+	t.toString("", buf)
+	return buf.String()
+}
+
+func (t *Tracker) toString(indent string, buf *bytes.Buffer) {
+	fmt.Fprintf(buf, "%s\"%s\"{\n", indent, t.label)
+	if t.bytesLimit > 0 {
+		fmt.Fprintf(buf, "%s  \"quota\": %s\n", indent, FormatBytes(t.bytesLimit))
+	}
+	fmt.Fprintf(buf, "%s  \"consumed\": %s\n", indent, FormatBytes(t.BytesConsumed()))
+	fmt.Fprintf(buf, "%s  \"max consumed\": %s\n", indent, FormatBytes(t.MaxConsumed()))
+	t.mu.Lock()
+	for _, child := range t.mu.children {
+		child.toString(indent+"  ", buf)
+	}
+	t.mu.Unlock()
+	buf.WriteString(indent + "}\n")
+}
+
+// FormatBytes uses the largest unit to format the bytes.
+func FormatBytes(numBytes int64) string {
+	units := []string{"Bytes", "KB", "MB", "GB"}
+	size := float64(numBytes)
+	i := 0
+	for ; i < len(units)-1 && (size >= 1024 || size <= -1024); i++ {
+		size /= 1024
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d Bytes", numBytes)
+	}
+	return fmt.Sprintf("%.2f %s", size, units[i])
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	check.TestingT(t)
+}
+
+var _ = check.Suite(&testTrackerSuite{})
+
+type testTrackerSuite struct{}
+
+type errOnExceed struct {
+	acted int
+}
+
+func (a *errOnExceed) Action(t *Tracker) error {
+	a.acted++
+	return errors.New(t.Label() + " exceeds")
+}
+
+func (s *testTrackerSuite) TestConsume(c *check.C) {
+	defer testleak.AfterTest(c)()
+	session := NewTracker("session", -1)
+	stmt := NewTracker("statement", 100)
+	action := &errOnExceed{}
+	stmt.SetActionOnExceed(action)
+	stmt.AttachTo(session)
+	exec1 := NewTracker("exec1", -1)
+	exec1.AttachTo(stmt)
+	exec2 := NewTracker("exec2", -1)
+	exec2.AttachTo(stmt)
+
+	c.Assert(exec1.Consume(40), check.IsNil)
+	c.Assert(exec2.Consume(50), check.IsNil)
+	c.Assert(stmt.BytesConsumed(), check.Equals, int64(90))
+	c.Assert(session.BytesConsumed(), check.Equals, int64(90))
+
+	err := exec2.Consume(20)
+	c.Assert(err, check.NotNil)
+	c.Assert(err.Error(), check.Equals, "statement exceeds")
+	c.Assert(action.acted, check.Equals, 1)
+	c.Assert(stmt.MaxConsumed(), check.Equals, int64(110))
+
+	// Releasing memory never triggers the action.
+	c.Assert(exec2.Consume(-70), check.IsNil)
+	c.Assert(stmt.BytesConsumed(), check.Equals, int64(40))
+	c.Assert(exec1.ReplaceBytes(10), check.IsNil)
+	c.Assert(stmt.BytesConsumed(), check.Equals, int64(10))
+	exec1.Release(10)
+	c.Assert(stmt.BytesConsumed(), check.Equals, int64(0))
+	c.Assert(exec1.Consume(10), check.IsNil)
+
+	// The limits of the ancestors are checked before consuming.
+	c.Assert(exec2.WouldExceed(90), check.IsFalse)
+	c.Assert(exec2.WouldExceed(91), check.IsTrue)
+	c.Assert(session.WouldExceed(1<<40), check.IsFalse)
+
+	str := session.String()
+	c.Assert(strings.Contains(str, `"exec1"`), check.IsTrue)
+	c.Assert(strings.Contains(str, `"quota": 100 Bytes`), check.IsTrue)
+
+	stmt.Detach()
+	c.Assert(session.BytesConsumed(), check.Equals, int64(0))
+	c.Assert(session.MaxConsumed(), check.Equals, int64(110))
+	c.Assert(strings.Contains(session.String(), `"exec1"`), check.IsFalse)
+}
+
+func (s *testTrackerSuite) TestLogOnExceed(c *check.C) {
+	defer testleak.AfterTest(c)()
+	t := NewTracker("statement", 10)
+	action := &LogOnExceed{}
+	t.SetActionOnExceed(action)
+	c.Assert(t.Consume(20), check.IsNil)
+	c.Assert(action.acted, check.IsTrue)
+}
+
+func (s *testTrackerSuite) TestFormatBytes(c *check.C) {
+	defer testleak.AfterTest(c)()
+	c.Assert(FormatBytes(100), check.Equals, "100 Bytes")
+	c.Assert(FormatBytes(2048), check.Equals, "2.00 KB")
+	c.Assert(FormatBytes(3*1024*1024), check.Equals, "3.00 MB")
+	c.Assert(FormatBytes(5*1024*1024*1024), check.Equals, "5.00 GB")
+}
@@ -0,0 +1,288 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slowlog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+)
+
+// The field names of an entry in the slow log file, an entry looks like:
+//
+//	# Time: 2017-05-11T10:19:10.123456789+08:00
+//	# Txn_start_ts: 392417430447013889
+//	# Conn_ID: 1
+//	# User: root@127.0.0.1
+//	# DB: test
+//	# Query_time: 1.527627037
+//	# Parse_time: 0.000054933
+//	# Compile_time: 0.000129729
+//	# Cop_time: 1.485716128
+//	# Rows: 1
+//	# Digest: 42a1c8aae6f133e934d4bf0147491709
+//	select * from t where a = 1;
+//
+// The newlines and backslashes in the field values are escaped, and the lines of the SQL that start with '#' or
+// '\' are prefixed with '\', so a statement can't forge the fields or the start of an entry.
+const (
+	fieldPrefix      = "# "
+	fieldTime        = "Time"
+	fieldTxnStartTS  = "Txn_start_ts"
+	fieldConnID      = "Conn_ID"
+	fieldUser        = "User"
+	fieldDB          = "DB"
+	fieldQueryTime   = "Query_time"
+	fieldParseTime   = "Parse_time"
+	fieldCompileTime = "Compile_time"
+	fieldCopTime     = "Cop_time"
+	fieldRows        = "Rows"
+	fieldDigest      = "Digest"
+
+	sqlEscape = '\\'
+)
+
+var (
+	fieldEscaper   = strings.NewReplacer("\\", "\\\\", "\n", "\\n")
+	fieldUnescaper = strings.NewReplacer("\\\\", "\\", "\\n", "\n")
+)
+
+// Entry is an entry of the slow log.
+type Entry struct {
+	// StartTime is the time when the statement starts.
+	StartTime  time.Time
+	TxnStartTS uint64
+	ConnID     uint64
+	User       string
+	DB         string
+	// QueryTime is the total time of the statement, including parse and compile.
+	QueryTime   time.Duration
+	ParseTime   time.Duration
+	CompileTime time.Duration
+	// CopTime is the time waiting for the coprocessor responses.
+	CopTime time.Duration
+	// Rows is the number of rows affected or sent to the client.
+	Rows   uint64
+	Digest string
+	SQL    string
+}
+
+func (e *Entry) encode() []byte {
+	var b bytes.Buffer
+	writeField := func(name string, value interface{}) {
+		fmt.Fprintf(&b, "%s%s: %v\n", fieldPrefix, name, value)
+	}
+	writeField(fieldTime, e.StartTime.Format(time.RFC3339Nano))
+	writeField(fieldTxnStartTS, e.TxnStartTS)
+	writeField(fieldConnID, e.ConnID)
+	writeField(fieldUser, fieldEscaper.Replace(e.User))
+	writeField(fieldDB, fieldEscaper.Replace(e.DB))
+	writeField(fieldQueryTime, e.QueryTime.Seconds())
+	writeField(fieldParseTime, e.ParseTime.Seconds())
+	writeField(fieldCompileTime, e.CompileTime.Seconds())
+	writeField(fieldCopTime, e.CopTime.Seconds())
+	writeField(fieldRows, e.Rows)
+	writeField(fieldDigest, fieldEscaper.Replace(e.Digest))
+	sql := strings.TrimSpace(e.SQL)
+	if !strings.HasSuffix(sql, ";") {
+		sql += ";"
+	}
+	for _, line := range strings.Split(sql, "\n") {
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, string(sqlEscape)) {
+			b.WriteByte(sqlEscape)
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+var (
+	mu       sync.Mutex
+	file     *os.File
+	filePath string
+)
+
+// SetFile sets the file that the slow log is appended to. An empty name closes the current file,
+// the slow log is written to the server log then.
+func SetFile(name string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if file != nil {
+		if err := file.Close(); err != nil {
+			log.Warnf("[slowlog] close %s error: %v", filePath, err)
+		}
+		file, filePath = nil, ""
+	}
+	if name == "" {
+		return nil
+	}
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	file, filePath = f, name
+	return nil
+}
+
+// FilePath returns the path of the slow log file, it is empty if SetFile has not been called.
+func FilePath() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return filePath
+}
+
+// Write appends an entry to the slow log.
+func Write(e *Entry) error {
+	data := e.encode()
+	mu.Lock()
+	defer mu.Unlock()
+	if file == nil {
+		log.Warnf("[SLOW_QUERY] %s", bytes.Replace(bytes.TrimSpace(data), []byte("\n"), []byte(" "), -1))
+		return nil
+	}
+	_, err := file.Write(data)
+	return errors.Trace(err)
+}
+
+// ReadFile reads all the entries in the slow log file, it returns nil if there is no slow log file.
+func ReadFile() ([]*Entry, error) {
+	name := FilePath()
+	if name == "" {
+		return nil, nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Trace(err)
+	}
+	defer f.Close()
+	entries, err := Parse(f)
+	return entries, errors.Trace(err)
+}
+
+// Parse parses the entries of the slow log from r. The lines before the first entry are skipped, and so are the
+// invalid entries and the truncated ones, whose SQL doesn't end with ';'.
+func Parse(r io.Reader) ([]*Entry, error) {
+	var (
+		entries []*Entry
+		cur     *Entry
+		sql     []string
+		inSQL   bool
+	)
+	finish := func() {
+		if cur != nil {
+			cur.SQL = strings.Join(sql, "\n")
+			if strings.HasSuffix(cur.SQL, ";") {
+				entries = append(entries, cur)
+			} else {
+				log.Warnf("[slowlog] skip the truncated entry at %v", cur.StartTime)
+			}
+		}
+		cur, sql, inSQL = nil, nil, false
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, fieldPrefix+fieldTime+": ") {
+			finish()
+			cur = &Entry{}
+		} else if cur == nil {
+			continue
+		}
+		if !inSQL && strings.HasPrefix(line, fieldPrefix) {
+			err := cur.parseField(line[len(fieldPrefix):])
+			if err != nil {
+				// The rest lines of the entry are skipped until the next entry.
+				log.Warnf("[slowlog] skip the invalid entry: %v", err)
+				cur, sql, inSQL = nil, nil, false
+			}
+			continue
+		}
+		inSQL = true
+		if len(line) > 0 && line[0] == sqlEscape {
+			line = line[1:]
+		}
+		sql = append(sql, line)
+	}
+	if err := scanner.Err(); err != nil {
+		// The entries after a too long line can't be read.
+		log.Warnf("[slowlog] skip the rest of the slow log: %v", err)
+		return entries, nil
+	}
+	finish()
+	return entries, nil
+}
+
+func (e *Entry) parseField(line string) error {
+	idx := strings.Index(line, ": ")
+	if idx < 0 {
+		// An empty value has no trailing space.
+		idx = strings.Index(line, ":")
+		if idx < 0 {
+			return errors.Errorf("invalid slow log field %q", line)
+		}
+	}
+	name := line[:idx]
+	value := strings.TrimSpace(line[idx+1:])
+	var err error
+	switch name {
+	case fieldTime:
+		e.StartTime, err = time.Parse(time.RFC3339Nano, value)
+	case fieldTxnStartTS:
+		e.TxnStartTS, err = strconv.ParseUint(value, 10, 64)
+	case fieldConnID:
+		e.ConnID, err = strconv.ParseUint(value, 10, 64)
+	case fieldUser:
+		e.User = fieldUnescaper.Replace(value)
+	case fieldDB:
+		e.DB = fieldUnescaper.Replace(value)
+	case fieldQueryTime:
+		e.QueryTime, err = parseSeconds(value)
+	case fieldParseTime:
+		e.ParseTime, err = parseSeconds(value)
+	case fieldCompileTime:
+		e.CompileTime, err = parseSeconds(value)
+	case fieldCopTime:
+		e.CopTime, err = parseSeconds(value)
+	case fieldRows:
+		e.Rows, err = strconv.ParseUint(value, 10, 64)
+	case fieldDigest:
+		e.Digest = fieldUnescaper.Replace(value)
+	}
+	if err != nil {
+		return errors.Errorf("invalid slow log field %q: %v", line, err)
+	}
+	return nil
+}
+
+func parseSeconds(value string) (time.Duration, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return time.Duration(f * float64(time.Second)), nil
+}
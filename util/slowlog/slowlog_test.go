@@ -0,0 +1,109 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slowlog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testSlowLogSuite{})
+
+type testSlowLogSuite struct{}
+
+func (s *testSlowLogSuite) TestParse(c *C) {
+	defer testleak.AfterTest(c)()
+	start, err := time.Parse(time.RFC3339Nano, "2017-05-11T10:19:10.123456789+08:00")
+	c.Assert(err, IsNil)
+	e1 := &Entry{
+		StartTime:   start,
+		TxnStartTS:  392417430447013889,
+		ConnID:      1,
+		User:        "root@127.0.0.1",
+		DB:          "test",
+		QueryTime:   1527627037,
+		ParseTime:   54933,
+		CompileTime: 129729,
+		CopTime:     1485716128,
+		Rows:        1,
+		Digest:      "42a1c8aae6f133e934d4bf0147491709",
+		SQL:         "select * from t where a = 1;",
+	}
+	// The SQL and the fields can't forge the fields or the start of an entry.
+	e2 := &Entry{
+		StartTime: start.Add(time.Second),
+		User:      "u\n# Time: 2017-05-11T10:19:10+08:00",
+		DB:        "a\\nb",
+		SQL:       "select *\n# not a field\n# Time: 2017-05-11T10:19:10+08:00\n\\N from t;",
+	}
+	var b bytes.Buffer
+	b.WriteString("garbage before the first entry\n")
+	b.Write(e1.encode())
+	b.Write(e2.encode())
+
+	entries, err := Parse(&b)
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 2)
+	c.Assert(entries[0].StartTime.Equal(e1.StartTime), IsTrue)
+	entries[0].StartTime = e1.StartTime
+	c.Assert(entries[0], DeepEquals, e1)
+	c.Assert(entries[1].User, Equals, e2.User)
+	c.Assert(entries[1].DB, Equals, e2.DB)
+	c.Assert(entries[1].SQL, Equals, e2.SQL)
+
+	// The invalid and the truncated entries are skipped.
+	b.Reset()
+	b.WriteString("# Time: 2017-05-11\n# Rows: 1\nselect 1;\n")
+	b.Write(e1.encode())
+	b.WriteString("# Time: 2017-05-11T10:19:10+08:00\n# Rows: x\nselect 2;\n")
+	data := e2.encode()
+	b.Write(data[:len(data)-3])
+	entries, err = Parse(&b)
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 1)
+	c.Assert(entries[0].SQL, Equals, e1.SQL)
+}
+
+func (s *testSlowLogSuite) TestFile(c *C) {
+	defer testleak.AfterTest(c)()
+	entries, err := ReadFile()
+	c.Assert(err, IsNil)
+	c.Assert(entries, IsNil)
+
+	dir, err := ioutil.TempDir("", "slowlog")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	err = SetFile(filepath.Join(dir, "slow.log"))
+	c.Assert(err, IsNil)
+	defer SetFile("")
+
+	c.Assert(Write(&Entry{StartTime: time.Now(), SQL: "select 1"}), IsNil)
+	c.Assert(Write(&Entry{StartTime: time.Now(), SQL: "select 2"}), IsNil)
+	entries, err = ReadFile()
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 2)
+	c.Assert(entries[0].SQL, Equals, "select 1;")
+	c.Assert(entries[1].SQL, Equals, "select 2;")
+}
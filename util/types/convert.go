@@ -27,11 +27,6 @@ import (
 	"github.com/pingcap/tidb/mysql"
 )
 
-var (
-	// ErrValueTruncated is used when a value has been truncated during conversion.
-	ErrValueTruncated = errors.New("value has been truncated")
-)
-
 // InvConv returns a failed conversion error.
 func invConv(val interface{}, tp byte) (interface{}, error) {
 	return nil, errors.Errorf("cannot convert %v (type %T) to type %s", val, val, TypeStr(tp))
@@ -194,7 +189,7 @@ func StrToFloat(str string) (float64, error) {
 	validStr := getValidFloatPrefix(str)
 	var err error
 	if validStr != str {
-		err = ErrValueTruncated
+		err = ErrTruncatedWrongVal.Gen("Truncated incorrect DOUBLE value: '%s'", str)
 	}
 	f, err1 := strconv.ParseFloat(validStr, 64)
 	if err == nil {
@@ -693,10 +693,8 @@ func (d *Datum) convertToFloat(target *FieldType) (Datum, error) {
 	case KindFloat32, KindFloat64:
 		f = d.GetFloat64()
 	case KindString, KindBytes:
+		// The valid prefix is converted when the string is truncated, it's returned with the error.
 		f, err = StrToFloat(d.GetString())
-		if err != nil {
-			return ret, errors.Trace(err)
-		}
 	case KindMysqlTime:
 		f, _ = d.GetMysqlTime().ToNumber().ToFloat64()
 	case KindMysqlDuration:
@@ -717,9 +715,10 @@ func (d *Datum) convertToFloat(target *FieldType) (Datum, error) {
 	// For float and following double type, we will only truncate it for float(M, D) format.
 	// If no D is set, we will handle it like origin float whether M is set or not.
 	if target.Flen != UnspecifiedLength && target.Decimal != UnspecifiedLength {
-		f, err = TruncateFloat(f, target.Flen, target.Decimal)
-		if err != nil {
-			return ret, errors.Trace(err)
+		var err1 error
+		f, err1 = TruncateFloat(f, target.Flen, target.Decimal)
+		if err1 != nil {
+			return ret, errors.Trace(err1)
 		}
 	}
 	if target.Tp == mysql.TypeFloat {
@@ -727,7 +726,7 @@ func (d *Datum) convertToFloat(target *FieldType) (Datum, error) {
 	} else {
 		ret.SetFloat64(f)
 	}
-	return ret, nil
+	return ret, errors.Trace(err)
 }
 
 func (d *Datum) convertToString(target *FieldType) (Datum, error) {
@@ -892,6 +891,24 @@ func (d *Datum) convertToUint(target *FieldType) (Datum, error) {
 	return ret, nil
 }
 
+// CheckZeroDate checks the time value against the NO_ZERO_DATE sql mode.
+// A date with zero parts like '2010-00-01' is always invalid, so NO_ZERO_IN_DATE is
+// always in effect and there is nothing to check for it.
+func CheckZeroDate(d Datum, mode mysql.SQLMode) error {
+	if d.Kind() != KindMysqlTime || !mode.HasNoZeroDateMode() {
+		return nil
+	}
+	t := d.GetMysqlTime()
+	if !t.IsZero() {
+		return nil
+	}
+	typeName := "datetime"
+	if t.Type == mysql.TypeDate {
+		typeName = "date"
+	}
+	return ErrTruncatedWrongVal.Gen("Incorrect %s value: '%s'", typeName, t.String())
+}
+
 func (d *Datum) convertToMysqlTime(target *FieldType) (Datum, error) {
 	tp := target.Tp
 	fsp := mysql.DefaultFsp
@@ -26,10 +26,8 @@ func CoerceArithmetic(a Datum) (d Datum, err error) {
 	switch a.Kind() {
 	case KindString, KindBytes:
 		// MySQL will convert string to float for arithmetic operation
+		// The valid prefix is kept when the string is truncated.
 		f, err := StrToFloat(a.GetString())
-		if err != nil {
-			return d, errors.Trace(err)
-		}
 		d.SetFloat64(f)
 		return d, errors.Trace(err)
 	case KindMysqlTime:
@@ -21,15 +21,19 @@ import (
 var (
 	// ErrDataTooLong is returned when converts a string value that is longer than field type length.
 	ErrDataTooLong = terror.ClassTypes.New(codeDataTooLong, "Data Too Long")
+	// ErrTruncatedWrongVal is returned when a value is invalid for the field type, like a zero date with NO_ZERO_DATE sql mode.
+	ErrTruncatedWrongVal = terror.ClassTypes.New(codeTruncatedWrongVal, "Truncated incorrect value")
 )
 
 const (
-	codeDataTooLong terror.ErrCode = terror.ErrCode(mysql.ErrDataTooLong)
+	codeDataTooLong       terror.ErrCode = terror.ErrCode(mysql.ErrDataTooLong)
+	codeTruncatedWrongVal terror.ErrCode = terror.ErrCode(mysql.ErrTruncatedWrongValue)
 )
 
 func init() {
 	typesMySQLErrCodes := map[terror.ErrCode]uint16{
-		codeDataTooLong: mysql.ErrDataTooLong,
+		codeDataTooLong:       mysql.ErrDataTooLong,
+		codeTruncatedWrongVal: mysql.ErrTruncatedWrongValue,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassTypes] = typesMySQLErrCodes
 }